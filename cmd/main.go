@@ -54,6 +54,12 @@ func main() {
 	services := service.NewServices(service.ServicesConfig{
 		Repository: repo,
 		SigningKey: config.Auth.SigningKey,
+		Company:    config.Company,
+		Fonts: service.DocumentFonts{
+			Regular: config.Documents.FontPath,
+			Bold:    config.Documents.BoldFontPath,
+		},
+		AllowUnrostered: config.Shifts.AllowUnrostered,
 	})
 
 	// Если указан флаг, генерируем тестовые данные
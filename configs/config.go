@@ -1,6 +1,7 @@
 package configs
 
 import (
+	"go-hinomontaj/models"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -25,6 +26,17 @@ type Config struct {
 	Migrations struct {
 		Path string `yaml:"path"`
 	} `yaml:"migrations"`
+	// Реквизиты сервиса для счетов и актов
+	Company   models.Company `yaml:"company"`
+	Documents struct {
+		FontPath     string `yaml:"font_path"`      // TTF шрифт с кириллицей для PDF
+		BoldFontPath string `yaml:"bold_font_path"` // жирное начертание, если пусто - используется обычное
+	} `yaml:"documents"`
+	Shifts struct {
+		// Разрешить заказы работникам без смены в графике на день, пока график ведется не для всех.
+		// По умолчанию заказ назначается только работнику на смене по графику или с отметкой прихода
+		AllowUnrostered bool `yaml:"allow_unrostered"`
+	} `yaml:"shifts"`
 }
 
 // GetDSN возвращает строку подключения к базе данных
@@ -82,6 +94,12 @@ func LoadConfig(path string) (*Config, error) {
 	if migPath := os.Getenv("MIGRATIONS_PATH"); migPath != "" {
 		config.Migrations.Path = migPath
 	}
+	if fontPath := os.Getenv("PDF_FONT_PATH"); fontPath != "" {
+		config.Documents.FontPath = fontPath
+	}
+	if boldFontPath := os.Getenv("PDF_BOLD_FONT_PATH"); boldFontPath != "" {
+		config.Documents.BoldFontPath = boldFontPath
+	}
 
 	return config, nil
 }
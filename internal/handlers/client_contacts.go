@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contactIDs читает ID клиента и, если есть, ID контакта из пути
+func contactIDs(c *gin.Context) (int, int, bool) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return 0, 0, false
+	}
+	if c.Param("contact_id") == "" {
+		return clientID, 0, true
+	}
+	contactID, err := strconv.Atoi(c.Param("contact_id"))
+	if err != nil {
+		logger.Warning("Неверный ID контакта: %s", c.Param("contact_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID контакта"})
+		return 0, 0, false
+	}
+	return clientID, contactID, true
+}
+
+func (h *Handler) GetClientContacts(c *gin.Context) {
+	clientID, _, ok := contactIDs(c)
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на получение контактов клиента ID:%d", clientID)
+	contacts, err := h.services.Client.GetContacts(clientID)
+	if err != nil {
+		logger.Error("Ошибка при получении контактов клиента ID:%d: %v", clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, contacts)
+}
+
+func (h *Handler) CreateClientContact(c *gin.Context) {
+	clientID, _, ok := contactIDs(c)
+	if !ok {
+		return
+	}
+
+	var input models.ClientContact
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при создании контакта: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+	input.ClientID = clientID
+
+	id, err := h.services.Client.CreateContact(input)
+	if err != nil {
+		logger.Error("Ошибка при создании контакта клиента ID:%d: %v", clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создан контакт ID:%d клиента ID:%d", id, clientID)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateClientContact(c *gin.Context) {
+	clientID, contactID, ok := contactIDs(c)
+	if !ok {
+		return
+	}
+
+	var input models.ClientContact
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении контакта: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+	input.ID = contactID
+	input.ClientID = clientID
+
+	if err := h.services.Client.UpdateContact(input); err != nil {
+		logger.Error("Ошибка при обновлении контакта ID:%d: %v", contactID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно обновлен контакт ID:%d", contactID)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) DeleteClientContact(c *gin.Context) {
+	clientID, contactID, ok := contactIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Client.DeleteContact(clientID, contactID); err != nil {
+		logger.Error("Ошибка при удалении контакта ID:%d: %v", contactID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно удален контакт ID:%d", contactID)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// SearchClientsByPhone ищет клиентов по телефону любого контактного лица
+func (h *Handler) SearchClientsByPhone(c *gin.Context) {
+	number := c.Query("phone")
+	if number == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не указан телефон для поиска"})
+		return
+	}
+
+	logger.Debug("Получен запрос на поиск клиентов по телефону: %s", number)
+	clients, err := h.services.Client.FindByPhone(number)
+	if err != nil {
+		logger.Error("Ошибка при поиске клиентов по телефону %s: %v", number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
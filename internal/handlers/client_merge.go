@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetClientDuplicates возвращает пары клиентов, похожих на одного и того же
+func (h *Handler) GetClientDuplicates(c *gin.Context) {
+	logger.Debug("Получен запрос на поиск дублей клиентов")
+	duplicates, err := h.services.Client.FindDuplicates()
+	if err != nil {
+		logger.Error("Ошибка при поиске дублей клиентов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, duplicates)
+}
+
+// MergeClients вливает клиента duplicate_id в клиента из пути
+func (h *Handler) MergeClients(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента при объединении: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input struct {
+		DuplicateID int `json:"duplicate_id"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при объединении клиентов: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	logger.Debug("Получен запрос на объединение клиента ID:%d с клиентом ID:%d", input.DuplicateID, id)
+	result, err := h.services.Client.Merge(id, input.DuplicateID, c.GetInt(userCtx))
+	if err != nil {
+		logger.Error("Ошибка при объединении клиентов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Клиент ID:%d влит в клиента ID:%d", input.DuplicateID, id)
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetClientTypeList возвращает справочник типов клиентов с договорами по умолчанию
+func (h *Handler) GetClientTypeList(c *gin.Context) {
+	logger.Debug("Получен запрос на получение справочника типов клиентов")
+	types, err := h.services.Client.GetTypeList()
+	if err != nil {
+		logger.Error("Ошибка при получении справочника типов клиентов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, types)
+}
+
+func (h *Handler) CreateClientType(c *gin.Context) {
+	logger.Debug("Получен запрос на создание типа клиента")
+	var input models.ClientType
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при создании типа клиента: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := h.services.Client.CreateType(input)
+	if err != nil {
+		logger.Error("Ошибка при создании типа клиента: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создан тип клиента ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// UpdateClientType обновляет тип клиента, переименование применяется ко всем клиентам типа
+func (h *Handler) UpdateClientType(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("type_id"))
+	if err != nil {
+		logger.Warning("Неверный ID типа клиента: %s", c.Param("type_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input models.ClientType
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении типа клиента: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Client.UpdateType(id, input); err != nil {
+		logger.Error("Ошибка при обновлении типа клиента ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно обновлен тип клиента ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) DeleteClientType(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("type_id"))
+	if err != nil {
+		logger.Warning("Неверный ID типа клиента: %s", c.Param("type_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Client.DeleteType(id); err != nil {
+		logger.Error("Ошибка при удалении типа клиента ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно удален тип клиента ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withDepositWarning добавляет к ответу по заказу предупреждение об отрицательном балансе предоплаты
+func (h *Handler) withDepositWarning(orderID int, response gin.H) gin.H {
+	if warning := h.services.Deposit.OrderWarning(orderID); warning != "" {
+		response["warning"] = warning
+	}
+	return response
+}
+
+// GetClientDeposit возвращает баланс предоплаты клиента и историю движений
+func (h *Handler) GetClientDeposit(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на получение предоплаты клиента ID:%d", clientID)
+	account, err := h.services.Deposit.GetAccount(clientID)
+	if err != nil {
+		logger.Error("Ошибка при получении предоплаты клиента ID:%d: %v", clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// depositOperation выполняет пополнение или выдачу по предоплате
+func (h *Handler) depositOperation(c *gin.Context, name string,
+	apply func(clientID, userID int, op models.DepositOperation) (int, error)) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input models.DepositOperation
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при операции '%s' по предоплате: %v", name, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := apply(clientID, c.GetInt(userCtx), input)
+	if err != nil {
+		logger.Error("Ошибка при операции '%s' по предоплате клиента ID:%d: %v", name, clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Операция '%s' по предоплате клиента ID:%d на сумму %s проведена", name, clientID, input.Amount)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) TopUpClientDeposit(c *gin.Context) {
+	h.depositOperation(c, models.DepositTopUp, h.services.Deposit.TopUp)
+}
+
+func (h *Handler) WithdrawClientDeposit(c *gin.Context) {
+	h.depositOperation(c, models.DepositWithdrawal, h.services.Deposit.Withdraw)
+}
+
+// SetClientDepositOverdraft задает допустимый минус по предоплате клиента
+func (h *Handler) SetClientDepositOverdraft(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input struct {
+		OverdraftLimit money.Amount `json:"overdraft_limit"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при изменении допустимого минуса: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Deposit.SetOverdraft(clientID, input.OverdraftLimit); err != nil {
+		logger.Error("Ошибка при изменении допустимого минуса клиента ID:%d: %v", clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Допустимый минус по предоплате клиента ID:%d изменен на %s", clientID, input.OverdraftLimit)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
@@ -5,6 +5,7 @@ import (
 	"go-hinomontaj/internal/service"
 	"go-hinomontaj/models"
 	"go-hinomontaj/pkg/logger"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -74,6 +75,9 @@ func (h *Handler) InitRoutes() *gin.Engine {
 	api.GET("/client-types", h.clientTypes)
 	api.GET("/clients", h.GetClient)
 	api.GET("/materials", h.GetMaterials)
+	api.GET("/vehicles/presets", h.GetVehiclePresets)
+	api.GET("/vehicles/search", h.SearchVehicles)
+	api.GET("/vehicles/:number/layout", h.GetVehicleLayout)
 
 	worker := api.Group("/worker")
 	worker.Use(h.workerRoleMiddleware)
@@ -81,6 +85,13 @@ func (h *Handler) InitRoutes() *gin.Engine {
 		worker.GET("", h.GetMyOrders)
 		worker.POST("", h.CreateOrder)
 		worker.GET("/statistics", h.GetWorkerStatistics)
+		worker.POST("/loyalty/preview", h.PreviewLoyalty)
+		worker.GET("/payslips", h.GetMyPayslips)
+		worker.GET("/payslips/:id", h.GetMyPayslip)
+		worker.GET("/payslips/:id/document", h.GetMyPayslipDocument) // ?format=pdf|xlsx
+		worker.GET("/shift", h.GetMyShift)
+		worker.POST("/clock-in", h.ClockIn)
+		worker.POST("/clock-out", h.ClockOut)
 	}
 
 	manager := api.Group("/manager")
@@ -109,6 +120,36 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			clients.POST("/:id/vehicles/upload", h.UploadClientCars)
 			clients.GET("/vehicles/template", h.GetCarsTemplate)
 
+			clients.GET("/search", h.SearchClientsByPhone)
+			clients.GET("/duplicates", h.GetClientDuplicates)
+			clients.POST("/:id/merge", h.MergeClients)
+
+			// Справочник типов клиентов
+			clients.GET("/types", h.GetClientTypeList)
+			clients.POST("/types", h.CreateClientType)
+			clients.PUT("/types/:type_id", h.UpdateClientType)
+			clients.DELETE("/types/:type_id", h.DeleteClientType)
+
+			// Контактные лица клиента
+			clients.GET("/:id/contacts", h.GetClientContacts)
+			clients.POST("/:id/contacts", h.CreateClientContact)
+			clients.PUT("/:id/contacts/:contact_id", h.UpdateClientContact)
+			clients.DELETE("/:id/contacts/:contact_id", h.DeleteClientContact)
+
+			// Расчеты с клиентами по договору
+			clients.GET("/receivables", h.GetReceivables)
+			clients.GET("/:id/receivables", h.GetClientReceivables)
+			clients.POST("/:id/payments", h.AddClientPayment)
+			clients.DELETE("/:id/payments/:payment_id", h.DeleteClientPayment)
+			clients.GET("/:id/statement", h.GetClientStatement)
+			clients.GET("/:id/statement/export", h.ExportClientStatement)
+
+			// Предоплата клиента
+			clients.GET("/:id/deposit", h.GetClientDeposit)
+			clients.POST("/:id/deposit/top-up", h.TopUpClientDeposit)
+			clients.POST("/:id/deposit/withdraw", h.WithdrawClientDeposit)
+			clients.PUT("/:id/deposit/overdraft", h.SetClientDepositOverdraft)
+
 			clients.GET("/whoose/:car", h.WhooseCar)
 			clients.GET("/compare/:car", h.CompareClientsForCar)
 
@@ -117,6 +158,34 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			clients.PUT("/onlinedate", h.UpdateOnlineDate)  // отредактировать встречу, например чтобы написать заметку
 		}
 
+		// Розничные покупатели за наличку и программа лояльности
+		retailCustomers := manager.Group("/retail-customers")
+		{
+			retailCustomers.GET("", h.GetRetailCustomers)
+			retailCustomers.POST("", h.CreateRetailCustomer)
+			retailCustomers.GET("/:id", h.GetRetailCustomer)
+			retailCustomers.PUT("/:id", h.UpdateRetailCustomer)
+			retailCustomers.DELETE("/:id", h.DeleteRetailCustomer)
+			retailCustomers.GET("/:id/orders", h.GetRetailCustomerOrders)
+		}
+		loyalty := manager.Group("/loyalty")
+		{
+			loyalty.GET("/rules", h.GetLoyaltyRules)
+			loyalty.POST("/rules", h.CreateLoyaltyRule)
+			loyalty.PUT("/rules/:id", h.UpdateLoyaltyRule)
+			loyalty.DELETE("/rules/:id", h.DeleteLoyaltyRule)
+			loyalty.POST("/preview", h.PreviewLoyalty) // скидки по заказу без сохранения
+		}
+
+		// Профили машин
+		vehicles := manager.Group("/vehicles")
+		{
+			vehicles.GET("/:number", h.GetVehicleProfile)
+			vehicles.PUT("/:number", h.UpdateVehicleProfile)
+			vehicles.GET("/:number/history", h.GetVehicleHistory)
+			vehicles.POST("/:number/transfer", h.TransferVehicle)
+		}
+
 		// Управление сотрудниками
 		workers := manager.Group("/workers")
 		{
@@ -125,6 +194,8 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			workers.GET("/:id", h.GetWorker)
 			workers.PUT("/:id", h.UpdateWorker)
 			workers.DELETE("/:id", h.DeleteWorker)
+			workers.PUT("/:id/user", h.LinkWorkerUser)
+			workers.GET("/unlinked", h.GetUnlinkedWorkers) // не связанные с учетной записью после переноса
 
 			workers.POST("/penalties", h.AddPenalty)
 			workers.GET("/penalties/:id", h.GetPenalties)
@@ -132,8 +203,31 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			workers.POST("bonuses", h.AddBonus)
 			workers.GET("bonuses/:id", h.GetBonuses)
 
+			// Исправление и отмена штрафов и бонусов с историей, список за период ?start=&end=
+			workers.GET("/:id/penalties", h.GetPenalties)
+			workers.PUT("/:id/penalties/:entry_id", h.UpdatePenalty)
+			workers.POST("/:id/penalties/:entry_id/revoke", h.RevokePenalty)
+			workers.GET("/:id/penalties/:entry_id/history", h.GetPenaltyHistory)
+			workers.GET("/:id/bonuses", h.GetBonuses)
+			workers.PUT("/:id/bonuses/:entry_id", h.UpdateBonus)
+			workers.POST("/:id/bonuses/:entry_id/revoke", h.RevokeBonus)
+			workers.GET("/:id/bonuses/:entry_id/history", h.GetBonusHistory)
+			workers.GET("/penalty-categories", h.GetPenaltyCategories)
+
 			workers.GET("statistics/:id", h.GetStatistics) // Изменено на /api/manager/workers/statistics/:id
 
+			// Схемы оплаты и расчет зарплаты
+			workers.GET("/:id/salary-schemes", h.GetSalarySchemes)
+			workers.POST("/:id/salary-schemes", h.AddSalaryScheme)
+			workers.DELETE("/:id/salary-schemes/:scheme_id", h.DeleteSalaryScheme)
+			workers.GET("/:id/salary", h.CalculateSalary)
+
+			// Авансы, выплаты и баланс расчетов с работником
+			workers.GET("/:id/payments", h.GetWorkerPayments)
+			workers.POST("/:id/payments", h.AddWorkerPayment)
+			workers.DELETE("/:id/payments/:payment_id", h.DeleteWorkerPayment)
+			workers.GET("/:id/balance", h.GetWorkerBalance)
+
 		}
 		services := manager.Group("/services")
 		{
@@ -157,6 +251,56 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			contracts.POST("/:id/prices/upload", h.UploadContractPrices)
 		}
 
+		// Счета по договорам, счет и акт в PDF и Excel
+		invoices := manager.Group("/invoices")
+		{
+			invoices.GET("", h.GetInvoices)
+			invoices.GET("/preview", h.PreviewInvoice)
+			invoices.POST("", h.CreateInvoice)
+			invoices.GET("/:id", h.GetInvoice)
+			invoices.DELETE("/:id", h.DeleteInvoice)
+			invoices.GET("/:id/documents/:kind", h.GetInvoiceDocument) // ?format=pdf|xlsx
+		}
+
+		// Расчет зарплаты за период: черновик -> утвержден -> закрыт
+		payroll := manager.Group("/payroll")
+		{
+			payroll.GET("", h.GetPayrollPeriods)
+			payroll.POST("", h.CreatePayrollPeriod)
+			payroll.GET("/:id", h.GetPayrollPeriod)
+			payroll.DELETE("/:id", h.DeletePayrollPeriod)
+			payroll.POST("/:id/calculate", h.RecalculatePayroll)
+			payroll.POST("/:id/approve", h.ApprovePayroll)
+			payroll.POST("/:id/reopen", h.ReopenPayroll)
+			payroll.POST("/:id/lock", h.LockPayroll)
+			payroll.GET("/:id/payslips/:worker_id", h.GetPayslip)
+			payroll.GET("/:id/payslips/:worker_id/document", h.GetPayslipDocument) // ?format=pdf|xlsx
+		}
+
+		// Правила автоматических штрафов и бонусов, применяются при расчете зарплаты
+		penaltyRules := manager.Group("/penalty-rules")
+		{
+			penaltyRules.GET("", h.GetPenaltyRules)
+			penaltyRules.POST("", h.CreatePenaltyRule)
+			penaltyRules.PUT("/:id", h.UpdatePenaltyRule)
+			penaltyRules.DELETE("/:id", h.DeletePenaltyRule)
+		}
+
+		// Смены: шаблоны, график по дням, отметки прихода и ухода, табель
+		shifts := manager.Group("/shifts")
+		{
+			shifts.GET("/templates", h.GetShiftTemplates)
+			shifts.POST("/templates", h.CreateShiftTemplate)
+			shifts.PUT("/templates/:id", h.UpdateShiftTemplate)
+			shifts.DELETE("/templates/:id", h.DeleteShiftTemplate)
+			shifts.GET("/roster", h.GetRoster)
+			shifts.POST("/roster", h.AddToRoster)
+			shifts.DELETE("/roster/:id", h.RemoveFromRoster)
+			shifts.GET("/time-entries", h.GetTimeEntries) // ?worker_id=&start=&end=
+			shifts.PUT("/time-entries/:id", h.UpdateTimeEntry)
+			shifts.GET("/timesheet", h.GetTimesheet)
+		}
+
 		// Управление материалами
 		materials := manager.Group("/materials")
 		{
@@ -166,6 +310,43 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			materials.DELETE("/:id", h.DeleteMaterial)
 			materials.POST("/:id/add-quantity", h.AddMaterialQuantity)
 			materials.POST("/:id/subtract-quantity", h.SubtractMaterialQuantity)
+			materials.POST("/:id/adjust", h.AdjustMaterialStock) // фактический остаток на складе
+
+			// Нормы расхода материалов на услуги
+			materials.GET("/norms", h.GetMaterialNorms)
+			materials.POST("/norms", h.CreateMaterialNorm)
+			materials.PUT("/norms/:id", h.UpdateMaterialNorm)
+			materials.DELETE("/norms/:id", h.DeleteMaterialNorm)
+			materials.GET("/norms/variance", h.GetMaterialVariance)
+
+			// Загрузка и выгрузка Excel
+			materials.GET("/export", h.ExportMaterialStock)
+			materials.GET("/template", h.GetMaterialsTemplate)
+			materials.POST("/upload", h.UploadMaterials)
+
+			// Категории материалов
+			materials.GET("/categories", h.GetMaterialCategories)
+			materials.POST("/categories", h.CreateMaterialCategory)
+			materials.PUT("/categories/:id", h.UpdateMaterialCategory)
+			materials.DELETE("/categories/:id", h.DeleteMaterialCategory)
+
+			// Склады, остатки по складам и перемещения
+			materials.GET("/warehouses", h.GetWarehouses)
+			materials.POST("/warehouses", h.CreateWarehouse)
+			materials.PUT("/warehouses/:id", h.UpdateWarehouse)
+			materials.DELETE("/warehouses/:id", h.DeleteWarehouse)
+			materials.GET("/balances", h.GetMaterialBalances)
+			materials.GET("/transfers", h.GetMaterialTransfers)
+			materials.POST("/transfers", h.CreateMaterialTransfer)
+
+			// Журнал движения и инвентаризация
+			materials.GET("/:id/movements", h.GetMaterialMovements)
+			materials.GET("/stock-takes", h.GetStockTakes)
+			materials.POST("/stock-takes", h.OpenStockTake)
+			materials.GET("/stock-takes/:id", h.GetStockTake)
+			materials.POST("/stock-takes/:id/counts", h.AddStockTakeCounts)
+			materials.DELETE("/stock-takes/:id/counts/:count_id", h.DeleteStockTakeCount)
+			materials.POST("/stock-takes/:id/approve", h.ApproveStockTake)
 		}
 
 	}
@@ -289,12 +470,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	// Валидация обязательных полей
-	if input.ClientID == 0 {
-		logger.Warning("Не указан ID клиента")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "не указан ID клиента"})
-		return
-	}
+	// Валидация обязательных полей, клиент без ID определяется по владельцу машины
 	if input.VehicleNumber == "" {
 		logger.Warning("Не указан номер автомобиля")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "не указан номер автомобиля"})
@@ -354,7 +530,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 	}
 
 	logger.Info("Успешно создан заказ ID:%d работником ID:%d", id, input.WorkerID)
-	c.JSON(http.StatusCreated, gin.H{"id": id})
+	c.JSON(http.StatusCreated, h.withDepositWarning(id, gin.H{"id": id}))
 }
 
 func (h *Handler) UpdateOrder(c *gin.Context) {
@@ -380,7 +556,7 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 	}
 
 	logger.Info("Успешно обновлен заказ ID:%d", id)
-	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+	c.JSON(http.StatusOK, h.withDepositWarning(id, gin.H{"status": "успешно обновлено"}))
 }
 
 func (h *Handler) UpdateOrderStatus(c *gin.Context) {
@@ -409,7 +585,7 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	logger.Info("Успешно обновлен статус заказа ID:%d на %s", id, input.Status)
-	c.JSON(http.StatusOK, gin.H{"status": "статус успешно обновлен"})
+	c.JSON(http.StatusOK, h.withDepositWarning(id, gin.H{"status": "статус успешно обновлен"}))
 }
 
 func (h *Handler) DeleteOrder(c *gin.Context) {
@@ -541,6 +717,7 @@ func (h *Handler) CreateWorker(c *gin.Context) {
 		SalarySchema string `json:"salary_schema"`
 		TmpSalary    int    `json:"tmp_salary"`
 		HasCar       bool   `json:"has_car"`
+		WarehouseID  *int   `json:"warehouse_id"`
 		Password     string `json:"password"`
 		Role         string `json:"role"`
 	}
@@ -551,26 +728,9 @@ func (h *Handler) CreateWorker(c *gin.Context) {
 		return
 	}
 
-	logger.Debug("Получены данные для создания работника: %+v", input)
-
-	// Создаем учетную запись пользователя с данными из запроса
-	loginInput := models.SignUpInput{
-		Name:     input.Name,
-		Email:    input.Email,
-		Password: input.Password,
-		Role:     input.Role,
-	}
-
-	logger.Debug("Данные для регистрации: %+v", loginInput)
+	logger.Debug("Получены данные для создания работника: %s %s", input.Name, input.Surname)
 
-	_, user, err := h.services.Auth.Register(loginInput)
-	if err != nil {
-		logger.Error("Ошибка при создании учетной записи работника: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Создаем запись в таблице workers
+	// Работник и его учетная запись создаются в одной транзакции
 	workerInput := models.Worker{
 		Name:         input.Name,
 		Surname:      input.Surname,
@@ -579,6 +739,9 @@ func (h *Handler) CreateWorker(c *gin.Context) {
 		SalarySchema: input.SalarySchema,
 		Salary:       input.TmpSalary,
 		HasCar:       input.HasCar,
+		WarehouseID:  input.WarehouseID,
+		Password:     input.Password,
+		Role:         input.Role,
 	}
 
 	workerId, err := h.services.Worker.Create(workerInput)
@@ -588,7 +751,7 @@ func (h *Handler) CreateWorker(c *gin.Context) {
 		return
 	}
 
-	logger.Info("Успешно создан работник ID:%d с учетной записью %s", workerId, user.Email)
+	logger.Info("Успешно создан работник ID:%d", workerId)
 	c.JSON(http.StatusCreated, gin.H{"id": workerId})
 }
 
@@ -644,6 +807,44 @@ func (h *Handler) DeleteWorker(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
 }
 
+// GetUnlinkedWorkers возвращает работников без учетной записи с причиной, по которой перенос их не связал
+func (h *Handler) GetUnlinkedWorkers(c *gin.Context) {
+	workers, err := h.services.Worker.GetUnlinked()
+	if err != nil {
+		logger.Error("Ошибка при получении несвязанных работников: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, workers)
+}
+
+// LinkWorkerUser привязывает работника к учетной записи {"user_id": N}, null отвязывает
+func (h *Handler) LinkWorkerUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input struct {
+		UserID *int `json:"user_id"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при привязке учетной записи: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Worker.LinkUser(id, input.UserID); err != nil {
+		logger.Error("Ошибка при привязке учетной записи работника ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Учетная запись работника ID:%d обновлена", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
 func (h *Handler) GetServices(c *gin.Context) {
 	logger.Debug("Получен запрос на получение списка услуг")
 	services, err := h.services.Service.GetAll()
@@ -929,7 +1130,8 @@ func (h *Handler) CreateClientCar(c *gin.Context) {
 //	c.JSON(http.StatusOK, stats)
 //}
 
-// UploadClientCars обрабатывает загрузку Excel файла с машинами клиента
+// UploadClientCars загружает машины клиента из Excel или CSV файла.
+// dry_run=true - только проверить файл, conflict=skip|move|share - что делать с машинами других клиентов.
 func (h *Handler) UploadClientCars(c *gin.Context) {
 	clientId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -938,6 +1140,15 @@ func (h *Handler) UploadClientCars(c *gin.Context) {
 		return
 	}
 
+	opts := models.CarImportOptions{Conflict: c.Query("conflict")}
+	if value := c.Query("dry_run"); value != "" {
+		opts.DryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверный параметр dry_run"})
+			return
+		}
+	}
+
 	// Получаем файл из формы
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -946,6 +1157,12 @@ func (h *Handler) UploadClientCars(c *gin.Context) {
 		return
 	}
 
+	name := strings.ToLower(file.Filename)
+	if !strings.HasSuffix(name, ".xlsx") && !strings.HasSuffix(name, ".csv") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Поддерживаются только файлы Excel (.xlsx) и CSV (.csv)"})
+		return
+	}
+
 	// Открываем файл
 	src, err := file.Open()
 	if err != nil {
@@ -955,9 +1172,7 @@ func (h *Handler) UploadClientCars(c *gin.Context) {
 	}
 	defer src.Close()
 
-	// Читаем содержимое файла
-	fileData := make([]byte, file.Size)
-	_, err = src.Read(fileData)
+	fileData, err := io.ReadAll(src)
 	if err != nil {
 		logger.Error("Ошибка при чтении файла: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось прочитать файл"})
@@ -965,14 +1180,15 @@ func (h *Handler) UploadClientCars(c *gin.Context) {
 	}
 
 	// Обрабатываем файл
-	if err := h.services.Client.UploadCarsFromExcel(clientId, fileData); err != nil {
+	result, err := h.services.Client.ImportCars(clientId, file.Filename, fileData, opts)
+	if err != nil {
 		logger.Error("Ошибка при обработке файла: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Ошибка при обработке файла: %v", err)})
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Ошибка при обработке файла: %v", err)})
 		return
 	}
 
-	logger.Info("Успешно загружены машины для клиента ID:%d", clientId)
-	c.JSON(http.StatusOK, gin.H{"message": "Машины успешно загружены"})
+	logger.Info("Обработан файл машин для клиента ID:%d (проверка: %v)", clientId, opts.DryRun)
+	c.JSON(http.StatusOK, result)
 }
 
 // GetCarsTemplate обрабатывает запрос на скачивание шаблона Excel файла
@@ -1109,9 +1325,15 @@ func (h *Handler) DeleteContract(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
 }
 
+// GetMaterials возвращает материалы, поддерживает фильтры ?category_id= и ?search= (название или артикул)
 func (h *Handler) GetMaterials(c *gin.Context) {
 	logger.Debug("Получен запрос на получение списка материалов")
-	materials, err := h.services.Material.GetAll()
+	filter, ok := parseMaterialFilter(c)
+	if !ok {
+		return
+	}
+
+	materials, err := h.services.Material.GetAll(filter)
 	if err != nil {
 		logger.Error("Ошибка при получении списка материалов: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1194,7 +1416,8 @@ func (h *Handler) AddMaterialQuantity(c *gin.Context) {
 	}
 
 	var input struct {
-		Quantity int `json:"quantity" binding:"required"`
+		Quantity    float64 `json:"quantity" binding:"required"`
+		WarehouseID int     `json:"warehouse_id"` // 0 = склад по умолчанию
 	}
 	if err := c.BindJSON(&input); err != nil {
 		logger.Warning("Ошибка привязки JSON при добавлении количества: %v", err)
@@ -1202,9 +1425,9 @@ func (h *Handler) AddMaterialQuantity(c *gin.Context) {
 		return
 	}
 
-	logger.Debug("Получен запрос на добавление %d единиц к материалу ID:%d", input.Quantity, id)
+	logger.Debug("Получен запрос на добавление %v единиц к материалу ID:%d", input.Quantity, id)
 
-	if err := h.services.Material.AddQuantity(id, input.Quantity); err != nil {
+	if err := h.services.Material.AddQuantity(id, input.WarehouseID, input.Quantity); err != nil {
 		logger.Error("Ошибка при добавлении количества материала ID:%d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1215,10 +1438,10 @@ func (h *Handler) AddMaterialQuantity(c *gin.Context) {
 	if err != nil {
 		logger.Warning("Не удалось получить обновленный материал ID:%d для проверки: %v", id, err)
 	} else {
-		logger.Debug("Материал ID:%d обновлен, новое количество: %d", id, updatedMaterial.Storage)
+		logger.Debug("Материал ID:%d обновлен, новое количество: %v", id, updatedMaterial.Storage)
 	}
 
-	logger.Info("Успешно добавлено количество %d к материалу ID:%d", input.Quantity, id)
+	logger.Info("Успешно добавлено количество %v к материалу ID:%d", input.Quantity, id)
 	c.JSON(http.StatusOK, gin.H{
 		"status":      "количество успешно добавлено",
 		"new_storage": updatedMaterial.Storage,
@@ -1234,7 +1457,8 @@ func (h *Handler) SubtractMaterialQuantity(c *gin.Context) {
 	}
 
 	var input struct {
-		Quantity int `json:"quantity" binding:"required"`
+		Quantity    float64 `json:"quantity" binding:"required"`
+		WarehouseID int     `json:"warehouse_id"` // 0 = склад по умолчанию
 	}
 	if err := c.BindJSON(&input); err != nil {
 		logger.Warning("Ошибка привязки JSON при вычитании количества: %v", err)
@@ -1242,9 +1466,9 @@ func (h *Handler) SubtractMaterialQuantity(c *gin.Context) {
 		return
 	}
 
-	logger.Debug("Получен запрос на вычитание %d единиц у материала ID:%d", input.Quantity, id)
+	logger.Debug("Получен запрос на вычитание %v единиц у материала ID:%d", input.Quantity, id)
 
-	if err := h.services.Material.SubtractQuantity(id, input.Quantity); err != nil {
+	if err := h.services.Material.SubtractQuantity(id, input.WarehouseID, input.Quantity); err != nil {
 		logger.Error("Ошибка при вычитании количества материала ID:%d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1255,10 +1479,10 @@ func (h *Handler) SubtractMaterialQuantity(c *gin.Context) {
 	if err != nil {
 		logger.Warning("Не удалось получить обновленный материал ID:%d для проверки: %v", id, err)
 	} else {
-		logger.Debug("Материал ID:%d обновлен, новое количество: %d", id, updatedMaterial.Storage)
+		logger.Debug("Материал ID:%d обновлен, новое количество: %v", id, updatedMaterial.Storage)
 	}
 
-	logger.Info("Успешно вычтено количество %d у материала ID:%d", input.Quantity, id)
+	logger.Info("Успешно вычтено количество %v у материала ID:%d", input.Quantity, id)
 	c.JSON(http.StatusOK, gin.H{
 		"status":      "количество успешно вычтено",
 		"new_storage": updatedMaterial.Storage,
@@ -1270,6 +1494,8 @@ func (h *Handler) AddPenalty(context *gin.Context) {
 		WorkerID    int    `json:"worker_id"`
 		Amount      int    `json:"amount"`
 		Description string `json:"description"`
+		Category    string `json:"category"`
+		OrderID     *int   `json:"order_id"`
 	}
 	logger.Debug("Получен запрос на добавление штрафа")
 	if err := context.BindJSON(&input); err != nil {
@@ -1291,9 +1517,14 @@ func (h *Handler) AddPenalty(context *gin.Context) {
 		WorkerID: input.WorkerID,
 		Amount:   input.Amount,
 		Desc:     input.Description,
+		Category: input.Category,
+		OrderID:  input.OrderID,
+	}
+	if userID := context.GetInt(userCtx); userID != 0 {
+		penalty.CreatedBy = &userID
 	}
 
-	err = h.services.Worker.AddPenalty(penalty)
+	penalty.ID, err = h.services.Worker.AddPenalty(penalty)
 	if err != nil {
 		logger.Error("Ошибка при добавлении штрафа: %v", err)
 		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1312,7 +1543,11 @@ func (h *Handler) GetPenalties(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
 		return
 	}
-	penalties, err = h.services.Worker.GetPenalties(id)
+	start, end, ok := parseOptionalDateRange(context)
+	if !ok {
+		return
+	}
+	penalties, err = h.services.Worker.GetPenalties(id, start, end)
 	if err != nil {
 		logger.Error("Ошибка при получении списка штрафов: %v", err)
 		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1327,6 +1562,8 @@ func (h *Handler) AddBonus(context *gin.Context) {
 		WorkerID    int    `json:"worker_id"`
 		Amount      int    `json:"amount"`
 		Description string `json:"description"`
+		Category    string `json:"category"`
+		OrderID     *int   `json:"order_id"`
 	}
 	logger.Debug("Получен запрос на добавление бонуса")
 	if err := context.BindJSON(&input); err != nil {
@@ -1348,9 +1585,14 @@ func (h *Handler) AddBonus(context *gin.Context) {
 		WorkerID: input.WorkerID,
 		Amount:   input.Amount,
 		Desc:     input.Description,
+		Category: input.Category,
+		OrderID:  input.OrderID,
+	}
+	if userID := context.GetInt(userCtx); userID != 0 {
+		bonus.CreatedBy = &userID
 	}
 
-	err = h.services.Worker.AddBonus(bonus)
+	bonus.ID, err = h.services.Worker.AddBonus(bonus)
 	if err != nil {
 		logger.Error("Ошибка при добавлении бонуса: %v", err)
 		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1367,8 +1609,13 @@ func (h *Handler) GetBonuses(context *gin.Context) {
 		return
 	}
 
+	start, end, ok := parseOptionalDateRange(context)
+	if !ok {
+		return
+	}
+
 	logger.Debug("Получен запрос на получение списка бонусов")
-	bonuses, err := h.services.Worker.GetBonuses(id)
+	bonuses, err := h.services.Worker.GetBonuses(id, start, end)
 	if err != nil {
 		logger.Error("Ошибка при получении списка бонусов: %v", err)
 		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1385,20 +1632,9 @@ func (h *Handler) GetStatistics(context *gin.Context) {
 		return
 	}
 
-	startStr := context.Query("start")
-	endStr := context.Query("end")
-
-	const layout = "2006-01-02" // формат даты: YYYY-MM-DD (например: 2025-07-16)
-
-	start, err := time.Parse(layout, startStr)
-	if err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат start, ожидается YYYY-MM-DD"})
-		return
-	}
-
-	end, err := time.Parse(layout, endStr)
-	if err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат end, ожидается YYYY-MM-DD"})
+	// Дата окончания включается в период, как и в расчете зарплаты
+	start, end, ok := parseDateRange(context)
+	if !ok {
 		return
 	}
 
@@ -1758,3 +1994,33 @@ func (h *Handler) GetOrderMaterials(c *gin.Context) {
 
 	c.JSON(http.StatusOK, materials)
 }
+
+// parseDateRange разбирает параметры start и end (YYYY-MM-DD) из запроса.
+// Конец периода включительный, поэтому возвращается начало следующего дня.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	return parsePeriod(c, c.Query("start"), c.Query("end"))
+}
+
+// parsePeriod разбирает период из строк YYYY-MM-DD, при ошибке отвечает 400
+func parsePeriod(c *gin.Context, startStr, endStr string) (time.Time, time.Time, bool) {
+	const layout = "2006-01-02"
+
+	start, err := time.Parse(layout, startStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат start, ожидается YYYY-MM-DD"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	end, err := time.Parse(layout, endStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат end, ожидается YYYY-MM-DD"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "дата окончания периода раньше даты начала"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return start, end.AddDate(0, 0, 1), true
+}
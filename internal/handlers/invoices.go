@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetInvoices(c *gin.Context) {
+	contractID, ok := parseContractFilter(c)
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на получение счетов по договору ID:%d", contractID)
+	invoices, err := h.services.Invoice.GetAll(contractID)
+	if err != nil {
+		logger.Error("Ошибка при получении счетов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoices)
+}
+
+// PreviewInvoice показывает строки будущего счета по договору за период start-end
+func (h *Handler) PreviewInvoice(c *gin.Context) {
+	contractID, ok := parseContractFilter(c)
+	if !ok {
+		return
+	}
+	if contractID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не указан договор"})
+		return
+	}
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на предпросмотр счета по договору ID:%d", contractID)
+	invoice, err := h.services.Invoice.Preview(contractID, start, end)
+	if err != nil {
+		logger.Error("Ошибка при предпросмотре счета: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// CreateInvoice выставляет счет по выполненным заказам договора за период
+func (h *Handler) CreateInvoice(c *gin.Context) {
+	logger.Debug("Получен запрос на выставление счета")
+	var input models.InvoiceRequest
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при выставлении счета: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+	start, end, ok := parsePeriod(c, input.Start, input.End)
+	if !ok {
+		return
+	}
+
+	id, err := h.services.Invoice.Create(input.ContractID, start, end, c.GetInt(userCtx))
+	if err != nil {
+		logger.Error("Ошибка при выставлении счета по договору ID:%d: %v", input.ContractID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Выставлен счет ID:%d по договору ID:%d", id, input.ContractID)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) GetInvoice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID счета: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на получение счета ID:%d", id)
+	invoice, err := h.services.Invoice.GetById(id)
+	if err != nil {
+		logger.Error("Ошибка при получении счета ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// DeleteInvoice отменяет счет, заказы из него снова можно выставить
+func (h *Handler) DeleteInvoice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID счета: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Invoice.Delete(id); err != nil {
+		logger.Error("Ошибка при удалении счета ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Счет ID:%d удален", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// GetInvoiceDocument отдает счет на оплату (invoice) или акт (act) в PDF или Excel
+func (h *Handler) GetInvoiceDocument(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID счета: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+	kind := c.Param("kind")
+	format := c.DefaultQuery("format", "pdf")
+	filename := fmt.Sprintf("%s_%d.%s", kind, id, format)
+	logger.Debug("Получен запрос на документ '%s' по счету ID:%d в формате %s", kind, id, format)
+
+	switch format {
+	case "pdf":
+		document, err := h.services.Invoice.InvoicePDF(id, kind)
+		if err != nil {
+			logger.Error("Ошибка при формировании PDF по счету ID:%d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "application/pdf", document.Bytes())
+	case "xlsx":
+		document, err := h.services.Invoice.InvoiceExcel(id, kind)
+		if err != nil {
+			logger.Error("Ошибка при формировании Excel по счету ID:%d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		sendXLSX(c, filename, document.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат, доступны pdf и xlsx"})
+	}
+}
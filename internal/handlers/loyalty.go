@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRetailCustomers возвращает розничных покупателей, ?search= ищет по имени, телефону, карте и машине
+func (h *Handler) GetRetailCustomers(c *gin.Context) {
+	logger.Debug("Получен запрос на получение розничных покупателей")
+	customers, err := h.services.Loyalty.GetCustomers(c.Query("search"))
+	if err != nil {
+		logger.Error("Ошибка при получении розничных покупателей: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, customers)
+}
+
+func (h *Handler) GetRetailCustomer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID покупателя: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	customer, err := h.services.Loyalty.GetCustomer(id)
+	if err != nil {
+		logger.Error("Ошибка при получении покупателя ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, customer)
+}
+
+// GetRetailCustomerOrders возвращает заказы покупателя со скидками по услугам
+func (h *Handler) GetRetailCustomerOrders(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID покупателя: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	orders, err := h.services.Loyalty.GetCustomerOrders(id)
+	if err != nil {
+		logger.Error("Ошибка при получении заказов покупателя ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+func (h *Handler) CreateRetailCustomer(c *gin.Context) {
+	logger.Debug("Получен запрос на создание розничного покупателя")
+	var input models.RetailCustomer
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при создании покупателя: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := h.services.Loyalty.CreateCustomer(input)
+	if err != nil {
+		logger.Error("Ошибка при создании розничного покупателя: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создан розничный покупатель ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateRetailCustomer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID покупателя: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input models.RetailCustomer
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении покупателя: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Loyalty.UpdateCustomer(id, input); err != nil {
+		logger.Error("Ошибка при обновлении покупателя ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно обновлен розничный покупатель ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) DeleteRetailCustomer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID покупателя: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Loyalty.DeleteCustomer(id); err != nil {
+		logger.Error("Ошибка при удалении покупателя ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно удален розничный покупатель ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+func (h *Handler) GetLoyaltyRules(c *gin.Context) {
+	logger.Debug("Получен запрос на получение правил лояльности")
+	rules, err := h.services.Loyalty.GetRules()
+	if err != nil {
+		logger.Error("Ошибка при получении правил лояльности: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *Handler) CreateLoyaltyRule(c *gin.Context) {
+	logger.Debug("Получен запрос на создание правила лояльности")
+	var input models.LoyaltyRule
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при создании правила лояльности: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := h.services.Loyalty.CreateRule(input)
+	if err != nil {
+		logger.Error("Ошибка при создании правила лояльности: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создано правило лояльности ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateLoyaltyRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID правила лояльности: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input models.LoyaltyRule
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении правила лояльности: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Loyalty.UpdateRule(id, input); err != nil {
+		logger.Error("Ошибка при обновлении правила лояльности ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно обновлено правило лояльности ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) DeleteLoyaltyRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID правила лояльности: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Loyalty.DeleteRule(id); err != nil {
+		logger.Error("Ошибка при удалении правила лояльности ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно удалено правило лояльности ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// PreviewLoyalty возвращает заказ с рассчитанными скидками без сохранения
+func (h *Handler) PreviewLoyalty(c *gin.Context) {
+	logger.Debug("Получен запрос на расчет скидок по заказу")
+	var input models.Order
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при расчете скидок: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	order, err := h.services.Loyalty.Preview(input)
+	if err != nil {
+		logger.Error("Ошибка при расчете скидок по заказу: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetMaterialCategories(c *gin.Context) {
+	logger.Debug("Получен запрос на получение категорий материалов")
+	categories, err := h.services.Material.GetCategories()
+	if err != nil {
+		logger.Error("Ошибка при получении категорий материалов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+func (h *Handler) CreateMaterialCategory(c *gin.Context) {
+	logger.Debug("Получен запрос на создание категории материалов")
+	var input models.MaterialCategory
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при создании категории материалов: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := h.services.Material.CreateCategory(input)
+	if err != nil {
+		logger.Error("Ошибка при создании категории материалов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создана категория материалов ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateMaterialCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID категории материалов при обновлении: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на обновление категории материалов ID:%d", id)
+	var input models.MaterialCategory
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении категории материалов: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Material.UpdateCategory(id, input); err != nil {
+		logger.Error("Ошибка при обновлении категории материалов ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) DeleteMaterialCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID категории материалов при удалении: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на удаление категории материалов ID:%d", id)
+	if err := h.services.Material.DeleteCategory(id); err != nil {
+		logger.Error("Ошибка при удалении категории материалов ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
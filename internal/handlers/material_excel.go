@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseMaterialFilter читает фильтры ?category_id= и ?search= (название или артикул)
+func parseMaterialFilter(c *gin.Context) (models.MaterialFilter, bool) {
+	filter := models.MaterialFilter{Search: c.Query("search")}
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		id, err := strconv.Atoi(categoryID)
+		if err != nil {
+			logger.Warning("Неверный ID категории в фильтре материалов: %s", categoryID)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID категории"})
+			return filter, false
+		}
+		filter.CategoryID = id
+	}
+	return filter, true
+}
+
+// sendXLSX отдает сформированный Excel файл на скачивание
+func sendXLSX(c *gin.Context, filename string, data []byte) {
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Transfer-Encoding", "binary")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Expires", "0")
+	c.Header("Cache-Control", "must-revalidate")
+	c.Header("Pragma", "public")
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", data)
+}
+
+// ExportMaterialStock выгружает остатки материалов в Excel
+func (h *Handler) ExportMaterialStock(c *gin.Context) {
+	logger.Debug("Получен запрос на выгрузку остатков материалов")
+	filter, ok := parseMaterialFilter(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.services.Material.ExportStock(filter)
+	if err != nil {
+		logger.Error("Ошибка при формировании отчета об остатках: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendXLSX(c, "materials_stock.xlsx", report.Bytes())
+}
+
+// GetMaterialsTemplate отдает шаблон Excel файла для загрузки материалов
+func (h *Handler) GetMaterialsTemplate(c *gin.Context) {
+	logger.Debug("Получен запрос на скачивание шаблона загрузки материалов")
+	template, err := h.services.Material.GenerateImportTemplate()
+	if err != nil {
+		logger.Error("Ошибка при генерации шаблона: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось сгенерировать шаблон"})
+		return
+	}
+
+	sendXLSX(c, "materials_template.xlsx", template.Bytes())
+}
+
+// UploadMaterials загружает материалы из Excel и возвращает построчный отчет
+func (h *Handler) UploadMaterials(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error("Ошибка при получении файла: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось получить файл"})
+		return
+	}
+
+	if !strings.HasSuffix(strings.ToLower(file.Filename), ".xlsx") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Поддерживаются только Excel файлы (.xlsx)"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		logger.Error("Ошибка при открытии файла: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось открыть файл"})
+		return
+	}
+	defer src.Close()
+
+	fileData, err := io.ReadAll(src)
+	if err != nil {
+		logger.Error("Ошибка при чтении файла: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Не удалось прочитать файл"})
+		return
+	}
+
+	result, err := h.services.Material.ImportFromExcel(fileData, c.GetInt(userCtx))
+	if err != nil {
+		logger.Error("Ошибка при обработке файла материалов: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Загружены материалы из файла %s", file.Filename)
+	c.JSON(http.StatusOK, result)
+}
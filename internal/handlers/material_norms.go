@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMaterialNorms возвращает нормы расхода материалов по услугам
+func (h *Handler) GetMaterialNorms(c *gin.Context) {
+	logger.Debug("Получен запрос на получение норм расхода")
+	norms, err := h.services.Material.GetNorms()
+	if err != nil {
+		logger.Error("Ошибка при получении норм расхода: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Debug("Успешно получено %d норм расхода", len(norms))
+	c.JSON(http.StatusOK, norms)
+}
+
+func (h *Handler) CreateMaterialNorm(c *gin.Context) {
+	logger.Debug("Получен запрос на создание нормы расхода")
+	var input models.ServiceMaterialNorm
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при создании нормы расхода: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := h.services.Material.CreateNorm(input)
+	if err != nil {
+		logger.Error("Ошибка при создании нормы расхода: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создана норма расхода ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateMaterialNorm(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID нормы расхода при обновлении: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на обновление нормы расхода ID:%d", id)
+	var input models.ServiceMaterialNorm
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении нормы расхода: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Material.UpdateNorm(id, input); err != nil {
+		logger.Error("Ошибка при обновлении нормы расхода ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно обновлена норма расхода ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) DeleteMaterialNorm(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID нормы расхода при удалении: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на удаление нормы расхода ID:%d", id)
+	if err := h.services.Material.DeleteNorm(id); err != nil {
+		logger.Error("Ошибка при удалении нормы расхода ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно удалена норма расхода ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// GetMaterialVariance возвращает отчет "норма / факт" по расходу материалов работниками
+func (h *Handler) GetMaterialVariance(c *gin.Context) {
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на отчет о расходе материалов")
+	variance, err := h.services.Material.GetVariance(start, end)
+	if err != nil {
+		logger.Error("Ошибка при получении отчета о расходе материалов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, variance)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetPayrollPeriods(c *gin.Context) {
+	logger.Debug("Получен запрос на получение расчетов зарплаты")
+	periods, err := h.services.Payroll.GetAll()
+	if err != nil {
+		logger.Error("Ошибка при получении расчетов зарплаты: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, periods)
+}
+
+// GetPayrollPeriod возвращает расчет зарплаты со строками по работникам и перерасчетами
+func (h *Handler) GetPayrollPeriod(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID расчета зарплаты: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	period, err := h.services.Payroll.GetById(id)
+	if err != nil {
+		logger.Error("Ошибка при получении расчета зарплаты ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, period)
+}
+
+// CreatePayrollPeriod заводит и считает зарплату всех работников за период start-end
+func (h *Handler) CreatePayrollPeriod(c *gin.Context) {
+	logger.Debug("Получен запрос на расчет зарплаты за период")
+	var input models.PayrollRequest
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при расчете зарплаты: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+	start, end, ok := parsePeriod(c, input.Start, input.End)
+	if !ok {
+		return
+	}
+
+	id, err := h.services.Payroll.Create(start, end, c.GetInt(userCtx))
+	if err != nil {
+		logger.Error("Ошибка при расчете зарплаты за %s - %s: %v", input.Start, input.End, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Создан расчет зарплаты ID:%d за %s - %s", id, input.Start, input.End)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// payrollAction выполняет действие над расчетом зарплаты по ID из пути
+func (h *Handler) payrollAction(c *gin.Context, name string, action func(id int) error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID расчета зарплаты: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := action(id); err != nil {
+		logger.Error("Ошибка (%s) расчета зарплаты ID:%d: %v", name, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Расчет зарплаты ID:%d: %s", id, name)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) RecalculatePayroll(c *gin.Context) {
+	h.payrollAction(c, "пересчет", h.services.Payroll.Calculate)
+}
+
+func (h *Handler) ApprovePayroll(c *gin.Context) {
+	userID := c.GetInt(userCtx)
+	h.payrollAction(c, "утверждение", func(id int) error {
+		return h.services.Payroll.Approve(id, userID)
+	})
+}
+
+func (h *Handler) ReopenPayroll(c *gin.Context) {
+	h.payrollAction(c, "возврат в черновик", h.services.Payroll.Reopen)
+}
+
+// LockPayroll закрывает выплаченный период, дальнейшие изменения идут перерасчетом в следующий
+func (h *Handler) LockPayroll(c *gin.Context) {
+	h.payrollAction(c, "закрытие", h.services.Payroll.Lock)
+}
+
+func (h *Handler) DeletePayrollPeriod(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID расчета зарплаты: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Payroll.Delete(id); err != nil {
+		logger.Error("Ошибка при удалении расчета зарплаты ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Удален расчет зарплаты ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
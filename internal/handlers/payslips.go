@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// payslipParams читает ID расчета и работника из пути
+func payslipParams(c *gin.Context) (int, int, bool) {
+	periodID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID расчета зарплаты: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return 0, 0, false
+	}
+	workerID, err := strconv.Atoi(c.Param("worker_id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("worker_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return 0, 0, false
+	}
+	return periodID, workerID, true
+}
+
+// GetPayslip возвращает расчетный листок работника за период расчета зарплаты
+func (h *Handler) GetPayslip(c *gin.Context) {
+	periodID, workerID, ok := payslipParams(c)
+	if !ok {
+		return
+	}
+
+	payslip, err := h.services.Payroll.Payslip(periodID, workerID)
+	if err != nil {
+		logger.Error("Ошибка при формировании расчетного листка работника ID:%d: %v", workerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payslip)
+}
+
+// GetPayslipDocument отдает расчетный листок работника в PDF или Excel, ?format=pdf|xlsx
+func (h *Handler) GetPayslipDocument(c *gin.Context) {
+	periodID, workerID, ok := payslipParams(c)
+	if !ok {
+		return
+	}
+
+	payslip, err := h.services.Payroll.Payslip(periodID, workerID)
+	if err != nil {
+		logger.Error("Ошибка при формировании расчетного листка работника ID:%d: %v", workerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.sendPayslipDocument(c, payslip)
+}
+
+// GetMyPayslips возвращает утвержденные расчетные листки работника, вошедшего в систему
+func (h *Handler) GetMyPayslips(c *gin.Context) {
+	userID := c.GetInt(userCtx)
+	logger.Debug("Получен запрос на расчетные листки работника user_id:%d", userID)
+
+	payslips, err := h.services.Payroll.WorkerPayslips(userID)
+	if err != nil {
+		logger.Error("Ошибка при получении расчетных листков user_id:%d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payslips)
+}
+
+func (h *Handler) GetMyPayslip(c *gin.Context) {
+	payslip, ok := h.myPayslip(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, payslip)
+}
+
+func (h *Handler) GetMyPayslipDocument(c *gin.Context) {
+	payslip, ok := h.myPayslip(c)
+	if !ok {
+		return
+	}
+	h.sendPayslipDocument(c, payslip)
+}
+
+// myPayslip находит расчетный листок работника, вошедшего в систему, за период из пути
+func (h *Handler) myPayslip(c *gin.Context) (models.Payslip, bool) {
+	periodID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID расчета зарплаты: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return models.Payslip{}, false
+	}
+
+	userID := c.GetInt(userCtx)
+	payslip, err := h.services.Payroll.WorkerPayslip(userID, periodID)
+	if err != nil {
+		logger.Error("Ошибка при формировании расчетного листка user_id:%d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return models.Payslip{}, false
+	}
+	return payslip, true
+}
+
+// sendPayslipDocument формирует листок в формате из ?format= и отдает на скачивание
+func (h *Handler) sendPayslipDocument(c *gin.Context, payslip models.Payslip) {
+	format := c.DefaultQuery("format", "pdf")
+	filename := fmt.Sprintf("payslip_%d_%d.%s", payslip.PeriodID, payslip.WorkerID, format)
+
+	switch format {
+	case "pdf":
+		document, err := h.services.Payroll.PayslipPDF(payslip)
+		if err != nil {
+			logger.Error("Ошибка при формировании PDF расчетного листка: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "application/pdf", document.Bytes())
+	case "xlsx":
+		document, err := h.services.Payroll.PayslipExcel(payslip)
+		if err != nil {
+			logger.Error("Ошибка при формировании Excel расчетного листка: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		sendXLSX(c, filename, document.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат, доступны pdf и xlsx"})
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) UpdatePenalty(c *gin.Context) {
+	h.updatePenaltyOrBonus(c, models.EntryPenalty)
+}
+
+func (h *Handler) UpdateBonus(c *gin.Context) {
+	h.updatePenaltyOrBonus(c, models.EntryBonus)
+}
+
+func (h *Handler) RevokePenalty(c *gin.Context) {
+	h.revokePenaltyOrBonus(c, models.EntryPenalty)
+}
+
+func (h *Handler) RevokeBonus(c *gin.Context) {
+	h.revokePenaltyOrBonus(c, models.EntryBonus)
+}
+
+func (h *Handler) GetPenaltyHistory(c *gin.Context) {
+	h.getPenaltyOrBonusHistory(c, models.EntryPenalty)
+}
+
+func (h *Handler) GetBonusHistory(c *gin.Context) {
+	h.getPenaltyOrBonusHistory(c, models.EntryBonus)
+}
+
+func (h *Handler) GetPenaltyCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, models.PenaltyBonusCategories)
+}
+
+// updatePenaltyOrBonus исправляет сумму, категорию, описание и заказ записи, reason обязателен
+func (h *Handler) updatePenaltyOrBonus(c *gin.Context, kind string) {
+	workerID, id, ok := parseEntryIDs(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Amount      int    `json:"amount"`
+		Description string `json:"description"`
+		Category    string `json:"category"`
+		OrderID     *int   `json:"order_id"`
+		Reason      string `json:"reason"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при исправлении записи '%s': %v", kind, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	entry := models.PenaltyOrBonus{
+		ID:       id,
+		WorkerID: workerID,
+		Amount:   input.Amount,
+		Desc:     input.Description,
+		Category: input.Category,
+		OrderID:  input.OrderID,
+	}
+	if err := h.services.Worker.UpdatePenaltyOrBonus(kind, entry, input.Reason, c.GetInt(userCtx)); err != nil {
+		logger.Error("Ошибка при исправлении записи '%s' ID:%d: %v", kind, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Исправлена запись '%s' ID:%d работника ID:%d", kind, id, workerID)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) revokePenaltyOrBonus(c *gin.Context, kind string) {
+	workerID, id, ok := parseEntryIDs(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при отмене записи '%s': %v", kind, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Worker.RevokePenaltyOrBonus(kind, workerID, id, input.Reason, c.GetInt(userCtx)); err != nil {
+		logger.Error("Ошибка при отмене записи '%s' ID:%d: %v", kind, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Отменена запись '%s' ID:%d работника ID:%d", kind, id, workerID)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно отменено"})
+}
+
+func (h *Handler) getPenaltyOrBonusHistory(c *gin.Context, kind string) {
+	workerID, id, ok := parseEntryIDs(c)
+	if !ok {
+		return
+	}
+
+	changes, err := h.services.Worker.GetPenaltyOrBonusHistory(kind, workerID, id)
+	if err != nil {
+		logger.Error("Ошибка при получении истории записи '%s' ID:%d: %v", kind, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// parseEntryIDs разбирает ID работника и ID штрафа или бонуса из пути, при ошибке отвечает 400
+func parseEntryIDs(c *gin.Context) (int, int, bool) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil {
+		logger.Warning("Неверный ID записи: %s", c.Param("entry_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return 0, 0, false
+	}
+	return workerID, id, true
+}
+
+// parseOptionalDateRange разбирает ?start=&end=, без них возвращает нулевые даты - весь период
+func parseOptionalDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	if c.Query("start") == "" && c.Query("end") == "" {
+		return time.Time{}, time.Time{}, true
+	}
+	return parseDateRange(c)
+}
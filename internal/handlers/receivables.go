@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseContractFilter читает необязательный фильтр ?contract_id=, 0 - все договоры
+func parseContractFilter(c *gin.Context) (int, bool) {
+	value := c.Query("contract_id")
+	if value == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Warning("Неверный ID договора в фильтре: %s", value)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID договора"})
+		return 0, false
+	}
+	return id, true
+}
+
+// GetReceivables возвращает клиентов с долгом или переплатой и разбивкой долга по давности
+func (h *Handler) GetReceivables(c *gin.Context) {
+	logger.Debug("Получен запрос на получение дебиторской задолженности")
+	receivables, err := h.services.Receivables.GetAllReceivables()
+	if err != nil {
+		logger.Error("Ошибка при получении дебиторской задолженности: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, receivables)
+}
+
+// GetClientReceivables возвращает сальдо клиента и разбивку долга по давности
+func (h *Handler) GetClientReceivables(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+	contractID, ok := parseContractFilter(c)
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на получение расчетов с клиентом ID:%d", clientID)
+	receivables, err := h.services.Receivables.GetClientReceivables(clientID, contractID)
+	if err != nil {
+		logger.Error("Ошибка при получении расчетов с клиентом ID:%d: %v", clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, receivables)
+}
+
+// AddClientPayment вносит поступившую от клиента оплату
+func (h *Handler) AddClientPayment(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input models.ClientPayment
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при добавлении оплаты: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := h.services.Receivables.AddPayment(clientID, c.GetInt(userCtx), input)
+	if err != nil {
+		logger.Error("Ошибка при добавлении оплаты клиента ID:%d: %v", clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Добавлена оплата ID:%d клиента ID:%d на сумму %s", id, clientID, input.Amount)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) DeleteClientPayment(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+	paymentID, err := strconv.Atoi(c.Param("payment_id"))
+	if err != nil {
+		logger.Warning("Неверный ID оплаты: %s", c.Param("payment_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID оплаты"})
+		return
+	}
+
+	if err := h.services.Receivables.DeletePayment(clientID, paymentID); err != nil {
+		logger.Error("Ошибка при удалении оплаты ID:%d: %v", paymentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Оплата ID:%d клиента ID:%d удалена", paymentID, clientID)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// parseStatementRequest читает клиента, договор и период выписки
+func parseStatementRequest(c *gin.Context) (clientID, contractID int, ok bool) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID клиента: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return 0, 0, false
+	}
+	contractID, ok = parseContractFilter(c)
+	return clientID, contractID, ok
+}
+
+// GetClientStatement возвращает выписку по расчетам с клиентом за период start-end
+func (h *Handler) GetClientStatement(c *gin.Context) {
+	clientID, contractID, ok := parseStatementRequest(c)
+	if !ok {
+		return
+	}
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на выписку клиента ID:%d", clientID)
+	statement, err := h.services.Receivables.GetStatement(clientID, contractID, start, end)
+	if err != nil {
+		logger.Error("Ошибка при формировании выписки клиента ID:%d: %v", clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// ExportClientStatement выгружает выписку по расчетам с клиентом в Excel
+func (h *Handler) ExportClientStatement(c *gin.Context) {
+	clientID, contractID, ok := parseStatementRequest(c)
+	if !ok {
+		return
+	}
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на выгрузку выписки клиента ID:%d", clientID)
+	report, err := h.services.Receivables.ExportStatement(clientID, contractID, start, end)
+	if err != nil {
+		logger.Error("Ошибка при выгрузке выписки клиента ID:%d: %v", clientID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sendXLSX(c, fmt.Sprintf("statement_%d_%s_%s.xlsx", clientID, c.Query("start"), c.Query("end")), report.Bytes())
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetSalarySchemes(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	schemes, err := h.services.Worker.GetSalarySchemes(workerID)
+	if err != nil {
+		logger.Error("Ошибка при получении схем оплаты работника ID:%d: %v", workerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schemes)
+}
+
+// AddSalaryScheme задает работнику схему оплаты, effective_from в формате YYYY-MM-DD
+func (h *Handler) AddSalaryScheme(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input struct {
+		Type          string              `json:"type"`
+		Rate          float64             `json:"rate"`
+		Percent       float64             `json:"percent"`
+		Tiers         []models.SalaryTier `json:"tiers"`
+		Rates         []models.PieceRate  `json:"rates"`
+		EffectiveFrom string              `json:"effective_from"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при добавлении схемы оплаты: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	effectiveFrom, err := time.ParseInLocation("2006-01-02", input.EffectiveFrom, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат effective_from, ожидается YYYY-MM-DD"})
+		return
+	}
+
+	id, err := h.services.Worker.AddSalaryScheme(models.WorkerSalaryScheme{
+		WorkerID:      workerID,
+		Type:          input.Type,
+		Rate:          input.Rate,
+		Percent:       input.Percent,
+		Tiers:         input.Tiers,
+		Rates:         input.Rates,
+		EffectiveFrom: effectiveFrom,
+	})
+	if err != nil {
+		logger.Error("Ошибка при добавлении схемы оплаты работнику ID:%d: %v", workerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Работнику ID:%d задана схема оплаты '%s' с %s", workerID, input.Type, input.EffectiveFrom)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) DeleteSalaryScheme(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+	schemeID, err := strconv.Atoi(c.Param("scheme_id"))
+	if err != nil {
+		logger.Warning("Неверный ID схемы оплаты: %s", c.Param("scheme_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Worker.DeleteSalaryScheme(workerID, schemeID); err != nil {
+		logger.Error("Ошибка при удалении схемы оплаты ID:%d: %v", schemeID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Удалена схема оплаты ID:%d работника ID:%d", schemeID, workerID)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// CalculateSalary возвращает расчет зарплаты работника за период ?start=&end= по частям схем
+func (h *Handler) CalculateSalary(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	calc, err := h.services.Worker.CalculateSalary(workerID, start, end)
+	if err != nil {
+		logger.Error("Ошибка при расчете зарплаты работника ID:%d: %v", workerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, calc)
+}
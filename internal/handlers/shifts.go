@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetShiftTemplates(c *gin.Context) {
+	logger.Debug("Получен запрос на получение шаблонов смен")
+	templates, err := h.services.Shift.GetTemplates()
+	if err != nil {
+		logger.Error("Ошибка при получении шаблонов смен: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (h *Handler) CreateShiftTemplate(c *gin.Context) {
+	logger.Debug("Получен запрос на создание шаблона смены")
+	var input models.ShiftTemplate
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при создании шаблона смены: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := h.services.Shift.CreateTemplate(input)
+	if err != nil {
+		logger.Error("Ошибка при создании шаблона смены: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создан шаблон смены ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateShiftTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID шаблона смены: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input models.ShiftTemplate
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении шаблона смены: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Shift.UpdateTemplate(id, input); err != nil {
+		logger.Error("Ошибка при обновлении шаблона смены ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно обновлен шаблон смены ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) DeleteShiftTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID шаблона смены: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Shift.DeleteTemplate(id); err != nil {
+		logger.Error("Ошибка при удалении шаблона смены ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно удален шаблон смены ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// GetRoster возвращает график смен на дни start-end
+func (h *Handler) GetRoster(c *gin.Context) {
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	roster, err := h.services.Shift.GetRoster(start, end)
+	if err != nil {
+		logger.Error("Ошибка при получении графика смен: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, roster)
+}
+
+// AddToRoster ставит работников в график на день по шаблону смены
+func (h *Handler) AddToRoster(c *gin.Context) {
+	logger.Debug("Получен запрос на назначение смены")
+	var input models.RosterRequest
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при назначении смены: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат date, ожидается YYYY-MM-DD"})
+		return
+	}
+
+	ids, err := h.services.Shift.AddToRoster(date, input.TemplateID, input.WorkerIDs, c.GetInt(userCtx))
+	if err != nil {
+		logger.Error("Ошибка при назначении смены на %s: %v", input.Date, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("На %s назначено смен: %d", input.Date, len(ids))
+	c.JSON(http.StatusCreated, gin.H{"ids": ids})
+}
+
+func (h *Handler) RemoveFromRoster(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID смены: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Shift.RemoveFromRoster(id); err != nil {
+		logger.Error("Ошибка при удалении смены ID:%d из графика: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Смена ID:%d удалена из графика", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// GetTimeEntries возвращает отметки прихода и ухода за период, ?worker_id= ограничивает одним работником
+func (h *Handler) GetTimeEntries(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.DefaultQuery("worker_id", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID работника"})
+		return
+	}
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.services.Shift.GetTimeEntries(workerID, start, end)
+	if err != nil {
+		logger.Error("Ошибка при получении отметок табеля: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// UpdateTimeEntry исправляет время прихода и ухода, время в формате RFC3339
+func (h *Handler) UpdateTimeEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID отметки табеля: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input models.TimeEntry
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при исправлении отметки табеля: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Shift.UpdateTimeEntry(id, input); err != nil {
+		logger.Error("Ошибка при исправлении отметки табеля ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Исправлена отметка табеля ID:%d", id)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+// GetTimesheet возвращает табель: смены и часы каждого работника по графику и по отметкам
+func (h *Handler) GetTimesheet(c *gin.Context) {
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	timesheet, err := h.services.Shift.Timesheet(start, end)
+	if err != nil {
+		logger.Error("Ошибка при формировании табеля: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, timesheet)
+}
+
+// ClockIn отмечает приход работника, вошедшего в систему
+func (h *Handler) ClockIn(c *gin.Context) {
+	userID := c.GetInt(userCtx)
+	id, err := h.services.Shift.ClockIn(userID)
+	if err != nil {
+		logger.Error("Ошибка при отметке прихода user_id:%d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// ClockOut отмечает уход работника, вошедшего в систему
+func (h *Handler) ClockOut(c *gin.Context) {
+	userID := c.GetInt(userCtx)
+	id, err := h.services.Shift.ClockOut(userID)
+	if err != nil {
+		logger.Error("Ошибка при отметке ухода user_id:%d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// GetMyShift показывает работнику, отмечен ли он на смене, и его смены по графику на сегодня
+func (h *Handler) GetMyShift(c *gin.Context) {
+	userID := c.GetInt(userCtx)
+	status, err := h.services.Shift.Status(userID)
+	if err != nil {
+		logger.Error("Ошибка при получении смены user_id:%d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
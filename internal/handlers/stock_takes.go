@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMaterialMovements возвращает журнал движения материала
+func (h *Handler) GetMaterialMovements(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID материала при получении журнала движения: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на журнал движения материала ID:%d", id)
+	movements, err := h.services.Material.GetMovements(id)
+	if err != nil {
+		logger.Error("Ошибка при получении журнала движения материала ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, movements)
+}
+
+func (h *Handler) GetStockTakes(c *gin.Context) {
+	logger.Debug("Получен запрос на получение списка инвентаризаций")
+	stockTakes, err := h.services.Material.GetStockTakes()
+	if err != nil {
+		logger.Error("Ошибка при получении списка инвентаризаций: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stockTakes)
+}
+
+func (h *Handler) OpenStockTake(c *gin.Context) {
+	logger.Debug("Получен запрос на открытие инвентаризации")
+	var input models.StockTake
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&input); err != nil {
+			logger.Warning("Ошибка привязки JSON при открытии инвентаризации: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+			return
+		}
+	}
+
+	userID := c.GetInt(userCtx)
+	input.OpenedBy = &userID
+
+	id, err := h.services.Material.OpenStockTake(input)
+	if err != nil {
+		logger.Error("Ошибка при открытии инвентаризации: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Открыта инвентаризация ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// GetStockTake возвращает инвентаризацию с проходами пересчета и расхождениями с остатками
+func (h *Handler) GetStockTake(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID инвентаризации: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на получение инвентаризации ID:%d", id)
+	stockTake, err := h.services.Material.GetStockTake(id)
+	if err != nil {
+		logger.Error("Ошибка при получении инвентаризации ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stockTake)
+}
+
+// AddStockTakeCounts записывает проход пересчета: {"items": [{"material_id": 1, "quantity": 10}]}
+func (h *Handler) AddStockTakeCounts(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID инвентаризации при записи пересчета: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input struct {
+		Items []models.StockTakeCount `json:"items"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при записи пересчета: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	logger.Debug("Получен запрос на запись пересчета в инвентаризацию ID:%d", id)
+	if err := h.services.Material.AddStockTakeCounts(id, c.GetInt(userCtx), input.Items); err != nil {
+		logger.Error("Ошибка при записи пересчета в инвентаризацию ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "пересчет записан"})
+}
+
+func (h *Handler) DeleteStockTakeCount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID инвентаризации при удалении пересчета: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+	countID, err := strconv.Atoi(c.Param("count_id"))
+	if err != nil {
+		logger.Warning("Неверный ID записи пересчета: %s", c.Param("count_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на удаление пересчета ID:%d из инвентаризации ID:%d", countID, id)
+	if err := h.services.Material.DeleteStockTakeCount(id, countID); err != nil {
+		logger.Error("Ошибка при удалении пересчета ID:%d: %v", countID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// ApproveStockTake проводит корректировки остатков и закрывает инвентаризацию
+func (h *Handler) ApproveStockTake(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID инвентаризации при утверждении: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на утверждение инвентаризации ID:%d", id)
+	if err := h.services.Material.ApproveStockTake(id, c.GetInt(userCtx)); err != nil {
+		logger.Error("Ошибка при утверждении инвентаризации ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Инвентаризация ID:%d утверждена", id)
+	c.JSON(http.StatusOK, gin.H{"status": "инвентаризация утверждена"})
+}
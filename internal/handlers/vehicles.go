@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/plate"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetVehicleProfile возвращает карточку машины со схемой осей и владельцами
+func (h *Handler) GetVehicleProfile(c *gin.Context) {
+	number := plate.Normalize(c.Param("number"))
+	logger.Debug("Получен запрос на получение профиля машины %s", number)
+
+	profile, err := h.services.Vehicle.GetProfile(number)
+	if err != nil {
+		logger.Error("Ошибка при получении профиля машины %s: %v", number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) UpdateVehicleProfile(c *gin.Context) {
+	number := plate.Normalize(c.Param("number"))
+	logger.Debug("Получен запрос на обновление профиля машины %s", number)
+
+	var input models.Car
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении профиля машины: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Vehicle.UpdateProfile(number, input); err != nil {
+		logger.Error("Ошибка при обновлении профиля машины %s: %v", number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Профиль машины %s успешно обновлен", number)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+// GetVehicleLayout возвращает схему колес машины, по ней выбирается позиция колеса в заказе
+func (h *Handler) GetVehicleLayout(c *gin.Context) {
+	number := plate.Normalize(c.Param("number"))
+	logger.Debug("Получен запрос на получение схемы колес машины %s", number)
+
+	layout, err := h.services.Vehicle.GetLayout(number)
+	if err != nil {
+		logger.Error("Ошибка при получении схемы колес машины %s: %v", number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, layout)
+}
+
+// GetVehiclePresets возвращает типы машин и типовые схемы осей
+func (h *Handler) GetVehiclePresets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"vehicle_types": models.VehicleTypes,
+		"axle_presets":  models.AxlePresets,
+	})
+}
+
+// GetVehicleHistory возвращает все заказы по машине и сводку по позициям колес, окно задается параметром days
+func (h *Handler) GetVehicleHistory(c *gin.Context) {
+	number := plate.Normalize(c.Param("number"))
+	days := 0
+	if value := c.Query("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			logger.Warning("Неверное количество дней: %s", value)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверное количество дней"})
+			return
+		}
+	}
+	logger.Debug("Получен запрос на получение истории машины %s", number)
+
+	history, err := h.services.Vehicle.GetHistory(number, days)
+	if err != nil {
+		logger.Error("Ошибка при получении истории машины %s: %v", number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// SearchVehicles ищет машины по номеру с учетом кириллицы и одной опечатки
+func (h *Handler) SearchVehicles(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "не указан номер для поиска"})
+		return
+	}
+	logger.Debug("Получен запрос на поиск машины по номеру: %s", query)
+
+	results, err := h.services.Vehicle.Search(query)
+	if err != nil {
+		logger.Error("Ошибка при поиске машины по номеру %s: %v", query, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// TransferVehicle передает машину другому клиенту с сохранением истории владельцев
+func (h *Handler) TransferVehicle(c *gin.Context) {
+	number := plate.Normalize(c.Param("number"))
+	logger.Debug("Получен запрос на передачу машины %s", number)
+
+	var input models.CarTransfer
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при передаче машины: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Vehicle.Transfer(number, input); err != nil {
+		logger.Error("Ошибка при передаче машины %s: %v", number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Машина %s успешно передана клиенту ID:%d", number, input.ToClientID)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryID читает необязательный числовой параметр запроса, отсутствующий параметр = 0
+func queryID(c *gin.Context, name string) (int, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Warning("Неверное значение параметра %s: %s", name, value)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) GetWarehouses(c *gin.Context) {
+	logger.Debug("Получен запрос на получение списка складов")
+	warehouses, err := h.services.Material.GetWarehouses()
+	if err != nil {
+		logger.Error("Ошибка при получении списка складов: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouses)
+}
+
+func (h *Handler) CreateWarehouse(c *gin.Context) {
+	logger.Debug("Получен запрос на создание склада")
+	var input models.Warehouse
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при создании склада: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	id, err := h.services.Material.CreateWarehouse(input)
+	if err != nil {
+		logger.Error("Ошибка при создании склада: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создан склад ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID склада при обновлении: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на обновление склада ID:%d", id)
+	var input models.Warehouse
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при обновлении склада: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	if err := h.services.Material.UpdateWarehouse(id, input); err != nil {
+		logger.Error("Ошибка при обновлении склада ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+func (h *Handler) DeleteWarehouse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID склада при удалении: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	logger.Debug("Получен запрос на удаление склада ID:%d", id)
+	if err := h.services.Material.DeleteWarehouse(id); err != nil {
+		logger.Error("Ошибка при удалении склада ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// GetMaterialBalances возвращает остатки по складам, фильтры ?warehouse_id= и ?material_id=
+func (h *Handler) GetMaterialBalances(c *gin.Context) {
+	warehouseID, ok := queryID(c, "warehouse_id")
+	if !ok {
+		return
+	}
+	materialID, ok := queryID(c, "material_id")
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на остатки по складам")
+	balances, err := h.services.Material.GetBalances(warehouseID, materialID)
+	if err != nil {
+		logger.Error("Ошибка при получении остатков по складам: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balances)
+}
+
+// AdjustMaterialStock приводит остаток материала на складе к фактическому количеству.
+// Остаток меняется только так или движениями, редактирование материала его не трогает.
+func (h *Handler) AdjustMaterialStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input struct {
+		WarehouseID int      `json:"warehouse_id" binding:"required"`
+		Quantity    *float64 `json:"quantity" binding:"required"` // фактический остаток на складе
+		Description string   `json:"description"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при корректировке остатка: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	logger.Debug("Получен запрос на корректировку остатка материала ID:%d на складе ID:%d", id, input.WarehouseID)
+	err = h.services.Material.AdjustStock(id, input.WarehouseID, *input.Quantity, input.Description, c.GetInt(userCtx))
+	if err != nil {
+		logger.Error("Ошибка при корректировке остатка материала ID:%d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "успешно обновлено"})
+}
+
+// GetMaterialTransfers возвращает перемещения, фильтр ?warehouse_id= по складу отправления или назначения
+func (h *Handler) GetMaterialTransfers(c *gin.Context) {
+	warehouseID, ok := queryID(c, "warehouse_id")
+	if !ok {
+		return
+	}
+
+	logger.Debug("Получен запрос на список перемещений")
+	transfers, err := h.services.Material.GetTransfers(warehouseID)
+	if err != nil {
+		logger.Error("Ошибка при получении списка перемещений: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+func (h *Handler) CreateMaterialTransfer(c *gin.Context) {
+	logger.Debug("Получен запрос на перемещение материала")
+	var input models.MaterialTransfer
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при перемещении материала: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	userID := c.GetInt(userCtx)
+	input.UserID = &userID
+
+	id, err := h.services.Material.Transfer(input)
+	if err != nil {
+		logger.Error("Ошибка при перемещении материала: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Успешно создано перемещение ID:%d", id)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
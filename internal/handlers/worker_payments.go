@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWorkerPayments возвращает авансы и выплаты работнику за период ?start=&end=
+func (h *Handler) GetWorkerPayments(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+	start, end, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	payments, err := h.services.Worker.GetPayments(workerID, start, end)
+	if err != nil {
+		logger.Error("Ошибка при получении выплат работнику ID:%d: %v", workerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+// AddWorkerPayment записывает аванс или выплату работнику, paid_at в формате YYYY-MM-DD
+func (h *Handler) AddWorkerPayment(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	var input struct {
+		Kind        string       `json:"kind"`
+		Amount      money.Amount `json:"amount"`
+		PaidAt      string       `json:"paid_at"`
+		Method      string       `json:"method"`
+		PeriodID    *int         `json:"period_id"`
+		Description string       `json:"description"`
+	}
+	if err := c.BindJSON(&input); err != nil {
+		logger.Warning("Ошибка привязки JSON при записи выплаты: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат данных"})
+		return
+	}
+
+	paidAt, err := time.Parse("2006-01-02", input.PaidAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный формат paid_at, ожидается YYYY-MM-DD"})
+		return
+	}
+
+	payment := models.WorkerPayment{
+		WorkerID:    workerID,
+		Kind:        input.Kind,
+		Amount:      input.Amount,
+		PaidAt:      paidAt,
+		Method:      input.Method,
+		PeriodID:    input.PeriodID,
+		Description: input.Description,
+	}
+	if userID := c.GetInt(userCtx); userID != 0 {
+		payment.IssuedBy = &userID
+	}
+
+	id, err := h.services.Worker.AddPayment(payment)
+	if err != nil {
+		logger.Error("Ошибка при записи выплаты работнику ID:%d: %v", workerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Работнику ID:%d записана выплата '%s' на %s", workerID, input.Kind, input.Amount)
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func (h *Handler) DeleteWorkerPayment(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+	paymentID, err := strconv.Atoi(c.Param("payment_id"))
+	if err != nil {
+		logger.Warning("Неверный ID выплаты: %s", c.Param("payment_id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	if err := h.services.Worker.DeletePayment(workerID, paymentID); err != nil {
+		logger.Error("Ошибка при удалении выплаты ID:%d: %v", paymentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Info("Удалена выплата ID:%d работника ID:%d", paymentID, workerID)
+	c.JSON(http.StatusOK, gin.H{"status": "успешно удалено"})
+}
+
+// GetWorkerBalance возвращает долг перед работником: заработано минус выдано
+func (h *Handler) GetWorkerBalance(c *gin.Context) {
+	workerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Warning("Неверный ID работника: %s", c.Param("id"))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный ID"})
+		return
+	}
+
+	balance, err := h.services.Worker.Balance(workerID)
+	if err != nil {
+		logger.Error("Ошибка при расчете баланса работника ID:%d: %v", workerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
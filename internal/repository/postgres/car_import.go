@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// GetCarOwnerships возвращает машины из списка номеров, которые уже есть в базе, с текущими владельцами
+func (r *Repository) GetCarOwnerships(numbers []string) ([]models.CarOwnership, error) {
+	var ownerships []models.CarOwnership
+	query := `
+		SELECT c.number, c.id AS car_id,
+			   COALESCE(array_agg(cc.client_id ORDER BY cc.valid_from) FILTER (WHERE cc.client_id IS NOT NULL), ARRAY[]::integer[]) AS client_ids
+		FROM cars c
+		LEFT JOIN clients_cars cc ON cc.car_id = c.id AND cc.valid_to IS NULL
+		WHERE c.number = ANY($1)
+		GROUP BY c.id, c.number`
+
+	logger.Debug("Получение владельцев для %d номеров", len(numbers))
+	err := r.db.Select(&ownerships, query, pq.Array(numbers))
+	if err != nil {
+		logger.Error("Ошибка при получении владельцев машин: %v", err)
+		return nil, fmt.Errorf("ошибка при получении владельцев машин: %w", err)
+	}
+
+	return ownerships, nil
+}
+
+// ImportClientCars применяет строки загрузки машин одной транзакцией: при ошибке в любой строке
+// не сохраняется ничего. Строки без изменений (повторы, чужие машины, ошибки) пропускаются.
+func (r *Repository) ImportClientCars(clientID int, rows []models.CarImportRow) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		if err = importClientCar(tx, clientID, row); err != nil {
+			logger.Error("Ошибка при загрузке машины %s в строке %d: %v", row.Number, row.Row, err)
+			return fmt.Errorf("строка %d, машина %s: %w", row.Row, row.Number, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Загрузка машин клиента ID:%d сохранена, строк: %d", clientID, len(rows))
+	return nil
+}
+
+func importClientCar(tx *sql.Tx, clientID int, row models.CarImportRow) error {
+	var carID int
+	switch row.Status {
+	case models.CarImportAdded:
+		car := row.Car
+		err := tx.QueryRow(`
+			INSERT INTO cars (number, model, year, vehicle_type, vin)
+			VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), NULLIF($5, ''))
+			ON CONFLICT (number) DO UPDATE SET updated_at = CURRENT_TIMESTAMP
+			RETURNING id`,
+			car.Number, car.Model, car.Year, car.VehicleType, car.VIN).Scan(&carID)
+		if err != nil {
+			return carWriteError("ошибка при создании машины", err)
+		}
+	case models.CarImportLinked, models.CarImportMoved, models.CarImportShared:
+		if err := tx.QueryRow(`SELECT id FROM cars WHERE number = $1 FOR UPDATE`, row.Number).Scan(&carID); err != nil {
+			return fmt.Errorf("ошибка при поиске машины: %w", err)
+		}
+	default:
+		return nil
+	}
+
+	if row.Status == models.CarImportMoved {
+		_, err := tx.Exec(`
+			UPDATE clients_cars SET valid_to = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE car_id = $1 AND valid_to IS NULL AND client_id <> $2`, carID, clientID)
+		if err != nil {
+			return fmt.Errorf("ошибка при закрытии привязки к прежнему владельцу: %w", err)
+		}
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO clients_cars (client_id, car_id)
+		SELECT $1, $2
+		WHERE NOT EXISTS (SELECT 1 FROM clients_cars WHERE client_id = $1 AND car_id = $2 AND valid_to IS NULL)`,
+		clientID, carID)
+	if err != nil {
+		return fmt.Errorf("ошибка при привязке машины к клиенту: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Общий список полей машины, ожидает алиас c
+const carColumns = `c.id, c.number, COALESCE(c.model, '') AS model, COALESCE(c.year, 0) AS year,
+		COALESCE(c.vehicle_type, '') AS vehicle_type, COALESCE(c.vin, '') AS vin, c.created_at, c.updated_at`
+
+// carWriteError переводит нарушения ограничений таблицы cars в понятные сообщения
+func carWriteError(msg string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_cars_vin" {
+		return fmt.Errorf("машина с таким VIN уже существует")
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// saveCarAxles заменяет схему осей машины
+func saveCarAxles(tx *sql.Tx, carID int, axles []models.CarAxle) error {
+	if _, err := tx.Exec(`DELETE FROM car_axles WHERE car_id = $1`, carID); err != nil {
+		logger.Error("Ошибка при удалении осей машины ID %d: %v", carID, err)
+		return fmt.Errorf("ошибка при удалении осей машины: %w", err)
+	}
+	for _, axle := range axles {
+		_, err := tx.Exec(`
+			INSERT INTO car_axles (car_id, axle_number, is_dual, tire_size)
+			VALUES ($1, $2, $3, NULLIF($4, ''))`,
+			carID, axle.Number, axle.Dual, axle.TireSize)
+		if err != nil {
+			logger.Error("Ошибка при сохранении оси %d машины ID %d: %v", axle.Number, carID, err)
+			return fmt.Errorf("ошибка при сохранении осей машины: %w", err)
+		}
+	}
+	return nil
+}
+
+func getCarAxles(db *sqlx.DB, carID int) ([]models.CarAxle, error) {
+	var axles []models.CarAxle
+	query := `
+		SELECT axle_number, is_dual, COALESCE(tire_size, '') AS tire_size
+		FROM car_axles
+		WHERE car_id = $1
+		ORDER BY axle_number`
+
+	if err := db.Select(&axles, query, carID); err != nil {
+		logger.Error("Ошибка при получении осей машины ID %d: %v", carID, err)
+		return nil, fmt.Errorf("ошибка при получении осей машины: %w", err)
+	}
+	return axles, nil
+}
+
+// GetCarByNumber возвращает машину вместе со схемой осей
+func (r *Repository) GetCarByNumber(number string) (models.Car, error) {
+	var car models.Car
+	query := `SELECT ` + carColumns + ` FROM cars c WHERE c.number = $1`
+
+	logger.Debug("Получение машины с номером: %s", number)
+	err := r.db.Get(&car, query, number)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Car{}, fmt.Errorf("машина с номером %s не найдена", number)
+		}
+		logger.Error("Ошибка при получении машины %s: %v", number, err)
+		return models.Car{}, fmt.Errorf("ошибка при получении машины: %w", err)
+	}
+
+	car.Axles, err = getCarAxles(r.db, car.ID)
+	if err != nil {
+		return models.Car{}, err
+	}
+
+	return car, nil
+}
+
+// UpdateCarProfile обновляет данные машины и полностью заменяет схему осей
+func (r *Repository) UpdateCarProfile(number string, car models.Car) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	query := `
+		UPDATE cars
+		SET model = $1, year = NULLIF($2, 0), vehicle_type = NULLIF($3, ''), vin = NULLIF($4, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE number = $5
+		RETURNING id`
+
+	logger.Debug("Обновление профиля машины %s", number)
+	err = tx.QueryRow(query, car.Model, car.Year, car.VehicleType, car.VIN, number).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("машина с номером %s не найдена", number)
+		}
+		logger.Error("Ошибка при обновлении машины %s: %v", number, err)
+		return carWriteError("ошибка при обновлении машины", err)
+	}
+
+	if err = saveCarAxles(tx, id, car.Axles); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Профиль машины %s обновлен, осей: %d", number, len(car.Axles))
+	return nil
+}
+
+// GetCarOwners возвращает историю владельцев машины, включая закрытые привязки
+func (r *Repository) GetCarOwners(carID int) ([]models.CarOwner, error) {
+	var owners []models.CarOwner
+	query := `
+		SELECT cl.id AS client_id, cl.name AS client_name, cc.valid_from, cc.valid_to
+		FROM clients_cars cc
+		JOIN clients cl ON cl.id = cc.client_id
+		WHERE cc.car_id = $1
+		ORDER BY cc.valid_from, cc.id`
+
+	logger.Debug("Получение владельцев машины ID: %d", carID)
+	err := r.db.Select(&owners, query, carID)
+	if err != nil {
+		logger.Error("Ошибка при получении владельцев машины: %v", err)
+		return nil, fmt.Errorf("ошибка при получении владельцев машины: %w", err)
+	}
+
+	return owners, nil
+}
+
+// GetVehicleOrders возвращает все заказы по номеру машины независимо от клиента, новые первыми
+func (r *Repository) GetVehicleOrders(number string) ([]models.VehicleHistoryOrder, error) {
+	var orders []models.VehicleHistoryOrder
+	query := `
+		SELECT o.id, o.status, o.client_id, COALESCE(cl.name, '') AS client_name,
+			   o.worker_id, COALESCE(w.name || ' ' || w.surname, '') AS worker_name,
+			   o.payment_method, o.total_amount, o.created_at
+		FROM orders o
+		LEFT JOIN clients cl ON cl.id = o.client_id
+		LEFT JOIN workers w ON w.id = o.worker_id
+		WHERE o.vehicle_number = $1
+		ORDER BY o.created_at DESC`
+
+	logger.Debug("Получение истории заказов машины %s", number)
+	err := r.db.Select(&orders, query, number)
+	if err != nil {
+		logger.Error("Ошибка при получении истории заказов машины %s: %v", number, err)
+		return nil, fmt.Errorf("ошибка при получении истории заказов машины: %w", err)
+	}
+
+	for i := range orders {
+		err = r.db.Select(&orders[i].Services, `
+			SELECT os.service_id, COALESCE(s.name, '') AS service_name, COALESCE(os.service_description, '') AS service_description,
+				   COALESCE(os.wheel_position, '') AS wheel_position, os.price
+			FROM order_services os
+			LEFT JOIN services s ON s.id = os.service_id
+			WHERE os.order_id = $1
+			ORDER BY os.id`, orders[i].OrderID)
+		if err != nil {
+			logger.Error("Ошибка при получении услуг заказа %d: %v", orders[i].OrderID, err)
+			return nil, fmt.Errorf("ошибка при получении услуг заказа: %w", err)
+		}
+
+		orders[i].Materials, err = r.GetOrderMaterials(orders[i].OrderID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Debug("Получено заказов по машине %s: %d", number, len(orders))
+	return orders, nil
+}
+
+// GetCarSearchCandidates возвращает до limit машин, номер которых совпадает с запросом, содержит его
+// (если запрос не короче minPartialLen) или отличается от него не больше чем на maxDistance символов.
+// Сначала идут точные совпадения, затем частичные и с опечатками, ближе к запросу раньше.
+func (r *Repository) GetCarSearchCandidates(number string, maxDistance, minPartialLen, limit int) ([]models.Car, error) {
+	var cars []models.Car
+	query := `
+		SELECT ` + carColumns + `
+		FROM cars c
+		WHERE c.number = $1
+		   OR (length($1) >= $3 AND c.number LIKE '%' || $1 || '%')
+		   OR levenshtein_less_equal(c.number, $1, $2) <= $2
+		ORDER BY CASE
+				WHEN c.number = $1 THEN 0
+				WHEN length($1) >= $3 AND strpos(c.number, $1) > 0 THEN 1
+				ELSE 2
+			END,
+			levenshtein(c.number, $1), c.number
+		LIMIT $4`
+
+	logger.Debug("Поиск машин по номеру: %s", number)
+	err := r.db.Select(&cars, query, number, maxDistance, minPartialLen, limit)
+	if err != nil {
+		logger.Error("Ошибка при поиске машин по номеру %s: %v", number, err)
+		return nil, fmt.Errorf("ошибка при поиске машин по номеру: %w", err)
+	}
+
+	return cars, nil
+}
+
+// GetCarOwnerAt возвращает клиента, за которым машина числилась на указанный момент.
+// Если владельцев несколько, берется привязанный последним. 0 = владельца нет.
+func (r *Repository) GetCarOwnerAt(number string, at time.Time) (int, error) {
+	var clientID int
+	query := `
+		SELECT cc.client_id
+		FROM clients_cars cc
+		JOIN cars ON cars.id = cc.car_id
+		WHERE cars.number = $1 AND cc.valid_from <= $2 AND (cc.valid_to IS NULL OR cc.valid_to > $2)
+		ORDER BY cc.valid_from DESC, cc.id DESC
+		LIMIT 1`
+
+	logger.Debug("Поиск владельца машины %s на %v", number, at)
+	err := r.db.Get(&clientID, query, number, at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		logger.Error("Ошибка при поиске владельца машины %s: %v", number, err)
+		return 0, fmt.Errorf("ошибка при поиске владельца машины: %w", err)
+	}
+
+	return clientID, nil
+}
+
+// TransferCar закрывает привязку машины к прежнему владельцу и открывает привязку к новому на дату передачи
+func (r *Repository) TransferCar(number string, transfer models.CarTransfer, at time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var carID int
+	err = tx.QueryRow(`SELECT id FROM cars WHERE number = $1 FOR UPDATE`, number).Scan(&carID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("машина с номером %s не найдена", number)
+		}
+		logger.Error("Ошибка при поиске машины %s: %v", number, err)
+		return fmt.Errorf("ошибка при поиске машины: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE clients_cars
+		SET valid_to = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE car_id = $2 AND valid_to IS NULL AND client_id <> $3 AND ($4 = 0 OR client_id = $4)`,
+		at, carID, transfer.ToClientID, transfer.FromClientID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23514" {
+			return fmt.Errorf("дата передачи раньше начала владения прежнего клиента")
+		}
+		logger.Error("Ошибка при закрытии привязки машины %s: %v", number, err)
+		return fmt.Errorf("ошибка при закрытии привязки машины: %w", err)
+	}
+	closed, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if transfer.FromClientID != 0 && closed == 0 {
+		return fmt.Errorf("машина %s не числится за клиентом ID %d", number, transfer.FromClientID)
+	}
+
+	// Если новый клиент уже владеет машиной, новую привязку не открываем
+	_, err = tx.Exec(`
+		INSERT INTO clients_cars (client_id, car_id, valid_from)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (SELECT 1 FROM clients_cars WHERE client_id = $1 AND car_id = $2 AND valid_to IS NULL)`,
+		transfer.ToClientID, carID, at)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("клиент с ID %d не найден", transfer.ToClientID)
+		}
+		logger.Error("Ошибка при привязке машины %s к клиенту: %v", number, err)
+		return fmt.Errorf("ошибка при привязке машины к клиенту: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Машина %s передана клиенту ID:%d, закрыто привязок: %d", number, transfer.ToClientID, closed)
+	return nil
+}
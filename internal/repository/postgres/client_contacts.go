@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const contactColumns = `id, client_id, name, role, phones, COALESCE(email, '') AS email, COALESCE(messenger, '') AS messenger,
+		notify_sms, notify_email, notify_messenger, created_at, updated_at`
+
+// legacyPhoneDigits приводит свободно записанный телефон клиента к цифрам E.164 без плюса: 8 (916) 123-45-67 -> 79161234567
+func legacyPhoneDigits(column string) string {
+	return `regexp_replace(regexp_replace(regexp_replace(` + column + `, '[^0-9]', '', 'g'), '^8([0-9]{10})$', '7\1'), '^(9[0-9]{9})$', '7\1')`
+}
+
+func (r *Repository) GetClientContacts(clientID int) ([]models.ClientContact, error) {
+	var contacts []models.ClientContact
+	query := `SELECT ` + contactColumns + ` FROM client_contacts WHERE client_id = $1 ORDER BY id`
+
+	logger.Debug("Получение контактов клиента ID: %d", clientID)
+	err := r.db.Select(&contacts, query, clientID)
+	if err != nil {
+		logger.Error("Ошибка при получении контактов клиента: %v", err)
+		return nil, fmt.Errorf("ошибка при получении контактов клиента: %w", err)
+	}
+
+	return contacts, nil
+}
+
+func (r *Repository) CreateClientContact(contact models.ClientContact) (int, error) {
+	var id int
+	query := `
+		INSERT INTO client_contacts (client_id, name, role, phones, email, messenger, notify_sms, notify_email, notify_messenger)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9)
+		RETURNING id`
+
+	logger.Debug("Создание контакта клиента ID %d: %s", contact.ClientID, contact.Name)
+	err := r.db.QueryRow(query, contact.ClientID, contact.Name, contact.Role, contact.Phones, contact.Email, contact.Messenger,
+		contact.NotifySMS, contact.NotifyEmail, contact.NotifyMessenger).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, fmt.Errorf("клиент с ID %d не найден", contact.ClientID)
+		}
+		logger.Error("Ошибка при создании контакта клиента: %v", err)
+		return 0, fmt.Errorf("ошибка при создании контакта клиента: %w", err)
+	}
+
+	logger.Info("Контакт клиента успешно создан с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) UpdateClientContact(contact models.ClientContact) error {
+	query := `
+		UPDATE client_contacts
+		SET name = $1, role = $2, phones = $3, email = NULLIF($4, ''), messenger = NULLIF($5, ''),
+			notify_sms = $6, notify_email = $7, notify_messenger = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $9 AND client_id = $10`
+
+	logger.Debug("Обновление контакта ID: %d", contact.ID)
+	result, err := r.db.Exec(query, contact.Name, contact.Role, contact.Phones, contact.Email, contact.Messenger,
+		contact.NotifySMS, contact.NotifyEmail, contact.NotifyMessenger, contact.ID, contact.ClientID)
+	if err != nil {
+		logger.Error("Ошибка при обновлении контакта клиента: %v", err)
+		return fmt.Errorf("ошибка при обновлении контакта клиента: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("контакт с ID %d не найден у клиента", contact.ID)
+	}
+
+	logger.Info("Контакт клиента успешно обновлен")
+	return nil
+}
+
+func (r *Repository) DeleteClientContact(clientID, id int) error {
+	logger.Debug("Удаление контакта ID: %d", id)
+	result, err := r.db.Exec(`DELETE FROM client_contacts WHERE id = $1 AND client_id = $2`, id, clientID)
+	if err != nil {
+		logger.Error("Ошибка при удалении контакта клиента: %v", err)
+		return fmt.Errorf("ошибка при удалении контакта клиента: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("контакт с ID %d не найден у клиента", id)
+	}
+
+	logger.Info("Контакт клиента успешно удален")
+	return nil
+}
+
+// FindClientsByPhone ищет клиентов по телефону в формате E.164 среди контактов,
+// а также в телефонах владельца и менеджера самого клиента. Клиент налички (ID 1) с заглушкой вместо телефона не ищется
+func (r *Repository) FindClientsByPhone(number string) ([]models.Client, error) {
+	var clients []models.Client
+	query := `
+		SELECT c.id, c.name, c.client_type, c.created_at, c.updated_at,
+			   COALESCE(array_remove(array_agg(cars.number), NULL), ARRAY[]::varchar[]) as car_numbers,
+			   c.owner_phone, c.manager_phone, c.contract_id
+		FROM clients c
+		LEFT JOIN clients_cars cc ON c.id = cc.client_id AND cc.valid_to IS NULL
+		LEFT JOIN cars ON cc.car_id = cars.id
+		WHERE c.id <> 1
+		  AND (c.id IN (SELECT client_id FROM client_contacts WHERE phones @> ARRAY[$1]::varchar[])
+		   OR ` + legacyPhoneDigits("c.owner_phone") + ` = $2
+		   OR ` + legacyPhoneDigits("c.manager_phone") + ` = $2)
+		GROUP BY c.id, c.name, c.client_type, c.created_at, c.updated_at
+		ORDER BY c.name`
+
+	logger.Debug("Поиск клиентов по телефону: %s", number)
+	err := r.db.Select(&clients, query, number, strings.TrimPrefix(number, "+"))
+	if err != nil {
+		logger.Error("Ошибка при поиске клиентов по телефону: %v", err)
+		return nil, fmt.Errorf("ошибка при поиске клиентов по телефону: %w", err)
+	}
+
+	return clients, nil
+}
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+)
+
+// GetClientLinks возвращает пары клиентов с общей действующей машиной или одинаковым ИНН договора.
+// Нулевой ИНН договора для наличных не считается совпадением.
+func (r *Repository) GetClientLinks() ([]models.ClientLink, error) {
+	var links []models.ClientLink
+	query := `
+		SELECT a.client_id, b.client_id AS duplicate_id, 'общая машина ' || cars.number AS reason
+		FROM clients_cars a
+		JOIN clients_cars b ON b.car_id = a.car_id AND b.client_id > a.client_id AND b.valid_to IS NULL
+		JOIN cars ON cars.id = a.car_id
+		WHERE a.valid_to IS NULL
+		UNION ALL
+		SELECT a.id, b.id, 'одинаковый ИНН ' || ca.client_company_inn
+		FROM clients a
+		JOIN contracts ca ON ca.id = a.contract_id
+		JOIN clients b ON b.id > a.id
+		JOIN contracts cb ON cb.id = b.contract_id
+		WHERE ca.client_company_inn = cb.client_company_inn AND ca.client_company_inn !~ '^0*$'
+		ORDER BY 1, 2`
+
+	logger.Debug("Поиск совпадений клиентов по машинам и ИНН")
+	err := r.db.Select(&links, query)
+	if err != nil {
+		logger.Error("Ошибка при поиске совпадений клиентов: %v", err)
+		return nil, fmt.Errorf("ошибка при поиске совпадений клиентов: %w", err)
+	}
+
+	return links, nil
+}
+
+// MergeClients переносит машины, заказы, онлайн-записи, контакты, расчеты и предоплату клиента sourceID в targetID,
+// удаляет sourceID и оставляет запись о том, куда он влит. Клиент налички не участвует в объединении:
+// на нем розничные заказы, и данные клиента с договором не должны в них попасть
+func (r *Repository) MergeClients(targetID, sourceID, userID int) (models.ClientMergeResult, error) {
+	result := models.ClientMergeResult{ClientID: targetID}
+	if sourceID == models.CashClientID {
+		return result, fmt.Errorf("клиента налички нельзя влить в другого клиента")
+	}
+	if targetID == models.CashClientID {
+		return result, fmt.Errorf("нельзя влить клиента в клиента налички")
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return result, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sourceName string
+	err = tx.QueryRow(`SELECT name FROM clients WHERE id = $1 FOR UPDATE`, sourceID).Scan(&sourceName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return result, fmt.Errorf("клиент с ID %d не найден", sourceID)
+		}
+		return result, fmt.Errorf("ошибка при получении клиента: %w", err)
+	}
+
+	// Пустые телефоны оставшегося клиента заполняем телефонами объединяемого, допустимый минус по предоплате
+	// берем больший из двух. Баланс предоплаты складывается из движений, они переносятся ниже
+	res, err := tx.Exec(`
+		UPDATE clients t
+		SET owner_phone = COALESCE(NULLIF(t.owner_phone, ''), s.owner_phone),
+			manager_phone = COALESCE(NULLIF(t.manager_phone, ''), s.manager_phone),
+			deposit_overdraft_limit = GREATEST(t.deposit_overdraft_limit, s.deposit_overdraft_limit),
+			updated_at = CURRENT_TIMESTAMP
+		FROM clients s
+		WHERE t.id = $1 AND s.id = $2`, targetID, sourceID)
+	if err != nil {
+		logger.Error("Ошибка при обновлении клиента ID %d: %v", targetID, err)
+		return result, fmt.Errorf("ошибка при обновлении клиента: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return result, fmt.Errorf("клиент с ID %d не найден", targetID)
+	}
+
+	// Машины, которые уже есть у оставшегося клиента, второй раз не привязываем
+	if _, err = tx.Exec(`
+		DELETE FROM clients_cars s
+		WHERE s.client_id = $2 AND s.valid_to IS NULL
+		  AND EXISTS (SELECT 1 FROM clients_cars t WHERE t.client_id = $1 AND t.car_id = s.car_id AND t.valid_to IS NULL)`,
+		targetID, sourceID); err != nil {
+		logger.Error("Ошибка при удалении повторных привязок машин: %v", err)
+		return result, fmt.Errorf("ошибка при переносе машин: %w", err)
+	}
+
+	moves := []struct {
+		query string
+		count *int
+	}{
+		{`UPDATE clients_cars SET client_id = $1, updated_at = CURRENT_TIMESTAMP WHERE client_id = $2 AND valid_to IS NULL`, &result.Cars},
+		{`UPDATE clients_cars SET client_id = $1 WHERE client_id = $2`, nil},
+		{`UPDATE orders SET client_id = $1 WHERE client_id = $2`, &result.Orders},
+		{`UPDATE order_services SET client_id = $1 WHERE client_id = $2`, nil},
+		{`UPDATE online_date SET client_id = $1 WHERE client_id = $2`, &result.Bookings},
+		{`UPDATE client_contacts SET client_id = $1 WHERE client_id = $2`, nil},
+		{`UPDATE client_ledger SET client_id = $1 WHERE client_id = $2`, nil},
+		{`UPDATE client_deposit_movements SET client_id = $1 WHERE client_id = $2`, nil},
+		{`UPDATE client_merges SET into_client_id = $1 WHERE into_client_id = $2`, nil},
+	}
+	for _, m := range moves {
+		res, err := tx.Exec(m.query, targetID, sourceID)
+		if err != nil {
+			logger.Error("Ошибка при переносе данных клиента ID %d: %v", sourceID, err)
+			return result, fmt.Errorf("ошибка при переносе данных клиента: %w", err)
+		}
+		if m.count != nil {
+			n, _ := res.RowsAffected()
+			*m.count = int(n)
+		}
+	}
+
+	if _, err = tx.Exec(`
+		INSERT INTO client_merges (merged_client_id, merged_name, into_client_id, user_id)
+		VALUES ($1, $2, $3, $4)`,
+		sourceID, sourceName, targetID, nullableID(userID)); err != nil {
+		logger.Error("Ошибка при сохранении записи об объединении: %v", err)
+		return result, fmt.Errorf("ошибка при сохранении записи об объединении: %w", err)
+	}
+
+	if _, err = tx.Exec(`DELETE FROM clients WHERE id = $1`, sourceID); err != nil {
+		logger.Error("Ошибка при удалении клиента ID %d: %v", sourceID, err)
+		return result, fmt.Errorf("ошибка при удалении объединенного клиента: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return result, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Клиент ID:%d влит в клиента ID:%d: машин %d, заказов %d, записей %d",
+		sourceID, targetID, result.Cars, result.Orders, result.Bookings)
+	return result, nil
+}
+
+// GetClientRedirect возвращает ID клиента, в которого влит объединенный клиент, 0 = записи нет
+func (r *Repository) GetClientRedirect(id int) (int, error) {
+	var intoID int
+	err := r.db.Get(&intoID, `SELECT into_client_id FROM client_merges WHERE merged_client_id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		logger.Error("Ошибка при поиске объединения клиента ID %d: %v", id, err)
+		return 0, fmt.Errorf("ошибка при поиске объединения клиента: %w", err)
+	}
+	return intoID, nil
+}
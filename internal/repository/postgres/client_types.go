@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+const clientTypeColumns = `ct.id, ct.name, ct.description, ct.default_contract_id,
+		COALESCE(c.number, '') AS default_contract_number,
+		(SELECT COUNT(*) FROM clients cl WHERE cl.client_type = ct.name) AS clients_count,
+		ct.created_at, ct.updated_at`
+
+// clientTypeWriteError переводит нарушения ограничений справочника типов в понятные сообщения
+func clientTypeWriteError(msg string, clientType models.ClientType, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return fmt.Errorf("тип клиента '%s' уже существует", clientType.Name)
+		case "23503":
+			return fmt.Errorf("договор с ID %d не найден", *clientType.DefaultContractID)
+		}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func (r *Repository) GetAllClientTypes() ([]models.ClientType, error) {
+	var types []models.ClientType
+	query := `
+		SELECT ` + clientTypeColumns + `
+		FROM client_types ct
+		LEFT JOIN contracts c ON c.id = ct.default_contract_id
+		ORDER BY ct.name`
+
+	logger.Debug("Получение справочника типов клиентов")
+	err := r.db.Select(&types, query)
+	if err != nil {
+		logger.Error("Ошибка при получении справочника типов клиентов: %v", err)
+		return nil, fmt.Errorf("ошибка при получении типов клиентов: %w", err)
+	}
+
+	return types, nil
+}
+
+func (r *Repository) GetClientTypeByName(name string) (models.ClientType, error) {
+	var clientType models.ClientType
+	query := `
+		SELECT ` + clientTypeColumns + `
+		FROM client_types ct
+		LEFT JOIN contracts c ON c.id = ct.default_contract_id
+		WHERE ct.name = $1`
+
+	err := r.db.Get(&clientType, query, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ClientType{}, fmt.Errorf("неизвестный тип клиента '%s'", name)
+		}
+		logger.Error("Ошибка при получении типа клиента %s: %v", name, err)
+		return models.ClientType{}, fmt.Errorf("ошибка при получении типа клиента: %w", err)
+	}
+
+	return clientType, nil
+}
+
+func (r *Repository) CreateClientType(clientType models.ClientType) (int, error) {
+	var id int
+	query := `
+		INSERT INTO client_types (name, description, default_contract_id)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	logger.Debug("Создание типа клиента: %s", clientType.Name)
+	err := r.db.QueryRow(query, clientType.Name, clientType.Description, clientType.DefaultContractID).Scan(&id)
+	if err != nil {
+		logger.Error("Ошибка при создании типа клиента: %v", err)
+		return 0, clientTypeWriteError("ошибка при создании типа клиента", clientType, err)
+	}
+
+	logger.Info("Тип клиента успешно создан с ID: %d", id)
+	return id, nil
+}
+
+// UpdateClientType обновляет тип, при переименовании внешние ключи переносят новое название
+// на всех клиентов и договоры этого типа
+func (r *Repository) UpdateClientType(id int, clientType models.ClientType) error {
+	query := `
+		UPDATE client_types
+		SET name = $1, description = $2, default_contract_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`
+
+	logger.Debug("Обновление типа клиента ID: %d", id)
+	result, err := r.db.Exec(query, clientType.Name, clientType.Description, clientType.DefaultContractID, id)
+	if err != nil {
+		logger.Error("Ошибка при обновлении типа клиента: %v", err)
+		return clientTypeWriteError("ошибка при обновлении типа клиента", clientType, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("тип клиента с ID %d не найден", id)
+	}
+
+	logger.Info("Тип клиента успешно обновлен")
+	return nil
+}
+
+func (r *Repository) DeleteClientType(id int) error {
+	logger.Debug("Удаление типа клиента ID: %d", id)
+	result, err := r.db.Exec(`DELETE FROM client_types WHERE id = $1`, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("тип клиента используется клиентами или договорами")
+		}
+		logger.Error("Ошибка при удалении типа клиента: %v", err)
+		return fmt.Errorf("ошибка при удалении типа клиента: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("тип клиента с ID %d не найден", id)
+	}
+
+	logger.Info("Тип клиента успешно удален")
+	return nil
+}
@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+)
+
+// lockDeposit блокирует клиента и возвращает баланс предоплаты и допустимый минус
+func lockDeposit(tx *sql.Tx, clientID int) (balance, overdraft money.Amount, err error) {
+	err = tx.QueryRow(`
+		SELECT COALESCE((SELECT SUM(amount) FROM client_deposit_movements WHERE client_id = c.id), 0),
+			   c.deposit_overdraft_limit
+		FROM clients c
+		WHERE c.id = $1
+		FOR UPDATE`, clientID).Scan(&balance, &overdraft)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, fmt.Errorf("клиент с ID %d не найден", clientID)
+		}
+		logger.Error("Ошибка при получении баланса предоплаты клиента ID %d: %v", clientID, err)
+		return 0, 0, fmt.Errorf("ошибка при получении баланса предоплаты: %w", err)
+	}
+	return balance, overdraft, nil
+}
+
+// postDepositMovement проводит движение по предоплате. Списание по заказу может уйти в минус
+// не больше допустимого, выдача денег клиенту - только в пределах положительного баланса.
+func postDepositMovement(tx *sql.Tx, m models.DepositMovement) (int, error) {
+	balance, overdraft, err := lockDeposit(tx, m.ClientID)
+	if err != nil {
+		return 0, err
+	}
+
+	if m.Amount < 0 {
+		floor := -overdraft
+		if m.Type == models.DepositWithdrawal {
+			floor = 0
+		}
+		if balance+m.Amount < floor {
+			return 0, fmt.Errorf("недостаточно средств на предоплате клиента: баланс %s, к списанию %s", balance, -m.Amount)
+		}
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO client_deposit_movements (client_id, order_id, movement_type, amount, document, description, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		m.ClientID, m.OrderID, m.Type, m.Amount, m.Document, m.Description, m.UserID).Scan(&id)
+	if err != nil {
+		logger.Error("Ошибка при проведении движения по предоплате клиента ID %d: %v", m.ClientID, err)
+		return 0, fmt.Errorf("ошибка при проведении движения по предоплате: %w", err)
+	}
+	return id, nil
+}
+
+// settleOrderDeposit приводит списания по заказу в соответствие с заказом: выполненный заказ
+// с оплатой с предоплаты списывается с его клиента, в остальных случаях списанное возвращается.
+// cancel возвращает все списания, используется перед удалением заказа.
+func settleOrderDeposit(tx *sql.Tx, orderID int, cancel bool) error {
+	// Сколько по заказу должно быть списано с каждого клиента
+	want := map[int]money.Amount{}
+	if !cancel {
+		var clientID sql.NullInt64
+		var paymentMethod, status string
+		var total money.Amount
+		err := tx.QueryRow(`SELECT client_id, payment_method, status, total_amount FROM orders WHERE id = $1`, orderID).
+			Scan(&clientID, &paymentMethod, &status, &total)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logger.Error("Ошибка при получении заказа ID %d: %v", orderID, err)
+			return fmt.Errorf("ошибка при получении заказа: %w", err)
+		}
+		if err == nil && clientID.Valid && paymentMethod == models.PaymentPrepaid &&
+			status == string(models.OrderStatusCompleted) && total > 0 {
+			want[int(clientID.Int64)] = -total
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT client_id, SUM(amount)
+		FROM client_deposit_movements
+		WHERE order_id = $1
+		GROUP BY client_id
+		HAVING SUM(amount) <> 0`, orderID)
+	if err != nil {
+		logger.Error("Ошибка при получении списаний по заказу ID %d: %v", orderID, err)
+		return fmt.Errorf("ошибка при получении списаний по заказу: %w", err)
+	}
+	have := map[int]money.Amount{}
+	for rows.Next() {
+		var clientID int
+		var amount money.Amount
+		if err := rows.Scan(&clientID, &amount); err != nil {
+			rows.Close()
+			return fmt.Errorf("ошибка при чтении списаний по заказу: %w", err)
+		}
+		have[clientID] = amount
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("ошибка при чтении списаний по заказу: %w", err)
+	}
+
+	// Сначала возвраты, потом списания, чтобы при смене суммы хватило баланса
+	var refunds, drawdowns []models.DepositMovement
+	for clientID := range have {
+		if _, ok := want[clientID]; !ok {
+			want[clientID] = 0
+		}
+	}
+	for clientID, amount := range want {
+		delta := amount - have[clientID]
+		m := models.DepositMovement{
+			ClientID:    clientID,
+			OrderID:     &orderID,
+			Amount:      delta,
+			Description: fmt.Sprintf("Заказ №%d", orderID),
+		}
+		switch {
+		case delta > 0:
+			m.Type = models.DepositRefund
+			refunds = append(refunds, m)
+		case delta < 0:
+			m.Type = models.DepositDrawdown
+			drawdowns = append(drawdowns, m)
+		}
+	}
+
+	for _, m := range append(refunds, drawdowns...) {
+		if _, err := postDepositMovement(tx, m); err != nil {
+			return err
+		}
+		logger.Info("По заказу ID:%d проведено движение по предоплате клиента ID:%d: %s %s", orderID, m.ClientID, m.Type, m.Amount)
+	}
+	return nil
+}
+
+// AddDepositMovement проводит пополнение или выдачу по предоплате клиента
+func (r *Repository) AddDepositMovement(m models.DepositMovement) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	logger.Debug("Движение по предоплате клиента ID:%d: %s %s", m.ClientID, m.Type, m.Amount)
+	id, err := postDepositMovement(tx, m)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Проведено движение ID:%d по предоплате клиента ID:%d", id, m.ClientID)
+	return id, nil
+}
+
+// GetDepositAccount возвращает баланс предоплаты клиента и историю движений с балансом после каждого
+func (r *Repository) GetDepositAccount(clientID int) (models.DepositAccount, error) {
+	var account models.DepositAccount
+	err := r.db.Get(&account, `
+		SELECT c.id AS client_id, c.name AS client_name, c.deposit_overdraft_limit AS overdraft_limit,
+			   COALESCE((SELECT SUM(amount) FROM client_deposit_movements WHERE client_id = c.id), 0) AS balance
+		FROM clients c
+		WHERE c.id = $1`, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return account, fmt.Errorf("клиент с ID %d не найден", clientID)
+		}
+		logger.Error("Ошибка при получении предоплаты клиента ID %d: %v", clientID, err)
+		return account, fmt.Errorf("ошибка при получении предоплаты клиента: %w", err)
+	}
+
+	account.Movements = []models.DepositMovement{}
+	err = r.db.Select(&account.Movements, `
+		SELECT id, client_id, order_id, movement_type, amount, document, description, user_id, created_at,
+			   SUM(amount) OVER (ORDER BY created_at, id) AS balance
+		FROM client_deposit_movements
+		WHERE client_id = $1
+		ORDER BY created_at DESC, id DESC`, clientID)
+	if err != nil {
+		logger.Error("Ошибка при получении движений по предоплате клиента ID %d: %v", clientID, err)
+		return account, fmt.Errorf("ошибка при получении движений по предоплате: %w", err)
+	}
+	return account, nil
+}
+
+// SetDepositOverdraft задает допустимый минус по предоплате клиента
+func (r *Repository) SetDepositOverdraft(clientID int, limit money.Amount) error {
+	logger.Debug("Изменение допустимого минуса по предоплате клиента ID:%d на %s", clientID, limit)
+	result, err := r.db.Exec(`UPDATE clients SET deposit_overdraft_limit = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		limit, clientID)
+	if err != nil {
+		logger.Error("Ошибка при изменении допустимого минуса по предоплате: %v", err)
+		return fmt.Errorf("ошибка при изменении допустимого минуса по предоплате: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("клиент с ID %d не найден", clientID)
+	}
+	return nil
+}
+
+// GetOrderDepositBalance возвращает баланс предоплаты клиента заказа, если заказ оплачивается с предоплаты
+func (r *Repository) GetOrderDepositBalance(orderID int) (money.Amount, bool, error) {
+	var balance money.Amount
+	err := r.db.Get(&balance, `
+		SELECT COALESCE((SELECT SUM(m.amount) FROM client_deposit_movements m WHERE m.client_id = o.client_id), 0)
+		FROM orders o
+		WHERE o.id = $1 AND o.payment_method = $2 AND o.client_id IS NOT NULL`, orderID, models.PaymentPrepaid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		logger.Error("Ошибка при получении баланса предоплаты по заказу ID %d: %v", orderID, err)
+		return 0, false, fmt.Errorf("ошибка при получении баланса предоплаты по заказу: %w", err)
+	}
+	return balance, true, nil
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// uninvoicedOrderIDs отбирает выполненные заказы по договору за период [$3, $4), еще не попавшие в счет.
+// Договор заказа берется из начисления, оно фиксируется при создании заказа.
+const uninvoicedOrderIDs = `
+	SELECT o.id
+	FROM orders o
+	JOIN client_ledger l ON l.order_id = o.id
+	WHERE l.contract_id = $1 AND o.status = $2 AND o.invoice_id IS NULL
+	  AND o.created_at >= $3 AND o.created_at < $4`
+
+// invoiceLinesQuery группирует услуги заказов $1 по машине, названию и цене (цена услуги уже со скидкой).
+// Счет сходится с начислениями по заказам (orders.total_amount): если сумма заказа отличается от суммы его услуг,
+// разница выносится отдельной строкой.
+const invoiceLinesQuery = `
+	SELECT vehicle_number, service_name, price, COUNT(*) AS quantity, SUM(price) AS amount
+	FROM (
+		SELECT o.vehicle_number, COALESCE(NULLIF(s.name, ''), os.service_description, '') AS service_name, os.price
+		FROM orders o
+		JOIN order_services os ON os.order_id = o.id
+		LEFT JOIN services s ON s.id = os.service_id
+		WHERE o.id = ANY($1)
+		UNION ALL
+		SELECT o.vehicle_number, 'Корректировка суммы заказа №' || o.id,
+			   o.total_amount - COALESCE(SUM(os.price), 0)
+		FROM orders o
+		LEFT JOIN order_services os ON os.order_id = o.id
+		WHERE o.id = ANY($1)
+		GROUP BY o.id
+		HAVING o.total_amount <> COALESCE(SUM(os.price), 0)
+	) t
+	GROUP BY 1, 2, 3
+	ORDER BY 1, 2, 3`
+
+const invoiceColumns = `
+	i.id, i.contract_id, c.number AS contract_number, c.client_company_name AS company_name,
+	i.period_start, i.period_end, i.total_amount, i.user_id, i.created_at,
+	(SELECT COUNT(*) FROM orders o WHERE o.invoice_id = i.id) AS orders_count`
+
+// lockOrderForChange блокирует заказ перед изменением или удалением и возвращает время его создания.
+// Заказ, выставленный в счет, менять нельзя: сумма счета разошлась бы с заказами, сначала счет отменяется.
+func lockOrderForChange(tx *sql.Tx, id int) (time.Time, error) {
+	var createdAt time.Time
+	var invoiceID *int
+	err := tx.QueryRow(`SELECT created_at, invoice_id FROM orders WHERE id = $1 FOR UPDATE`, id).Scan(&createdAt, &invoiceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return createdAt, fmt.Errorf("заказ с ID %d не найден", id)
+		}
+		logger.Error("Ошибка при получении заказа ID %d: %v", id, err)
+		return createdAt, fmt.Errorf("ошибка при получении заказа: %w", err)
+	}
+	if invoiceID != nil {
+		return createdAt, fmt.Errorf("заказ с ID %d выставлен в счет ID %d, сначала отмените счет", id, *invoiceID)
+	}
+	return createdAt, nil
+}
+
+// GetInvoiceDraft возвращает строки и количество заказов будущего счета без сохранения
+func (r *Repository) GetInvoiceDraft(contractID int, start, end time.Time) ([]models.InvoiceLine, int, error) {
+	var ids pq.Int64Array
+	logger.Debug("Подбор заказов для счета по договору ID:%d за период %v - %v", contractID, start, end)
+	err := r.db.Get(&ids, `SELECT COALESCE(array_agg(id ORDER BY id), '{}') FROM (`+uninvoicedOrderIDs+`) t`,
+		contractID, models.OrderStatusCompleted, start, end)
+	if err != nil {
+		logger.Error("Ошибка при подборе заказов для счета: %v", err)
+		return nil, 0, fmt.Errorf("ошибка при подборе заказов для счета: %w", err)
+	}
+
+	lines := []models.InvoiceLine{}
+	if err = r.db.Select(&lines, invoiceLinesQuery, ids); err != nil {
+		logger.Error("Ошибка при формировании строк счета: %v", err)
+		return nil, 0, fmt.Errorf("ошибка при формировании строк счета: %w", err)
+	}
+	return lines, len(ids), nil
+}
+
+// CreateInvoice выставляет счет по всем подходящим заказам за период [start, end) и помечает их.
+// Заказы блокируются, поэтому два одновременных выставления не включат один заказ дважды.
+func (r *Repository) CreateInvoice(contractID int, start, end time.Time, userID int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var ids pq.Int64Array
+	err = tx.QueryRow(`SELECT COALESCE(array_agg(id ORDER BY id), '{}') FROM (`+uninvoicedOrderIDs+` FOR UPDATE OF o) t`,
+		contractID, models.OrderStatusCompleted, start, end).Scan(&ids)
+	if err != nil {
+		logger.Error("Ошибка при подборе заказов для счета: %v", err)
+		return 0, fmt.Errorf("ошибка при подборе заказов для счета: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("нет выполненных заказов по договору за период, не выставленных в счет")
+	}
+
+	// В счете период хранится включительно
+	var invoiceID int
+	err = tx.QueryRow(`
+		INSERT INTO invoices (contract_id, period_start, period_end, user_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		contractID, start, end.AddDate(0, 0, -1), nullableID(userID)).Scan(&invoiceID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, fmt.Errorf("договор с ID %d не найден", contractID)
+		}
+		logger.Error("Ошибка при создании счета: %v", err)
+		return 0, fmt.Errorf("ошибка при создании счета: %w", err)
+	}
+
+	if _, err = tx.Exec(`
+		INSERT INTO invoice_lines (invoice_id, vehicle_number, service_name, price, quantity, amount)
+		SELECT $2::int, q.* FROM (`+invoiceLinesQuery+`) q`, ids, invoiceID); err != nil {
+		logger.Error("Ошибка при сохранении строк счета ID %d: %v", invoiceID, err)
+		return 0, fmt.Errorf("ошибка при сохранении строк счета: %w", err)
+	}
+
+	if _, err = tx.Exec(`UPDATE orders SET invoice_id = $1 WHERE id = ANY($2)`, invoiceID, ids); err != nil {
+		logger.Error("Ошибка при привязке заказов к счету ID %d: %v", invoiceID, err)
+		return 0, fmt.Errorf("ошибка при привязке заказов к счету: %w", err)
+	}
+
+	// Сумма счета - сумма начислений по его заказам, строки счета сходятся с ней
+	if _, err = tx.Exec(`
+		UPDATE invoices
+		SET total_amount = (SELECT COALESCE(SUM(total_amount), 0) FROM orders WHERE invoice_id = $1)
+		WHERE id = $1`, invoiceID); err != nil {
+		logger.Error("Ошибка при подсчете суммы счета ID %d: %v", invoiceID, err)
+		return 0, fmt.Errorf("ошибка при подсчете суммы счета: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Выставлен счет ID:%d по договору ID:%d, заказов: %d", invoiceID, contractID, len(ids))
+	return invoiceID, nil
+}
+
+// GetInvoices возвращает счета, contractID 0 - по всем договорам
+func (r *Repository) GetInvoices(contractID int) ([]models.Invoice, error) {
+	invoices := []models.Invoice{}
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices i
+		JOIN contracts c ON c.id = i.contract_id
+		WHERE ($1 = 0 OR i.contract_id = $1)
+		ORDER BY i.created_at DESC, i.id DESC`
+
+	logger.Debug("Получение счетов по договору ID:%d", contractID)
+	if err := r.db.Select(&invoices, query, contractID); err != nil {
+		logger.Error("Ошибка при получении счетов: %v", err)
+		return nil, fmt.Errorf("ошибка при получении счетов: %w", err)
+	}
+	return invoices, nil
+}
+
+// GetInvoiceById возвращает счет со строками
+func (r *Repository) GetInvoiceById(id int) (models.Invoice, error) {
+	var invoice models.Invoice
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices i
+		JOIN contracts c ON c.id = i.contract_id
+		WHERE i.id = $1`
+
+	logger.Debug("Получение счета ID:%d", id)
+	if err := r.db.Get(&invoice, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return invoice, fmt.Errorf("счет с ID %d не найден", id)
+		}
+		logger.Error("Ошибка при получении счета: %v", err)
+		return invoice, fmt.Errorf("ошибка при получении счета: %w", err)
+	}
+
+	invoice.Lines = []models.InvoiceLine{}
+	err := r.db.Select(&invoice.Lines, `
+		SELECT id, invoice_id, vehicle_number, service_name, quantity, price, amount
+		FROM invoice_lines
+		WHERE invoice_id = $1
+		ORDER BY id`, id)
+	if err != nil {
+		logger.Error("Ошибка при получении строк счета: %v", err)
+		return invoice, fmt.Errorf("ошибка при получении строк счета: %w", err)
+	}
+	return invoice, nil
+}
+
+// DeleteInvoice отменяет счет, его заказы снова можно выставить
+func (r *Repository) DeleteInvoice(id int) error {
+	logger.Debug("Удаление счета ID:%d", id)
+	result, err := r.db.Exec(`DELETE FROM invoices WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Ошибка при удалении счета: %v", err)
+		return fmt.Errorf("ошибка при удалении счета: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("счет с ID %d не найден", id)
+	}
+
+	logger.Info("Счет ID:%d удален", id)
+	return nil
+}
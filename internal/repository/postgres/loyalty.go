@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+const loyaltyRuleColumns = `lr.id, lr.name, lr.rule_type, lr.service_id, COALESCE(s.name, '') AS service_name,
+		lr.every_n, lr.min_visits, lr.percent, lr.active, lr.created_at, lr.updated_at`
+
+// loyaltyRuleWriteError переводит нарушения ограничений правил лояльности в понятные сообщения
+func loyaltyRuleWriteError(msg string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return fmt.Errorf("услуга правила не найдена")
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// GetLoyaltyRules возвращает правила лояльности, activeOnly - только действующие
+func (r *Repository) GetLoyaltyRules(activeOnly bool) ([]models.LoyaltyRule, error) {
+	var rules []models.LoyaltyRule
+	query := `
+		SELECT ` + loyaltyRuleColumns + `
+		FROM loyalty_rules lr
+		LEFT JOIN services s ON s.id = lr.service_id
+		WHERE lr.active OR NOT $1
+		ORDER BY lr.id`
+
+	logger.Debug("Получение правил лояльности")
+	err := r.db.Select(&rules, query, activeOnly)
+	if err != nil {
+		logger.Error("Ошибка при получении правил лояльности: %v", err)
+		return nil, fmt.Errorf("ошибка при получении правил лояльности: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (r *Repository) CreateLoyaltyRule(rule models.LoyaltyRule) (int, error) {
+	var id int
+	query := `
+		INSERT INTO loyalty_rules (name, rule_type, service_id, every_n, min_visits, percent, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	logger.Debug("Создание правила лояльности: %s", rule.Name)
+	err := r.db.QueryRow(query, rule.Name, rule.Type, rule.ServiceID, rule.EveryN, rule.MinVisits, rule.Percent, rule.Active).Scan(&id)
+	if err != nil {
+		logger.Error("Ошибка при создании правила лояльности: %v", err)
+		return 0, loyaltyRuleWriteError("ошибка при создании правила лояльности", err)
+	}
+
+	logger.Info("Правило лояльности успешно создано с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) UpdateLoyaltyRule(id int, rule models.LoyaltyRule) error {
+	query := `
+		UPDATE loyalty_rules
+		SET name = $1, rule_type = $2, service_id = $3, every_n = $4, min_visits = $5, percent = $6, active = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8`
+
+	logger.Debug("Обновление правила лояльности ID: %d", id)
+	result, err := r.db.Exec(query, rule.Name, rule.Type, rule.ServiceID, rule.EveryN, rule.MinVisits, rule.Percent, rule.Active, id)
+	if err != nil {
+		logger.Error("Ошибка при обновлении правила лояльности: %v", err)
+		return loyaltyRuleWriteError("ошибка при обновлении правила лояльности", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("правило лояльности с ID %d не найдено", id)
+	}
+
+	logger.Info("Правило лояльности успешно обновлено")
+	return nil
+}
+
+func (r *Repository) DeleteLoyaltyRule(id int) error {
+	logger.Debug("Удаление правила лояльности ID: %d", id)
+	result, err := r.db.Exec(`DELETE FROM loyalty_rules WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Ошибка при удалении правила лояльности: %v", err)
+		return fmt.Errorf("ошибка при удалении правила лояльности: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("правило лояльности с ID %d не найдено", id)
+	}
+
+	logger.Info("Правило лояльности успешно удалено")
+	return nil
+}
+
+// GetLoyaltyHistory считает прошлые заказы покупателя и строки услуг в них по каждой услуге
+func (r *Repository) GetLoyaltyHistory(customerID int) (models.LoyaltyHistory, error) {
+	history := models.LoyaltyHistory{ServiceCounts: map[int]int{}}
+
+	err := r.db.Get(&history.Visits, `SELECT COUNT(*) FROM orders WHERE retail_customer_id = $1`, customerID)
+	if err != nil {
+		logger.Error("Ошибка при подсчете визитов покупателя %d: %v", customerID, err)
+		return history, fmt.Errorf("ошибка при подсчете визитов покупателя: %w", err)
+	}
+
+	var counts []struct {
+		ServiceID int `db:"service_id"`
+		Count     int `db:"count"`
+	}
+	err = r.db.Select(&counts, `
+		SELECT os.service_id, COUNT(*) AS count
+		FROM order_services os
+		JOIN orders o ON o.id = os.order_id
+		WHERE o.retail_customer_id = $1
+		GROUP BY os.service_id`, customerID)
+	if err != nil {
+		logger.Error("Ошибка при подсчете услуг покупателя %d: %v", customerID, err)
+		return history, fmt.Errorf("ошибка при подсчете услуг покупателя: %w", err)
+	}
+	for _, c := range counts {
+		history.ServiceCounts[c.ServiceID] = c.Count
+	}
+
+	return history, nil
+}
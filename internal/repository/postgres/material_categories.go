@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// Общий список полей материала вместе с названием категории, ожидает алиасы m и c
+const (
+	materialColumns = `m.id, m.name, COALESCE(m.sku, '') AS sku, m.category_id, c.name AS category_name,
+		m.category_id AS type_ds, m.unit, m.storage, m.min_storage,
+		(SELECT MAX(mm.created_at) FROM material_movements mm WHERE mm.material_id = m.id) AS last_movement_at,
+		m.created_at, m.updated_at`
+	materialFrom = `FROM material m JOIN material_categories c ON c.id = m.category_id`
+)
+
+// materialWriteError переводит нарушения ограничений таблицы material в понятные сообщения
+func materialWriteError(msg string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "23505" && pqErr.Constraint == "idx_material_sku":
+			return fmt.Errorf("материал с таким артикулом уже существует")
+		case pqErr.Code == "23505":
+			return fmt.Errorf("материал с таким названием в этой категории уже существует")
+		case pqErr.Code == "23503":
+			return fmt.Errorf("категория материала не найдена")
+		}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func (r *Repository) CreateMaterialCategory(category models.MaterialCategory) (int, error) {
+	var id int
+	query := `INSERT INTO material_categories (name) VALUES ($1) RETURNING id`
+
+	logger.Debug("Создание категории материалов: %s", category.Name)
+	err := r.db.QueryRow(query, category.Name).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("категория '%s' уже существует", category.Name)
+		}
+		logger.Error("Ошибка при создании категории материалов: %v", err)
+		return 0, fmt.Errorf("ошибка при создании категории материалов: %w", err)
+	}
+
+	logger.Info("Категория материалов успешно создана с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) GetAllMaterialCategories() ([]models.MaterialCategory, error) {
+	var categories []models.MaterialCategory
+	query := `SELECT id, name, created_at, updated_at FROM material_categories ORDER BY name`
+
+	logger.Debug("Получение списка категорий материалов")
+	err := r.db.Select(&categories, query)
+	if err != nil {
+		logger.Error("Ошибка при получении категорий материалов: %v", err)
+		return nil, fmt.Errorf("ошибка при получении категорий материалов: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (r *Repository) UpdateMaterialCategory(id int, category models.MaterialCategory) error {
+	query := `
+		UPDATE material_categories
+		SET name = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`
+
+	logger.Debug("Обновление категории материалов ID: %d", id)
+	result, err := r.db.Exec(query, category.Name, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("категория '%s' уже существует", category.Name)
+		}
+		logger.Error("Ошибка при обновлении категории материалов: %v", err)
+		return fmt.Errorf("ошибка при обновлении категории материалов: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("категория материалов с ID %d не найдена", id)
+	}
+
+	logger.Info("Категория материалов успешно обновлена")
+	return nil
+}
+
+func (r *Repository) DeleteMaterialCategory(id int) error {
+	query := `DELETE FROM material_categories WHERE id = $1`
+
+	logger.Debug("Удаление категории материалов ID: %d", id)
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("в категории есть материалы, сначала перенесите их в другую категорию")
+		}
+		logger.Error("Ошибка при удалении категории материалов: %v", err)
+		return fmt.Errorf("ошибка при удалении категории материалов: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("категория материалов с ID %d не найдена", id)
+	}
+
+	logger.Info("Категория материалов успешно удалена")
+	return nil
+}
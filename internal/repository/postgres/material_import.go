@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+)
+
+// ImportMaterial создает или обновляет материал из строки загрузки и проводит приход.
+// Материал ищется по артикулу, а если артикул не указан - по названию и категории.
+// Пустая единица измерения и нулевой порог не затирают значения существующего материала.
+// Приход проводится на указанный склад, 0 = склад по умолчанию.
+func (r *Repository) ImportMaterial(material models.Material, receipt float64, warehouseID, userID int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return false, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	if material.SKU != "" {
+		err = tx.QueryRow(`SELECT id FROM material WHERE sku = $1 FOR UPDATE`, material.SKU).Scan(&id)
+	} else {
+		err = tx.QueryRow(`SELECT id FROM material WHERE name = $1 AND category_id = $2 FOR UPDATE`,
+			material.Name, material.CategoryID).Scan(&id)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error("Ошибка при поиске материала %s: %v", material.Name, err)
+		return false, fmt.Errorf("ошибка при поиске материала: %w", err)
+	}
+
+	created := err == sql.ErrNoRows
+	if created {
+		unit := material.Unit
+		if unit == "" {
+			unit = models.UnitPiece
+		}
+		err = tx.QueryRow(`
+			INSERT INTO material (name, sku, category_id, unit, min_storage, storage)
+			VALUES ($1, NULLIF($2, ''), $3, $4, $5, 0)
+			RETURNING id`,
+			material.Name, material.SKU, material.CategoryID, unit, material.MinStorage).Scan(&id)
+		if err != nil {
+			logger.Error("Ошибка при создании материала %s: %v", material.Name, err)
+			return false, materialWriteError("ошибка при создании материала", err)
+		}
+	} else {
+		_, err = tx.Exec(`
+			UPDATE material
+			SET name = $1, category_id = $2, unit = COALESCE(NULLIF($3, ''), unit),
+				min_storage = CASE WHEN $4::numeric > 0 THEN $4::numeric ELSE min_storage END, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $5`,
+			material.Name, material.CategoryID, material.Unit, material.MinStorage, id)
+		if err != nil {
+			logger.Error("Ошибка при обновлении материала ID %d: %v", id, err)
+			return false, materialWriteError("ошибка при обновлении материала", err)
+		}
+	}
+
+	if receipt > 0 {
+		err = postMaterialMovement(tx, models.MaterialMovement{
+			MaterialID:  id,
+			WarehouseID: warehouseID,
+			Delta:       receipt,
+			Reason:      models.MovementReceipt,
+			UserID:      nullableID(userID),
+			Description: "загрузка из Excel",
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return false, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// normForContract выбирает нормы договора, а если у договора своих норм на услугу нет - нормы по умолчанию.
+// Ожидает алиас n для service_material_norms, выражение с названием услуги и выражение с ID договора.
+func normForContract(serviceName, contractID string) string {
+	return fmt.Sprintf(`(n.contract_id = %[2]s OR (n.contract_id IS NULL AND NOT EXISTS (
+			SELECT 1 FROM service_material_norms cn WHERE cn.service_name = %[1]s AND cn.contract_id = %[2]s)))`,
+		serviceName, contractID)
+}
+
+func (r *Repository) CreateMaterialNorm(norm models.ServiceMaterialNorm) (int, error) {
+	var id int
+	query := `
+		INSERT INTO service_material_norms (service_name, contract_id, material_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	logger.Debug("Создание нормы расхода: услуга %s, материал ID %d", norm.ServiceName, norm.MaterialID)
+	err := r.db.QueryRow(query, norm.ServiceName, norm.ContractID, norm.MaterialID, norm.Quantity).Scan(&id)
+	if err != nil {
+		logger.Error("Ошибка при создании нормы расхода: %v", err)
+		return 0, fmt.Errorf("ошибка при создании нормы расхода: %w", err)
+	}
+
+	logger.Info("Норма расхода успешно создана с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) GetAllMaterialNorms() ([]models.ServiceMaterialNorm, error) {
+	var norms []models.ServiceMaterialNorm
+	query := `
+		SELECT n.id, n.service_name, n.contract_id, n.material_id, m.name AS material_name, n.quantity, n.created_at, n.updated_at
+		FROM service_material_norms n
+		JOIN material m ON m.id = n.material_id
+		ORDER BY n.service_name, n.contract_id NULLS FIRST, m.name`
+
+	logger.Debug("Получение списка норм расхода")
+	err := r.db.Select(&norms, query)
+	if err != nil {
+		logger.Error("Ошибка при получении норм расхода: %v", err)
+		return nil, fmt.Errorf("ошибка при получении норм расхода: %w", err)
+	}
+
+	logger.Debug("Получено норм расхода: %d", len(norms))
+	return norms, nil
+}
+
+func (r *Repository) UpdateMaterialNorm(id int, norm models.ServiceMaterialNorm) error {
+	query := `
+		UPDATE service_material_norms
+		SET service_name = $1, contract_id = $2, material_id = $3, quantity = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5`
+
+	logger.Debug("Обновление нормы расхода ID: %d", id)
+	result, err := r.db.Exec(query, norm.ServiceName, norm.ContractID, norm.MaterialID, norm.Quantity, id)
+	if err != nil {
+		logger.Error("Ошибка при обновлении нормы расхода: %v", err)
+		return fmt.Errorf("ошибка при обновлении нормы расхода: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("норма расхода с ID %d не найдена", id)
+	}
+
+	logger.Info("Норма расхода успешно обновлена")
+	return nil
+}
+
+func (r *Repository) DeleteMaterialNorm(id int) error {
+	query := `DELETE FROM service_material_norms WHERE id = $1`
+
+	logger.Debug("Удаление нормы расхода ID: %d", id)
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		logger.Error("Ошибка при удалении нормы расхода: %v", err)
+		return fmt.Errorf("ошибка при удалении нормы расхода: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("норма расхода с ID %d не найдена", id)
+	}
+
+	logger.Info("Норма расхода успешно удалена")
+	return nil
+}
+
+// GetMaterialNormsForServices возвращает нормы расхода, действующие для услуг по договору
+func (r *Repository) GetMaterialNormsForServices(serviceIDs []int, contractID int) ([]models.ServiceMaterialNorm, error) {
+	var norms []models.ServiceMaterialNorm
+	query := `
+		SELECT s.id AS service_id, n.id, n.service_name, n.contract_id, n.material_id, m.name AS material_name,
+			   n.quantity, n.created_at, n.updated_at
+		FROM services s
+		JOIN service_material_norms n ON n.service_name = s.name
+		JOIN material m ON m.id = n.material_id
+		WHERE s.id = ANY($1) AND ` + normForContract("s.name", "$2")
+
+	logger.Debug("Подбор норм расхода для услуг %v по договору ID:%d", serviceIDs, contractID)
+	err := r.db.Select(&norms, query, pq.Array(serviceIDs), contractID)
+	if err != nil {
+		logger.Error("Ошибка при подборе норм расхода: %v", err)
+		return nil, fmt.Errorf("ошибка при подборе норм расхода: %w", err)
+	}
+
+	return norms, nil
+}
+
+// GetMaterialVariance сравнивает нормативный и фактический расход материалов по работникам за период
+func (r *Repository) GetMaterialVariance(start, end time.Time) ([]models.MaterialVariance, error) {
+	var variance []models.MaterialVariance
+	query := `
+		WITH norm AS (
+			SELECT o.worker_id, n.material_id, SUM(n.quantity) AS qty
+			FROM orders o
+			JOIN clients c ON c.id = o.client_id
+			JOIN order_services os ON os.order_id = o.id
+			JOIN services s ON s.id = os.service_id
+			JOIN service_material_norms n ON n.service_name = s.name
+			WHERE o.created_at >= $1 AND o.created_at < $2 AND ` + normForContract("s.name", "c.contract_id") + `
+			GROUP BY o.worker_id, n.material_id
+		), actual AS (
+			SELECT o.worker_id, om.material_id, SUM(om.quantity) AS qty
+			FROM orders o
+			JOIN order_materials om ON om.order_id = o.id
+			WHERE o.created_at >= $1 AND o.created_at < $2
+			GROUP BY o.worker_id, om.material_id
+		)
+		SELECT w.id AS worker_id, w.name AS worker_name, w.surname AS worker_surname,
+			   m.id AS material_id, m.name AS material_name,
+			   COALESCE(norm.qty, 0) AS norm_quantity,
+			   COALESCE(actual.qty, 0) AS actual_quantity,
+			   COALESCE(actual.qty, 0) - COALESCE(norm.qty, 0) AS variance
+		FROM norm
+		FULL OUTER JOIN actual ON actual.worker_id = norm.worker_id AND actual.material_id = norm.material_id
+		JOIN workers w ON w.id = COALESCE(norm.worker_id, actual.worker_id)
+		JOIN material m ON m.id = COALESCE(norm.material_id, actual.material_id)
+		ORDER BY w.id, m.name`
+
+	logger.Debug("Получение отчета о расходе материалов за период с %v по %v", start, end)
+	err := r.db.Select(&variance, query, start, end)
+	if err != nil {
+		logger.Error("Ошибка при получении отчета о расходе материалов: %v", err)
+		return nil, fmt.Errorf("ошибка при получении отчета о расходе материалов: %w", err)
+	}
+
+	logger.Debug("Получено строк отчета о расходе: %d", len(variance))
+	return variance, nil
+}
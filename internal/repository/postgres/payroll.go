@@ -0,0 +1,356 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+)
+
+const payrollPeriodColumns = `
+	p.id, p.period_start, p.period_end, p.status, p.created_by, p.approved_by,
+	p.calculated_at, p.approved_at, p.locked_at, p.created_at,
+	COALESCE((SELECT SUM(l.total) FROM payroll_lines l WHERE l.period_id = p.id), 0) AS total_amount`
+
+// CreatePayrollPeriod заводит черновик расчета зарплаты за период [start, end), периоды не пересекаются
+func (r *Repository) CreatePayrollPeriod(start, end time.Time, userID int) (int, error) {
+	end = end.AddDate(0, 0, -1)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка таблицы не дает двум одновременным запросам завести пересекающиеся периоды
+	if _, err = tx.Exec(`LOCK TABLE payroll_periods IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return 0, fmt.Errorf("ошибка при блокировке периодов расчета зарплаты: %w", err)
+	}
+
+	var overlapStart, overlapEnd time.Time
+	err = tx.QueryRow(`
+		SELECT period_start, period_end FROM payroll_periods
+		WHERE period_start <= $2 AND period_end >= $1
+		LIMIT 1`, start, end).Scan(&overlapStart, &overlapEnd)
+	if err == nil {
+		return 0, fmt.Errorf("период пересекается с расчетом зарплаты за %s - %s",
+			overlapStart.Format("02.01.2006"), overlapEnd.Format("02.01.2006"))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("ошибка при проверке периодов расчета зарплаты: %w", err)
+	}
+
+	var id int
+	logger.Debug("Создание расчета зарплаты за %v - %v", start, end)
+	err = tx.QueryRow(`
+		INSERT INTO payroll_periods (period_start, period_end, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING id`,
+		start, end, nullableID(userID)).Scan(&id)
+	if err != nil {
+		logger.Error("Ошибка при создании расчета зарплаты: %v", err)
+		return 0, fmt.Errorf("ошибка при создании расчета зарплаты: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Расчет зарплаты успешно создан с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) GetPayrollPeriods() ([]models.PayrollPeriod, error) {
+	periods := []models.PayrollPeriod{}
+	query := `SELECT ` + payrollPeriodColumns + ` FROM payroll_periods p ORDER BY p.period_start DESC`
+
+	logger.Debug("Получение расчетов зарплаты")
+	if err := r.db.Select(&periods, query); err != nil {
+		logger.Error("Ошибка при получении расчетов зарплаты: %v", err)
+		return nil, fmt.Errorf("ошибка при получении расчетов зарплаты: %w", err)
+	}
+	return periods, nil
+}
+
+// GetPayrollPeriodById возвращает расчет зарплаты со строками по работникам и перерасчетами
+func (r *Repository) GetPayrollPeriodById(id int) (models.PayrollPeriod, error) {
+	var period models.PayrollPeriod
+	query := `SELECT ` + payrollPeriodColumns + ` FROM payroll_periods p WHERE p.id = $1`
+
+	logger.Debug("Получение расчета зарплаты ID:%d", id)
+	if err := r.db.Get(&period, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return period, fmt.Errorf("расчет зарплаты с ID %d не найден", id)
+		}
+		logger.Error("Ошибка при получении расчета зарплаты: %v", err)
+		return period, fmt.Errorf("ошибка при получении расчета зарплаты: %w", err)
+	}
+
+	period.Lines = []models.PayrollLine{}
+	err := r.db.Select(&period.Lines, `
+		SELECT l.id, l.period_id, l.worker_id, w.name || ' ' || w.surname AS worker_name,
+			   l.accrued, l.bonuses, l.penalties, l.advances, l.adjustments, l.total
+		FROM payroll_lines l
+		JOIN workers w ON w.id = l.worker_id
+		WHERE l.period_id = $1
+		ORDER BY w.name, w.surname`, id)
+	if err != nil {
+		logger.Error("Ошибка при получении строк расчета зарплаты %d: %v", id, err)
+		return period, fmt.Errorf("ошибка при получении строк расчета зарплаты: %w", err)
+	}
+
+	period.Adjustments = []models.PayrollAdjustment{}
+	err = r.db.Select(&period.Adjustments, `
+		SELECT a.id, a.period_id, a.worker_id, w.name || ' ' || w.surname AS worker_name,
+			   a.source_period_id, a.amount, COALESCE(a.description, '') AS description, a.created_at
+		FROM payroll_adjustments a
+		JOIN workers w ON w.id = a.worker_id
+		WHERE a.period_id = $1
+		ORDER BY w.name, w.surname, a.source_period_id`, id)
+	if err != nil {
+		logger.Error("Ошибка при получении перерасчетов зарплаты %d: %v", id, err)
+		return period, fmt.Errorf("ошибка при получении перерасчетов зарплаты: %w", err)
+	}
+
+	return period, nil
+}
+
+// GetPayrollAdjustmentsExcept возвращает перерасчеты, учтенные во всех периодах, кроме periodID
+func (r *Repository) GetPayrollAdjustmentsExcept(periodID int) ([]models.PayrollAdjustment, error) {
+	var adjustments []models.PayrollAdjustment
+	err := r.db.Select(&adjustments, `
+		SELECT id, period_id, worker_id, source_period_id, amount, COALESCE(description, '') AS description, created_at
+		FROM payroll_adjustments
+		WHERE period_id <> $1`, periodID)
+	if err != nil {
+		logger.Error("Ошибка при получении перерасчетов зарплаты: %v", err)
+		return nil, fmt.Errorf("ошибка при получении перерасчетов зарплаты: %w", err)
+	}
+	return adjustments, nil
+}
+
+// GetChangedLockedPayrollPeriods возвращает закрытые периоды, закончившиеся до before, в которых после закрытия
+// изменились заказы, бонусы, штрафы, отметки табеля или схемы оплаты. Заказ периода считается, если он выполнен
+// или принадлежит работнику с начислением в периоде: остальные заказы на зарплату не влияют.
+// Схема оплаты из карточки работника отмечает периоды через changed_at при изменении (UpdateWorker).
+// Остальные закрытые периоды пересчитывать не нужно: их суммы совпадают с выплаченными.
+func (r *Repository) GetChangedLockedPayrollPeriods(before time.Time) ([]int, error) {
+	var ids []int
+	query := `
+		SELECT p.id
+		FROM payroll_periods p
+		WHERE p.status = $1 AND p.period_end < $2
+		  AND (p.changed_at > p.locked_at
+			OR EXISTS (SELECT 1 FROM orders o
+					   WHERE o.created_at >= p.period_start AND o.created_at < p.period_end + 1
+						 AND o.updated_at > p.locked_at
+						 AND (o.status = $3
+							  OR EXISTS (SELECT 1 FROM payroll_lines l WHERE l.period_id = p.id AND l.worker_id = o.worker_id)))
+			OR EXISTS (SELECT 1 FROM bonuses e
+					   WHERE e.created_at >= p.period_start AND e.created_at < p.period_end + 1
+						 AND GREATEST(e.created_at, e.updated_at, e.revoked_at) > p.locked_at)
+			OR EXISTS (SELECT 1 FROM penalties e
+					   WHERE e.created_at >= p.period_start AND e.created_at < p.period_end + 1
+						 AND GREATEST(e.created_at, e.updated_at, e.revoked_at) > p.locked_at)
+			OR EXISTS (SELECT 1 FROM time_entries t
+					   WHERE t.clock_in >= p.period_start AND t.clock_in < p.period_end + 1
+						 AND GREATEST(t.created_at, t.updated_at) > p.locked_at)
+			OR EXISTS (SELECT 1 FROM worker_salary_schemes s
+					   WHERE s.effective_from <= p.period_end AND s.created_at > p.locked_at))
+		ORDER BY p.period_start`
+
+	logger.Debug("Получение закрытых периодов с изменениями после закрытия")
+	if err := r.db.Select(&ids, query, models.PayrollLocked, before, models.OrderStatusCompleted); err != nil {
+		logger.Error("Ошибка при получении измененных закрытых периодов: %v", err)
+		return nil, fmt.Errorf("ошибка при получении измененных закрытых периодов: %w", err)
+	}
+	return ids, nil
+}
+
+// markLockedPayrollChanged отмечает закрытые периоды, пересекающиеся с днями [from, to], как измененные.
+// Нужна при удалении данных: после удаления не остается записи с датой изменения.
+func markLockedPayrollChanged(tx *sql.Tx, from, to time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE payroll_periods SET changed_at = CURRENT_TIMESTAMP
+		WHERE status = $1 AND period_start <= $3::date AND period_end >= $2::date`,
+		models.PayrollLocked, from, to)
+	if err != nil {
+		logger.Error("Ошибка при отметке изменения закрытых периодов: %v", err)
+		return fmt.Errorf("ошибка при отметке изменения закрытых периодов: %w", err)
+	}
+	return nil
+}
+
+// SavePayrollCalculation заменяет строки, перерасчеты и записи правил черновика новым расчетом в одной транзакции
+func (r *Repository) SavePayrollCalculation(periodID int, lines []models.PayrollLine, adjustments []models.PayrollAdjustment,
+	penalties, bonuses []models.PenaltyOrBonus) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM payroll_periods WHERE id = $1 FOR UPDATE`, periodID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("расчет зарплаты с ID %d не найден", periodID)
+		}
+		return fmt.Errorf("ошибка при получении расчета зарплаты: %w", err)
+	}
+	if status != models.PayrollDraft {
+		return fmt.Errorf("расчет зарплаты в статусе '%s' нельзя пересчитать", status)
+	}
+
+	if err = replaceRuleEntries(tx, periodID, penalties, bonuses); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM payroll_adjustments WHERE period_id = $1`, periodID); err != nil {
+		return fmt.Errorf("ошибка при удалении перерасчетов зарплаты: %w", err)
+	}
+	if _, err = tx.Exec(`DELETE FROM payroll_lines WHERE period_id = $1`, periodID); err != nil {
+		return fmt.Errorf("ошибка при удалении строк расчета зарплаты: %w", err)
+	}
+
+	for _, line := range lines {
+		var details []byte
+		if line.Details != nil {
+			if details, err = json.Marshal(line.Details); err != nil {
+				return fmt.Errorf("ошибка при сохранении расшифровки расчета зарплаты: %w", err)
+			}
+		}
+		_, err = tx.Exec(`
+			INSERT INTO payroll_lines (period_id, worker_id, accrued, bonuses, penalties, advances, adjustments, total, details)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			periodID, line.WorkerID, line.Accrued, line.Bonuses, line.Penalties, line.Advances, line.Adjustments, line.Total, details)
+		if err != nil {
+			logger.Error("Ошибка при сохранении строки расчета зарплаты: %v", err)
+			return fmt.Errorf("ошибка при сохранении строки расчета зарплаты: %w", err)
+		}
+	}
+	for _, adjustment := range adjustments {
+		_, err = tx.Exec(`
+			INSERT INTO payroll_adjustments (period_id, worker_id, source_period_id, amount, description)
+			VALUES ($1, $2, $3, $4, $5)`,
+			periodID, adjustment.WorkerID, adjustment.SourcePeriodID, adjustment.Amount, adjustment.Description)
+		if err != nil {
+			logger.Error("Ошибка при сохранении перерасчета зарплаты: %v", err)
+			return fmt.Errorf("ошибка при сохранении перерасчета зарплаты: %w", err)
+		}
+	}
+
+	if _, err = tx.Exec(`UPDATE payroll_periods SET calculated_at = CURRENT_TIMESTAMP WHERE id = $1`, periodID); err != nil {
+		return fmt.Errorf("ошибка при обновлении расчета зарплаты: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Сохранен расчет зарплаты ID:%d, строк: %d, перерасчетов: %d", periodID, len(lines), len(adjustments))
+	return nil
+}
+
+// GetPayrollLineDetails возвращает расшифровку строки расчета работника, сохраненную при расчете, nil - не сохранена
+func (r *Repository) GetPayrollLineDetails(periodID, workerID int) (*models.PayrollLineDetails, error) {
+	var details []byte
+	err := r.db.QueryRow(`SELECT details FROM payroll_lines WHERE period_id = $1 AND worker_id = $2`,
+		periodID, workerID).Scan(&details)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("работнику ID %d не начислена зарплата за этот период", workerID)
+		}
+		logger.Error("Ошибка при получении расшифровки расчета зарплаты: %v", err)
+		return nil, fmt.Errorf("ошибка при получении расшифровки расчета зарплаты: %w", err)
+	}
+	if details == nil {
+		return nil, nil
+	}
+
+	var result models.PayrollLineDetails
+	if err := json.Unmarshal(details, &result); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении расшифровки расчета зарплаты: %w", err)
+	}
+	return &result, nil
+}
+
+// UpdatePayrollStatus переводит расчет из статуса from в статус to. При утверждении запоминается,
+// кто утвердил, при возврате в черновик отметка об утверждении снимается.
+func (r *Repository) UpdatePayrollStatus(id int, from, to string, userID int) error {
+	logger.Debug("Перевод расчета зарплаты ID:%d из '%s' в '%s'", id, from, to)
+	result, err := r.db.Exec(`
+		UPDATE payroll_periods SET
+			status = $3::varchar,
+			approved_by = CASE WHEN $3 = 'утвержден' THEN $4::integer
+			                   WHEN $3 = 'черновик' THEN NULL ELSE approved_by END,
+			approved_at = CASE WHEN $3 = 'утвержден' THEN CURRENT_TIMESTAMP
+			                   WHEN $3 = 'черновик' THEN NULL ELSE approved_at END,
+			locked_at = CASE WHEN $3 = 'закрыт' THEN CURRENT_TIMESTAMP ELSE locked_at END
+		WHERE id = $1 AND status = $2`,
+		id, from, to, nullableID(userID))
+	if err != nil {
+		logger.Error("Ошибка при смене статуса расчета зарплаты: %v", err)
+		return fmt.Errorf("ошибка при смене статуса расчета зарплаты: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		var status string
+		err = r.db.Get(&status, `SELECT status FROM payroll_periods WHERE id = $1`, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("расчет зарплаты с ID %d не найден", id)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при получении расчета зарплаты: %w", err)
+		}
+		return fmt.Errorf("расчет зарплаты в статусе '%s', ожидается '%s'", status, from)
+	}
+
+	logger.Info("Расчет зарплаты ID:%d переведен в статус '%s'", id, to)
+	return nil
+}
+
+// DeletePayrollPeriod удаляет черновик расчета зарплаты
+func (r *Repository) DeletePayrollPeriod(id int) error {
+	logger.Debug("Удаление расчета зарплаты ID:%d", id)
+	result, err := r.db.Exec(`DELETE FROM payroll_periods WHERE id = $1 AND status = 'черновик'`, id)
+	if err != nil {
+		logger.Error("Ошибка при удалении расчета зарплаты: %v", err)
+		return fmt.Errorf("ошибка при удалении расчета зарплаты: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("расчет зарплаты с ID %d не найден или уже утвержден", id)
+	}
+
+	logger.Info("Расчет зарплаты успешно удален")
+	return nil
+}
+
+// GetWorkerPayslips возвращает периоды, в которых работнику начислена зарплата, с суммой к выплате
+func (r *Repository) GetWorkerPayslips(workerID int) ([]models.PayslipSummary, error) {
+	payslips := []models.PayslipSummary{}
+	query := `
+		SELECT p.id AS period_id, p.period_start, p.period_end, p.status, l.total
+		FROM payroll_lines l
+		JOIN payroll_periods p ON p.id = l.period_id
+		WHERE l.worker_id = $1
+		ORDER BY p.period_start DESC`
+
+	logger.Debug("Получение расчетных листков работника ID:%d", workerID)
+	if err := r.db.Select(&payslips, query, workerID); err != nil {
+		logger.Error("Ошибка при получении расчетных листков: %v", err)
+		return nil, fmt.Errorf("ошибка при получении расчетных листков: %w", err)
+	}
+	return payslips, nil
+}
@@ -0,0 +1,221 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// workerID без кавычек в таблицах хранится как workerid, алиас сохраняет имя для тега db
+const penaltyBonusColumns = `
+	e.id, e.workerID AS "workerID", e.delta, COALESCE(e.description, '') AS description, e.category,
+	e.order_id, e.created_by, e.created_at, e.updated_at, e.revoked_at, e.revoked_by,
+	COALESCE(e.revoke_reason, '') AS revoke_reason, e.rule_id, e.period_id`
+
+// penaltyBonusTable возвращает таблицу записей вида kind
+func penaltyBonusTable(kind string) string {
+	if kind == models.EntryBonus {
+		return "bonuses"
+	}
+	return "penalties"
+}
+
+// nullableTime возвращает nil для нулевой даты, чтобы не ограничивать выборку
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (r *Repository) AddPenalty(penalty models.PenaltyOrBonus) (int, error) {
+	return r.addPenaltyOrBonus(models.EntryPenalty, penalty)
+}
+
+func (r *Repository) AddBonus(bonus models.PenaltyOrBonus) (int, error) {
+	return r.addPenaltyOrBonus(models.EntryBonus, bonus)
+}
+
+func (r *Repository) addPenaltyOrBonus(kind string, entry models.PenaltyOrBonus) (int, error) {
+	var id int
+	query := `
+		INSERT INTO ` + penaltyBonusTable(kind) + ` (workerID, delta, description, category, order_id, created_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+		RETURNING id`
+
+	logger.Debug("Добавление записи '%s' работнику ID:%d на сумму %d", kind, entry.WorkerID, entry.Amount)
+	err := r.db.QueryRow(query, entry.WorkerID, entry.Amount, entry.Desc, entry.Category, entry.OrderID, entry.CreatedBy).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			if entry.OrderID != nil && pqErr.Constraint == penaltyBonusTable(kind)+"_order_id_fkey" {
+				return 0, fmt.Errorf("заказ с ID %d не найден", *entry.OrderID)
+			}
+			return 0, fmt.Errorf("работник с ID %d не найден", entry.WorkerID)
+		}
+		logger.Error("Ошибка при добавлении записи '%s': %v", kind, err)
+		return 0, fmt.Errorf("ошибка при добавлении записи '%s': %w", kind, err)
+	}
+
+	logger.Info("Запись '%s' успешно добавлена с ID: %d", kind, id)
+	return id, nil
+}
+
+// GetPenalties возвращает штрафы работника за [start, end), нулевые даты - без ограничения
+func (r *Repository) GetPenalties(workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error) {
+	return r.getPenaltiesOrBonuses(models.EntryPenalty, workerID, start, end)
+}
+
+// GetBonuses возвращает бонусы работника за [start, end), нулевые даты - без ограничения
+func (r *Repository) GetBonuses(workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error) {
+	return r.getPenaltiesOrBonuses(models.EntryBonus, workerID, start, end)
+}
+
+func (r *Repository) getPenaltiesOrBonuses(kind string, workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error) {
+	entries := []models.PenaltyOrBonus{}
+	query := `
+		SELECT ` + penaltyBonusColumns + `
+		FROM ` + penaltyBonusTable(kind) + ` e
+		WHERE e.workerID = $1
+		  AND ($2::timestamptz IS NULL OR e.created_at >= $2)
+		  AND ($3::timestamptz IS NULL OR e.created_at < $3)
+		ORDER BY e.created_at, e.id`
+
+	logger.Debug("Получение записей '%s' работника ID:%d", kind, workerID)
+	if err := r.db.Select(&entries, query, workerID, nullableTime(start), nullableTime(end)); err != nil {
+		logger.Error("Ошибка при получении записей '%s': %v", kind, err)
+		return nil, fmt.Errorf("ошибка при получении записей '%s': %w", kind, err)
+	}
+	return entries, nil
+}
+
+func (r *Repository) GetPenaltyOrBonusById(kind string, id int) (models.PenaltyOrBonus, error) {
+	var entry models.PenaltyOrBonus
+	query := `SELECT ` + penaltyBonusColumns + ` FROM ` + penaltyBonusTable(kind) + ` e WHERE e.id = $1`
+
+	if err := r.db.Get(&entry, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entry, fmt.Errorf("запись '%s' с ID %d не найдена", kind, id)
+		}
+		logger.Error("Ошибка при получении записи '%s': %v", kind, err)
+		return entry, fmt.Errorf("ошибка при получении записи '%s': %w", kind, err)
+	}
+	return entry, nil
+}
+
+// UpdatePenaltyOrBonus исправляет сумму, категорию, описание и заказ записи и пишет изменение в историю
+func (r *Repository) UpdatePenaltyOrBonus(kind string, entry models.PenaltyOrBonus, reason string, userID *int) error {
+	logger.Debug("Исправление записи '%s' ID:%d", kind, entry.ID)
+	return r.changePenaltyOrBonus(kind, entry.WorkerID, entry.ID, models.EntryChangeUpdate, reason, userID,
+		func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+				UPDATE `+penaltyBonusTable(kind)+`
+				SET delta = $1, description = NULLIF($2, ''), category = $3, order_id = $4, updated_at = NOW()
+				WHERE id = $5`,
+				entry.Amount, entry.Desc, entry.Category, entry.OrderID, entry.ID)
+			if err != nil {
+				var pqErr *pq.Error
+				if errors.As(err, &pqErr) && pqErr.Code == "23503" && entry.OrderID != nil {
+					return fmt.Errorf("заказ с ID %d не найден", *entry.OrderID)
+				}
+				return fmt.Errorf("ошибка при исправлении записи '%s': %w", kind, err)
+			}
+			return nil
+		})
+}
+
+// RevokePenaltyOrBonus отменяет запись: она остается в списке, но не учитывается в зарплате
+func (r *Repository) RevokePenaltyOrBonus(kind string, workerID, id int, reason string, userID *int) error {
+	logger.Debug("Отмена записи '%s' ID:%d", kind, id)
+	return r.changePenaltyOrBonus(kind, workerID, id, models.EntryChangeRevoke, reason, userID,
+		func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+				UPDATE `+penaltyBonusTable(kind)+`
+				SET revoked_at = NOW(), revoked_by = $1, revoke_reason = $2
+				WHERE id = $3`,
+				userID, reason, id)
+			if err != nil {
+				return fmt.Errorf("ошибка при отмене записи '%s': %w", kind, err)
+			}
+			return nil
+		})
+}
+
+// changePenaltyOrBonus блокирует запись работника, выполняет apply и сохраняет значения до и после в историю
+func (r *Repository) changePenaltyOrBonus(kind string, workerID, id int, action, reason string, userID *int, apply func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	table := penaltyBonusTable(kind)
+	var old models.PenaltyOrBonus
+	var revokedAt *time.Time
+	err = tx.QueryRow(`
+		SELECT delta, COALESCE(description, ''), category, order_id, revoked_at
+		FROM `+table+`
+		WHERE id = $1 AND workerID = $2
+		FOR UPDATE`, id, workerID).Scan(&old.Amount, &old.Desc, &old.Category, &old.OrderID, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("запись '%s' с ID %d не найдена у работника", kind, id)
+		}
+		logger.Error("Ошибка при получении записи '%s': %v", kind, err)
+		return fmt.Errorf("ошибка при получении записи '%s': %w", kind, err)
+	}
+	if revokedAt != nil {
+		return fmt.Errorf("запись '%s' с ID %d уже отменена %s", kind, id, revokedAt.Format("02.01.2006"))
+	}
+
+	if err := apply(tx); err != nil {
+		logger.Error("Ошибка при изменении записи '%s' ID:%d: %v", kind, id, err)
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO penalty_bonus_changes (kind, entry_id, worker_id, action,
+			old_delta, new_delta, old_category, new_category, old_description, new_description,
+			old_order_id, new_order_id, reason, user_id)
+		SELECT $1::varchar, e.id, e.workerID, $2::varchar, $3::integer, e.delta, $4::varchar, e.category,
+			NULLIF($5::text, ''), e.description, $6::integer, e.order_id, $7::text, $8::integer
+		FROM `+table+` e
+		WHERE e.id = $9`,
+		kind, action, old.Amount, old.Category, old.Desc, old.OrderID, reason, userID, id)
+	if err != nil {
+		logger.Error("Ошибка при записи истории '%s' ID:%d: %v", kind, id, err)
+		return fmt.Errorf("ошибка при записи истории изменений: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Запись '%s' ID:%d: %s", kind, id, action)
+	return nil
+}
+
+// GetPenaltyOrBonusChanges возвращает историю исправлений и отмен записи
+func (r *Repository) GetPenaltyOrBonusChanges(kind string, workerID, id int) ([]models.PenaltyOrBonusChange, error) {
+	changes := []models.PenaltyOrBonusChange{}
+	query := `
+		SELECT c.id, c.kind, c.entry_id, c.worker_id, c.action, c.old_delta, c.new_delta,
+			   c.old_category, c.new_category, COALESCE(c.old_description, '') AS old_description,
+			   COALESCE(c.new_description, '') AS new_description, c.old_order_id, c.new_order_id,
+			   c.reason, c.user_id, COALESCE(u.name, '') AS user_name, c.created_at
+		FROM penalty_bonus_changes c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.kind = $1 AND c.worker_id = $2 AND c.entry_id = $3
+		ORDER BY c.created_at, c.id`
+
+	if err := r.db.Select(&changes, query, kind, workerID, id); err != nil {
+		logger.Error("Ошибка при получении истории записи '%s': %v", kind, err)
+		return nil, fmt.Errorf("ошибка при получении истории изменений: %w", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const penaltyBonusRuleColumns = `
+	id, name, kind, metric, operator, threshold, amount, per_unit, category, worker_id, active, created_at`
+
+func (r *Repository) GetPenaltyBonusRules(activeOnly bool) ([]models.PenaltyBonusRule, error) {
+	rules := []models.PenaltyBonusRule{}
+	query := `
+		SELECT ` + penaltyBonusRuleColumns + `
+		FROM penalty_bonus_rules
+		WHERE (NOT $1 OR active)
+		ORDER BY kind, name, id`
+
+	logger.Debug("Получение правил штрафов и бонусов")
+	if err := r.db.Select(&rules, query, activeOnly); err != nil {
+		logger.Error("Ошибка при получении правил штрафов и бонусов: %v", err)
+		return nil, fmt.Errorf("ошибка при получении правил штрафов и бонусов: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *Repository) CreatePenaltyBonusRule(rule models.PenaltyBonusRule) (int, error) {
+	var id int
+	logger.Debug("Создание правила '%s'", rule.Name)
+	err := r.db.QueryRow(`
+		INSERT INTO penalty_bonus_rules (name, kind, metric, operator, threshold, amount, per_unit, category, worker_id, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`,
+		rule.Name, rule.Kind, rule.Metric, rule.Operator, rule.Threshold, rule.Amount, rule.PerUnit,
+		rule.Category, rule.WorkerID, rule.Active).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, fmt.Errorf("работник с ID %d не найден", *rule.WorkerID)
+		}
+		logger.Error("Ошибка при создании правила: %v", err)
+		return 0, fmt.Errorf("ошибка при создании правила: %w", err)
+	}
+
+	logger.Info("Правило успешно создано с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) UpdatePenaltyBonusRule(id int, rule models.PenaltyBonusRule) error {
+	logger.Debug("Обновление правила ID: %d", id)
+	result, err := r.db.Exec(`
+		UPDATE penalty_bonus_rules
+		SET name = $1, kind = $2, metric = $3, operator = $4, threshold = $5, amount = $6, per_unit = $7,
+			category = $8, worker_id = $9, active = $10
+		WHERE id = $11`,
+		rule.Name, rule.Kind, rule.Metric, rule.Operator, rule.Threshold, rule.Amount, rule.PerUnit,
+		rule.Category, rule.WorkerID, rule.Active, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("работник с ID %d не найден", *rule.WorkerID)
+		}
+		logger.Error("Ошибка при обновлении правила: %v", err)
+		return fmt.Errorf("ошибка при обновлении правила: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("правило с ID %d не найдено", id)
+	}
+
+	logger.Info("Правило успешно обновлено")
+	return nil
+}
+
+// DeletePenaltyBonusRule удаляет правило, созданные им записи остаются без ссылки на правило
+func (r *Repository) DeletePenaltyBonusRule(id int) error {
+	logger.Debug("Удаление правила ID: %d", id)
+	result, err := r.db.Exec(`DELETE FROM penalty_bonus_rules WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Ошибка при удалении правила: %v", err)
+		return fmt.Errorf("ошибка при удалении правила: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("правило с ID %d не найдено", id)
+	}
+
+	logger.Info("Правило успешно удалено")
+	return nil
+}
+
+// GetWorkerRuleMetrics считает показатели работников, работавших в [start, end): выполненные заказы и выручку по ним,
+// переделки их заказов и жалобы клиентов, записанные штрафами вручную. Работавшими считаются работники с заказами,
+// отметками табеля или сменами в графике за период, остальные (в отпуске, уволенные) правилами не оцениваются.
+func (r *Repository) GetWorkerRuleMetrics(start, end time.Time) ([]models.WorkerRuleMetrics, error) {
+	metrics := []models.WorkerRuleMetrics{}
+	query := `
+		SELECT
+			w.id AS worker_id,
+			(SELECT COUNT(*) FROM orders o
+			 WHERE o.worker_id = w.id AND o.status = $3 AND o.created_at >= $1 AND o.created_at < $2) AS orders,
+			(SELECT COALESCE(SUM(o.total_amount), 0) FROM orders o
+			 WHERE o.worker_id = w.id AND o.status = $3 AND o.created_at >= $1 AND o.created_at < $2) AS revenue,
+			(SELECT COUNT(*) FROM orders rw
+			 JOIN orders o ON o.id = rw.rework_of_id
+			 WHERE o.worker_id = w.id AND rw.created_at >= $1 AND rw.created_at < $2) AS reworks,
+			(SELECT COUNT(*) FROM penalties p
+			 WHERE p.workerID = w.id AND p.category = 'жалоба клиента' AND p.revoked_at IS NULL
+			   AND p.period_id IS NULL AND p.created_at >= $1 AND p.created_at < $2) AS complaints
+		FROM workers w
+		WHERE EXISTS (SELECT 1 FROM orders o WHERE o.worker_id = w.id AND o.created_at >= $1 AND o.created_at < $2)
+		   OR EXISTS (SELECT 1 FROM time_entries t WHERE t.worker_id = w.id AND t.clock_in >= $1 AND t.clock_in < $2)
+		   OR EXISTS (SELECT 1 FROM shift_assignments a
+					  WHERE a.worker_id = w.id AND a.work_date >= $1::date AND a.work_date < $2::date)
+		ORDER BY w.id`
+
+	logger.Debug("Получение показателей работников для правил с %v по %v", start, end)
+	if err := r.db.Select(&metrics, query, start, end, models.OrderStatusCompleted); err != nil {
+		logger.Error("Ошибка при получении показателей работников: %v", err)
+		return nil, fmt.Errorf("ошибка при получении показателей работников: %w", err)
+	}
+	return metrics, nil
+}
+
+// GetPeriodRuleEntries возвращает штрафы и бонусы, созданные правилами при прошлом расчете periodID
+func (r *Repository) GetPeriodRuleEntries(periodID int) ([]models.PenaltyOrBonus, []models.PenaltyOrBonus, error) {
+	var result [2][]models.PenaltyOrBonus
+	for i, kind := range []string{models.EntryPenalty, models.EntryBonus} {
+		result[i] = []models.PenaltyOrBonus{}
+		query := `SELECT ` + penaltyBonusColumns + ` FROM ` + penaltyBonusTable(kind) + ` e WHERE e.period_id = $1 ORDER BY e.id`
+		if err := r.db.Select(&result[i], query, periodID); err != nil {
+			logger.Error("Ошибка при получении записей правил '%s': %v", kind, err)
+			return nil, nil, fmt.Errorf("ошибка при получении записей правил: %w", err)
+		}
+	}
+	return result[0], result[1], nil
+}
+
+// replaceRuleEntries заменяет записи, созданные правилами при прошлом расчете periodID, на новые.
+// Записи, исправленные или отмененные менеджером, остаются, и правило не создает их повторно.
+func replaceRuleEntries(tx *sql.Tx, periodID int, penalties, bonuses []models.PenaltyOrBonus) error {
+	logger.Debug("Замена записей правил для расчета зарплаты ID:%d", periodID)
+	for kind, entries := range map[string][]models.PenaltyOrBonus{models.EntryPenalty: penalties, models.EntryBonus: bonuses} {
+		table := penaltyBonusTable(kind)
+		_, err := tx.Exec(`
+			DELETE FROM `+table+`
+			WHERE period_id = $1 AND updated_at IS NULL AND revoked_at IS NULL`, periodID)
+		if err != nil {
+			logger.Error("Ошибка при удалении записей правил: %v", err)
+			return fmt.Errorf("ошибка при удалении записей правил: %w", err)
+		}
+
+		rows, err := tx.Query(`SELECT rule_id, workerID FROM `+table+` WHERE period_id = $1 AND rule_id IS NOT NULL`, periodID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении исправленных записей правил: %w", err)
+		}
+		overridden := make(map[[2]int]bool)
+		for rows.Next() {
+			var ruleID, workerID int
+			if err := rows.Scan(&ruleID, &workerID); err != nil {
+				rows.Close()
+				return fmt.Errorf("ошибка при чтении исправленных записей правил: %w", err)
+			}
+			overridden[[2]int{ruleID, workerID}] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("ошибка при чтении исправленных записей правил: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.RuleID != nil && overridden[[2]int{*entry.RuleID, entry.WorkerID}] {
+				continue
+			}
+			_, err = tx.Exec(`
+				INSERT INTO `+table+` (workerID, delta, description, category, rule_id, period_id, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				entry.WorkerID, entry.Amount, entry.Desc, entry.Category, entry.RuleID, periodID, entry.CreatedAt)
+			if err != nil {
+				logger.Error("Ошибка при добавлении записи правила: %v", err)
+				return fmt.Errorf("ошибка при добавлении записи правила: %w", err)
+			}
+		}
+	}
+	return nil
+}
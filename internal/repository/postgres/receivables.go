@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const ledgerColumns = `id, client_id, contract_id, order_id, entry_type, amount, entry_date, document, description, user_id, created_at`
+
+// syncOrderCharge приводит начисление по заказу в соответствие с заказом:
+// заказ по договору с суммой создает или обновляет начисление, иначе начисление удаляется.
+// Договор берется у клиента и не меняется, пока заказ остается за тем же клиентом.
+func syncOrderCharge(tx *sql.Tx, orderID int) error {
+	if _, err := tx.Exec(`
+		DELETE FROM client_ledger l
+		WHERE l.order_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM orders o
+			WHERE o.id = $1 AND o.payment_method = $2 AND o.client_id IS NOT NULL AND o.total_amount > 0
+		)`, orderID, models.PaymentContract); err != nil {
+		logger.Error("Ошибка при удалении начисления по заказу ID %d: %v", orderID, err)
+		return fmt.Errorf("ошибка при удалении начисления по заказу: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO client_ledger (client_id, contract_id, order_id, entry_type, amount, entry_date, description)
+		SELECT o.client_id, cl.contract_id, o.id, $3, o.total_amount, o.created_at,
+			   'Заказ №' || o.id || ', ' || o.vehicle_number
+		FROM orders o
+		JOIN clients cl ON cl.id = o.client_id
+		WHERE o.id = $1 AND o.payment_method = $2 AND o.total_amount > 0
+		ON CONFLICT (order_id) DO UPDATE
+		SET contract_id = CASE WHEN client_ledger.client_id = EXCLUDED.client_id
+							   THEN client_ledger.contract_id ELSE EXCLUDED.contract_id END,
+			client_id = EXCLUDED.client_id,
+			amount = EXCLUDED.amount,
+			description = EXCLUDED.description`,
+		orderID, models.PaymentContract, models.LedgerCharge); err != nil {
+		logger.Error("Ошибка при сохранении начисления по заказу ID %d: %v", orderID, err)
+		return fmt.Errorf("ошибка при сохранении начисления по заказу: %w", err)
+	}
+	return nil
+}
+
+// GetLedgerEntries возвращает начисления и оплаты по дате. 0 в параметрах - без фильтра,
+// before ограничивает записи датой (нулевое время - без ограничения)
+func (r *Repository) GetLedgerEntries(clientID, contractID int, before time.Time) ([]models.LedgerEntry, error) {
+	var entries []models.LedgerEntry
+	query := `
+		SELECT ` + ledgerColumns + `
+		FROM client_ledger
+		WHERE ($1 = 0 OR client_id = $1)
+		  AND ($2 = 0 OR contract_id = $2)
+		  AND ($3::timestamptz IS NULL OR entry_date < $3)
+		ORDER BY client_id, entry_date, id`
+
+	var beforeArg interface{}
+	if !before.IsZero() {
+		beforeArg = before
+	}
+
+	logger.Debug("Получение расчетов с клиентом ID:%d по договору ID:%d", clientID, contractID)
+	err := r.db.Select(&entries, query, clientID, contractID, beforeArg)
+	if err != nil {
+		logger.Error("Ошибка при получении расчетов с клиентом: %v", err)
+		return nil, fmt.Errorf("ошибка при получении расчетов с клиентом: %w", err)
+	}
+
+	return entries, nil
+}
+
+// AddClientPayment сохраняет поступившую от клиента оплату
+func (r *Repository) AddClientPayment(entry models.LedgerEntry) (int, error) {
+	var id int
+	query := `
+		INSERT INTO client_ledger (client_id, contract_id, entry_type, amount, entry_date, document, description, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	logger.Debug("Добавление оплаты клиента ID:%d на сумму %s", entry.ClientID, entry.Amount)
+	err := r.db.QueryRow(query, entry.ClientID, entry.ContractID, models.LedgerPayment, entry.Amount,
+		entry.Date, entry.Document, entry.Description, entry.UserID).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, fmt.Errorf("клиент или договор не найден")
+		}
+		logger.Error("Ошибка при добавлении оплаты клиента: %v", err)
+		return 0, fmt.Errorf("ошибка при добавлении оплаты клиента: %w", err)
+	}
+
+	logger.Info("Оплата ID:%d клиента ID:%d сохранена", id, entry.ClientID)
+	return id, nil
+}
+
+// DeleteClientPayment удаляет ошибочно внесенную оплату, начисления по заказам так удалить нельзя
+func (r *Repository) DeleteClientPayment(clientID, id int) error {
+	logger.Debug("Удаление оплаты ID:%d клиента ID:%d", id, clientID)
+	result, err := r.db.Exec(`DELETE FROM client_ledger WHERE id = $1 AND client_id = $2 AND entry_type = $3`,
+		id, clientID, models.LedgerPayment)
+	if err != nil {
+		logger.Error("Ошибка при удалении оплаты: %v", err)
+		return fmt.Errorf("ошибка при удалении оплаты: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("оплата с ID %d не найдена", id)
+	}
+
+	logger.Info("Оплата ID:%d удалена", id)
+	return nil
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"database/sql"
 	"fmt"
 	"go-hinomontaj/models"
 	"go-hinomontaj/pkg/logger"
@@ -165,12 +166,12 @@ func (r *Repository) GetUserById(id int) (models.User, error) {
 func (r *Repository) CreateWorker(worker models.Worker) (int, error) {
 	var id int
 	query := `
-		INSERT INTO workers (name, surname, email, phone, salary_schema, salary, has_car)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO workers (name, surname, email, phone, salary_schema, salary, has_car, warehouse_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id`
 
 	logger.Debug("Создание нового работника: %s %s", worker.Name, worker.Surname)
-	err := r.db.QueryRow(query, worker.Name, worker.Surname, worker.Email, worker.Phone, worker.SalarySchema, worker.Salary, worker.HasCar).Scan(&id)
+	err := r.db.QueryRow(query, worker.Name, worker.Surname, worker.Email, worker.Phone, worker.SalarySchema, worker.Salary, worker.HasCar, worker.WarehouseID).Scan(&id)
 	if err != nil {
 		logger.Error("Ошибка при создании работника: %v", err)
 		return 0, fmt.Errorf("ошибка при создании работника: %w", err)
@@ -183,7 +184,7 @@ func (r *Repository) CreateWorker(worker models.Worker) (int, error) {
 func (r *Repository) GetAllWorkers() ([]models.Worker, error) {
 	var workers []models.Worker
 	query := `
-		SELECT id, name, surname, email, phone, salary_schema, salary, has_car, created_at, updated_at
+		SELECT id, name, surname, email, phone, salary_schema, salary, has_car, warehouse_id, user_id, link_issue, created_at, updated_at
 		FROM workers
 		ORDER BY id`
 
@@ -201,7 +202,7 @@ func (r *Repository) GetAllWorkers() ([]models.Worker, error) {
 func (r *Repository) GetWorkerById(id int) (models.Worker, error) {
 	var worker models.Worker
 	query := `
-		SELECT id, name, surname, email, phone, salary_schema, salary, has_car, created_at, updated_at
+		SELECT id, name, surname, email, phone, salary_schema, salary, has_car, warehouse_id, user_id, link_issue, created_at, updated_at
 		FROM workers
 		WHERE id = $1`
 
@@ -216,14 +217,35 @@ func (r *Repository) GetWorkerById(id int) (models.Worker, error) {
 	return worker, nil
 }
 
+// UpdateWorker обновляет карточку работника. Если меняется схема оплаты из карточки у работника без
+// заданных схем, закрытые периоды с его начислениями отмечаются как измененные для перерасчета.
 func (r *Repository) UpdateWorker(id int, worker models.Worker) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE payroll_periods p SET changed_at = CURRENT_TIMESTAMP
+		FROM workers w
+		WHERE w.id = $1 AND (w.salary_schema IS DISTINCT FROM $2 OR w.salary IS DISTINCT FROM $3)
+		  AND NOT EXISTS (SELECT 1 FROM worker_salary_schemes s WHERE s.worker_id = w.id)
+		  AND p.status = $4
+		  AND EXISTS (SELECT 1 FROM payroll_lines l WHERE l.period_id = p.id AND l.worker_id = w.id)`,
+		id, worker.SalarySchema, worker.Salary, models.PayrollLocked)
+	if err != nil {
+		logger.Error("Ошибка при отметке изменения закрытых периодов: %v", err)
+		return fmt.Errorf("ошибка при отметке изменения закрытых периодов: %w", err)
+	}
+
 	query := `
 		UPDATE workers
-		SET name = $1, surname = $2, email = $3, phone = $4, salary_schema = $5, salary = $6, has_car = $7, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $8`
+		SET name = $1, surname = $2, email = $3, phone = $4, salary_schema = $5, salary = $6, has_car = $7, warehouse_id = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $9`
 
 	logger.Debug("Обновление данных работника ID: %d", id)
-	result, err := r.db.Exec(query, worker.Name, worker.Surname, worker.Email, worker.Phone, worker.SalarySchema, worker.Salary, worker.HasCar, id)
+	result, err := tx.Exec(query, worker.Name, worker.Surname, worker.Email, worker.Phone, worker.SalarySchema, worker.Salary, worker.HasCar, worker.WarehouseID, id)
 	if err != nil {
 		logger.Error("Ошибка при обновлении работника: %v", err)
 		return fmt.Errorf("ошибка при обновлении работника: %w", err)
@@ -237,6 +259,10 @@ func (r *Repository) UpdateWorker(id int, worker models.Worker) error {
 		return fmt.Errorf("работник с ID %d не найден", id)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при подтверждении транзакции: %w", err)
+	}
+
 	logger.Info("Данные работника успешно обновлены")
 	return nil
 }
@@ -248,7 +274,7 @@ func (r *Repository) DeleteWorker(id int) error {
 	result, err := r.db.Exec(query, id)
 	if err != nil {
 		logger.Error("Ошибка при удалении работника: %v", err)
-		return fmt.Errorf("ошибка при удалении работника: %w", err)
+		return workerDeleteError(id, err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -288,7 +314,7 @@ func (r *Repository) GetAllClients() ([]models.Client, error) {
 			   COALESCE(array_remove(array_agg(cars.number), NULL), ARRAY[]::varchar[]) as car_numbers,
 			   c.owner_phone, c.manager_phone, c.contract_id
 		FROM clients c
-		LEFT JOIN clients_cars cc ON c.id = cc.client_id
+		LEFT JOIN clients_cars cc ON c.id = cc.client_id AND cc.valid_to IS NULL
 		LEFT JOIN cars ON cc.car_id = cars.id
 		GROUP BY c.id, c.name, c.client_type, c.created_at, c.updated_at`
 
@@ -310,7 +336,7 @@ func (r *Repository) GetClientById(id int) (models.Client, error) {
 			   array_agg(cars.number) as car_numbers,
 			   c.owner_phone, c.manager_phone, c.contract_id
 		FROM clients c
-		LEFT JOIN clients_cars cc ON c.id = cc.client_id
+		LEFT JOIN clients_cars cc ON c.id = cc.client_id AND cc.valid_to IS NULL
 		LEFT JOIN cars ON cc.car_id = cars.id
 		WHERE c.id = $1
 		GROUP BY c.id, c.name, c.client_type, c.created_at, c.updated_at`
@@ -329,10 +355,10 @@ func (r *Repository) GetClientById(id int) (models.Client, error) {
 func (r *Repository) GetClientCars(clientId int) ([]models.Car, error) {
 	var cars []models.Car
 	query := `
-		SELECT c.id, c.number, c.model, c.year, c.created_at, c.updated_at
+		SELECT ` + carColumns + `
 		FROM cars c
 		JOIN clients_cars cc ON c.id = cc.car_id
-		WHERE cc.client_id = $1
+		WHERE cc.client_id = $1 AND cc.valid_to IS NULL
 		ORDER BY c.created_at DESC`
 
 	logger.Debug("Получение автомобилей клиента ID:%d", clientId)
@@ -364,14 +390,17 @@ func (r *Repository) AddCarToClient(clientId int, car models.Car) error {
 		// Если машина не найдена, создаем новую
 		if err.Error() == "sql: no rows in result set" {
 			query = `
-				INSERT INTO cars (number, model, year, created_at, updated_at)
-				VALUES ($1, $2, $3, NOW(), NOW())
+				INSERT INTO cars (number, model, year, vehicle_type, vin, created_at, updated_at)
+				VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NOW(), NOW())
 				RETURNING id`
 
-			err = tx.QueryRow(query, car.Number, car.Model, car.Year).Scan(&carId)
+			err = tx.QueryRow(query, car.Number, car.Model, car.Year, car.VehicleType, car.VIN).Scan(&carId)
 			if err != nil {
 				logger.Error("Ошибка при создании автомобиля: %v", err)
-				return fmt.Errorf("ошибка при создании автомобиля: %w", err)
+				return carWriteError("ошибка при создании автомобиля", err)
+			}
+			if err = saveCarAxles(tx, carId, car.Axles); err != nil {
+				return err
 			}
 			logger.Debug("Создана новая машина ID:%d с номером %s", carId, car.Number)
 		} else {
@@ -384,7 +413,7 @@ func (r *Repository) AddCarToClient(clientId int, car models.Car) error {
 
 	// Проверяем, не связана ли уже эта машина с данным клиентом
 	var linkExists bool
-	query = `SELECT EXISTS(SELECT 1 FROM clients_cars WHERE client_id = $1 AND car_id = $2)`
+	query = `SELECT EXISTS(SELECT 1 FROM clients_cars WHERE client_id = $1 AND car_id = $2 AND valid_to IS NULL)`
 	err = tx.QueryRow(query, clientId, carId).Scan(&linkExists)
 	if err != nil {
 		logger.Error("Ошибка при проверке связи клиент-машина: %v", err)
@@ -555,7 +584,7 @@ func (r *Repository) WhooseCar(car string) ([]models.Client, error) {
 		SELECT DISTINCT c.id, c.name, c.client_type, c.owner_phone, c.manager_phone, c.contract_id, c.created_at, c.updated_at,
 			   COALESCE(array_remove(array_agg(cars.number), NULL), ARRAY[]::varchar[]) as car_numbers
 		FROM clients c
-		JOIN clients_cars cc ON c.id = cc.client_id 
+		JOIN clients_cars cc ON c.id = cc.client_id AND cc.valid_to IS NULL
 		JOIN cars ON cars.id = cc.car_id 
 		WHERE cars.number = $1
 		GROUP BY c.id, c.name, c.client_type, c.owner_phone, c.manager_phone, c.contract_id, c.created_at, c.updated_at`
@@ -624,14 +653,26 @@ func (r *Repository) CreateOrder(order models.Order) (int, error) {
 	}
 	defer tx.Rollback()
 
+	// Новый розничный покупатель заводится вместе с заказом, чтобы при ошибке заказа не остался покупатель без заказов.
+	// Машина закрепляется за ним ниже, вместе с машинами известных покупателей.
+	if order.RetailCustomerID == nil && (order.CustomerPhone != "" || order.CustomerCard != "") {
+		customerID, err := insertRetailCustomer(tx, models.RetailCustomer{Phone: order.CustomerPhone, CardNumber: order.CustomerCard})
+		if err != nil {
+			return 0, err
+		}
+		order.RetailCustomerID = &customerID
+	}
+
 	var orderId int
 	query := `
-		INSERT INTO orders (status, worker_id, client_id, vehicle_number, payment_method, total_amount)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO orders (status, worker_id, client_id, vehicle_number, payment_method, total_amount,
+			retail_customer_id, discount_amount, rework_of_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id`
 
 	logger.Debug("Создание нового заказа")
-	err = tx.QueryRow(query, order.Status, order.WorkerID, order.ClientID, order.VehicleNumber, order.PaymentMethod, order.TotalAmount).Scan(&orderId)
+	err = tx.QueryRow(query, order.Status, order.WorkerID, order.ClientID, order.VehicleNumber, order.PaymentMethod, order.TotalAmount,
+		order.RetailCustomerID, orderDiscount(order.Services), order.ReworkOfID).Scan(&orderId)
 	if err != nil {
 		logger.Error("Ошибка при создании заказа: %v", err)
 		return 0, fmt.Errorf("ошибка при создании заказа: %w", err)
@@ -643,14 +684,17 @@ func (r *Repository) CreateOrder(order models.Order) (int, error) {
 			if service.ServiceID == 0 {
 				return 0, fmt.Errorf("не указан ID услуги")
 			}
-			if service.Price <= 0 {
+			// Услуга может стать бесплатной по скидке, но цена по прайсу должна быть
+			if service.Price < 0 || service.Discount < 0 || service.Price+service.Discount <= 0 {
 				return 0, fmt.Errorf("неверная цена услуги")
 			}
 
 			_, err = tx.Exec(`
-				INSERT INTO order_services (order_id, service_id, client_id, service_description, wheel_position, price)
-				VALUES ($1, $2, $3, $4, $5, $6)`,
-				orderId, service.ServiceID, order.ClientID, service.Description, service.WheelPosition, service.Price)
+				INSERT INTO order_services (order_id, service_id, client_id, service_description, wheel_position, price,
+					discount, discount_reason)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+				orderId, service.ServiceID, order.ClientID, service.Description, service.WheelPosition, service.Price,
+				service.Discount, service.DiscountReason)
 			if err != nil {
 				logger.Error("Ошибка при добавлении услуги к заказу: %v", err)
 				return 0, fmt.Errorf("ошибка при добавлении услуги к заказу: %w", err)
@@ -658,6 +702,26 @@ func (r *Repository) CreateOrder(order models.Order) (int, error) {
 		}
 	}
 
+	// Добавляем расходники и списываем их со склада
+	if err = insertOrderMaterials(tx, orderId, order.Materials); err != nil {
+		return 0, err
+	}
+
+	// Машина запоминается за розничным покупателем, чтобы узнать его в следующий раз без телефона
+	if err = linkRetailCustomerCar(tx, order.RetailCustomerID, order.VehicleNumber); err != nil {
+		return 0, err
+	}
+
+	// Заказ по договору попадает в расчеты с клиентом
+	if err = syncOrderCharge(tx, orderId); err != nil {
+		return 0, err
+	}
+
+	// Выполненный заказ с оплатой с предоплаты списывается с баланса клиента
+	if err = settleOrderDeposit(tx, orderId, false); err != nil {
+		return 0, err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return 0, fmt.Errorf("ошибка при коммите транзакции: %w", err)
 	}
@@ -670,7 +734,7 @@ func (r *Repository) GetAllOrders() ([]models.Order, error) {
 	var orders []models.Order
 	query := `
 		SELECT o.id, o.status, o.worker_id, o.client_id, o.vehicle_number, o.payment_method, o.total_amount,
-			   o.created_at, o.updated_at
+			   o.retail_customer_id, o.discount_amount, o.rework_of_id, o.created_at, o.updated_at
 		FROM orders o`
 
 	logger.Debug("Получение списка всех заказов")
@@ -696,7 +760,8 @@ func (r *Repository) GetAllOrders() ([]models.Order, error) {
 func (r *Repository) GetOrdersByWorkerId(workerId int) ([]models.Order, error) {
 	var orders []models.Order
 	query := `
-		SELECT id, status, worker_id, client_id, vehicle_number, payment_method, total_amount, created_at, updated_at
+		SELECT id, status, worker_id, client_id, vehicle_number, payment_method, total_amount,
+			   retail_customer_id, discount_amount, rework_of_id, created_at, updated_at
 		FROM orders
 		WHERE worker_id = $1
 		ORDER BY created_at DESC`
@@ -724,7 +789,8 @@ func (r *Repository) GetOrdersByWorkerId(workerId int) ([]models.Order, error) {
 func (r *Repository) GetOrdersByWorkerIdAndDateRange(workerId int, start, end time.Time) ([]models.Order, error) {
 	var orders []models.Order
 	query := `
-		SELECT id, status, worker_id, client_id, vehicle_number, payment_method, total_amount, created_at, updated_at
+		SELECT id, status, worker_id, client_id, vehicle_number, payment_method, total_amount,
+			   retail_customer_id, discount_amount, rework_of_id, created_at, updated_at
 		FROM orders
 		WHERE worker_id = $1 AND created_at >= $2 AND created_at < $3
 		ORDER BY created_at DESC`
@@ -740,6 +806,20 @@ func (r *Repository) GetOrdersByWorkerIdAndDateRange(workerId int, start, end ti
 	return orders, nil
 }
 
+// GetOrderWorker возвращает исполнителя заказа и время создания заказа
+func (r *Repository) GetOrderWorker(id int) (*int, time.Time, error) {
+	var workerID *int
+	var createdAt time.Time
+	err := r.db.QueryRow(`SELECT worker_id, created_at FROM orders WHERE id = $1`, id).Scan(&workerID, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, createdAt, fmt.Errorf("заказ с ID %d не найден", id)
+		}
+		return nil, createdAt, fmt.Errorf("ошибка при получении заказа: %w", err)
+	}
+	return workerID, createdAt, nil
+}
+
 func (r *Repository) UpdateOrder(id int, order models.Order) error {
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -747,15 +827,20 @@ func (r *Repository) UpdateOrder(id int, order models.Order) error {
 	}
 	defer tx.Rollback()
 
+	if _, err = lockOrderForChange(tx, id); err != nil {
+		return err
+	}
+
 	// Обновляем основную информацию о заказе
 	query := `
 		UPDATE orders
 		SET status = $1, worker_id = $2, client_id = $3, vehicle_number = $4, payment_method = $5, total_amount = $6,
-			updated_at = CURRENT_TIMESTAMP
-		WHERE id = $7`
+			discount_amount = $7, rework_of_id = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $9`
 
 	logger.Debug("Обновление данных заказа ID: %d", id)
-	result, err := tx.Exec(query, order.Status, order.WorkerID, order.ClientID, order.VehicleNumber, order.PaymentMethod, order.TotalAmount, id)
+	result, err := tx.Exec(query, order.Status, order.WorkerID, order.ClientID, order.VehicleNumber, order.PaymentMethod, order.TotalAmount,
+		orderDiscount(order.Services), order.ReworkOfID, id)
 	if err != nil {
 		logger.Error("Ошибка при обновлении заказа: %v", err)
 		return fmt.Errorf("ошибка при обновлении заказа: %w", err)
@@ -779,15 +864,34 @@ func (r *Repository) UpdateOrder(id int, order models.Order) error {
 	// Добавляем новые услуги
 	for _, service := range order.Services {
 		_, err = tx.Exec(`
-			INSERT INTO order_services (order_id, service_id, client_id, service_description, wheel_position, price)
-			VALUES ($1, $2, $3, $4, $5, $6)`,
-			id, service.ServiceID, order.ClientID, service.Description, service.WheelPosition, service.Price)
+			INSERT INTO order_services (order_id, service_id, client_id, service_description, wheel_position, price,
+				discount, discount_reason)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			id, service.ServiceID, order.ClientID, service.Description, service.WheelPosition, service.Price,
+			service.Discount, service.DiscountReason)
 		if err != nil {
 			logger.Error("Ошибка при добавлении услуги к заказу: %v", err)
 			return fmt.Errorf("ошибка при добавлении услуги к заказу: %w", err)
 		}
 	}
 
+	// Расходники заменяем только если они переданы, иначе оставляем списанные ранее
+	if order.Materials != nil {
+		if err = returnOrderMaterials(tx, id); err != nil {
+			return err
+		}
+		if err = insertOrderMaterials(tx, id, order.Materials); err != nil {
+			return err
+		}
+	}
+
+	if err = syncOrderCharge(tx, id); err != nil {
+		return err
+	}
+	if err = settleOrderDeposit(tx, id, false); err != nil {
+		return err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("ошибка при коммите транзакции: %w", err)
 	}
@@ -797,10 +901,36 @@ func (r *Repository) UpdateOrder(id int, order models.Order) error {
 }
 
 func (r *Repository) DeleteOrder(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	createdAt, err := lockOrderForChange(tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Возвращаем списанные расходники на склад
+	if err = returnOrderMaterials(tx, id); err != nil {
+		return err
+	}
+
+	// Возвращаем списанное с предоплаты клиента
+	if err = settleOrderDeposit(tx, id, true); err != nil {
+		return err
+	}
+
+	// Заказ из закрытого периода зарплаты попадет в перерасчет
+	if err = markLockedPayrollChanged(tx, createdAt, createdAt); err != nil {
+		return err
+	}
+
 	query := `DELETE FROM orders WHERE id = $1`
 
 	logger.Debug("Удаление заказа ID: %d", id)
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		logger.Error("Ошибка при удалении заказа: %v", err)
 		return fmt.Errorf("ошибка при удалении заказа: %w", err)
@@ -814,18 +944,32 @@ func (r *Repository) DeleteOrder(id int) error {
 		return fmt.Errorf("заказ с ID %d не найден", id)
 	}
 
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при коммите транзакции: %w", err)
+	}
+
 	logger.Info("Заказ успешно удален")
 	return nil
 }
 
 func (r *Repository) UpdateOrderStatus(id int, status string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = lockOrderForChange(tx, id); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE orders
 		SET status = $1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $2`
 
 	logger.Debug("Обновление статуса заказа ID: %d на %s", id, status)
-	result, err := r.db.Exec(query, status, id)
+	result, err := tx.Exec(query, status, id)
 	if err != nil {
 		logger.Error("Ошибка при обновлении статуса заказа: %v", err)
 		return fmt.Errorf("ошибка при обновлении статуса заказа: %w", err)
@@ -839,6 +983,15 @@ func (r *Repository) UpdateOrderStatus(id int, status string) error {
 		return fmt.Errorf("заказ с ID %d не найден", id)
 	}
 
+	// При выполнении заказ списывается с предоплаты, при возврате в работу - возвращается
+	if err = settleOrderDeposit(tx, id, false); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при коммите транзакции: %w", err)
+	}
+
 	logger.Info("Статус заказа успешно обновлен")
 	return nil
 }
@@ -846,12 +999,12 @@ func (r *Repository) UpdateOrderStatus(id int, status string) error {
 func (r *Repository) AddMaterial(material models.Material) error {
 	// Проверяем, существует ли уже материал с таким именем и типом
 	var existingId int
-	checkQuery := `SELECT id FROM material WHERE name = $1 AND type_ds = $2`
-	err := r.db.Get(&existingId, checkQuery, material.Name, material.TypeDS)
+	checkQuery := `SELECT id FROM material WHERE name = $1 AND category_id = $2`
+	err := r.db.Get(&existingId, checkQuery, material.Name, material.CategoryID)
 	if err == nil {
 		// Материал уже существует
-		logger.Warning("Материал %s (тип ДС: %d) уже существует с ID: %d", material.Name, material.TypeDS, existingId)
-		return fmt.Errorf("материал с названием '%s' и типом ДС %d уже существует", material.Name, material.TypeDS)
+		logger.Warning("Материал %s (категория ID: %d) уже существует с ID: %d", material.Name, material.CategoryID, existingId)
+		return fmt.Errorf("материал с названием '%s' в этой категории уже существует", material.Name)
 	}
 
 	// Проверяем, что это действительно ошибка "не найдено", а не другая ошибка
@@ -860,32 +1013,58 @@ func (r *Repository) AddMaterial(material models.Material) error {
 		return fmt.Errorf("ошибка при проверке существования материала: %w", err)
 	}
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO material (name, type_ds, storage)
-		VALUES ($1, $2, $3)
+		INSERT INTO material (name, sku, category_id, unit, min_storage, storage)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, 0)
 		RETURNING id`
 
-	logger.Debug("Создание нового материала: %s (тип ДС: %d)", material.Name, material.TypeDS)
+	logger.Debug("Создание нового материала: %s (категория ID: %d)", material.Name, material.CategoryID)
 	var id int
-	err = r.db.QueryRow(query, material.Name, material.TypeDS, material.Storage).Scan(&id)
+	err = tx.QueryRow(query, material.Name, material.SKU, material.CategoryID, material.Unit, material.MinStorage).Scan(&id)
 	if err != nil {
 		logger.Error("Ошибка при создании материала: %v", err)
-		return fmt.Errorf("ошибка при создании материала: %w", err)
+		return materialWriteError("ошибка при создании материала", err)
+	}
+
+	if material.Storage != 0 {
+		err = postMaterialMovement(tx, models.MaterialMovement{
+			MaterialID:  id,
+			Delta:       material.Storage,
+			Reason:      models.MovementReceipt,
+			Description: "количество при создании материала",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
 	}
 
 	logger.Info("Материал успешно создан с ID: %d", id)
 	return nil
 }
 
-func (r *Repository) GetAllMaterials() ([]models.Material, error) {
+func (r *Repository) GetAllMaterials(filter models.MaterialFilter) ([]models.Material, error) {
 	var materials []models.Material
 	query := `
-		SELECT id, name, type_ds, storage, created_at, updated_at
-		FROM material
-		ORDER BY name`
+		SELECT ` + materialColumns + `
+		` + materialFrom + `
+		WHERE ($1 = 0 OR m.category_id = $1)
+		  AND ($2 = '' OR m.name ILIKE '%' || $2 || '%' OR m.sku ILIKE '%' || $2 || '%')
+		ORDER BY c.name, m.name`
 
-	logger.Debug("Получение списка всех материалов")
-	err := r.db.Select(&materials, query)
+	logger.Debug("Получение списка материалов: категория ID %d, поиск '%s'", filter.CategoryID, filter.Search)
+	err := r.db.Select(&materials, query, filter.CategoryID, filter.Search)
 	if err != nil {
 		logger.Error("Ошибка при получении списка материалов: %v", err)
 		return nil, fmt.Errorf("ошибка при получении списка материалов: %w", err)
@@ -898,9 +1077,9 @@ func (r *Repository) GetAllMaterials() ([]models.Material, error) {
 func (r *Repository) GetMaterialById(id int) (models.Material, error) {
 	var material models.Material
 	query := `
-		SELECT id, name, type_ds, storage, created_at, updated_at
-		FROM material
-		WHERE id = $1`
+		SELECT ` + materialColumns + `
+		` + materialFrom + `
+		WHERE m.id = $1`
 
 	logger.Debug("Поиск материала по ID: %d", id)
 	err := r.db.Get(&material, query, id)
@@ -913,17 +1092,17 @@ func (r *Repository) GetMaterialById(id int) (models.Material, error) {
 	return material, nil
 }
 
-func (r *Repository) GetMaterialByNameAndType(name string, typeDS int) (models.Material, error) {
+func (r *Repository) GetMaterialByNameAndCategory(name string, categoryID int) (models.Material, error) {
 	var material models.Material
 	query := `
-		SELECT id, name, type_ds, storage, created_at, updated_at
-		FROM material
-		WHERE name = $1 AND type_ds = $2`
+		SELECT ` + materialColumns + `
+		` + materialFrom + `
+		WHERE m.name = $1 AND m.category_id = $2`
 
-	logger.Debug("Поиск материала по названию: %s и типу ДС: %d", name, typeDS)
-	err := r.db.Get(&material, query, name, typeDS)
+	logger.Debug("Поиск материала по названию: %s и категории ID: %d", name, categoryID)
+	err := r.db.Get(&material, query, name, categoryID)
 	if err != nil {
-		logger.Debug("Материал не найден: %s (тип ДС: %d)", name, typeDS)
+		logger.Debug("Материал не найден: %s (категория ID: %d)", name, categoryID)
 		return models.Material{}, fmt.Errorf("материал не найден: %w", err)
 	}
 
@@ -931,111 +1110,81 @@ func (r *Repository) GetMaterialByNameAndType(name string, typeDS int) (models.M
 	return material, nil
 }
 
-func (r *Repository) AddMaterialQuantity(id int, quantity int) error {
-	// Сначала получаем текущее количество
-	var currentStorage int
-	selectQuery := `SELECT storage FROM material WHERE id = $1`
-	err := r.db.Get(&currentStorage, selectQuery, id)
-	if err != nil {
-		logger.Error("Ошибка при получении текущего количества материала ID %d: %v", id, err)
-		return fmt.Errorf("ошибка при получении текущего количества материала: %w", err)
-	}
-
-	logger.Debug("Текущее количество материала ID %d: %d, добавляем: %d", id, currentStorage, quantity)
-
-	query := `
-		UPDATE material
-		SET storage = storage + $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2`
-
-	logger.Debug("Выполнение SQL: %s с параметрами: quantity=%d, id=%d", query, quantity, id)
-	result, err := r.db.Exec(query, quantity, id)
+func (r *Repository) AddMaterialQuantity(id, warehouseID int, quantity float64) error {
+	tx, err := r.db.Begin()
 	if err != nil {
-		logger.Error("Ошибка при выполнении UPDATE для материала ID %d: %v", id, err)
-		return fmt.Errorf("ошибка при добавлении количества материала: %w", err)
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	logger.Debug("Приход материала ID %d: %v", id, quantity)
+	err = postMaterialMovement(tx, models.MaterialMovement{
+		MaterialID:  id,
+		WarehouseID: warehouseID,
+		Delta:       quantity,
+		Reason:      models.MovementReceipt,
+	})
 	if err != nil {
-		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("материал с ID %d не найден", id)
+		return err
 	}
 
-	// Проверяем результат
-	var newStorage int
-	err = r.db.Get(&newStorage, selectQuery, id)
-	if err != nil {
-		logger.Error("Ошибка при проверке результата обновления материала ID %d: %v", id, err)
-	} else {
-		logger.Debug("Новое количество материала ID %d: %d", id, newStorage)
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
 	}
 
-	logger.Info("Количество материала успешно добавлено с %d до %d", currentStorage, newStorage)
+	logger.Info("Количество материала ID %d успешно увеличено на %v", id, quantity)
 	return nil
 }
 
-func (r *Repository) SubtractMaterialQuantity(id int, quantity int) error {
-	// Сначала получаем текущее количество
-	var currentStorage int
-	selectQuery := `SELECT storage FROM material WHERE id = $1`
-	err := r.db.Get(&currentStorage, selectQuery, id)
-	if err != nil {
-		logger.Error("Ошибка при получении текущего количества материала ID %d: %v", id, err)
-		return fmt.Errorf("ошибка при получении текущего количества материала: %w", err)
-	}
-
-	logger.Debug("Текущее количество материала ID %d: %d, вычитаем: %d", id, currentStorage, quantity)
-
-	if currentStorage < quantity {
-		return fmt.Errorf("недостаточно материала на складе: текущий остаток %d, требуется вычесть %d", currentStorage, quantity)
-	}
-
-	query := `
-		UPDATE material
-		SET storage = storage - $1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $2`
-
-	logger.Debug("Выполнение SQL: %s с параметрами: quantity=%d, id=%d", query, quantity, id)
-	result, err := r.db.Exec(query, quantity, id)
+func (r *Repository) SubtractMaterialQuantity(id, warehouseID int, quantity float64) error {
+	tx, err := r.db.Begin()
 	if err != nil {
-		logger.Error("Ошибка при выполнении UPDATE для материала ID %d: %v", id, err)
-		return fmt.Errorf("ошибка при уменьшении количества материала: %w", err)
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	logger.Debug("Списание материала ID %d: %v", id, quantity)
+	err = postMaterialMovement(tx, models.MaterialMovement{
+		MaterialID:  id,
+		WarehouseID: warehouseID,
+		Delta:       -quantity,
+		Reason:      models.MovementWriteOff,
+	})
 	if err != nil {
-		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("материал с ID %d не найден", id)
+		return err
 	}
 
-	// Проверяем результат
-	var newStorage int
-	err = r.db.Get(&newStorage, selectQuery, id)
-	if err != nil {
-		logger.Error("Ошибка при проверке результата обновления материала ID %d: %v", id, err)
-	} else {
-		logger.Debug("Новое количество материала ID %d: %d", id, newStorage)
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
 	}
 
-	logger.Info("Количество материала успешно уменьшено с %d до %d", currentStorage, newStorage)
+	logger.Info("Количество материала ID %d успешно уменьшено на %v", id, quantity)
 	return nil
 }
 
 func (r *Repository) UpdateMaterial(id int, material models.Material) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Остаток здесь не меняется: он хранится по складам и корректируется через AdjustMaterialStock
 	query := `
 		UPDATE material
-		SET name = $1, type_ds = $2, storage = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4`
+		SET name = $1, sku = NULLIF($2, ''), category_id = $3, unit = $4, min_storage = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6`
 
 	logger.Debug("Обновление данных материала ID: %d", id)
-	result, err := r.db.Exec(query, material.Name, material.TypeDS, material.Storage, id)
+	result, err := tx.Exec(query, material.Name, material.SKU, material.CategoryID, material.Unit, material.MinStorage, id)
 	if err != nil {
 		logger.Error("Ошибка при обновлении материала: %v", err)
-		return fmt.Errorf("ошибка при обновлении материала: %w", err)
+		return materialWriteError("ошибка при обновлении материала", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -1046,6 +1195,11 @@ func (r *Repository) UpdateMaterial(id int, material models.Material) error {
 		return fmt.Errorf("материал с ID %d не найден", id)
 	}
 
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
 	logger.Info("Данные материала успешно обновлены")
 	return nil
 }
@@ -1111,7 +1265,7 @@ func (r *Repository) GetOrderStatistics() (models.Statistics, error) {
 func (r *Repository) getOrderServices(orderId int) ([]models.OrderService, error) {
 	var services []models.OrderService
 	query := `
-		SELECT service_id, service_description, wheel_position, price
+		SELECT service_id, service_description, wheel_position, price, discount, discount_reason
 		FROM order_services
 		WHERE order_id = $1`
 
@@ -1124,16 +1278,132 @@ func (r *Repository) getOrderServices(orderId int) ([]models.OrderService, error
 	return services, nil
 }
 
+// insertOrderMaterials сохраняет расходники заказа и списывает их со склада.
+// Материал, добавленный по норме расхода, которого не хватает на складе, пропускается с предупреждением в журнале:
+// нехватка расходника по норме не мешает записать заказ, а материалы, указанные вручную, списываются строго.
+func insertOrderMaterials(tx *sql.Tx, orderID int, materials []models.OrderMaterial) error {
+	if len(materials) == 0 {
+		return nil
+	}
+
+	// Материалы списываются со склада работника заказа. Если склад не назначен, работник с машиной
+	// расходует из выездной машины, остальные и заказы без работника - со склада по умолчанию.
+	var warehouseID int
+	err := tx.QueryRow(`
+		SELECT COALESCE(
+			w.warehouse_id,
+			CASE WHEN w.has_car THEN (SELECT id FROM warehouses WHERE is_mobile ORDER BY id LIMIT 1) END,
+			(SELECT id FROM warehouses WHERE is_default))
+		FROM orders o
+		LEFT JOIN workers w ON w.id = o.worker_id
+		WHERE o.id = $1`, orderID).Scan(&warehouseID)
+	if err != nil {
+		logger.Error("Ошибка при определении склада для заказа %d: %v", orderID, err)
+		return fmt.Errorf("ошибка при определении склада для списания материалов: %w", err)
+	}
+
+	for _, m := range materials {
+		if m.Quantity <= 0 {
+			continue
+		}
+		if m.FromNorm {
+			var stock float64
+			err := tx.QueryRow(`
+				SELECT quantity FROM material_stock
+				WHERE material_id = $1 AND warehouse_id = $2
+				FOR UPDATE`,
+				m.MaterialID, warehouseID).Scan(&stock)
+			if err != nil && err != sql.ErrNoRows {
+				logger.Error("Ошибка при проверке остатка материала ID %d: %v", m.MaterialID, err)
+				return fmt.Errorf("ошибка при проверке остатка материала: %w", err)
+			}
+			if stock < m.Quantity {
+				logger.Warning("Материала ID %d по норме для заказа %d недостаточно на складе ID %d (остаток %v, по норме %v), не списан",
+					m.MaterialID, orderID, warehouseID, stock, m.Quantity)
+				continue
+			}
+		}
+
+		err := postMaterialMovement(tx, models.MaterialMovement{
+			MaterialID:  m.MaterialID,
+			WarehouseID: warehouseID,
+			Delta:       -m.Quantity,
+			Reason:      models.MovementOrder,
+			OrderID:     &orderID,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO order_materials (order_id, material_id, quantity, from_norm, warehouse_id)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (order_id, material_id) DO UPDATE SET quantity = order_materials.quantity + EXCLUDED.quantity`,
+			orderID, m.MaterialID, m.Quantity, m.FromNorm, warehouseID)
+		if err != nil {
+			logger.Error("Ошибка при добавлении материала к заказу %d: %v", orderID, err)
+			return fmt.Errorf("ошибка при добавлении материала к заказу: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// returnOrderMaterials возвращает расходники заказа на склад и удаляет их из заказа
+func returnOrderMaterials(tx *sql.Tx, orderID int) error {
+	rows, err := tx.Query(`SELECT material_id, quantity, warehouse_id FROM order_materials WHERE order_id = $1`, orderID)
+	if err != nil {
+		logger.Error("Ошибка при получении материалов заказа %d: %v", orderID, err)
+		return fmt.Errorf("ошибка при получении материалов заказа: %w", err)
+	}
+
+	var materials []models.OrderMaterial
+	for rows.Next() {
+		var m models.OrderMaterial
+		if err := rows.Scan(&m.MaterialID, &m.Quantity, &m.WarehouseID); err != nil {
+			rows.Close()
+			return fmt.Errorf("ошибка при чтении материалов заказа: %w", err)
+		}
+		materials = append(materials, m)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("ошибка при чтении материалов заказа: %w", err)
+	}
+
+	for _, m := range materials {
+		err = postMaterialMovement(tx, models.MaterialMovement{
+			MaterialID:  m.MaterialID,
+			WarehouseID: m.WarehouseID,
+			Delta:       m.Quantity,
+			Reason:      models.MovementOrderReturn,
+			OrderID:     &orderID,
+		})
+		if err != nil {
+			logger.Error("Ошибка при возврате материалов заказа %d на склад: %v", orderID, err)
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM order_materials WHERE order_id = $1`, orderID)
+	if err != nil {
+		logger.Error("Ошибка при удалении материалов заказа %d: %v", orderID, err)
+		return fmt.Errorf("ошибка при удалении материалов заказа: %w", err)
+	}
+
+	return nil
+}
+
 // GetOrderMaterials получает материалы для конкретного заказа
 func (r *Repository) GetOrderMaterials(orderID int) ([]models.OrderMaterial, error) {
 	var orderMaterials []models.OrderMaterial
 	query := `
-		SELECT om.id, om.order_id, om.material_id, om.quantity, om.created_at,
-			   m.id as "material.id", m.name as "material.name", m.type_ds as "material.type_ds", 
-			   m.storage as "material.storage", m.created_at as "material.created_at", 
-			   m.updated_at as "material.updated_at"
+		SELECT om.id, om.order_id, om.material_id, om.quantity, om.from_norm, om.warehouse_id, om.created_at,
+			   m.id, m.name, COALESCE(m.sku, ''), m.category_id, c.name, m.unit,
+			   m.storage, m.created_at, m.updated_at
 		FROM order_materials om
-		JOIN materials m ON om.material_id = m.id
+		JOIN material m ON om.material_id = m.id
+		JOIN material_categories c ON c.id = m.category_id
 		WHERE om.order_id = $1
 		ORDER BY om.created_at`
 
@@ -1149,15 +1419,16 @@ func (r *Repository) GetOrderMaterials(orderID int) ([]models.OrderMaterial, err
 		var material models.Material
 		
 		err := rows.Scan(
-			&om.ID, &om.OrderID, &om.MaterialID, &om.Quantity, &om.CreatedAt,
-			&material.ID, &material.Name, &material.TypeDS, &material.Storage,
-			&material.CreatedAt, &material.UpdatedAt,
+			&om.ID, &om.OrderID, &om.MaterialID, &om.Quantity, &om.FromNorm, &om.WarehouseID, &om.CreatedAt,
+			&material.ID, &material.Name, &material.SKU, &material.CategoryID, &material.CategoryName, &material.Unit,
+			&material.Storage, &material.CreatedAt, &material.UpdatedAt,
 		)
 		if err != nil {
 			logger.Error("Ошибка при сканировании материала заказа: %v", err)
 			return nil, fmt.Errorf("ошибка при сканировании материала заказа: %w", err)
 		}
 		
+		material.TypeDS = material.CategoryID
 		om.Material = &material
 		orderMaterials = append(orderMaterials, om)
 	}
@@ -1173,7 +1444,7 @@ func (r *Repository) GetOrderMaterials(orderID int) ([]models.OrderMaterial, err
 
 func (r *Repository) GetClientTypes() ([]string, error) {
 	var types []string
-	query := `SELECT DISTINCT client_type FROM clients ORDER BY client_type`
+	query := `SELECT name FROM client_types ORDER BY name`
 
 	logger.Debug("Получение списка типов клиентов из БД")
 	err := r.db.Select(&types, query)
@@ -1186,38 +1457,25 @@ func (r *Repository) GetClientTypes() ([]string, error) {
 	return types, nil
 }
 
-func (r *Repository) GetWorkerByName(name string) (models.Worker, error) {
+func (r *Repository) GetWorkerByUserId(userId int) (models.Worker, error) {
 	var worker models.Worker
 	query := `
-		SELECT id, name, surname, email, phone, salary_schema, salary, has_car, created_at, updated_at
+		SELECT id, name, surname, email, phone, salary_schema, salary, has_car, warehouse_id, user_id, link_issue, created_at, updated_at
 		FROM workers
-		WHERE name = $1`
+		WHERE user_id = $1`
 
-	logger.Debug("Поиск работника по имени: %s", name)
-	logger.Debug("SQL запрос: %s", query)
-
-	err := r.db.Get(&worker, query, name)
+	logger.Debug("Поиск работника по user_id: %d", userId)
+	err := r.db.Get(&worker, query, userId)
 	if err != nil {
-		logger.Error("Ошибка при получении работника по имени: %v", err)
+		if err == sql.ErrNoRows {
+			return models.Worker{}, fmt.Errorf("учетная запись %d не привязана к работнику", userId)
+		}
+		logger.Error("Ошибка при получении работника по user_id: %v", err)
 		return models.Worker{}, fmt.Errorf("ошибка при получении работника: %w", err)
 	}
-
-	logger.Debug("Работник найден: %+v", worker)
 	return worker, nil
 }
 
-func (r *Repository) GetWorkerByUserId(userId int) (models.Worker, error) {
-	var worker models.Worker
-	query := `
-		SELECT w.id, w.name, w.surname, w.email, w.phone, w.salary_schema, w.salary, w.has_car, w.created_at, w.updated_at
-		FROM workers w
-		JOIN users u ON w.name = u.name
-		WHERE u.id = $1
-	`
-	err := r.db.Get(&worker, query, userId)
-	return worker, err
-}
-
 func (r *Repository) CarExists(number string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM cars WHERE number = $1)`
@@ -1233,72 +1491,8 @@ func (r *Repository) CarExists(number string) (bool, error) {
 	return exists, nil
 }
 
-func (r *Repository) AddPenalty(penalty models.PenaltyOrBonus) error {
-	query := `
-		INSERT INTO penalties (workerID, delta, description, order_id)
-		VALUES ($1, $2, $3, $4)
-	`
-	_, err := r.db.Exec(query, penalty.WorkerID, penalty.Amount, penalty.Desc, penalty.OrderID)
-	if err != nil {
-		logger.Error("Ошибка при добавлении штрафа: %v", err)
-		return fmt.Errorf("ошибка при добавлении штрафа: %w", err)
-	}
-
-	logger.Debug("Штраф успешно добавлен: %+v", penalty)
-	return nil
-}
-
-func (r *Repository) AddBonus(bonus models.PenaltyOrBonus) error {
-	query := `
-		INSERT INTO bonuses (workerID, delta, description, order_id)
-		VALUES ($1, $2, $3, $4)
-	`
-	_, err := r.db.Exec(query, bonus.WorkerID, bonus.Amount, bonus.Desc, bonus.OrderID)
-	if err != nil {
-		logger.Error("Ошибка при добавлении бонуса: %v", err)
-		return fmt.Errorf("ошибка при добавлении бонуса: %w", err)
-	}
-
-	logger.Debug("Бонус успешно добавлен: %+v", bonus)
-	return nil
-}
-
-func (r *Repository) GetPenalties(workerID int) ([]models.PenaltyOrBonus, error) {
-	var penalties []models.PenaltyOrBonus
-	query := `
-		SELECT id, workerID, delta, description, order_id, created_at
-		FROM penalties
-		WHERE workerID = $1`
-
-	logger.Debug("Получение списка штрафов работника: %d", workerID)
-	err := r.db.Select(&penalties, query, workerID)
-	if err != nil {
-		logger.Error("Ошибка при получении штрафов: %v", err)
-		return nil, fmt.Errorf("ошибка при получении штрафов: %w", err)
-	}
-
-	logger.Debug("Штрафы успешно получены: %d", len(penalties))
-	return penalties, nil
-}
-
-func (r *Repository) GetBonuses(workerID int) ([]models.PenaltyOrBonus, error) {
-	var bonuses []models.PenaltyOrBonus
-	query := `
-		SELECT id, workerID, delta, description, order_id, created_at
-		FROM bonuses
-		WHERE workerID = $1`
-
-	logger.Debug("Получение списка бонусов работника: %d", workerID)
-	err := r.db.Select(&bonuses, query, workerID)
-	if err != nil {
-		logger.Error("Ошибка при получении бонусов: %v", err)
-		return nil, fmt.Errorf("ошибка при получении бонусов: %w", err)
-	}
-
-	logger.Debug("Бонусы успешно получены: %d", len(bonuses))
-	return bonuses, nil
-}
-
+// GetWorkerStatistic считает выполненные заказы, бонусы и штрафы работника за период [start, end).
+// Заказы отбираются так же, как в расчете зарплаты (GetWorkerOutput), чтобы статистика совпадала с начислением.
 func (r *Repository) GetWorkerStatistic(workerID int, start, end time.Time) (models.WorkerStatistics, error) {
 	var stats models.WorkerStatistics
 
@@ -1310,29 +1504,24 @@ func (r *Repository) GetWorkerStatistic(workerID int, start, end time.Time) (mod
 			w.phone AS worker_phone,
 			w.salary_schema AS salary_schema,
 			
-			-- Общее количество заказов
-			(SELECT COUNT(*) FROM orders WHERE worker_id = w.id AND created_at BETWEEN $2 AND $3) AS total_orders,
+			-- Общее количество выполненных заказов
+			(SELECT COUNT(*) FROM orders WHERE worker_id = w.id AND status = $4 AND created_at >= $2 AND created_at < $3) AS total_orders,
 			
-			-- Общая выручка по заказам
-			(SELECT COALESCE(SUM(total_amount), 0) FROM orders WHERE worker_id = w.id AND created_at BETWEEN $2 AND $3) AS total_revenue,
+			-- Общая выручка по выполненным заказам
+			(SELECT COALESCE(SUM(total_amount), 0) FROM orders WHERE worker_id = w.id AND status = $4 AND created_at >= $2 AND created_at < $3) AS total_revenue,
 			
 			-- Общая сумма бонусов
-			(SELECT COALESCE(SUM(delta), 0) FROM bonuses WHERE workerID = w.id AND created_at BETWEEN $2 AND $3) AS total_bonus,
+			(SELECT COALESCE(SUM(delta), 0) FROM bonuses WHERE workerID = w.id AND revoked_at IS NULL AND created_at >= $2 AND created_at < $3) AS total_bonus,
 			
 			-- Общая сумма штрафов
-			(SELECT COALESCE(SUM(delta), 0) FROM penalties WHERE workerID = w.id AND created_at BETWEEN $2 AND $3) AS total_penalties,
-			
-			-- Финальная зарплата
-			(w.salary + 
-			 COALESCE((SELECT SUM(delta) FROM bonuses WHERE workerID = w.id AND created_at BETWEEN $2 AND $3), 0) -
-			 COALESCE((SELECT SUM(delta) FROM penalties WHERE workerID = w.id AND created_at BETWEEN $2 AND $3), 0)
-			) AS total_salary
+			(SELECT COALESCE(SUM(delta), 0) FROM penalties WHERE workerID = w.id AND revoked_at IS NULL AND created_at >= $2 AND created_at < $3) AS total_penalties
 
+			-- Зарплата считается в сервисе по схемам оплаты работника
 		FROM workers w
 		WHERE w.id = $1
 	`
 
-	err := r.db.Get(&stats, query, workerID, start, end)
+	err := r.db.Get(&stats, query, workerID, start, end, models.OrderStatusCompleted)
 	if err != nil {
 		return stats, fmt.Errorf("failed to get worker statistics: %w", err)
 	}
@@ -1342,10 +1531,10 @@ func (r *Repository) GetWorkerStatistic(workerID int, start, end time.Time) (mod
 
 func (r *Repository) OnlineDate(date *models.OnlineDate) error {
 	query := `
-		INSERT INTO online_date (date, name, phone, car_number, client_desc, manager_desc, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW()) 
+		INSERT INTO online_date (date, name, phone, car_number, client_id, client_desc, manager_desc, created_at, updated_at) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) 
 		RETURNING id`
-	err := r.db.QueryRow(query, date.Date, date.Name, date.Phone, date.CarNumber, date.ClientDesc, date.ManagerDesc).Scan(&date.ID)
+	err := r.db.QueryRow(query, date.Date, date.Name, date.Phone, date.CarNumber, date.ClientID, date.ClientDesc, date.ManagerDesc).Scan(&date.ID)
 	if err != nil {
 		logger.Error("Ошибка при добавлении онлайн записи: %v", err)
 		return err
@@ -1359,7 +1548,7 @@ func (r *Repository) GetOnlineDate() ([]models.OnlineDate, error) {
 	query := `SELECT * FROM online_date ORDER BY date ASC`
 	err := r.db.Select(&dates, query)
 	if err != nil {
-		logger.Error("Ошибка при получении даты онлайн:", err)
+		logger.Error("Ошибка при получении даты онлайн: %v", err)
 		return nil, err
 	}
 	return dates, nil
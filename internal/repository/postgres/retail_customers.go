@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// Поля розничного покупателя вместе с машинами и счетчиком визитов, ожидает алиас rc
+const retailCustomerColumns = `rc.id, rc.name, COALESCE(rc.phone, '') AS phone, COALESCE(rc.card_number, '') AS card_number,
+		COALESCE((SELECT array_agg(rcc.vehicle_number ORDER BY rcc.vehicle_number) FROM retail_customer_cars rcc
+			WHERE rcc.customer_id = rc.id), ARRAY[]::varchar[]) AS cars,
+		(SELECT COUNT(*) FROM orders o WHERE o.retail_customer_id = rc.id) AS visits,
+		(SELECT MAX(o.created_at) FROM orders o WHERE o.retail_customer_id = rc.id) AS last_visit,
+		rc.created_at, rc.updated_at`
+
+// retailCustomerWriteError переводит нарушения уникальности телефона, карты и машины в понятные сообщения
+func retailCustomerWriteError(msg string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		case "retail_customers_phone_key":
+			return fmt.Errorf("покупатель с таким телефоном уже есть")
+		case "retail_customers_card_number_key":
+			return fmt.Errorf("карта с таким номером уже выдана другому покупателю")
+		case "retail_customer_cars_vehicle_number_key":
+			return fmt.Errorf("машина уже закреплена за другим покупателем")
+		}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// orderDiscount считает сумму скидок по услугам заказа
+func orderDiscount(services []models.OrderService) float64 {
+	var total float64
+	for _, service := range services {
+		total += service.Discount
+	}
+	return total
+}
+
+// linkRetailCustomerCar закрепляет машину за покупателем, если она еще ни за кем не числится
+func linkRetailCustomerCar(tx *sql.Tx, customerID *int, vehicleNumber string) error {
+	if customerID == nil || vehicleNumber == "" {
+		return nil
+	}
+	_, err := tx.Exec(`
+		INSERT INTO retail_customer_cars (customer_id, vehicle_number)
+		VALUES ($1, $2)
+		ON CONFLICT (vehicle_number) DO NOTHING`, *customerID, vehicleNumber)
+	if err != nil {
+		logger.Error("Ошибка при закреплении машины %s за покупателем %d: %v", vehicleNumber, *customerID, err)
+		return fmt.Errorf("ошибка при закреплении машины за покупателем: %w", err)
+	}
+	return nil
+}
+
+// replaceRetailCustomerCars заменяет список машин покупателя
+func replaceRetailCustomerCars(tx *sql.Tx, customerID int, cars []string) error {
+	if _, err := tx.Exec(`DELETE FROM retail_customer_cars WHERE customer_id = $1`, customerID); err != nil {
+		return fmt.Errorf("ошибка при удалении машин покупателя: %w", err)
+	}
+	for _, number := range cars {
+		_, err := tx.Exec(`INSERT INTO retail_customer_cars (customer_id, vehicle_number) VALUES ($1, $2)`, customerID, number)
+		if err != nil {
+			logger.Error("Ошибка при добавлении машины %s покупателю %d: %v", number, customerID, err)
+			return retailCustomerWriteError("ошибка при добавлении машины покупателю", err)
+		}
+	}
+	return nil
+}
+
+// GetRetailCustomers возвращает покупателей. search ищет по имени и карте, phone - по телефону в E.164,
+// number - по части номера машины. Пустой search возвращает всех.
+func (r *Repository) GetRetailCustomers(search, phone, number string) ([]models.RetailCustomer, error) {
+	var customers []models.RetailCustomer
+	query := `
+		SELECT ` + retailCustomerColumns + `
+		FROM retail_customers rc
+		WHERE $1 = ''
+		   OR rc.name ILIKE '%' || $1 || '%'
+		   OR rc.card_number = $1
+		   OR rc.phone = $2
+		   OR ($3 <> '' AND EXISTS (SELECT 1 FROM retail_customer_cars rcc
+				WHERE rcc.customer_id = rc.id AND rcc.vehicle_number LIKE '%' || $3 || '%'))
+		ORDER BY rc.name, rc.id`
+
+	logger.Debug("Получение розничных покупателей, поиск: '%s'", search)
+	err := r.db.Select(&customers, query, search, phone, number)
+	if err != nil {
+		logger.Error("Ошибка при получении розничных покупателей: %v", err)
+		return nil, fmt.Errorf("ошибка при получении розничных покупателей: %w", err)
+	}
+
+	return customers, nil
+}
+
+func (r *Repository) GetRetailCustomerById(id int) (models.RetailCustomer, error) {
+	var customer models.RetailCustomer
+	query := `SELECT ` + retailCustomerColumns + ` FROM retail_customers rc WHERE rc.id = $1`
+
+	logger.Debug("Получение розничного покупателя ID: %d", id)
+	err := r.db.Get(&customer, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RetailCustomer{}, fmt.Errorf("покупатель с ID %d не найден", id)
+		}
+		logger.Error("Ошибка при получении розничного покупателя: %v", err)
+		return models.RetailCustomer{}, fmt.Errorf("ошибка при получении розничного покупателя: %w", err)
+	}
+
+	return customer, nil
+}
+
+// FindRetailCustomer ищет покупателя по телефону, карте или номеру машины именно в этом порядке.
+// Пустые значения пропускаются, 0 - покупатель не найден.
+func (r *Repository) FindRetailCustomer(phone, card, vehicleNumber string) (int, error) {
+	lookups := []struct {
+		value string
+		query string
+	}{
+		{phone, `SELECT id FROM retail_customers WHERE phone = $1`},
+		{card, `SELECT id FROM retail_customers WHERE card_number = $1`},
+		{vehicleNumber, `SELECT customer_id FROM retail_customer_cars WHERE vehicle_number = $1`},
+	}
+
+	for _, lookup := range lookups {
+		if lookup.value == "" {
+			continue
+		}
+		var id int
+		err := r.db.Get(&id, lookup.query, lookup.value)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			logger.Error("Ошибка при поиске розничного покупателя: %v", err)
+			return 0, fmt.Errorf("ошибка при поиске розничного покупателя: %w", err)
+		}
+		return id, nil
+	}
+
+	return 0, nil
+}
+
+func (r *Repository) CreateRetailCustomer(customer models.RetailCustomer) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertRetailCustomer(tx, customer)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Розничный покупатель успешно создан с ID: %d", id)
+	return id, nil
+}
+
+// insertRetailCustomer заводит покупателя с машинами в транзакции tx
+func insertRetailCustomer(tx *sql.Tx, customer models.RetailCustomer) (int, error) {
+	var id int
+	logger.Debug("Создание розничного покупателя: %s %s", customer.Name, customer.Phone)
+	err := tx.QueryRow(`
+		INSERT INTO retail_customers (name, phone, card_number)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		RETURNING id`, customer.Name, customer.Phone, customer.CardNumber).Scan(&id)
+	if err != nil {
+		logger.Error("Ошибка при создании розничного покупателя: %v", err)
+		return 0, retailCustomerWriteError("ошибка при создании розничного покупателя", err)
+	}
+
+	if err = replaceRetailCustomerCars(tx, id, customer.Cars); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *Repository) UpdateRetailCustomer(id int, customer models.RetailCustomer) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	logger.Debug("Обновление розничного покупателя ID: %d", id)
+	result, err := tx.Exec(`
+		UPDATE retail_customers
+		SET name = $1, phone = NULLIF($2, ''), card_number = NULLIF($3, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`, customer.Name, customer.Phone, customer.CardNumber, id)
+	if err != nil {
+		logger.Error("Ошибка при обновлении розничного покупателя: %v", err)
+		return retailCustomerWriteError("ошибка при обновлении розничного покупателя", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("покупатель с ID %d не найден", id)
+	}
+
+	if err = replaceRetailCustomerCars(tx, id, customer.Cars); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Розничный покупатель успешно обновлен")
+	return nil
+}
+
+// DeleteRetailCustomer удаляет покупателя, его заказы остаются без привязки к покупателю
+func (r *Repository) DeleteRetailCustomer(id int) error {
+	logger.Debug("Удаление розничного покупателя ID: %d", id)
+	result, err := r.db.Exec(`DELETE FROM retail_customers WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Ошибка при удалении розничного покупателя: %v", err)
+		return fmt.Errorf("ошибка при удалении розничного покупателя: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("покупатель с ID %d не найден", id)
+	}
+
+	logger.Info("Розничный покупатель успешно удален")
+	return nil
+}
+
+// GetRetailCustomerOrders возвращает заказы покупателя, новые первыми
+func (r *Repository) GetRetailCustomerOrders(customerID int) ([]models.Order, error) {
+	var orders []models.Order
+	query := `
+		SELECT id, status, worker_id, client_id, vehicle_number, payment_method, total_amount,
+			   retail_customer_id, discount_amount, rework_of_id, created_at, updated_at
+		FROM orders
+		WHERE retail_customer_id = $1
+		ORDER BY created_at DESC`
+
+	logger.Debug("Получение заказов розничного покупателя ID: %d", customerID)
+	err := r.db.Select(&orders, query, customerID)
+	if err != nil {
+		logger.Error("Ошибка при получении заказов покупателя: %v", err)
+		return nil, fmt.Errorf("ошибка при получении заказов покупателя: %w", err)
+	}
+
+	for i := range orders {
+		services, err := r.getOrderServices(orders[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		orders[i].Services = services
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetSalarySchemes возвращает схемы оплаты работника со ступенями и ставками, по дате начала действия
+func (r *Repository) GetSalarySchemes(workerID int) ([]models.WorkerSalaryScheme, error) {
+	var schemes []models.WorkerSalaryScheme
+	query := `
+		SELECT id, worker_id, scheme_type, rate, percent, effective_from, created_at
+		FROM worker_salary_schemes
+		WHERE worker_id = $1
+		ORDER BY effective_from`
+
+	logger.Debug("Получение схем оплаты работника ID: %d", workerID)
+	err := r.db.Select(&schemes, query, workerID)
+	if err != nil {
+		logger.Error("Ошибка при получении схем оплаты работника: %v", err)
+		return nil, fmt.Errorf("ошибка при получении схем оплаты работника: %w", err)
+	}
+
+	for i := range schemes {
+		err = r.db.Select(&schemes[i].Tiers, `
+			SELECT revenue_from, percent FROM salary_scheme_tiers WHERE scheme_id = $1 ORDER BY revenue_from`, schemes[i].ID)
+		if err != nil {
+			logger.Error("Ошибка при получении ступеней схемы оплаты %d: %v", schemes[i].ID, err)
+			return nil, fmt.Errorf("ошибка при получении ступеней схемы оплаты: %w", err)
+		}
+		err = r.db.Select(&schemes[i].Rates, `
+			SELECT service_name, rate FROM salary_scheme_rates WHERE scheme_id = $1 ORDER BY service_name`, schemes[i].ID)
+		if err != nil {
+			logger.Error("Ошибка при получении ставок схемы оплаты %d: %v", schemes[i].ID, err)
+			return nil, fmt.Errorf("ошибка при получении ставок схемы оплаты: %w", err)
+		}
+	}
+
+	return schemes, nil
+}
+
+func (r *Repository) CreateSalaryScheme(scheme models.WorkerSalaryScheme) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	logger.Debug("Создание схемы оплаты '%s' работника ID: %d", scheme.Type, scheme.WorkerID)
+	err = tx.QueryRow(`
+		INSERT INTO worker_salary_schemes (worker_id, scheme_type, rate, percent, effective_from)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		scheme.WorkerID, scheme.Type, scheme.Rate, scheme.Percent, scheme.EffectiveFrom).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code {
+			case "23505":
+				return 0, fmt.Errorf("схема оплаты с %s уже задана, удалите ее или выберите другую дату",
+					scheme.EffectiveFrom.Format("02.01.2006"))
+			case "23503":
+				return 0, fmt.Errorf("работник с ID %d не найден", scheme.WorkerID)
+			}
+		}
+		logger.Error("Ошибка при создании схемы оплаты: %v", err)
+		return 0, fmt.Errorf("ошибка при создании схемы оплаты: %w", err)
+	}
+
+	for _, tier := range scheme.Tiers {
+		_, err = tx.Exec(`INSERT INTO salary_scheme_tiers (scheme_id, revenue_from, percent) VALUES ($1, $2, $3)`,
+			id, tier.From, tier.Percent)
+		if err != nil {
+			logger.Error("Ошибка при добавлении ступени схемы оплаты: %v", err)
+			return 0, fmt.Errorf("ошибка при добавлении ступени схемы оплаты: %w", err)
+		}
+	}
+	for _, rate := range scheme.Rates {
+		_, err = tx.Exec(`INSERT INTO salary_scheme_rates (scheme_id, service_name, rate) VALUES ($1, $2, $3)`,
+			id, rate.ServiceName, rate.Rate)
+		if err != nil {
+			logger.Error("Ошибка при добавлении сдельной ставки: %v", err)
+			return 0, fmt.Errorf("ошибка при добавлении сдельной ставки: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Схема оплаты успешно создана с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) DeleteSalaryScheme(workerID, id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	logger.Debug("Удаление схемы оплаты ID: %d", id)
+	var effectiveFrom time.Time
+	err = tx.QueryRow(`DELETE FROM worker_salary_schemes WHERE id = $1 AND worker_id = $2 RETURNING effective_from`,
+		id, workerID).Scan(&effectiveFrom)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("схема оплаты с ID %d не найдена у работника", id)
+		}
+		logger.Error("Ошибка при удалении схемы оплаты: %v", err)
+		return fmt.Errorf("ошибка при удалении схемы оплаты: %w", err)
+	}
+
+	// Закрытые периоды, посчитанные по удаленной схеме, попадут в перерасчет
+	if err = markLockedPayrollChanged(tx, effectiveFrom, time.Now()); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Схема оплаты успешно удалена")
+	return nil
+}
+
+// GetWorkerOutput возвращает выполненные заказы работника за период [start, end) с названиями услуг для сдельной оплаты
+func (r *Repository) GetWorkerOutput(workerID int, start, end time.Time) ([]models.WorkerOrderOutput, error) {
+	var output []models.WorkerOrderOutput
+	query := `
+		SELECT o.id, o.created_at, o.total_amount,
+			   COALESCE(array_agg(COALESCE(s.name, os.service_description, '')) FILTER (WHERE os.order_id IS NOT NULL),
+			            ARRAY[]::text[]) AS services
+		FROM orders o
+		LEFT JOIN order_services os ON os.order_id = o.id
+		LEFT JOIN services s ON s.id = os.service_id
+		WHERE o.worker_id = $1 AND o.status = $4 AND o.created_at >= $2 AND o.created_at < $3
+		GROUP BY o.id
+		ORDER BY o.created_at`
+
+	logger.Debug("Получение выработки работника ID: %d с %v по %v", workerID, start, end)
+	err := r.db.Select(&output, query, workerID, start, end, models.OrderStatusCompleted)
+	if err != nil {
+		logger.Error("Ошибка при получении выработки работника: %v", err)
+		return nil, fmt.Errorf("ошибка при получении выработки работника: %w", err)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,360 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const shiftTemplateColumns = `
+	id, name, to_char(start_time, 'HH24:MI') AS start_time, to_char(end_time, 'HH24:MI') AS end_time, active, created_at`
+
+func (r *Repository) GetShiftTemplates(activeOnly bool) ([]models.ShiftTemplate, error) {
+	templates := []models.ShiftTemplate{}
+	query := `
+		SELECT ` + shiftTemplateColumns + `
+		FROM shift_templates
+		WHERE (NOT $1 OR active)
+		ORDER BY start_time, name`
+
+	logger.Debug("Получение шаблонов смен")
+	if err := r.db.Select(&templates, query, activeOnly); err != nil {
+		logger.Error("Ошибка при получении шаблонов смен: %v", err)
+		return nil, fmt.Errorf("ошибка при получении шаблонов смен: %w", err)
+	}
+	return templates, nil
+}
+
+func (r *Repository) GetShiftTemplateById(id int) (models.ShiftTemplate, error) {
+	var template models.ShiftTemplate
+	query := `SELECT ` + shiftTemplateColumns + ` FROM shift_templates WHERE id = $1`
+
+	if err := r.db.Get(&template, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return template, fmt.Errorf("шаблон смены с ID %d не найден", id)
+		}
+		logger.Error("Ошибка при получении шаблона смены: %v", err)
+		return template, fmt.Errorf("ошибка при получении шаблона смены: %w", err)
+	}
+	return template, nil
+}
+
+func (r *Repository) CreateShiftTemplate(template models.ShiftTemplate) (int, error) {
+	var id int
+	logger.Debug("Создание шаблона смены '%s'", template.Name)
+	err := r.db.QueryRow(`
+		INSERT INTO shift_templates (name, start_time, end_time, active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		template.Name, template.StartTime, template.EndTime, template.Active).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("шаблон смены '%s' уже существует", template.Name)
+		}
+		logger.Error("Ошибка при создании шаблона смены: %v", err)
+		return 0, fmt.Errorf("ошибка при создании шаблона смены: %w", err)
+	}
+
+	logger.Info("Шаблон смены успешно создан с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) UpdateShiftTemplate(id int, template models.ShiftTemplate) error {
+	logger.Debug("Обновление шаблона смены ID: %d", id)
+	result, err := r.db.Exec(`
+		UPDATE shift_templates SET name = $1, start_time = $2, end_time = $3, active = $4
+		WHERE id = $5`,
+		template.Name, template.StartTime, template.EndTime, template.Active, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("шаблон смены '%s' уже существует", template.Name)
+		}
+		logger.Error("Ошибка при обновлении шаблона смены: %v", err)
+		return fmt.Errorf("ошибка при обновлении шаблона смены: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("шаблон смены с ID %d не найден", id)
+	}
+
+	logger.Info("Шаблон смены успешно обновлен")
+	return nil
+}
+
+// DeleteShiftTemplate удаляет шаблон, смены в графике сохраняют свое время
+func (r *Repository) DeleteShiftTemplate(id int) error {
+	logger.Debug("Удаление шаблона смены ID: %d", id)
+	result, err := r.db.Exec(`DELETE FROM shift_templates WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Ошибка при удалении шаблона смены: %v", err)
+		return fmt.Errorf("ошибка при удалении шаблона смены: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("шаблон смены с ID %d не найден", id)
+	}
+
+	logger.Info("Шаблон смены успешно удален")
+	return nil
+}
+
+// GetShiftAssignments возвращает график с рабочими днями в [start, end), workerID 0 - по всем работникам
+func (r *Repository) GetShiftAssignments(workerID int, start, end time.Time) ([]models.ShiftAssignment, error) {
+	assignments := []models.ShiftAssignment{}
+	query := `
+		SELECT a.id, a.worker_id, w.name || ' ' || w.surname AS worker_name, a.template_id,
+			   COALESCE(t.name, '') AS template_name, a.work_date, a.starts_at, a.ends_at, a.created_by, a.created_at
+		FROM shift_assignments a
+		JOIN workers w ON w.id = a.worker_id
+		LEFT JOIN shift_templates t ON t.id = a.template_id
+		WHERE ($1 = 0 OR a.worker_id = $1) AND a.work_date >= $2 AND a.work_date < $3
+		ORDER BY a.work_date, a.starts_at, w.name, w.surname`
+
+	logger.Debug("Получение графика смен работника ID:%d с %v по %v", workerID, start, end)
+	if err := r.db.Select(&assignments, query, workerID, start, end); err != nil {
+		logger.Error("Ошибка при получении графика смен: %v", err)
+		return nil, fmt.Errorf("ошибка при получении графика смен: %w", err)
+	}
+	return assignments, nil
+}
+
+// CreateShiftAssignments ставит смены в график одной транзакцией, смены работника не пересекаются
+func (r *Repository) CreateShiftAssignments(assignments []models.ShiftAssignment) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, 0, len(assignments))
+	for _, a := range assignments {
+		// Блокируем работника, чтобы параллельное назначение не создало пересекающиеся смены
+		var name string
+		err = tx.QueryRow(`SELECT name || ' ' || surname FROM workers WHERE id = $1 FOR UPDATE`, a.WorkerID).Scan(&name)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("работник с ID %d не найден", a.WorkerID)
+			}
+			return nil, fmt.Errorf("ошибка при получении работника: %w", err)
+		}
+
+		var busy bool
+		err = tx.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM shift_assignments
+				WHERE worker_id = $1 AND starts_at < $3 AND ends_at > $2
+			)`, a.WorkerID, a.StartsAt, a.EndsAt).Scan(&busy)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при проверке графика смен: %w", err)
+		}
+		if busy {
+			return nil, fmt.Errorf("работник %s уже стоит в графике на это время", name)
+		}
+
+		var id int
+		err = tx.QueryRow(`
+			INSERT INTO shift_assignments (worker_id, template_id, work_date, starts_at, ends_at, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+			a.WorkerID, a.TemplateID, a.WorkDate, a.StartsAt, a.EndsAt, a.CreatedBy).Scan(&id)
+		if err != nil {
+			logger.Error("Ошибка при добавлении смены в график: %v", err)
+			return nil, fmt.Errorf("ошибка при добавлении смены в график: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("В график добавлено смен: %d", len(ids))
+	return ids, nil
+}
+
+func (r *Repository) DeleteShiftAssignment(id int) error {
+	logger.Debug("Удаление смены из графика ID: %d", id)
+	result, err := r.db.Exec(`DELETE FROM shift_assignments WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Ошибка при удалении смены из графика: %v", err)
+		return fmt.Errorf("ошибка при удалении смены из графика: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("смена с ID %d не найдена в графике", id)
+	}
+
+	logger.Info("Смена успешно удалена из графика")
+	return nil
+}
+
+const timeEntryColumns = `
+	e.id, e.worker_id, w.name || ' ' || w.surname AS worker_name, e.clock_in, e.clock_out, e.auto_closed, e.created_at`
+
+// GetTimeEntries возвращает отметки с приходом в [start, end), workerID 0 - по всем работникам
+func (r *Repository) GetTimeEntries(workerID int, start, end time.Time) ([]models.TimeEntry, error) {
+	entries := []models.TimeEntry{}
+	query := `
+		SELECT ` + timeEntryColumns + `
+		FROM time_entries e
+		JOIN workers w ON w.id = e.worker_id
+		WHERE ($1 = 0 OR e.worker_id = $1) AND e.clock_in >= $2 AND e.clock_in < $3
+		ORDER BY e.clock_in`
+
+	logger.Debug("Получение отметок табеля работника ID:%d с %v по %v", workerID, start, end)
+	if err := r.db.Select(&entries, query, workerID, start, end); err != nil {
+		logger.Error("Ошибка при получении отметок табеля: %v", err)
+		return nil, fmt.Errorf("ошибка при получении отметок табеля: %w", err)
+	}
+	return entries, nil
+}
+
+// GetOpenTimeEntry возвращает отметку прихода без ухода, nil - работник не на смене
+func (r *Repository) GetOpenTimeEntry(workerID int) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	query := `
+		SELECT ` + timeEntryColumns + `
+		FROM time_entries e
+		JOIN workers w ON w.id = e.worker_id
+		WHERE e.worker_id = $1 AND e.clock_out IS NULL`
+
+	if err := r.db.Get(&entry, query, workerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		logger.Error("Ошибка при получении открытой отметки табеля: %v", err)
+		return nil, fmt.Errorf("ошибка при получении открытой отметки табеля: %w", err)
+	}
+	return &entry, nil
+}
+
+// CloseStaleTimeEntries закрывает отметки без ухода с приходом раньше before: уход ставится равным приходу,
+// чтобы забытая отметка не считалась сменой и не копила часы, пока менеджер ее не исправит
+func (r *Repository) CloseStaleTimeEntries(before time.Time) (int, error) {
+	result, err := r.db.Exec(`
+		UPDATE time_entries SET clock_out = clock_in, auto_closed = true, updated_at = NOW()
+		WHERE clock_out IS NULL AND clock_in < $1`, before)
+	if err != nil {
+		logger.Error("Ошибка при закрытии забытых отметок табеля: %v", err)
+		return 0, fmt.Errorf("ошибка при закрытии забытых отметок табеля: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected > 0 {
+		logger.Warning("Автоматически закрыто забытых отметок табеля: %d", rowsAffected)
+	}
+	return int(rowsAffected), nil
+}
+
+func (r *Repository) ClockIn(workerID int, at time.Time) (int, error) {
+	var id int
+	logger.Debug("Отметка прихода работника ID: %d", workerID)
+	err := r.db.QueryRow(`
+		INSERT INTO time_entries (worker_id, clock_in) VALUES ($1, $2) RETURNING id`,
+		workerID, at).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("приход уже отмечен, сначала отметьте уход")
+		}
+		logger.Error("Ошибка при отметке прихода: %v", err)
+		return 0, fmt.Errorf("ошибка при отметке прихода: %w", err)
+	}
+
+	logger.Info("Работник ID:%d отметил приход", workerID)
+	return id, nil
+}
+
+func (r *Repository) ClockOut(workerID int, at time.Time) (int, error) {
+	var id int
+	logger.Debug("Отметка ухода работника ID: %d", workerID)
+	err := r.db.QueryRow(`
+		UPDATE time_entries SET clock_out = GREATEST($2, clock_in + INTERVAL '1 second')
+		WHERE worker_id = $1 AND clock_out IS NULL
+		RETURNING id`,
+		workerID, at).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("приход не отмечен")
+		}
+		logger.Error("Ошибка при отметке ухода: %v", err)
+		return 0, fmt.Errorf("ошибка при отметке ухода: %w", err)
+	}
+
+	logger.Info("Работник ID:%d отметил уход", workerID)
+	return id, nil
+}
+
+// UpdateTimeEntry исправляет время прихода и ухода, например если работник забыл отметить уход
+func (r *Repository) UpdateTimeEntry(id int, entry models.TimeEntry) error {
+	logger.Debug("Исправление отметки табеля ID: %d", id)
+	result, err := r.db.Exec(`UPDATE time_entries SET clock_in = $1, clock_out = $2, auto_closed = false, updated_at = NOW() WHERE id = $3`,
+		entry.ClockIn, entry.ClockOut, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("у работника уже есть отметка без ухода")
+		}
+		logger.Error("Ошибка при исправлении отметки табеля: %v", err)
+		return fmt.Errorf("ошибка при исправлении отметки табеля: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("отметка табеля с ID %d не найдена", id)
+	}
+
+	logger.Info("Отметка табеля успешно исправлена")
+	return nil
+}
+
+// IsWorkerOnShift проверяет, что в момент at работник отметил приход или стоит в графике.
+// Отметка без ухода старше OpenTimeEntryLimit не считается. rostered - есть ли у работника смена
+// в графике на день at или на момент at.
+func (r *Repository) IsWorkerOnShift(workerID int, at time.Time) (onShift, rostered bool, err error) {
+	err = r.db.QueryRow(`
+		SELECT
+			EXISTS (
+				SELECT 1 FROM time_entries
+				WHERE worker_id = $1 AND clock_in <= $2 AND NOT auto_closed
+				  AND (clock_out >= $2 OR (clock_out IS NULL AND clock_in > $4))
+			) OR EXISTS (
+				SELECT 1 FROM shift_assignments
+				WHERE worker_id = $1 AND starts_at <= $2 AND ends_at > $2
+			),
+			EXISTS (
+				SELECT 1 FROM shift_assignments
+				WHERE worker_id = $1 AND (work_date = $3::date OR (starts_at <= $2 AND ends_at > $2))
+			)`, workerID, at, at.Format("2006-01-02"),
+		at.Add(-models.OpenTimeEntryLimit)).Scan(&onShift, &rostered)
+	if err != nil {
+		logger.Error("Ошибка при проверке смены работника: %v", err)
+		return false, false, fmt.Errorf("ошибка при проверке смены работника: %w", err)
+	}
+	return onShift, rostered, nil
+}
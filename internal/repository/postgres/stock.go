@@ -0,0 +1,399 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// nullableID превращает нулевой ID в NULL для необязательных ссылок
+func nullableID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// defaultWarehouseID возвращает склад по умолчанию
+func defaultWarehouseID(tx *sql.Tx) (int, error) {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM warehouses WHERE is_default`).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("не задан склад по умолчанию")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении склада по умолчанию: %w", err)
+	}
+	return id, nil
+}
+
+// postMaterialMovement изменяет остаток материала на складе и общий остаток, затем записывает движение в журнал.
+// Остаток на складе не может стать отрицательным. Без указания склада используется склад по умолчанию.
+func postMaterialMovement(tx *sql.Tx, m models.MaterialMovement) error {
+	if m.WarehouseID == 0 {
+		id, err := defaultWarehouseID(tx)
+		if err != nil {
+			return err
+		}
+		m.WarehouseID = id
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO material_stock (material_id, warehouse_id, quantity)
+		VALUES ($1, $2, 0)
+		ON CONFLICT (material_id, warehouse_id) DO NOTHING`,
+		m.MaterialID, m.WarehouseID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("материал с ID %d или склад с ID %d не найден", m.MaterialID, m.WarehouseID)
+		}
+		logger.Error("Ошибка при изменении остатка материала ID %d: %v", m.MaterialID, err)
+		return fmt.Errorf("ошибка при изменении остатка материала: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE material_stock
+		SET quantity = quantity + $1
+		WHERE material_id = $2 AND warehouse_id = $3 AND quantity + $1 >= 0`,
+		m.Delta, m.MaterialID, m.WarehouseID)
+	if err != nil {
+		logger.Error("Ошибка при изменении остатка материала ID %d на складе ID %d: %v", m.MaterialID, m.WarehouseID, err)
+		return fmt.Errorf("ошибка при изменении остатка материала: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("материала с ID %d недостаточно на складе ID %d (требуется %v)", m.MaterialID, m.WarehouseID, -m.Delta)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE material
+		SET storage = storage + $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`,
+		m.Delta, m.MaterialID)
+	if err != nil {
+		logger.Error("Ошибка при изменении общего остатка материала ID %d: %v", m.MaterialID, err)
+		return fmt.Errorf("ошибка при изменении остатка материала: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO material_movements (material_id, warehouse_id, delta, reason, order_id, stock_take_id, transfer_id, user_id, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		m.MaterialID, m.WarehouseID, m.Delta, m.Reason, m.OrderID, m.StockTakeID, m.TransferID, m.UserID, m.Description)
+	if err != nil {
+		logger.Error("Ошибка при записи движения материала ID %d: %v", m.MaterialID, err)
+		return fmt.Errorf("ошибка при записи движения материала: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetMaterialMovements(materialID int) ([]models.MaterialMovement, error) {
+	var movements []models.MaterialMovement
+	query := `
+		SELECT mm.id, mm.material_id, mm.warehouse_id, w.name AS warehouse_name, mm.delta, mm.reason,
+			   mm.order_id, mm.stock_take_id, mm.transfer_id, mm.user_id, COALESCE(mm.description, '') AS description, mm.created_at
+		FROM material_movements mm
+		JOIN warehouses w ON w.id = mm.warehouse_id
+		WHERE mm.material_id = $1
+		ORDER BY mm.created_at DESC, mm.id DESC`
+
+	logger.Debug("Получение журнала движения материала ID: %d", materialID)
+	err := r.db.Select(&movements, query, materialID)
+	if err != nil {
+		logger.Error("Ошибка при получении журнала движения материала: %v", err)
+		return nil, fmt.Errorf("ошибка при получении журнала движения материала: %w", err)
+	}
+
+	return movements, nil
+}
+
+func (r *Repository) CreateStockTake(stockTake models.StockTake) (int, error) {
+	var id int
+	query := `
+		INSERT INTO stock_takes (status, warehouse_id, description, opened_by)
+		VALUES ($1, COALESCE(NULLIF($2, 0), (SELECT id FROM warehouses WHERE is_default)), $3, $4)
+		RETURNING id`
+
+	logger.Debug("Открытие инвентаризации склада ID: %d", stockTake.WarehouseID)
+	err := r.db.QueryRow(query, models.StockTakeStatusOpen, stockTake.WarehouseID, stockTake.Description, stockTake.OpenedBy).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("по этому складу уже есть открытая инвентаризация, сначала утвердите её")
+		}
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, fmt.Errorf("склад с ID %d не найден", stockTake.WarehouseID)
+		}
+		logger.Error("Ошибка при открытии инвентаризации: %v", err)
+		return 0, fmt.Errorf("ошибка при открытии инвентаризации: %w", err)
+	}
+
+	logger.Info("Инвентаризация успешно открыта с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) GetAllStockTakes() ([]models.StockTake, error) {
+	var stockTakes []models.StockTake
+	query := `
+		SELECT st.id, st.status, st.warehouse_id, w.name AS warehouse_name, COALESCE(st.description, '') AS description,
+			   st.opened_by, st.approved_by, st.created_at, st.approved_at
+		FROM stock_takes st
+		JOIN warehouses w ON w.id = st.warehouse_id
+		ORDER BY st.created_at DESC`
+
+	logger.Debug("Получение списка инвентаризаций")
+	err := r.db.Select(&stockTakes, query)
+	if err != nil {
+		logger.Error("Ошибка при получении списка инвентаризаций: %v", err)
+		return nil, fmt.Errorf("ошибка при получении списка инвентаризаций: %w", err)
+	}
+
+	return stockTakes, nil
+}
+
+// GetStockTakeById возвращает инвентаризацию с проходами пересчета и расхождениями.
+// Для открытой инвентаризации расхождения считаются по текущим остаткам, для утвержденной берутся из итога.
+func (r *Repository) GetStockTakeById(id int) (models.StockTake, error) {
+	var stockTake models.StockTake
+	query := `
+		SELECT st.id, st.status, st.warehouse_id, w.name AS warehouse_name, COALESCE(st.description, '') AS description,
+			   st.opened_by, st.approved_by, st.created_at, st.approved_at
+		FROM stock_takes st
+		JOIN warehouses w ON w.id = st.warehouse_id
+		WHERE st.id = $1`
+
+	logger.Debug("Получение инвентаризации ID: %d", id)
+	if err := r.db.Get(&stockTake, query, id); err != nil {
+		logger.Error("Ошибка при получении инвентаризации ID %d: %v", id, err)
+		return models.StockTake{}, fmt.Errorf("ошибка при получении инвентаризации: %w", err)
+	}
+
+	countsQuery := `
+		SELECT c.id, c.stock_take_id, c.material_id, m.name AS material_name, c.quantity, c.system_quantity, c.user_id,
+			   COALESCE(u.name, '') AS user_name, c.created_at
+		FROM stock_take_counts c
+		JOIN material m ON m.id = c.material_id
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.stock_take_id = $1
+		ORDER BY c.created_at, c.id`
+	if err := r.db.Select(&stockTake.Counts, countsQuery, id); err != nil {
+		logger.Error("Ошибка при получении проходов инвентаризации ID %d: %v", id, err)
+		return models.StockTake{}, fmt.Errorf("ошибка при получении проходов инвентаризации: %w", err)
+	}
+
+	linesQuery := `
+		SELECT l.material_id, m.name AS material_name, l.system_quantity, l.counted_quantity, l.delta
+		FROM stock_take_lines l
+		JOIN material m ON m.id = l.material_id
+		WHERE l.stock_take_id = $1
+		ORDER BY m.name`
+	if stockTake.Status == models.StockTakeStatusOpen {
+		linesQuery = stockTakeTotalsQuery
+	}
+	if err := r.db.Select(&stockTake.Lines, linesQuery, id); err != nil {
+		logger.Error("Ошибка при получении расхождений инвентаризации ID %d: %v", id, err)
+		return models.StockTake{}, fmt.Errorf("ошибка при получении расхождений инвентаризации: %w", err)
+	}
+
+	return stockTake, nil
+}
+
+// stockTakeTotalsQuery итоги открытой инвентаризации $1 по материалам. Пересчитанное сравнивается с остатком
+// на момент первого прохода по материалу: движения после пересчета уже учтены в остатке и не попадают в расхождение.
+const stockTakeTotalsQuery = `
+	SELECT m.id AS material_id, m.name AS material_name, s.system_quantity,
+		   SUM(c.quantity) AS counted_quantity, SUM(c.quantity) - s.system_quantity AS delta
+	FROM stock_take_counts c
+	JOIN material m ON m.id = c.material_id
+	JOIN LATERAL (
+		SELECT f.system_quantity FROM stock_take_counts f
+		WHERE f.stock_take_id = c.stock_take_id AND f.material_id = c.material_id
+		ORDER BY f.id
+		LIMIT 1
+	) s ON true
+	WHERE c.stock_take_id = $1
+	GROUP BY m.id, m.name, s.system_quantity
+	ORDER BY m.name`
+
+// lockOpenStockTake блокирует инвентаризацию до конца транзакции, проверяет, что она еще открыта, и возвращает ее склад
+func lockOpenStockTake(tx *sql.Tx, id int) (int, error) {
+	var status string
+	var warehouseID int
+	err := tx.QueryRow(`SELECT status, warehouse_id FROM stock_takes WHERE id = $1 FOR UPDATE`, id).Scan(&status, &warehouseID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("инвентаризация с ID %d не найдена", id)
+	}
+	if err != nil {
+		logger.Error("Ошибка при блокировке инвентаризации ID %d: %v", id, err)
+		return 0, fmt.Errorf("ошибка при получении инвентаризации: %w", err)
+	}
+	if status != models.StockTakeStatusOpen {
+		return 0, fmt.Errorf("инвентаризация ID %d уже утверждена и доступна только для чтения", id)
+	}
+	return warehouseID, nil
+}
+
+func (r *Repository) AddStockTakeCounts(stockTakeID int, counts []models.StockTakeCount) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	warehouseID, err := lockOpenStockTake(tx, stockTakeID)
+	if err != nil {
+		return err
+	}
+
+	// Вместе с проходом запоминается остаток в системе на этот момент
+	query := `
+		INSERT INTO stock_take_counts (stock_take_id, material_id, quantity, user_id, system_quantity)
+		SELECT $1::integer, $2::integer, $3::integer, $4::integer, COALESCE((
+			SELECT quantity FROM material_stock WHERE material_id = $2 AND warehouse_id = $5), 0)`
+
+	for _, count := range counts {
+		if _, err := tx.Exec(query, stockTakeID, count.MaterialID, count.Quantity, count.UserID, warehouseID); err != nil {
+			logger.Error("Ошибка при записи пересчета материала ID %d: %v", count.MaterialID, err)
+			return fmt.Errorf("ошибка при записи пересчета материала ID %d: %w", count.MaterialID, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("В инвентаризацию ID:%d записано позиций: %d", stockTakeID, len(counts))
+	return nil
+}
+
+func (r *Repository) DeleteStockTakeCount(stockTakeID, countID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = lockOpenStockTake(tx, stockTakeID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM stock_take_counts WHERE id = $1 AND stock_take_id = $2`, countID, stockTakeID)
+	if err != nil {
+		logger.Error("Ошибка при удалении прохода инвентаризации: %v", err)
+		return fmt.Errorf("ошибка при удалении прохода инвентаризации: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("запись пересчета с ID %d не найдена", countID)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	return nil
+}
+
+// ApproveStockTake фиксирует расхождения, проводит корректировки остатков и закрывает инвентаризацию
+func (r *Repository) ApproveStockTake(id int, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	warehouseID, err := lockOpenStockTake(tx, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(stockTakeTotalsQuery, id)
+	if err != nil {
+		logger.Error("Ошибка при подсчете итогов инвентаризации ID %d: %v", id, err)
+		return fmt.Errorf("ошибка при подсчете итогов инвентаризации: %w", err)
+	}
+
+	var lines []models.StockTakeLine
+	for rows.Next() {
+		var line models.StockTakeLine
+		if err := rows.Scan(&line.MaterialID, &line.MaterialName, &line.SystemQuantity, &line.CountedQuantity, &line.Delta); err != nil {
+			rows.Close()
+			return fmt.Errorf("ошибка при чтении итогов инвентаризации: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("ошибка при чтении итогов инвентаризации: %w", err)
+	}
+
+	if len(lines) == 0 {
+		return fmt.Errorf("в инвентаризации нет ни одной пересчитанной позиции")
+	}
+
+	// Расхождение считается от остатка на момент пересчета и проводится к текущему остатку,
+	// поэтому заказы, списавшие материал после пересчета, не искажают корректировку
+	for _, line := range lines {
+		_, err := tx.Exec(`
+			INSERT INTO stock_take_lines (stock_take_id, material_id, system_quantity, counted_quantity, delta)
+			VALUES ($1, $2, $3, $4, $5)`,
+			id, line.MaterialID, line.SystemQuantity, line.CountedQuantity, line.Delta)
+		if err != nil {
+			logger.Error("Ошибка при сохранении итога инвентаризации по материалу ID %d: %v", line.MaterialID, err)
+			return fmt.Errorf("ошибка при сохранении итога инвентаризации: %w", err)
+		}
+
+		if line.Delta == 0 {
+			continue
+		}
+
+		err = postMaterialMovement(tx, models.MaterialMovement{
+			MaterialID:  line.MaterialID,
+			Delta:       line.Delta,
+			WarehouseID: warehouseID,
+			Reason:      models.MovementStockTake,
+			StockTakeID: &id,
+			UserID:      nullableID(userID),
+			Description: fmt.Sprintf("инвентаризация №%d", id),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE stock_takes
+		SET status = $1, approved_by = $2, approved_at = CURRENT_TIMESTAMP
+		WHERE id = $3`,
+		models.StockTakeStatusApproved, nullableID(userID), id)
+	if err != nil {
+		logger.Error("Ошибка при утверждении инвентаризации ID %d: %v", id, err)
+		return fmt.Errorf("ошибка при утверждении инвентаризации: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Инвентаризация ID:%d утверждена, скорректировано позиций: %d", id, len(lines))
+	return nil
+}
@@ -0,0 +1,312 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+func (r *Repository) CreateWarehouse(warehouse models.Warehouse) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Склад по умолчанию может быть только один
+	if warehouse.IsDefault {
+		if _, err = tx.Exec(`UPDATE warehouses SET is_default = false WHERE is_default`); err != nil {
+			return 0, fmt.Errorf("ошибка при смене склада по умолчанию: %w", err)
+		}
+	}
+
+	var id int
+	query := `
+		INSERT INTO warehouses (name, is_mobile, is_default)
+		VALUES ($1, $2, $3)
+		RETURNING id`
+
+	logger.Debug("Создание склада: %s", warehouse.Name)
+	err = tx.QueryRow(query, warehouse.Name, warehouse.IsMobile, warehouse.IsDefault).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("склад '%s' уже существует", warehouse.Name)
+		}
+		logger.Error("Ошибка при создании склада: %v", err)
+		return 0, fmt.Errorf("ошибка при создании склада: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Склад успешно создан с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) GetAllWarehouses() ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	query := `
+		SELECT id, name, is_mobile, is_default, created_at, updated_at
+		FROM warehouses
+		ORDER BY is_default DESC, name`
+
+	logger.Debug("Получение списка складов")
+	err := r.db.Select(&warehouses, query)
+	if err != nil {
+		logger.Error("Ошибка при получении списка складов: %v", err)
+		return nil, fmt.Errorf("ошибка при получении списка складов: %w", err)
+	}
+
+	return warehouses, nil
+}
+
+func (r *Repository) UpdateWarehouse(id int, warehouse models.Warehouse) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if warehouse.IsDefault {
+		if _, err = tx.Exec(`UPDATE warehouses SET is_default = false WHERE is_default AND id <> $1`, id); err != nil {
+			return fmt.Errorf("ошибка при смене склада по умолчанию: %w", err)
+		}
+	}
+
+	// Снять признак по умолчанию можно только назначив другой склад
+	query := `
+		UPDATE warehouses
+		SET name = $1, is_mobile = $2, is_default = (is_default OR $3), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4`
+
+	logger.Debug("Обновление склада ID: %d", id)
+	result, err := tx.Exec(query, warehouse.Name, warehouse.IsMobile, warehouse.IsDefault, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("склад '%s' уже существует", warehouse.Name)
+		}
+		logger.Error("Ошибка при обновлении склада: %v", err)
+		return fmt.Errorf("ошибка при обновлении склада: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("склад с ID %d не найден", id)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Склад успешно обновлен")
+	return nil
+}
+
+func (r *Repository) DeleteWarehouse(id int) error {
+	var hasStock bool
+	err := r.db.Get(&hasStock, `SELECT EXISTS(SELECT 1 FROM material_stock WHERE warehouse_id = $1 AND quantity > 0)`, id)
+	if err != nil {
+		logger.Error("Ошибка при проверке остатков склада ID %d: %v", id, err)
+		return fmt.Errorf("ошибка при проверке остатков склада: %w", err)
+	}
+	if hasStock {
+		return fmt.Errorf("на складе есть материалы, сначала переместите их на другой склад")
+	}
+
+	query := `DELETE FROM warehouses WHERE id = $1 AND NOT is_default`
+
+	logger.Debug("Удаление склада ID: %d", id)
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("по складу есть движения материалов, удалить его нельзя")
+		}
+		logger.Error("Ошибка при удалении склада: %v", err)
+		return fmt.Errorf("ошибка при удалении склада: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("склад с ID %d не найден или является складом по умолчанию", id)
+	}
+
+	logger.Info("Склад успешно удален")
+	return nil
+}
+
+// GetMaterialBalances возвращает остатки по складам. Нулевые фильтры означают "все".
+func (r *Repository) GetMaterialBalances(warehouseID, materialID int) ([]models.MaterialBalance, error) {
+	var balances []models.MaterialBalance
+	query := `
+		SELECT m.id AS material_id, m.name AS material_name, COALESCE(m.sku, '') AS sku, c.name AS category_name, m.unit,
+			   w.id AS warehouse_id, w.name AS warehouse_name, ms.quantity
+		FROM material_stock ms
+		JOIN material m ON m.id = ms.material_id
+		JOIN material_categories c ON c.id = m.category_id
+		JOIN warehouses w ON w.id = ms.warehouse_id
+		WHERE ($1 = 0 OR ms.warehouse_id = $1) AND ($2 = 0 OR ms.material_id = $2)
+		ORDER BY w.is_default DESC, w.name, c.name, m.name`
+
+	logger.Debug("Получение остатков по складам: склад ID %d, материал ID %d", warehouseID, materialID)
+	err := r.db.Select(&balances, query, warehouseID, materialID)
+	if err != nil {
+		logger.Error("Ошибка при получении остатков по складам: %v", err)
+		return nil, fmt.Errorf("ошибка при получении остатков по складам: %w", err)
+	}
+
+	return balances, nil
+}
+
+// CreateMaterialTransfer перемещает материал между складами парой движений: расход с одного склада и приход на другой
+func (r *Repository) CreateMaterialTransfer(transfer models.MaterialTransfer) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO material_transfers (material_id, from_warehouse_id, to_warehouse_id, quantity, user_id, description)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		transfer.MaterialID, transfer.FromWarehouseID, transfer.ToWarehouseID, transfer.Quantity, transfer.UserID, transfer.Description).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return 0, fmt.Errorf("материал или склад не найден")
+		}
+		logger.Error("Ошибка при создании перемещения: %v", err)
+		return 0, fmt.Errorf("ошибка при создании перемещения: %w", err)
+	}
+
+	description := fmt.Sprintf("перемещение №%d", id)
+	movements := []models.MaterialMovement{
+		{WarehouseID: transfer.FromWarehouseID, Delta: -transfer.Quantity},
+		{WarehouseID: transfer.ToWarehouseID, Delta: transfer.Quantity},
+	}
+	for _, m := range movements {
+		m.MaterialID = transfer.MaterialID
+		m.Reason = models.MovementTransfer
+		m.TransferID = &id
+		m.UserID = transfer.UserID
+		m.Description = description
+		if err = postMaterialMovement(tx, m); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return 0, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Перемещение ID:%d материала ID:%d со склада %d на склад %d: %v",
+		id, transfer.MaterialID, transfer.FromWarehouseID, transfer.ToWarehouseID, transfer.Quantity)
+	return id, nil
+}
+
+// AdjustMaterialStock приводит остаток материала на складе к фактическому quantity корректировкой в журнале
+func (r *Repository) AdjustMaterialStock(id, warehouseID int, quantity float64, description string, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		logger.Error("Ошибка при начале транзакции: %v", err)
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO material_stock (material_id, warehouse_id, quantity)
+		VALUES ($1, $2, 0)
+		ON CONFLICT (material_id, warehouse_id) DO NOTHING`,
+		id, warehouseID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("материал с ID %d или склад с ID %d не найден", id, warehouseID)
+		}
+		logger.Error("Ошибка при получении остатка материала ID %d: %v", id, err)
+		return fmt.Errorf("ошибка при получении остатка материала: %w", err)
+	}
+
+	// Разницу считаем в БД под блокировкой остатка, чтобы заказы не изменили его до корректировки
+	var delta float64
+	err = tx.QueryRow(`
+		SELECT $3::numeric - quantity
+		FROM material_stock
+		WHERE material_id = $1 AND warehouse_id = $2
+		FOR UPDATE`,
+		id, warehouseID, quantity).Scan(&delta)
+	if err != nil {
+		logger.Error("Ошибка при получении остатка материала ID %d: %v", id, err)
+		return fmt.Errorf("ошибка при получении остатка материала: %w", err)
+	}
+	if delta == 0 {
+		return nil
+	}
+
+	if description == "" {
+		description = "корректировка остатка"
+	}
+	err = postMaterialMovement(tx, models.MaterialMovement{
+		MaterialID:  id,
+		WarehouseID: warehouseID,
+		Delta:       delta,
+		Reason:      models.MovementCorrection,
+		UserID:      nullableID(userID),
+		Description: description,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error("Ошибка при завершении транзакции: %v", err)
+		return fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Остаток материала ID:%d на складе ID:%d скорректирован на %v", id, warehouseID, delta)
+	return nil
+}
+
+func (r *Repository) GetMaterialTransfers(warehouseID int) ([]models.MaterialTransfer, error) {
+	var transfers []models.MaterialTransfer
+	query := `
+		SELECT t.id, t.material_id, m.name AS material_name,
+			   t.from_warehouse_id, wf.name AS from_warehouse_name,
+			   t.to_warehouse_id, wt.name AS to_warehouse_name,
+			   t.quantity, t.user_id, COALESCE(t.description, '') AS description, t.created_at
+		FROM material_transfers t
+		JOIN material m ON m.id = t.material_id
+		JOIN warehouses wf ON wf.id = t.from_warehouse_id
+		JOIN warehouses wt ON wt.id = t.to_warehouse_id
+		WHERE $1 = 0 OR t.from_warehouse_id = $1 OR t.to_warehouse_id = $1
+		ORDER BY t.created_at DESC`
+
+	logger.Debug("Получение списка перемещений, склад ID: %d", warehouseID)
+	err := r.db.Select(&transfers, query, warehouseID)
+	if err != nil {
+		logger.Error("Ошибка при получении списка перемещений: %v", err)
+		return nil, fmt.Errorf("ошибка при получении списка перемещений: %w", err)
+	}
+
+	return transfers, nil
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const workerPaymentColumns = `
+	p.id, p.worker_id, p.kind, p.amount, p.paid_at, p.method, p.period_id,
+	COALESCE(p.description, '') AS description, p.issued_by, COALESCE(u.name, '') AS issued_by_name, p.created_at`
+
+// workerDeleteError объясняет, почему работника нельзя удалить: выданные ему деньги и начисления остаются в учете
+func workerDeleteError(id int, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		switch pqErr.Constraint {
+		case "worker_payments_worker_id_fkey":
+			return fmt.Errorf("у работника с ID %d есть авансы или выплаты, его нельзя удалить", id)
+		case "payroll_lines_worker_id_fkey", "payroll_adjustments_worker_id_fkey":
+			return fmt.Errorf("работнику с ID %d начислялась зарплата в расчетах за периоды, его нельзя удалить", id)
+		}
+	}
+	return fmt.Errorf("ошибка при удалении работника: %w", err)
+}
+
+// GetWorkerPayments возвращает авансы и выплаты за [start, end), workerID 0 - по всем работникам
+func (r *Repository) GetWorkerPayments(workerID int, start, end time.Time) ([]models.WorkerPayment, error) {
+	payments := []models.WorkerPayment{}
+	query := `
+		SELECT ` + workerPaymentColumns + `
+		FROM worker_payments p
+		LEFT JOIN users u ON u.id = p.issued_by
+		WHERE ($1 = 0 OR p.worker_id = $1) AND p.paid_at >= $2 AND p.paid_at < $3
+		ORDER BY p.paid_at, p.id`
+
+	logger.Debug("Получение выплат работнику ID:%d с %v по %v", workerID, start, end)
+	if err := r.db.Select(&payments, query, workerID, start, end); err != nil {
+		logger.Error("Ошибка при получении выплат работникам: %v", err)
+		return nil, fmt.Errorf("ошибка при получении выплат работникам: %w", err)
+	}
+	return payments, nil
+}
+
+func (r *Repository) GetWorkerPaymentById(id int) (models.WorkerPayment, error) {
+	var payment models.WorkerPayment
+	query := `
+		SELECT ` + workerPaymentColumns + `
+		FROM worker_payments p
+		LEFT JOIN users u ON u.id = p.issued_by
+		WHERE p.id = $1`
+
+	if err := r.db.Get(&payment, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return payment, fmt.Errorf("выплата с ID %d не найдена", id)
+		}
+		logger.Error("Ошибка при получении выплаты: %v", err)
+		return payment, fmt.Errorf("ошибка при получении выплаты: %w", err)
+	}
+	return payment, nil
+}
+
+func (r *Repository) CreateWorkerPayment(payment models.WorkerPayment) (int, error) {
+	var id int
+	logger.Debug("Запись выплаты '%s' работнику ID:%d на сумму %s", payment.Kind, payment.WorkerID, payment.Amount)
+	err := r.db.QueryRow(`
+		INSERT INTO worker_payments (worker_id, kind, amount, paid_at, method, period_id, description, issued_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		RETURNING id`,
+		payment.WorkerID, payment.Kind, payment.Amount, payment.PaidAt, payment.Method,
+		payment.PeriodID, payment.Description, payment.IssuedBy).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			if pqErr.Constraint == "worker_payments_period_id_fkey" {
+				return 0, fmt.Errorf("расчет зарплаты с ID %d не найден", *payment.PeriodID)
+			}
+			return 0, fmt.Errorf("работник с ID %d не найден", payment.WorkerID)
+		}
+		logger.Error("Ошибка при записи выплаты работнику: %v", err)
+		return 0, fmt.Errorf("ошибка при записи выплаты работнику: %w", err)
+	}
+
+	logger.Info("Выплата работнику успешно записана с ID: %d", id)
+	return id, nil
+}
+
+func (r *Repository) DeleteWorkerPayment(workerID, id int) error {
+	logger.Debug("Удаление выплаты ID:%d", id)
+	result, err := r.db.Exec(`DELETE FROM worker_payments WHERE id = $1 AND worker_id = $2`, id, workerID)
+	if err != nil {
+		logger.Error("Ошибка при удалении выплаты: %v", err)
+		return fmt.Errorf("ошибка при удалении выплаты: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества удаленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("выплата с ID %d не найдена у работника", id)
+	}
+
+	logger.Info("Выплата успешно удалена")
+	return nil
+}
+
+// GetWorkerSettlement возвращает заработанное работником по утвержденным и закрытым расчетам зарплаты,
+// все выданные ему деньги и дату окончания последнего такого расчета
+func (r *Repository) GetWorkerSettlement(workerID int) (models.WorkerBalance, error) {
+	var balance models.WorkerBalance
+	query := `
+		SELECT
+			(SELECT COALESCE(SUM(l.accrued + l.bonuses - l.penalties + l.adjustments), 0)
+			 FROM payroll_lines l
+			 JOIN payroll_periods p ON p.id = l.period_id
+			 WHERE l.worker_id = $1 AND p.status <> 'черновик') AS earned,
+			(SELECT COALESCE(SUM(amount), 0) FROM worker_payments WHERE worker_id = $1) AS paid,
+			(SELECT MAX(period_end) FROM payroll_periods WHERE status <> 'черновик') AS settled_until`
+
+	logger.Debug("Получение расчетов с работником ID:%d", workerID)
+	err := r.db.QueryRow(query, workerID).Scan(&balance.Earned, &balance.Paid, &balance.SettledUntil)
+	if err != nil {
+		logger.Error("Ошибка при получении расчетов с работником: %v", err)
+		return balance, fmt.Errorf("ошибка при получении расчетов с работником: %w", err)
+	}
+	return balance, nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+
+	"github.com/lib/pq"
+)
+
+// CreateWorkerWithUser создает учетную запись и работника, привязанного к ней, в одной транзакции
+func (r *Repository) CreateWorkerWithUser(user models.User, worker models.Worker) (int, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID, workerID int
+	logger.Debug("Создание пользователя %s и работника %s %s", user.Email, worker.Name, worker.Surname)
+	err = tx.QueryRow(`
+		INSERT INTO users (name, email, password_hash, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		user.Name, user.Email, user.Password, user.Role).Scan(&userID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, 0, fmt.Errorf("пользователь с email %s уже существует", user.Email)
+		}
+		logger.Error("Ошибка при создании пользователя: %v", err)
+		return 0, 0, fmt.Errorf("ошибка при создании пользователя: %w", err)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO workers (name, surname, email, phone, salary_schema, salary, has_car, warehouse_id, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`,
+		worker.Name, worker.Surname, worker.Email, worker.Phone, worker.SalarySchema, worker.Salary, worker.HasCar, worker.WarehouseID, userID).Scan(&workerID)
+	if err != nil {
+		logger.Error("Ошибка при создании работника: %v", err)
+		return 0, 0, fmt.Errorf("ошибка при создании работника: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("ошибка при завершении транзакции: %w", err)
+	}
+
+	logger.Info("Работник ID:%d создан с учетной записью ID:%d", workerID, userID)
+	return userID, workerID, nil
+}
+
+// SetWorkerUser привязывает работника к учетной записи, nil отвязывает.
+// Привязка вручную снимает пометку переноса: менеджер разобрался с работником
+func (r *Repository) SetWorkerUser(workerID int, userID *int) error {
+	logger.Debug("Привязка работника ID:%d к учетной записи %v", workerID, userID)
+	result, err := r.db.Exec(`
+		UPDATE workers SET user_id = $1, link_issue = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, userID, workerID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code {
+			case "23505":
+				return fmt.Errorf("учетная запись %d уже привязана к другому работнику", *userID)
+			case "23503":
+				return fmt.Errorf("пользователь с ID %d не найден", *userID)
+			}
+		}
+		logger.Error("Ошибка при привязке учетной записи работника: %v", err)
+		return fmt.Errorf("ошибка при привязке учетной записи работника: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества обновленных строк: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("работник с ID %d не найден", workerID)
+	}
+
+	logger.Info("Учетная запись работника ID:%d обновлена", workerID)
+	return nil
+}
@@ -32,9 +32,8 @@ func (s *AuthServiceImpl) Register(input models.SignUpInput) (string, models.Use
 	logger.Debug("Начало регистрации пользователя: %s", input.Email)
 
 	// Хешируем пароль
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(input.Password)
 	if err != nil {
-		logger.Error("Ошибка хеширования пароля: %v", err)
 		return "", models.User{}, err
 	}
 
@@ -42,12 +41,22 @@ func (s *AuthServiceImpl) Register(input models.SignUpInput) (string, models.Use
 	user := models.User{
 		Name:     input.Name,
 		Email:    input.Email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 		Role:     input.Role,
 	}
 
-	// Сохраняем пользователя в базу
-	id, err := s.repo.CreateUser(user)
+	// Сохраняем пользователя в базу, работник создается в той же транзакции
+	var id, workerId int
+	if user.Role == "worker" {
+		logger.Debug("Создание записи в таблице workers для пользователя %s", user.Email)
+		id, workerId, err = s.repo.CreateWorkerWithUser(user, models.Worker{
+			Name:    user.Name,
+			Surname: "", // Пока оставляем пустым, можно добавить в форму регистрации
+			Email:   user.Email,
+		})
+	} else {
+		id, err = s.repo.CreateUser(user)
+	}
 	if err != nil {
 		logger.Error("Ошибка создания пользователя в БД: %v", err)
 		return "", models.User{}, err
@@ -60,17 +69,7 @@ func (s *AuthServiceImpl) Register(input models.SignUpInput) (string, models.Use
 		return "", models.User{}, err
 	}
 
-	if user.Role == "worker" {
-		logger.Debug("Создание записи в таблице workers для пользователя %s", user.Email)
-		worker := models.Worker{
-			Name:    user.Name,
-			Surname: "", // Пока оставляем пустым, можно добавить в форму регистрации
-		}
-		workerId, err := s.repo.CreateWorker(worker)
-		if err != nil {
-			logger.Error("Ошибка создания записи работника: %v", err)
-			return "", models.User{}, err
-		}
+	if workerId != 0 {
 		user.WorkerID = workerId
 		logger.Debug("Создана запись работника с ID: %d", workerId)
 	}
@@ -114,7 +113,7 @@ func (s *AuthServiceImpl) Login(input models.SignInInput) (string, models.User,
 	// Если пользователь работник, получаем его worker_id
 	if user.Role == "worker" {
 		logger.Debug("Получение worker_id для пользователя %s", user.Email)
-		worker, err := s.repo.GetWorkerByName(user.Name)
+		worker, err := s.repo.GetWorkerByUserId(user.ID)
 		if err != nil {
 			logger.Error("Ошибка получения worker_id для пользователя %s: %v", user.Email, err)
 			return "", models.User{}, err
@@ -134,6 +133,16 @@ func (s *AuthServiceImpl) Login(input models.SignInInput) (string, models.User,
 	return token, user, nil
 }
 
+// hashPassword хеширует пароль учетной записи
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		logger.Error("Ошибка хеширования пароля: %v", err)
+		return "", err
+	}
+	return string(hashed), nil
+}
+
 func (s *AuthServiceImpl) generateToken(user models.User) (string, error) {
 	logger.Debug("Генерация токена для пользователя: %s", user.Email)
 
@@ -4,8 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/fuzzy"
 	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/plate"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xuri/excelize/v2"
 )
@@ -39,7 +44,7 @@ func (s *CarService) GenerateTemplate() (*bytes.Buffer, error) {
 	f.DeleteSheet("Sheet1")
 
 	// Устанавливаем заголовки
-	headers := []string{"Номер", "Модель", "Год"}
+	headers := []string{"Номер", "Модель", "Год", "Тип", "VIN"}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		f.SetCellValue(sheetName, cell, header)
@@ -75,6 +80,8 @@ func (s *CarService) GenerateTemplate() (*bytes.Buffer, error) {
 	f.SetColWidth(sheetName, "A", "A", 20)
 	f.SetColWidth(sheetName, "B", "B", 30)
 	f.SetColWidth(sheetName, "C", "C", 10)
+	f.SetColWidth(sheetName, "D", "D", 15)
+	f.SetColWidth(sheetName, "E", "E", 22)
 
 	
 	// Сохраняем файл в буфер
@@ -88,121 +95,409 @@ func (s *CarService) GenerateTemplate() (*bytes.Buffer, error) {
 	return buffer, nil
 }
 
-func (s *CarService) UploadCarsFromExcel(clientId int, fileData []byte) error {
-	logger.Debug("Начало обработки Excel файла для клиента ID:%d", clientId)
+// ImportCars загружает машины клиента из Excel или CSV файла.
+// Строки проверяются целиком до сохранения, изменения сохраняются одной транзакцией.
+// В режиме проверки (DryRun) возвращается тот же отчет, но ничего не сохраняется.
+func (s *CarService) ImportCars(clientId int, fileName string, fileData []byte, opts models.CarImportOptions) (models.CarImportResult, error) {
+	logger.Debug("Начало обработки файла машин %s для клиента ID:%d", fileName, clientId)
+	result := models.CarImportResult{DryRun: opts.DryRun, Rows: []models.CarImportRow{}}
+
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = models.CarConflictSkip
+	case models.CarConflictSkip, models.CarConflictMove, models.CarConflictShare:
+	default:
+		return result, fmt.Errorf("неизвестный режим для чужих машин '%s', допустимые: skip, move, share", opts.Conflict)
+	}
 
-	// Открываем Excel файл из байтов
-	f, err := excelize.OpenReader(bytes.NewReader(fileData))
-	if err != nil {
-		logger.Error("Ошибка при открытии Excel файла: %v", err)
-		return fmt.Errorf("ошибка при открытии Excel файла: %w", err)
+	if _, err := s.repo.GetClientById(clientId); err != nil {
+		return result, fmt.Errorf("клиент с ID %d не найден", clientId)
 	}
-	defer f.Close()
 
-	// Получаем первый лист
-	sheetName := f.GetSheetName(0)
-	rows, err := f.GetRows(sheetName)
+	rows, err := readImportRows(fileName, fileData)
 	if err != nil {
-		logger.Error("Ошибка при чтении листа Excel: %v", err)
-		return fmt.Errorf("ошибка при чтении листа Excel: %w", err)
+		return result, err
 	}
-
-	// Проверяем наличие заголовков
 	if len(rows) < 2 {
 		logger.Error("Файл не содержит данных")
-		return fmt.Errorf("файл не содержит данных")
+		return result, fmt.Errorf("файл не содержит данных")
 	}
 
 	// Получаем индексы колонок
-	headers := rows[0]
-	numberIdx := -1
-	modelIdx := -1
-	yearIdx := -1
-
-	for i, header := range headers {
-		switch header {
-		case "number", "номер", "Номер":
-			numberIdx = i
-		case "model", "модель", "Модель":
-			modelIdx = i
-		case "year", "год", "Год":
-			yearIdx = i
+	columns := map[string]int{}
+	for i, header := range rows[0] {
+		switch strings.ToLower(strings.TrimSpace(header)) {
+		case "number", "номер", "госномер":
+			columns["number"] = i
+		case "model", "модель":
+			columns["model"] = i
+		case "year", "год":
+			columns["year"] = i
+		case "type", "vehicle_type", "тип":
+			columns["type"] = i
+		case "vin":
+			columns["vin"] = i
 		}
 	}
-
-	if numberIdx == -1 {
+	if _, ok := columns["number"]; !ok {
 		logger.Error("Не найдена колонка с номером автомобиля")
-		return fmt.Errorf("не найдена колонка с номером автомобиля")
+		return result, fmt.Errorf("не найдена колонка с номером автомобиля")
 	}
 
-	// Счетчики для статистики
-	var (
-		added    int
-		skipped  int
-		errors   int
-		errorMsg strings.Builder
-	)
-
-	// Обрабатываем каждую строку
+	// Проверяем строки
+	seen := make(map[string]int)
+	var numbers []string
 	for i, row := range rows[1:] {
-		if len(row) <= numberIdx {
-			logger.Warning("Пропуск строки %d: недостаточно колонок", i+2)
-			errors++
-			errorMsg.WriteString(fmt.Sprintf("Строка %d: недостаточно колонок\n", i+2))
-			continue
+		rowNum := i + 2
+		cell := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[idx])
+		}
+		if strings.Join(row, "") == "" {
+			continue // пустая строка
 		}
 
-		car := models.Car{
-			Number: strings.ToUpper(row[numberIdx]),
+		item := models.CarImportRow{Row: rowNum, Number: cell("number")}
+		invalid := func(format string, args ...interface{}) {
+			item.Status = models.CarImportInvalid
+			item.Message = fmt.Sprintf(format, args...)
+			result.Rows = append(result.Rows, item)
 		}
 
-		// Добавляем модель, если есть
-		if modelIdx != -1 && len(row) > modelIdx {
-			car.Model = row[modelIdx]
+		number, err := plate.Parse(item.Number)
+		if err != nil {
+			invalid("%v", err)
+			continue
+		}
+		item.Number = number
+		if first, ok := seen[number]; ok {
+			invalid("номер уже указан в строке %d", first)
+			continue
 		}
 
-		// Добавляем год, если есть
-		if yearIdx != -1 && len(row) > yearIdx {
-			year := 0
-			fmt.Sscanf(row[yearIdx], "%d", &year)
+		car := models.Car{Number: number, Model: cell("model"), VehicleType: cell("type"), VIN: cell("vin")}
+		if value := cell("year"); value != "" {
+			year, err := strconv.Atoi(value)
+			if err != nil || year < 1950 || year > time.Now().Year()+1 {
+				invalid("неверный год выпуска '%s'", value)
+				continue
+			}
 			car.Year = year
 		}
+		if err := validateCar(&car); err != nil {
+			invalid("%v", err)
+			continue
+		}
 
-		// Добавляем машину клиенту
-		err := s.repo.AddCarToClient(clientId, car)
+		seen[number] = rowNum
+		numbers = append(numbers, number)
+		item.Car = car
+		result.Rows = append(result.Rows, item)
+	}
+
+	// Определяем, что делать с каждой машиной, по ее текущим владельцам
+	owners := make(map[string][]int)
+	if len(numbers) > 0 {
+		ownerships, err := s.repo.GetCarOwnerships(numbers)
 		if err != nil {
-			if strings.Contains(err.Error(), "уже существует") {
-				logger.Warning("Пропуск строки %d: машина %s уже существует", i+2, car.Number)
-				skipped++
-				continue
+			return result, err
+		}
+		for _, o := range ownerships {
+			ids := make([]int, 0, len(o.ClientIDs))
+			for _, id := range o.ClientIDs {
+				ids = append(ids, int(id))
 			}
-			logger.Error("Ошибка при добавлении машины в строке %d: %v", i+2, err)
-			errors++
-			errorMsg.WriteString(fmt.Sprintf("Строка %d: ошибка добавления машины %s\n", i+2, car.Number))
+			owners[o.Number] = ids
+		}
+	}
+
+	var changes []models.CarImportRow
+	for i := range result.Rows {
+		item := &result.Rows[i]
+		if item.Status == models.CarImportInvalid {
+			result.Invalid++
 			continue
 		}
 
-		added++
-		logger.Debug("Успешно добавлена машина %s для клиента ID:%d", car.Number, clientId)
+		current, exists := owners[item.Number]
+		ownedByClient := false
+		for _, id := range current {
+			if id == clientId {
+				ownedByClient = true
+			} else {
+				item.OtherClientIDs = append(item.OtherClientIDs, id)
+			}
+		}
+
+		switch {
+		case !exists:
+			item.Status = models.CarImportAdded
+			result.Added++
+		case ownedByClient:
+			item.Status = models.CarImportSkipped
+			result.Skipped++
+		case len(current) == 0:
+			item.Status = models.CarImportLinked
+			result.Linked++
+		case opts.Conflict == models.CarConflictMove:
+			item.Status = models.CarImportMoved
+			result.Moved++
+		case opts.Conflict == models.CarConflictShare:
+			item.Status = models.CarImportShared
+			result.Shared++
+		default:
+			item.Status = models.CarImportOther
+			item.Message = "машина числится за другим клиентом"
+			result.Other++
+		}
+
+		if item.Status != models.CarImportSkipped && item.Status != models.CarImportOther {
+			changes = append(changes, *item)
+		}
 	}
 
-	// Формируем итоговое сообщение
-	var result strings.Builder
-	result.WriteString(fmt.Sprintf("Обработка завершена:\n"))
-	result.WriteString(fmt.Sprintf("- Добавлено: %d\n", added))
-	result.WriteString(fmt.Sprintf("- Пропущено (дубликаты): %d\n", skipped))
-	if errors > 0 {
-		result.WriteString(fmt.Sprintf("- Ошибок: %d\n", errors))
-		result.WriteString("\nДетали ошибок:\n")
-		result.WriteString(errorMsg.String())
+	if !opts.DryRun && len(changes) > 0 {
+		if err := s.repo.ImportClientCars(clientId, changes); err != nil {
+			return result, err
+		}
 	}
 
-	logger.Info("Результат обработки Excel файла для клиента ID:%d:\n%s", clientId, result.String())
+	logger.Info("Загрузка машин клиента ID:%d (проверка: %v): добавлено %d, привязано %d, передано %d, общих %d, повторов %d, у других клиентов %d, ошибок %d",
+		clientId, opts.DryRun, result.Added, result.Linked, result.Moved, result.Shared, result.Skipped, result.Other, result.Invalid)
+	return result, nil
+}
+
+// GetProfile возвращает карточку машины со схемой осей и владельцами
+func (s *CarService) GetProfile(number string) (models.VehicleProfile, error) {
+	logger.Debug("Получение профиля машины %s", number)
 
-	// Если были ошибки, возвращаем их
-	if errors > 0 {
-		return fmt.Errorf(result.String())
+	car, err := s.repo.GetCarByNumber(number)
+	if err != nil {
+		return models.VehicleProfile{}, err
 	}
 
+	owners, err := s.repo.GetCarOwners(car.ID)
+	if err != nil {
+		return models.VehicleProfile{}, err
+	}
+
+	return models.VehicleProfile{Car: car, Positions: car.WheelPositions(), Owners: owners}, nil
+}
+
+// UpdateProfile обновляет тип, VIN и схему осей машины
+func (s *CarService) UpdateProfile(number string, car models.Car) error {
+	logger.Debug("Обновление профиля машины %s", number)
+	if err := validateCar(&car); err != nil {
+		return err
+	}
+	return s.repo.UpdateCarProfile(number, car)
+}
+
+// GetLayout возвращает схему колес машины для заказа
+func (s *CarService) GetLayout(number string) (models.VehicleLayout, error) {
+	car, err := s.repo.GetCarByNumber(number)
+	if err != nil {
+		return models.VehicleLayout{}, err
+	}
+
+	axles := car.Axles
+	if axles == nil {
+		axles = []models.CarAxle{}
+	}
+	positions := car.WheelPositions()
+	if positions == nil {
+		positions = []string{}
+	}
+
+	return models.VehicleLayout{Number: car.Number, VehicleType: car.VehicleType, Axles: axles, Positions: positions}, nil
+}
+
+// validateCar проверяет и нормализует тип, VIN и оси машины.
+// Оси передаются по порядку от передней, номера проставляются по порядку.
+func validateCar(car *models.Car) error {
+	car.VIN = strings.ToUpper(strings.TrimSpace(car.VIN))
+	if car.VIN != "" && !isVIN(car.VIN) {
+		return fmt.Errorf("неверный VIN: 17 символов, латинские буквы кроме I, O, Q и цифры")
+	}
+
+	car.VehicleType = strings.TrimSpace(car.VehicleType)
+	if car.VehicleType != "" && !isVehicleType(car.VehicleType) {
+		return fmt.Errorf("неизвестный тип машины '%s', допустимые: %s", car.VehicleType, strings.Join(models.VehicleTypes, ", "))
+	}
+
+	if len(car.Axles) > maxCarAxles {
+		return fmt.Errorf("у машины не может быть больше %d осей", maxCarAxles)
+	}
+	for i := range car.Axles {
+		car.Axles[i].Number = i + 1
+		car.Axles[i].TireSize = strings.TrimSpace(car.Axles[i].TireSize)
+	}
 	return nil
 }
+
+const maxCarAxles = 10
+
+func isVIN(vin string) bool {
+	if len(vin) != 17 {
+		return false
+	}
+	for _, r := range vin {
+		if r == 'I' || r == 'O' || r == 'Q' {
+			return false
+		}
+		if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isVehicleType(vehicleType string) bool {
+	for _, t := range models.VehicleTypes {
+		if vehicleType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Окно по умолчанию, в котором повтор услуги на одном колесе считается повторяющейся проблемой
+const defaultHistoryWindowDays = 60
+
+// GetHistory возвращает все заказы по машине и сводку услуг по позициям колес.
+// Повтор услуги на одной позиции за последние days дней помечается как повторяющийся.
+func (s *CarService) GetHistory(number string, days int) (models.VehicleHistory, error) {
+	logger.Debug("Получение истории обслуживания машины %s за окно %d дней", number, days)
+	if days <= 0 {
+		days = defaultHistoryWindowDays
+	}
+
+	orders, err := s.repo.GetVehicleOrders(number)
+	if err != nil {
+		return models.VehicleHistory{}, err
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	summaries := make(map[string]*models.WheelPositionSummary)
+	var keys []string
+	for _, order := range orders {
+		for _, service := range order.Services {
+			if service.WheelPosition == "" {
+				continue
+			}
+			key := service.WheelPosition + "|" + service.ServiceName
+			summary, ok := summaries[key]
+			if !ok {
+				summary = &models.WheelPositionSummary{Position: service.WheelPosition, ServiceName: service.ServiceName}
+				summaries[key] = summary
+				keys = append(keys, key)
+			}
+			summary.Total++
+			if order.CreatedAt.After(since) {
+				summary.Recent++
+			}
+			if order.CreatedAt.After(summary.LastAt) {
+				summary.LastAt = order.CreatedAt
+			}
+		}
+	}
+
+	positions := make([]models.WheelPositionSummary, 0, len(keys))
+	for _, key := range keys {
+		summary := summaries[key]
+		summary.Recurring = summary.Recent > 1
+		positions = append(positions, *summary)
+	}
+	// Сначала повторяющиеся и самые частые за окно
+	sort.SliceStable(positions, func(i, j int) bool {
+		if positions[i].Recent != positions[j].Recent {
+			return positions[i].Recent > positions[j].Recent
+		}
+		if positions[i].Total != positions[j].Total {
+			return positions[i].Total > positions[j].Total
+		}
+		return positions[i].Position < positions[j].Position
+	})
+
+	if orders == nil {
+		orders = []models.VehicleHistoryOrder{}
+	}
+
+	logger.Info("История машины %s: заказов %d, позиций с услугами %d", number, len(orders), len(positions))
+	return models.VehicleHistory{Number: number, WindowDays: days, Orders: orders, Positions: positions}, nil
+}
+
+const (
+	searchMaxTypos      = 1  // допустимое число опечаток в номере
+	searchMinPartialLen = 3  // с какой длины ищем по части номера
+	searchLimit         = 20 // сколько машин возвращать
+)
+
+// Search ищет машины по введенному работником номеру: точное совпадение,
+// часть номера и номера, отличающиеся на один символ
+func (s *CarService) Search(query string) ([]models.CarSearchResult, error) {
+	number := plate.Normalize(query)
+	if number == "" {
+		return nil, fmt.Errorf("не указан номер для поиска")
+	}
+
+	cars, err := s.repo.GetCarSearchCandidates(number, searchMaxTypos, searchMinPartialLen, searchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	rank := map[string]int{models.PlateMatchExact: 0, models.PlateMatchPartial: 1, models.PlateMatchTypo: 2}
+	results := make([]models.CarSearchResult, 0)
+	for _, car := range cars {
+		distance := fuzzy.Distance(number, car.Number)
+		var match string
+		switch {
+		case distance == 0:
+			match = models.PlateMatchExact
+		case len(number) >= searchMinPartialLen && strings.Contains(car.Number, number):
+			match = models.PlateMatchPartial
+		case distance <= searchMaxTypos:
+			match = models.PlateMatchTypo
+		default:
+			continue
+		}
+		results = append(results, models.CarSearchResult{Car: car, Match: match, Distance: distance})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if rank[results[i].Match] != rank[results[j].Match] {
+			return rank[results[i].Match] < rank[results[j].Match]
+		}
+		return results[i].Distance < results[j].Distance
+	})
+	if len(results) > searchLimit {
+		results = results[:searchLimit]
+	}
+
+	logger.Debug("Поиск по номеру %s: найдено %d машин", number, len(results))
+	return results, nil
+}
+
+// Transfer передает машину другому клиенту: прежняя привязка закрывается, новая открывается с той же даты
+func (s *CarService) Transfer(number string, transfer models.CarTransfer) error {
+	logger.Debug("Передача машины %s клиенту ID:%d", number, transfer.ToClientID)
+	if transfer.ToClientID == 0 {
+		return fmt.Errorf("не указан новый владелец машины")
+	}
+	if transfer.FromClientID == transfer.ToClientID {
+		return fmt.Errorf("прежний и новый владелец совпадают")
+	}
+
+	at := time.Now()
+	if transfer.Date != nil {
+		if transfer.Date.After(at) {
+			return fmt.Errorf("дата передачи не может быть в будущем")
+		}
+		at = *transfer.Date
+	}
+
+	return s.repo.TransferCar(number, transfer, at)
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"go-hinomontaj/models"
 	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/plate"
+	"time"
 )
 
 type ClientService struct {
@@ -16,6 +18,9 @@ func NewClientService(repo Repository) *ClientService {
 
 func (s *ClientService) Create(client models.Client) (int, error) {
 	logger.Debug("Создание нового клиента в сервисе")
+	if err := applyClientType(s.repo, &client); err != nil {
+		return 0, err
+	}
 	return s.repo.CreateClient(client)
 }
 
@@ -26,11 +31,18 @@ func (s *ClientService) GetAll() ([]models.Client, error) {
 
 func (s *ClientService) GetById(id int) (models.Client, error) {
 	logger.Debug("Получение клиента по ID в сервисе: %d", id)
+	id, err := resolveClientID(s.repo, id)
+	if err != nil {
+		return models.Client{}, err
+	}
 	return s.repo.GetClientById(id)
 }
 
 func (s *ClientService) Update(id int, client models.Client) error {
 	logger.Debug("Обновление данных клиента в сервисе: %d", id)
+	if err := applyClientType(s.repo, &client); err != nil {
+		return err
+	}
 	return s.repo.UpdateClient(id, client)
 }
 
@@ -46,6 +58,14 @@ func (s *ClientService) GetClientCars(clientId int) ([]models.Car, error) {
 
 func (s *ClientService) AddCarToClient(clientId int, car models.Car) error {
 	logger.Debug("Добавление автомобиля клиенту в сервисе: %d", clientId)
+	number, err := plate.Parse(car.Number)
+	if err != nil {
+		return err
+	}
+	car.Number = number
+	if err := validateCar(&car); err != nil {
+		return err
+	}
 	return s.repo.AddCarToClient(clientId, car)
 }
 
@@ -54,9 +74,9 @@ func (s *ClientService) GetTypes() ([]string, error) {
 	return s.repo.GetClientTypes()
 }
 
-func (s *ClientService) UploadCarsFromExcel(clientId int, fileData []byte) error {
+func (s *ClientService) ImportCars(clientId int, fileName string, fileData []byte, opts models.CarImportOptions) (models.CarImportResult, error) {
 	carService := NewCarService(s.repo)
-	return carService.UploadCarsFromExcel(clientId, fileData)
+	return carService.ImportCars(clientId, fileName, fileData, opts)
 }
 
 func (s *ClientService) GetCarsTemplate() (*bytes.Buffer, error) {
@@ -65,10 +85,19 @@ func (s *ClientService) GetCarsTemplate() (*bytes.Buffer, error) {
 }
 
 func (s *ClientService) WhooseCar(car string) ([]models.Client, error) {
-	return s.repo.WhooseCar(car)
+	return s.repo.WhooseCar(plate.Normalize(car))
 }
 
 func (s *ClientService) OnlineDate(date *models.OnlineDate) error {
+	// Запись привязываем к клиенту, за которым машина числится сейчас
+	date.CarNumber = plate.Normalize(date.CarNumber)
+	clientID, err := s.repo.GetCarOwnerAt(date.CarNumber, time.Now())
+	if err != nil {
+		return err
+	}
+	if clientID != 0 {
+		date.ClientID = &clientID
+	}
 	return s.repo.OnlineDate(date)
 }
 func (s *ClientService) GetOnlineDate() ([]models.OnlineDate, error) {
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/phone"
+	"strings"
+)
+
+func (s *ClientService) GetContacts(clientID int) ([]models.ClientContact, error) {
+	return s.repo.GetClientContacts(clientID)
+}
+
+func (s *ClientService) CreateContact(contact models.ClientContact) (int, error) {
+	logger.Debug("Создание контакта клиента ID:%d в сервисе", contact.ClientID)
+	if err := validateContact(&contact); err != nil {
+		return 0, err
+	}
+	return s.repo.CreateClientContact(contact)
+}
+
+func (s *ClientService) UpdateContact(contact models.ClientContact) error {
+	logger.Debug("Обновление контакта ID:%d в сервисе", contact.ID)
+	if err := validateContact(&contact); err != nil {
+		return err
+	}
+	return s.repo.UpdateClientContact(contact)
+}
+
+func (s *ClientService) DeleteContact(clientID, id int) error {
+	return s.repo.DeleteClientContact(clientID, id)
+}
+
+// FindByPhone ищет клиентов по любому телефону контакта, номер можно вводить в свободной форме
+func (s *ClientService) FindByPhone(number string) ([]models.Client, error) {
+	normalized, err := phone.Normalize(number)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindClientsByPhone(normalized)
+}
+
+// validateContact проверяет контакт и приводит телефоны к E.164
+func validateContact(contact *models.ClientContact) error {
+	contact.Name = strings.TrimSpace(contact.Name)
+	if contact.Name == "" {
+		return fmt.Errorf("не указано имя контакта")
+	}
+
+	if contact.Role == "" {
+		contact.Role = models.ContactOther
+	}
+	if !isContactRole(contact.Role) {
+		return fmt.Errorf("неизвестная роль контакта '%s', допустимые: %s", contact.Role, strings.Join(models.ContactRoles, ", "))
+	}
+
+	phones := make([]string, 0, len(contact.Phones))
+	seen := make(map[string]bool)
+	for _, number := range contact.Phones {
+		if strings.TrimSpace(number) == "" {
+			continue
+		}
+		normalized, err := phone.Normalize(number)
+		if err != nil {
+			return err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			phones = append(phones, normalized)
+		}
+	}
+	contact.Phones = phones
+
+	contact.Email = strings.TrimSpace(contact.Email)
+	if contact.Email != "" && !strings.Contains(contact.Email, "@") {
+		return fmt.Errorf("неверный email '%s'", contact.Email)
+	}
+	contact.Messenger = strings.TrimSpace(contact.Messenger)
+
+	if len(contact.Phones) == 0 && contact.Email == "" && contact.Messenger == "" {
+		return fmt.Errorf("укажите телефон, email или мессенджер контакта")
+	}
+	if contact.NotifySMS && len(contact.Phones) == 0 {
+		return fmt.Errorf("для SMS-уведомлений нужен телефон")
+	}
+	if contact.NotifyEmail && contact.Email == "" {
+		return fmt.Errorf("для уведомлений по email нужен email")
+	}
+	if contact.NotifyMessenger && contact.Messenger == "" {
+		return fmt.Errorf("для уведомлений в мессенджер нужен ник")
+	}
+	return nil
+}
+
+func isContactRole(role string) bool {
+	for _, r := range models.ContactRoles {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/fuzzy"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/phone"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Организационно-правовые формы и кавычки не влияют на сравнение названий
+var (
+	legalFormPattern = regexp.MustCompile(`(^|\s)(ооо|оао|зао|пао|ао|ип|тк|ooo)(\s|$)`)
+	nonWordPattern   = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+)
+
+// normalizeClientName приводит название клиента к виду для сравнения: "ООО «Трак-Сервис»" -> "трак сервис"
+func normalizeClientName(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(name), "ё", "е")
+	name = strings.TrimSpace(nonWordPattern.ReplaceAllString(name, " "))
+	// Формы могут идти подряд, поэтому заменяем до стабилизации
+	for {
+		replaced := strings.TrimSpace(legalFormPattern.ReplaceAllString(name, " "))
+		if replaced == name {
+			return name
+		}
+		name = replaced
+	}
+}
+
+// similarNames сообщает, что названия совпадают с точностью до опечаток:
+// для коротких названий опечатки не допускаются, для длинных - одна или две
+func similarNames(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	length := min(len([]rune(a)), len([]rune(b)))
+	switch {
+	case length >= 12:
+		return fuzzy.Distance(a, b) <= 2
+	case length >= 6:
+		return fuzzy.Distance(a, b) <= 1
+	default:
+		return false
+	}
+}
+
+// FindDuplicates ищет клиентов, которые похожи на одного и того же: совпадающий телефон,
+// похожее название, общая машина или одинаковый ИНН договора
+func (s *ClientService) FindDuplicates() ([]models.ClientDuplicate, error) {
+	logger.Debug("Поиск дублей клиентов")
+
+	clients, err := s.repo.GetAllClients()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+
+	names := make(map[int]string, len(clients))
+	for _, client := range clients {
+		names[client.ID] = client.Name
+	}
+
+	type pair struct{ a, b int }
+	reasons := make(map[pair][]string)
+	var order []pair
+	addReason := func(a, b int, reason string) {
+		if a > b {
+			a, b = b, a
+		}
+		p := pair{a, b}
+		if _, ok := reasons[p]; !ok {
+			order = append(order, p)
+		}
+		reasons[p] = append(reasons[p], reason)
+	}
+
+	normalized := make([]string, len(clients))
+	phones := make([][]string, len(clients))
+	for i, client := range clients {
+		normalized[i] = normalizeClientName(client.Name)
+		for _, number := range []string{client.OwnerPhone, client.ManagerPhone} {
+			if key := phone.Key(number); key != "" {
+				phones[i] = append(phones[i], key)
+			}
+		}
+	}
+
+	for i := range clients {
+		for j := i + 1; j < len(clients); j++ {
+			if similarNames(normalized[i], normalized[j]) {
+				addReason(clients[i].ID, clients[j].ID, "похожее название")
+			}
+			if number := commonPhone(phones[i], phones[j]); number != "" {
+				addReason(clients[i].ID, clients[j].ID, "совпадает телефон "+number)
+			}
+		}
+	}
+
+	links, err := s.repo.GetClientLinks()
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		addReason(link.ClientID, link.DuplicateID, link.Reason)
+	}
+
+	duplicates := make([]models.ClientDuplicate, 0, len(order))
+	for _, p := range order {
+		duplicates = append(duplicates, models.ClientDuplicate{
+			ClientID:      p.a,
+			ClientName:    names[p.a],
+			DuplicateID:   p.b,
+			DuplicateName: names[p.b],
+			Reasons:       reasons[p],
+		})
+	}
+	// Пары с несколькими совпадениями вероятнее всего один клиент
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return len(duplicates[i].Reasons) > len(duplicates[j].Reasons)
+	})
+
+	logger.Info("Найдено возможных дублей клиентов: %d", len(duplicates))
+	return duplicates, nil
+}
+
+func commonPhone(a, b []string) string {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return x
+			}
+		}
+	}
+	return ""
+}
+
+// Merge вливает клиента sourceID в targetID. Машины, заказы и онлайн-записи переносятся,
+// по старому ID клиента остается переадресация на targetID.
+func (s *ClientService) Merge(targetID, sourceID, userID int) (models.ClientMergeResult, error) {
+	logger.Debug("Объединение клиента ID:%d с клиентом ID:%d", sourceID, targetID)
+	if sourceID == 0 {
+		return models.ClientMergeResult{}, fmt.Errorf("не указан объединяемый клиент")
+	}
+	if targetID == sourceID {
+		return models.ClientMergeResult{}, fmt.Errorf("нельзя объединить клиента с самим собой")
+	}
+	if sourceID == models.CashClientID {
+		return models.ClientMergeResult{}, fmt.Errorf("клиента налички нельзя влить в другого клиента")
+	}
+	if targetID == models.CashClientID {
+		return models.ClientMergeResult{}, fmt.Errorf("нельзя влить клиента в клиента налички")
+	}
+	return s.repo.MergeClients(targetID, sourceID, userID)
+}
+
+// resolveClientID возвращает ID клиента с учетом объединения: для влитого клиента - ID оставшегося
+func resolveClientID(repo Repository, id int) (int, error) {
+	if id == 0 {
+		return 0, nil
+	}
+	intoID, err := repo.GetClientRedirect(id)
+	if err != nil {
+		return 0, err
+	}
+	if intoID != 0 {
+		logger.Debug("Клиент ID:%d влит в клиента ID:%d", id, intoID)
+		return intoID, nil
+	}
+	return id, nil
+}
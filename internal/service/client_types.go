@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"strings"
+	"unicode/utf8"
+)
+
+// normalizeClientType приводит название типа к виду, в котором оно хранится в справочнике
+func normalizeClientType(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+// applyClientType проверяет тип клиента по справочнику и подставляет договор типа, если договор не указан
+func applyClientType(repo Repository, client *models.Client) error {
+	client.ClientType = normalizeClientType(client.ClientType)
+	if client.ClientType == "" {
+		return fmt.Errorf("не указан тип клиента")
+	}
+	clientType, err := repo.GetClientTypeByName(client.ClientType)
+	if err != nil {
+		return err
+	}
+
+	if client.ContractID == 0 {
+		if clientType.DefaultContractID == nil {
+			return fmt.Errorf("для типа '%s' не задан договор по умолчанию, укажите договор клиента", clientType.Name)
+		}
+		client.ContractID = *clientType.DefaultContractID
+	}
+	return nil
+}
+
+func (s *ClientService) GetTypeList() ([]models.ClientType, error) {
+	return s.repo.GetAllClientTypes()
+}
+
+func (s *ClientService) CreateType(clientType models.ClientType) (int, error) {
+	logger.Debug("Создание типа клиента в сервисе")
+	if err := validateClientType(&clientType); err != nil {
+		return 0, err
+	}
+	return s.repo.CreateClientType(clientType)
+}
+
+// UpdateType обновляет тип, новое название сразу получают все клиенты и договоры этого типа
+func (s *ClientService) UpdateType(id int, clientType models.ClientType) error {
+	logger.Debug("Обновление типа клиента ID:%d в сервисе", id)
+	if err := validateClientType(&clientType); err != nil {
+		return err
+	}
+	if err := s.checkCashType(id, clientType.Name); err != nil {
+		return err
+	}
+	return s.repo.UpdateClientType(id, clientType)
+}
+
+func (s *ClientService) DeleteType(id int) error {
+	if err := s.checkCashType(id, ""); err != nil {
+		return err
+	}
+	return s.repo.DeleteClientType(id)
+}
+
+// checkCashType не дает переименовать или удалить тип для расчетов наличными, по нему работает розница
+func (s *ClientService) checkCashType(id int, newName string) error {
+	types, err := s.repo.GetAllClientTypes()
+	if err != nil {
+		return err
+	}
+	for _, t := range types {
+		if t.ID == id && t.Name == models.ClientTypeCash && newName != models.ClientTypeCash {
+			return fmt.Errorf("тип '%s' используется для расчетов наличными, его нельзя переименовать или удалить", models.ClientTypeCash)
+		}
+	}
+	return nil
+}
+
+func validateClientType(clientType *models.ClientType) error {
+	clientType.Name = normalizeClientType(clientType.Name)
+	if clientType.Name == "" {
+		return fmt.Errorf("не указано название типа клиента")
+	}
+	if utf8.RuneCountInString(clientType.Name) > 50 {
+		return fmt.Errorf("название типа клиента длиннее 50 символов")
+	}
+	clientType.Description = strings.TrimSpace(clientType.Description)
+	if clientType.DefaultContractID != nil && *clientType.DefaultContractID == 0 {
+		clientType.DefaultContractID = nil
+	}
+	return nil
+}
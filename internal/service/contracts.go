@@ -16,6 +16,10 @@ func NewContractService(repo Repository) *ContractService {
 
 func (s *ContractService) Create(contract models.Contract) (int, error) {
 	logger.Debug("Создание нового договора в сервисе")
+	contract.ClientType = normalizeClientType(contract.ClientType)
+	if _, err := s.repo.GetClientTypeByName(contract.ClientType); err != nil {
+		return 0, err
+	}
 	return s.repo.CreateContract(contract)
 }
 
@@ -27,6 +31,10 @@ func (s *ContractService) GetAll() ([]models.Contract, error) {
 
 func (s *ContractService) Update(id int, contract models.Contract) error {
 	logger.Debug("Обновление договора в сервисе: %d", id)
+	contract.ClientType = normalizeClientType(contract.ClientType)
+	if _, err := s.repo.GetClientTypeByName(contract.ClientType); err != nil {
+		return err
+	}
 	return s.repo.UpdateContract(id, contract)
 }
 
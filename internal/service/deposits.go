@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+	"strings"
+)
+
+type DepositService struct {
+	repo Repository
+}
+
+func NewDepositService(repo Repository) *DepositService {
+	return &DepositService{repo: repo}
+}
+
+// GetAccount возвращает баланс предоплаты клиента и историю движений
+func (s *DepositService) GetAccount(clientID int) (models.DepositAccount, error) {
+	logger.Debug("Получение предоплаты клиента ID:%d в сервисе", clientID)
+	clientID, err := resolveClientID(s.repo, clientID)
+	if err != nil {
+		return models.DepositAccount{}, err
+	}
+
+	account, err := s.repo.GetDepositAccount(clientID)
+	if err != nil {
+		return account, err
+	}
+	account.Available = account.Balance + account.OverdraftLimit
+	return account, nil
+}
+
+// validateDepositOperation проверяет сумму и подготавливает движение
+func validateDepositOperation(clientID, userID int, op models.DepositOperation) (models.DepositMovement, error) {
+	if op.Amount <= 0 {
+		return models.DepositMovement{}, fmt.Errorf("сумма должна быть больше нуля")
+	}
+	return models.DepositMovement{
+		ClientID:    clientID,
+		Amount:      op.Amount,
+		Document:    strings.TrimSpace(op.Document),
+		Description: strings.TrimSpace(op.Description),
+		UserID:      optionalID(userID),
+	}, nil
+}
+
+// TopUp пополняет предоплату клиента
+func (s *DepositService) TopUp(clientID, userID int, op models.DepositOperation) (int, error) {
+	logger.Debug("Пополнение предоплаты клиента ID:%d в сервисе", clientID)
+	clientID, err := resolveClientID(s.repo, clientID)
+	if err != nil {
+		return 0, err
+	}
+	m, err := validateDepositOperation(clientID, userID, op)
+	if err != nil {
+		return 0, err
+	}
+	m.Type = models.DepositTopUp
+	return s.repo.AddDepositMovement(m)
+}
+
+// Withdraw выдает клиенту деньги с предоплаты, больше остатка выдать нельзя
+func (s *DepositService) Withdraw(clientID, userID int, op models.DepositOperation) (int, error) {
+	logger.Debug("Выдача с предоплаты клиента ID:%d в сервисе", clientID)
+	clientID, err := resolveClientID(s.repo, clientID)
+	if err != nil {
+		return 0, err
+	}
+	m, err := validateDepositOperation(clientID, userID, op)
+	if err != nil {
+		return 0, err
+	}
+	m.Type = models.DepositWithdrawal
+	m.Amount = -m.Amount
+	return s.repo.AddDepositMovement(m)
+}
+
+// SetOverdraft задает, на сколько баланс может уйти в минус при списании заказов
+func (s *DepositService) SetOverdraft(clientID int, limit money.Amount) error {
+	logger.Debug("Изменение допустимого минуса по предоплате клиента ID:%d в сервисе", clientID)
+	if limit < 0 {
+		return fmt.Errorf("допустимый минус не может быть отрицательным")
+	}
+	clientID, err := resolveClientID(s.repo, clientID)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetDepositOverdraft(clientID, limit)
+}
+
+// OrderWarning возвращает предупреждение, если после заказа с предоплаты баланс клиента ушел в минус
+func (s *DepositService) OrderWarning(orderID int) string {
+	balance, prepaid, err := s.repo.GetOrderDepositBalance(orderID)
+	if err != nil || !prepaid || balance >= 0 {
+		return ""
+	}
+	logger.Warning("Баланс предоплаты клиента по заказу ID:%d ушел в минус: %s", orderID, balance)
+	return fmt.Sprintf("баланс предоплаты клиента отрицательный: %s", balance)
+}
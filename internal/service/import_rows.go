@@ -0,0 +1,48 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go-hinomontaj/pkg/logger"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// readImportRows читает строки загружаемого файла: первый лист Excel или CSV.
+// CSV из Excel обычно разделен точкой с запятой, разделитель определяется по строке заголовков.
+func readImportRows(fileName string, fileData []byte) ([][]string, error) {
+	if !strings.HasSuffix(strings.ToLower(fileName), ".csv") {
+		f, err := excelize.OpenReader(bytes.NewReader(fileData))
+		if err != nil {
+			logger.Error("Ошибка при открытии Excel файла: %v", err)
+			return nil, fmt.Errorf("ошибка при открытии Excel файла: %w", err)
+		}
+		defer f.Close()
+
+		rows, err := f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			logger.Error("Ошибка при чтении листа Excel: %v", err)
+			return nil, fmt.Errorf("ошибка при чтении листа Excel: %w", err)
+		}
+		return rows, nil
+	}
+
+	data := bytes.TrimPrefix(fileData, []byte("\xef\xbb\xbf"))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		logger.Error("Ошибка при чтении CSV файла: %v", err)
+		return nil, fmt.Errorf("ошибка при чтении CSV файла: %w", err)
+	}
+	return rows, nil
+}
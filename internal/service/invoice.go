@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"time"
+)
+
+// DocumentFonts шрифты с кириллицей для PDF документов
+type DocumentFonts struct {
+	Regular string
+	Bold    string
+}
+
+type InvoiceService struct {
+	repo    Repository
+	company models.Company
+	fonts   DocumentFonts
+}
+
+func NewInvoiceService(repo Repository, company models.Company, fonts DocumentFonts) *InvoiceService {
+	return &InvoiceService{repo: repo, company: company, fonts: fonts}
+}
+
+// getContract находит договор по ID
+func (s *InvoiceService) getContract(id int) (models.Contract, error) {
+	contracts, err := s.repo.GetAllContracts()
+	if err != nil {
+		return models.Contract{}, err
+	}
+	for _, c := range contracts {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return models.Contract{}, fmt.Errorf("договор с ID %d не найден", id)
+}
+
+// Preview показывает, что попадет в счет по договору за период [start, end), ничего не сохраняя
+func (s *InvoiceService) Preview(contractID int, start, end time.Time) (models.Invoice, error) {
+	logger.Debug("Предпросмотр счета по договору ID:%d в сервисе", contractID)
+	contract, err := s.getContract(contractID)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	lines, orders, err := s.repo.GetInvoiceDraft(contractID, start, end)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	invoice := models.Invoice{
+		ContractID:     contract.ID,
+		ContractNumber: contract.Number,
+		CompanyName:    contract.ClientCompanyName,
+		PeriodStart:    start,
+		PeriodEnd:      end.AddDate(0, 0, -1),
+		OrdersCount:    orders,
+		Lines:          lines,
+	}
+	for _, line := range lines {
+		invoice.TotalAmount += line.Amount
+	}
+	return invoice, nil
+}
+
+// Create выставляет счет по выполненным заказам договора за период [start, end)
+func (s *InvoiceService) Create(contractID int, start, end time.Time, userID int) (int, error) {
+	logger.Debug("Выставление счета по договору ID:%d в сервисе", contractID)
+	if contractID == 0 {
+		return 0, fmt.Errorf("не указан договор")
+	}
+	return s.repo.CreateInvoice(contractID, start, end, userID)
+}
+
+func (s *InvoiceService) GetAll(contractID int) ([]models.Invoice, error) {
+	logger.Debug("Получение счетов в сервисе")
+	return s.repo.GetInvoices(contractID)
+}
+
+func (s *InvoiceService) GetById(id int) (models.Invoice, error) {
+	logger.Debug("Получение счета ID:%d в сервисе", id)
+	return s.repo.GetInvoiceById(id)
+}
+
+// Delete отменяет счет, заказы из него можно выставить заново
+func (s *InvoiceService) Delete(id int) error {
+	logger.Debug("Удаление счета ID:%d в сервисе", id)
+	return s.repo.DeleteInvoice(id)
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// Колонки таблицы услуг в счете и акте, ширина в мм для PDF
+var invoiceTableHeaders = []string{"№", "Машина", "Услуга", "Кол-во", "Ед.", "Цена", "Сумма"}
+var invoiceTableWidths = []float64{10, 28, 72, 16, 12, 24, 28}
+var invoiceTableAligns = []string{"C", "L", "L", "R", "C", "R", "R"}
+
+// invoiceDocument содержимое счета или акта, общее для PDF и Excel
+type invoiceDocument struct {
+	title      string
+	parties    [][2]string // подпись и текст: Поставщик, Покупатель
+	basis      string
+	lines      []models.InvoiceLine
+	total      money.Amount
+	vat        string
+	summary    []string
+	signatures bool
+}
+
+// requisites собирает реквизиты стороны в одну строку, пустые пропускаются
+func requisites(name, inn, kpp, address string) string {
+	parts := []string{name}
+	if inn != "" {
+		parts = append(parts, "ИНН "+inn)
+	}
+	if kpp != "" {
+		parts = append(parts, "КПП "+kpp)
+	}
+	if address != "" {
+		parts = append(parts, address)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// buildInvoiceDocument собирает счет на оплату или акт выполненных работ по сохраненному счету
+func (s *InvoiceService) buildInvoiceDocument(id int, kind string) (invoiceDocument, error) {
+	if kind != models.DocumentInvoice && kind != models.DocumentAct {
+		return invoiceDocument{}, fmt.Errorf("неизвестный документ '%s', доступны: %s, %s", kind, models.DocumentInvoice, models.DocumentAct)
+	}
+
+	invoice, err := s.repo.GetInvoiceById(id)
+	if err != nil {
+		return invoiceDocument{}, err
+	}
+	contract, err := s.getContract(invoice.ContractID)
+	if err != nil {
+		return invoiceDocument{}, err
+	}
+
+	seller := requisites(s.company.Name, s.company.INN, s.company.KPP, s.company.Address)
+	buyer := requisites(contract.ClientCompanyName, contract.ClientCompanyINN, contract.ClientCompanyKPP, contract.ClientCompanyAddress)
+	date := invoice.CreatedAt.Format("02.01.2006")
+	period := fmt.Sprintf("%s - %s", invoice.PeriodStart.Format("02.01.2006"), invoice.PeriodEnd.Format("02.01.2006"))
+
+	doc := invoiceDocument{
+		basis: fmt.Sprintf("Договор № %s, период %s", contract.Number, period),
+		lines: invoice.Lines,
+		total: invoice.TotalAmount,
+		vat:   "Без НДС",
+	}
+	if s.company.VATRate > 0 {
+		vat := invoice.TotalAmount.MulDiv(s.company.VATRate, 100+s.company.VATRate)
+		doc.vat = fmt.Sprintf("В том числе НДС %d%%: %s", s.company.VATRate, money.Format(vat))
+	}
+
+	if kind == models.DocumentInvoice {
+		doc.title = fmt.Sprintf("Счет на оплату № %d от %s", invoice.ID, date)
+		bank := fmt.Sprintf("р/с %s в %s, БИК %s, к/с %s", s.company.Account, s.company.Bank, s.company.BIK, s.company.CorrAccount)
+		doc.parties = [][2]string{{"Поставщик", seller + "\n" + bank}, {"Покупатель", buyer}}
+	} else {
+		doc.title = fmt.Sprintf("Акт выполненных работ № %d от %s", invoice.ID, date)
+		doc.parties = [][2]string{{"Исполнитель", seller}, {"Заказчик", buyer}}
+		doc.signatures = true
+	}
+
+	doc.summary = []string{
+		fmt.Sprintf("Всего наименований %d, на сумму %s руб.", len(doc.lines), money.Format(invoice.TotalAmount)),
+		money.InWords(invoice.TotalAmount),
+	}
+	if doc.signatures {
+		doc.summary = append(doc.summary,
+			"Вышеперечисленные услуги выполнены полностью и в срок. Заказчик претензий по объему, качеству и срокам оказания услуг не имеет.")
+	}
+	return doc, nil
+}
+
+// pdfLineCount считает, сколько строк займет текст в ячейке ширины width.
+// SplitText из fpdf не работает с UTF-8 шрифтами, поэтому переносим по словам сами.
+func pdfLineCount(pdf *fpdf.Fpdf, text string, width float64) int {
+	const cellPadding = 2.0
+	lines, current := 1, ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && pdf.GetStringWidth(candidate) > width-cellPadding {
+			lines++
+			candidate = word
+		}
+		current = candidate
+	}
+	return lines
+}
+
+// pdfTableRow выводит строку таблицы услуг счета
+func pdfTableRow(pdf *fpdf.Fpdf, values []string) {
+	pdfTableRowWidths(pdf, invoiceTableWidths, invoiceTableAligns, values)
+}
+
+// pdfTableRowWidths выводит строку таблицы, высота строки подстраивается под самый длинный текст
+func pdfTableRowWidths(pdf *fpdf.Fpdf, widths []float64, aligns []string, values []string) {
+	const lineHeight = 5.0
+	lines := 1
+	for i, v := range values {
+		if n := pdfLineCount(pdf, v, widths[i]); n > lines {
+			lines = n
+		}
+	}
+	height := lineHeight * float64(lines)
+
+	_, pageHeight := pdf.GetPageSize()
+	left, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	x, y := left, pdf.GetY()
+	for i, v := range values {
+		pdf.Rect(x, y, widths[i], height, "D")
+		pdf.SetXY(x, y)
+		pdf.MultiCell(widths[i], lineHeight, v, "", aligns[i], false)
+		x += widths[i]
+	}
+	pdf.SetXY(left, y+height)
+}
+
+// newDocumentPDF создает страницу A4 со шрифтом "doc" с кириллицей
+func newDocumentPDF(fonts DocumentFonts) (*fpdf.Fpdf, error) {
+	regular, err := os.ReadFile(fonts.Regular)
+	if err != nil {
+		logger.Error("Не найден шрифт для PDF %s: %v", fonts.Regular, err)
+		return nil, fmt.Errorf("не найден шрифт для PDF: %s", fonts.Regular)
+	}
+	bold, err := os.ReadFile(fonts.Bold)
+	if err != nil {
+		bold = regular
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("doc", "", regular)
+	pdf.AddUTF8FontFromBytes("doc", "B", bold)
+	pdf.SetMargins(10, 10, 10)
+	pdf.AddPage()
+	return pdf, nil
+}
+
+// InvoicePDF формирует счет или акт в PDF
+func (s *InvoiceService) InvoicePDF(id int, kind string) (*bytes.Buffer, error) {
+	logger.Debug("Формирование PDF документа '%s' по счету ID:%d", kind, id)
+	doc, err := s.buildInvoiceDocument(id, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf, err := newDocumentPDF(s.fonts)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf.SetFont("doc", "B", 14)
+	pdf.MultiCell(0, 8, doc.title, "", "L", false)
+	pdf.Ln(3)
+
+	for _, party := range doc.parties {
+		pdf.SetFont("doc", "B", 10)
+		pdf.CellFormat(30, 5, party[0]+":", "", 0, "L", false, 0, "")
+		pdf.SetFont("doc", "", 10)
+		pdf.MultiCell(0, 5, party[1], "", "L", false)
+		pdf.Ln(1)
+	}
+	pdf.SetFont("doc", "B", 10)
+	pdf.CellFormat(30, 5, "Основание:", "", 0, "L", false, 0, "")
+	pdf.SetFont("doc", "", 10)
+	pdf.MultiCell(0, 5, doc.basis, "", "L", false)
+	pdf.Ln(3)
+
+	pdf.SetFont("doc", "B", 9)
+	pdfTableRow(pdf, invoiceTableHeaders)
+	pdf.SetFont("doc", "", 9)
+	for i, line := range doc.lines {
+		pdfTableRow(pdf, []string{
+			strconv.Itoa(i + 1), line.VehicleNumber, line.ServiceName, strconv.Itoa(line.Quantity), "шт",
+			money.Format(line.Price), money.Format(line.Amount),
+		})
+	}
+	pdf.Ln(2)
+
+	pdf.SetFont("doc", "B", 10)
+	pdf.CellFormat(0, 6, "Итого: "+money.Format(doc.total), "", 1, "R", false, 0, "")
+	pdf.SetFont("doc", "", 10)
+	pdf.CellFormat(0, 6, doc.vat, "", 1, "R", false, 0, "")
+	pdf.Ln(2)
+	for i, line := range doc.summary {
+		if i == 1 {
+			pdf.SetFont("doc", "B", 10)
+		} else {
+			pdf.SetFont("doc", "", 10)
+		}
+		pdf.MultiCell(0, 5, line, "", "L", false)
+	}
+
+	pdf.Ln(12)
+	pdf.SetFont("doc", "", 10)
+	if doc.signatures {
+		pdf.CellFormat(95, 6, "Исполнитель ____________________", "", 0, "L", false, 0, "")
+		pdf.CellFormat(95, 6, "Заказчик ____________________", "", 1, "L", false, 0, "")
+	} else {
+		pdf.CellFormat(0, 6, "Руководитель ____________________     Бухгалтер ____________________", "", 1, "L", false, 0, "")
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := pdf.Output(buffer); err != nil {
+		logger.Error("Ошибка при формировании PDF: %v", err)
+		return nil, fmt.Errorf("ошибка при формировании PDF: %w", err)
+	}
+
+	logger.Info("PDF документ '%s' по счету ID:%d сформирован", kind, id)
+	return buffer, nil
+}
+
+// InvoiceExcel формирует счет или акт в Excel
+func (s *InvoiceService) InvoiceExcel(id int, kind string) (*bytes.Buffer, error) {
+	logger.Debug("Формирование Excel документа '%s' по счету ID:%d", kind, id)
+	doc, err := s.buildInvoiceDocument(id, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Счет"
+	if kind == models.DocumentAct {
+		sheet = "Акт"
+	}
+	f.SetSheetName("Sheet1", sheet)
+
+	f.SetCellValue(sheet, "A1", doc.title)
+	row := 3
+	for _, party := range doc.parties {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), party[0])
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), strings.ReplaceAll(party[1], "\n", "; "))
+		row++
+	}
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "Основание")
+	f.SetCellValue(sheet, fmt.Sprintf("C%d", row), doc.basis)
+	row += 2
+
+	style, err := newHeaderStyle(f)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании стиля: %w", err)
+	}
+	for i, header := range invoiceTableHeaders {
+		cell, _ := excelize.CoordinatesToCellName(i+1, row)
+		f.SetCellValue(sheet, cell, header)
+		f.SetCellStyle(sheet, cell, cell, style)
+	}
+	row++
+
+	// Числа пишутся числами, чтобы с файлом можно было работать дальше
+	for i, line := range doc.lines {
+		values := []interface{}{i + 1, line.VehicleNumber, line.ServiceName, line.Quantity, "шт", line.Price.Rubles(), line.Amount.Rubles()}
+		for col, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, row)
+			f.SetCellValue(sheet, cell, value)
+		}
+		row++
+	}
+
+	f.SetCellValue(sheet, fmt.Sprintf("F%d", row), "Итого")
+	f.SetCellValue(sheet, fmt.Sprintf("G%d", row), doc.total.Rubles())
+	f.SetCellValue(sheet, fmt.Sprintf("F%d", row+1), doc.vat)
+	row += 3
+	for _, line := range doc.summary {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), line)
+		row++
+	}
+	if doc.signatures {
+		row++
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "Исполнитель ____________________")
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), "Заказчик ____________________")
+	}
+
+	f.SetColWidth(sheet, "A", "A", 6)
+	f.SetColWidth(sheet, "B", "B", 14)
+	f.SetColWidth(sheet, "C", "C", 40)
+	f.SetColWidth(sheet, "D", "E", 8)
+	f.SetColWidth(sheet, "F", "G", 14)
+
+	buffer := new(bytes.Buffer)
+	if err := f.Write(buffer); err != nil {
+		logger.Error("Ошибка при сохранении файла: %v", err)
+		return nil, fmt.Errorf("ошибка при сохранении файла: %w", err)
+	}
+
+	logger.Info("Excel документ '%s' по счету ID:%d сформирован", kind, id)
+	return buffer, nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/phone"
+	"go-hinomontaj/pkg/plate"
+	"strings"
+)
+
+type LoyaltyService struct {
+	repo Repository
+}
+
+func NewLoyaltyService(repo Repository) *LoyaltyService {
+	return &LoyaltyService{repo: repo}
+}
+
+// GetCustomers ищет покупателей по имени, карте, телефону или номеру машины в свободной форме
+func (s *LoyaltyService) GetCustomers(search string) ([]models.RetailCustomer, error) {
+	search = strings.TrimSpace(search)
+	customerPhone, _ := phone.Normalize(search)
+	var number string
+	if strings.ContainsAny(search, "0123456789") {
+		number = plate.Normalize(search)
+	}
+	return s.repo.GetRetailCustomers(search, customerPhone, number)
+}
+
+func (s *LoyaltyService) GetCustomer(id int) (models.RetailCustomer, error) {
+	return s.repo.GetRetailCustomerById(id)
+}
+
+func (s *LoyaltyService) GetCustomerOrders(id int) ([]models.Order, error) {
+	if _, err := s.repo.GetRetailCustomerById(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRetailCustomerOrders(id)
+}
+
+func (s *LoyaltyService) CreateCustomer(customer models.RetailCustomer) (int, error) {
+	logger.Debug("Создание розничного покупателя в сервисе")
+	if err := validateRetailCustomer(&customer); err != nil {
+		return 0, err
+	}
+	return s.repo.CreateRetailCustomer(customer)
+}
+
+func (s *LoyaltyService) UpdateCustomer(id int, customer models.RetailCustomer) error {
+	logger.Debug("Обновление розничного покупателя ID:%d в сервисе", id)
+	if err := validateRetailCustomer(&customer); err != nil {
+		return err
+	}
+	return s.repo.UpdateRetailCustomer(id, customer)
+}
+
+func (s *LoyaltyService) DeleteCustomer(id int) error {
+	return s.repo.DeleteRetailCustomer(id)
+}
+
+func (s *LoyaltyService) GetRules() ([]models.LoyaltyRule, error) {
+	return s.repo.GetLoyaltyRules(false)
+}
+
+func (s *LoyaltyService) CreateRule(rule models.LoyaltyRule) (int, error) {
+	logger.Debug("Создание правила лояльности в сервисе")
+	if err := validateLoyaltyRule(&rule); err != nil {
+		return 0, err
+	}
+	return s.repo.CreateLoyaltyRule(rule)
+}
+
+func (s *LoyaltyService) UpdateRule(id int, rule models.LoyaltyRule) error {
+	logger.Debug("Обновление правила лояльности ID:%d в сервисе", id)
+	if err := validateLoyaltyRule(&rule); err != nil {
+		return err
+	}
+	return s.repo.UpdateLoyaltyRule(id, rule)
+}
+
+func (s *LoyaltyService) DeleteRule(id int) error {
+	return s.repo.DeleteLoyaltyRule(id)
+}
+
+// Preview считает скидки по заказу так же, как при создании, но ничего не сохраняет
+func (s *LoyaltyService) Preview(order models.Order) (models.Order, error) {
+	order.VehicleNumber = plate.Normalize(order.VehicleNumber)
+	clientID, err := resolveClientID(s.repo, order.ClientID)
+	if err != nil {
+		return order, err
+	}
+	if clientID == 0 {
+		return order, fmt.Errorf("не указан клиент заказа")
+	}
+	order.ClientID = clientID
+
+	if err := applyLoyalty(s.repo, &order); err != nil {
+		return order, err
+	}
+	return order, nil
+}
+
+// applyLoyalty находит розничного покупателя заказа за наличку и применяет к услугам правила лояльности.
+// Цены услуг считаются ценами по прайсу. Новый покупатель с телефоном или картой заводится вместе с заказом.
+func applyLoyalty(repo Repository, order *models.Order) error {
+	client, err := repo.GetClientById(order.ClientID)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении клиента заказа: %w", err)
+	}
+	if client.ClientType != models.ClientTypeCash {
+		order.RetailCustomerID = nil
+		order.CustomerPhone, order.CustomerCard = "", ""
+		return nil
+	}
+
+	customerID, known, err := identifyRetailCustomer(repo, order)
+	if err != nil || !known {
+		return err
+	}
+	order.RetailCustomerID = optionalID(customerID)
+
+	history := models.LoyaltyHistory{ServiceCounts: map[int]int{}}
+	if customerID != 0 {
+		if history, err = repo.GetLoyaltyHistory(customerID); err != nil {
+			return err
+		}
+	}
+	rules, err := repo.GetLoyaltyRules(true)
+	if err != nil {
+		return err
+	}
+
+	discount := applyLoyaltyRules(order.Services, history, rules)
+	order.DiscountAmount = discount
+	order.TotalAmount = roundMoney(order.TotalAmount - discount)
+	if order.TotalAmount < 0 {
+		order.TotalAmount = 0
+	}
+	if discount > 0 {
+		logger.Info("Скидка по программе лояльности для покупателя %d: %.2f", customerID, discount)
+	}
+	return nil
+}
+
+// identifyRetailCustomer узнает покупателя по ID, телефону, карте или номеру машины.
+// known=false - покупатель не назван, скидки не считаются. ID 0 при known - новый покупатель без истории,
+// его телефон и карта остаются в заказе в формате хранения, покупатель заводится в транзакции заказа.
+func identifyRetailCustomer(repo Repository, order *models.Order) (int, bool, error) {
+	if order.RetailCustomerID != nil && *order.RetailCustomerID != 0 {
+		customer, err := repo.GetRetailCustomerById(*order.RetailCustomerID)
+		if err != nil {
+			return 0, false, err
+		}
+		return customer.ID, true, nil
+	}
+
+	var customerPhone string
+	if strings.TrimSpace(order.CustomerPhone) != "" {
+		normalized, err := phone.Normalize(order.CustomerPhone)
+		if err != nil {
+			return 0, false, err
+		}
+		customerPhone = normalized
+	}
+	card := strings.TrimSpace(order.CustomerCard)
+
+	id, err := repo.FindRetailCustomer(customerPhone, card, order.VehicleNumber)
+	if err != nil || id != 0 {
+		order.CustomerPhone, order.CustomerCard = "", ""
+		return id, id != 0, err
+	}
+	order.CustomerPhone, order.CustomerCard = customerPhone, card
+	return 0, customerPhone != "" || card != "", nil
+}
+
+// applyLoyaltyRules проставляет скидки по строкам услуг и возвращает их сумму.
+// Бесплатная N-я услуга считается по всем прошлым строкам этой услуги у покупателя,
+// к остальным строкам применяется наибольшая из подходящих процентных скидок.
+func applyLoyaltyRules(services []models.OrderService, history models.LoyaltyHistory, rules []models.LoyaltyRule) float64 {
+	counts := make(map[int]int, len(history.ServiceCounts))
+	for id, count := range history.ServiceCounts {
+		counts[id] = count
+	}
+
+	var total float64
+	for i := range services {
+		line := &services[i]
+		line.Discount, line.DiscountReason = 0, ""
+		counts[line.ServiceID]++
+
+		var best *models.LoyaltyRule
+		for j := range rules {
+			rule := &rules[j]
+			if history.Visits < rule.MinVisits {
+				continue
+			}
+			if rule.ServiceID != nil && *rule.ServiceID != line.ServiceID {
+				continue
+			}
+			if rule.Type == models.LoyaltyFreeNth {
+				if rule.EveryN != nil && *rule.EveryN > 0 && counts[line.ServiceID]%*rule.EveryN == 0 {
+					best = rule
+					break
+				}
+				continue
+			}
+			if rule.Percent != nil && (best == nil || *rule.Percent > *best.Percent) {
+				best = rule
+			}
+		}
+		if best == nil {
+			continue
+		}
+
+		discount := line.Price
+		if best.Type == models.LoyaltyPercent {
+			discount = roundMoney(line.Price * *best.Percent / 100)
+		}
+		line.Price = roundMoney(line.Price - discount)
+		line.Discount = discount
+		line.DiscountReason = best.Name
+		total += discount
+	}
+	return roundMoney(total)
+}
+
+// validateRetailCustomer приводит телефон и номера машин к формату хранения
+func validateRetailCustomer(customer *models.RetailCustomer) error {
+	customer.Name = strings.TrimSpace(customer.Name)
+	customer.CardNumber = strings.TrimSpace(customer.CardNumber)
+	if strings.TrimSpace(customer.Phone) != "" {
+		normalized, err := phone.Normalize(customer.Phone)
+		if err != nil {
+			return err
+		}
+		customer.Phone = normalized
+	} else {
+		customer.Phone = ""
+	}
+
+	cars := make([]string, 0, len(customer.Cars))
+	seen := make(map[string]bool)
+	for _, number := range customer.Cars {
+		normalized := plate.Normalize(number)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		cars = append(cars, normalized)
+	}
+	customer.Cars = cars
+
+	if customer.Phone == "" && customer.CardNumber == "" && len(customer.Cars) == 0 {
+		return fmt.Errorf("укажите телефон, номер карты или машину покупателя")
+	}
+	return nil
+}
+
+// validateLoyaltyRule проверяет параметры правила и убирает лишние для его вида
+func validateLoyaltyRule(rule *models.LoyaltyRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("не указано название правила")
+	}
+	if rule.MinVisits < 0 {
+		return fmt.Errorf("число визитов не может быть отрицательным")
+	}
+	if rule.ServiceID != nil && *rule.ServiceID == 0 {
+		rule.ServiceID = nil
+	}
+
+	switch rule.Type {
+	case models.LoyaltyFreeNth:
+		if rule.ServiceID == nil {
+			return fmt.Errorf("для бесплатной услуги укажите услугу")
+		}
+		if rule.EveryN == nil || *rule.EveryN < 2 {
+			return fmt.Errorf("бесплатной может быть каждая N-я услуга, где N не меньше 2")
+		}
+		rule.Percent = nil
+	case models.LoyaltyPercent:
+		if rule.Percent == nil || *rule.Percent <= 0 || *rule.Percent > 100 {
+			return fmt.Errorf("процент скидки должен быть от 0 до 100")
+		}
+		rule.EveryN = nil
+	default:
+		return fmt.Errorf("неизвестный вид правила '%s', допустимые: %s, %s", rule.Type, models.LoyaltyFreeNth, models.LoyaltyPercent)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"go-hinomontaj/models"
+	"testing"
+)
+
+func TestApplyLoyaltyRules(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	floatPtr := func(v float64) *float64 { return &v }
+	percent := func(name string, value float64, minVisits int) models.LoyaltyRule {
+		return models.LoyaltyRule{Name: name, Type: models.LoyaltyPercent, Percent: floatPtr(value), MinVisits: minVisits}
+	}
+
+	tests := []struct {
+		name      string
+		services  []models.OrderService
+		history   models.LoyaltyHistory
+		rules     []models.LoyaltyRule
+		discounts []float64
+		reasons   []string
+		total     float64
+	}{
+		{
+			name:      "без правил",
+			services:  []models.OrderService{{ServiceID: 1, Price: 1000}},
+			discounts: []float64{0},
+			reasons:   []string{""},
+		},
+		{
+			name:      "процент после визитов",
+			services:  []models.OrderService{{ServiceID: 1, Price: 1000}, {ServiceID: 2, Price: 555}},
+			history:   models.LoyaltyHistory{Visits: 5},
+			rules:     []models.LoyaltyRule{percent("постоянный", 10, 5)},
+			discounts: []float64{100, 55.5},
+			reasons:   []string{"постоянный", "постоянный"},
+			total:     155.5,
+		},
+		{
+			name:      "мало визитов",
+			services:  []models.OrderService{{ServiceID: 1, Price: 1000}},
+			history:   models.LoyaltyHistory{Visits: 4},
+			rules:     []models.LoyaltyRule{percent("постоянный", 10, 5)},
+			discounts: []float64{0},
+			reasons:   []string{""},
+		},
+		{
+			name:      "выбирается больший процент",
+			services:  []models.OrderService{{ServiceID: 1, Price: 1000}},
+			history:   models.LoyaltyHistory{Visits: 10},
+			rules:     []models.LoyaltyRule{percent("малая", 5, 0), percent("большая", 15, 0)},
+			discounts: []float64{150},
+			reasons:   []string{"большая"},
+			total:     150,
+		},
+		{
+			name:     "правило для другой услуги",
+			services: []models.OrderService{{ServiceID: 1, Price: 1000}},
+			rules: []models.LoyaltyRule{{
+				Name: "балансировка", Type: models.LoyaltyPercent, ServiceID: intPtr(2), Percent: floatPtr(10),
+			}},
+			discounts: []float64{0},
+			reasons:   []string{""},
+		},
+		{
+			name:     "каждая третья бесплатно с учетом прошлых заказов",
+			services: []models.OrderService{{ServiceID: 1, Price: 500}, {ServiceID: 1, Price: 500}},
+			history:  models.LoyaltyHistory{Visits: 1, ServiceCounts: map[int]int{1: 1}},
+			rules: []models.LoyaltyRule{{
+				Name: "третья бесплатно", Type: models.LoyaltyFreeNth, ServiceID: intPtr(1), EveryN: intPtr(3),
+			}},
+			discounts: []float64{0, 500},
+			reasons:   []string{"", "третья бесплатно"},
+			total:     500,
+		},
+		{
+			name:     "бесплатная услуга важнее процента",
+			services: []models.OrderService{{ServiceID: 1, Price: 800}},
+			history:  models.LoyaltyHistory{Visits: 3, ServiceCounts: map[int]int{1: 1}},
+			rules: []models.LoyaltyRule{
+				percent("постоянный", 10, 0),
+				{Name: "вторая бесплатно", Type: models.LoyaltyFreeNth, EveryN: intPtr(2)},
+			},
+			discounts: []float64{800},
+			reasons:   []string{"вторая бесплатно"},
+			total:     800,
+		},
+	}
+	for _, tt := range tests {
+		prices := make([]float64, len(tt.services))
+		for i, line := range tt.services {
+			prices[i] = line.Price
+		}
+
+		total := applyLoyaltyRules(tt.services, tt.history, tt.rules)
+		if total != tt.total {
+			t.Errorf("%s: скидка %v, ожидалось %v", tt.name, total, tt.total)
+		}
+		for i, line := range tt.services {
+			if line.Discount != tt.discounts[i] || line.DiscountReason != tt.reasons[i] {
+				t.Errorf("%s: строка %d скидка %v '%s', ожидалось %v '%s'", tt.name, i,
+					line.Discount, line.DiscountReason, tt.discounts[i], tt.reasons[i])
+			}
+			if want := roundMoney(prices[i] - tt.discounts[i]); line.Price != want {
+				t.Errorf("%s: строка %d цена %v, ожидалось %v", tt.name, i, line.Price, want)
+			}
+		}
+	}
+}
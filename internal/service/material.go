@@ -1,8 +1,11 @@
 package service
 
 import (
+	"fmt"
 	"go-hinomontaj/models"
 	"go-hinomontaj/pkg/logger"
+	"strings"
+	"time"
 )
 
 type MaterialService struct {
@@ -15,12 +18,19 @@ func NewMaterialService(repo Repository) *MaterialService {
 
 func (s *MaterialService) Create(material models.Material) error {
 	logger.Debug("Создание нового материала в сервисе: %s", material.Name)
+	if err := validateMaterial(&material); err != nil {
+		return err
+	}
+	if material.Storage < 0 {
+		return fmt.Errorf("начальный остаток не может быть отрицательным")
+	}
 	return s.repo.AddMaterial(material)
 }
 
-func (s *MaterialService) GetAll() ([]models.Material, error) {
-	logger.Debug("Получение списка всех материалов в сервисе")
-	return s.repo.GetAllMaterials()
+func (s *MaterialService) GetAll(filter models.MaterialFilter) ([]models.Material, error) {
+	logger.Debug("Получение списка материалов в сервисе")
+	filter.Search = strings.TrimSpace(filter.Search)
+	return s.repo.GetAllMaterials(filter)
 }
 
 func (s *MaterialService) GetById(id int) (models.Material, error) {
@@ -28,13 +38,16 @@ func (s *MaterialService) GetById(id int) (models.Material, error) {
 	return s.repo.GetMaterialById(id)
 }
 
-func (s *MaterialService) GetByNameAndType(name string, typeDS int) (models.Material, error) {
-	logger.Debug("Получение материала по названию и типу ДС в сервисе: %s (тип ДС: %d)", name, typeDS)
-	return s.repo.GetMaterialByNameAndType(name, typeDS)
+func (s *MaterialService) GetByNameAndCategory(name string, categoryID int) (models.Material, error) {
+	logger.Debug("Получение материала по названию и категории в сервисе: %s (категория ID: %d)", name, categoryID)
+	return s.repo.GetMaterialByNameAndCategory(name, categoryID)
 }
 
 func (s *MaterialService) Update(id int, material models.Material) error {
 	logger.Debug("Обновление материала в сервисе: %d", id)
+	if err := validateMaterial(&material); err != nil {
+		return err
+	}
 	return s.repo.UpdateMaterial(id, material)
 }
 
@@ -43,12 +56,120 @@ func (s *MaterialService) Delete(id int) error {
 	return s.repo.DeleteMaterial(id)
 }
 
-func (s *MaterialService) AddQuantity(id int, quantity int) error {
-	logger.Debug("Добавление количества %d к материалу ID: %d в сервисе", quantity, id)
-	return s.repo.AddMaterialQuantity(id, quantity)
+func (s *MaterialService) AddQuantity(id, warehouseID int, quantity float64) error {
+	logger.Debug("Добавление количества %v к материалу ID: %d на склад ID: %d в сервисе", quantity, id, warehouseID)
+	if quantity <= 0 {
+		return fmt.Errorf("количество должно быть больше нуля")
+	}
+	return s.repo.AddMaterialQuantity(id, warehouseID, quantity)
 }
 
-func (s *MaterialService) SubtractQuantity(id int, quantity int) error {
-	logger.Debug("Уменьшение количества %d у материала ID: %d в сервисе", quantity, id)
-	return s.repo.SubtractMaterialQuantity(id, quantity)
+func (s *MaterialService) SubtractQuantity(id, warehouseID int, quantity float64) error {
+	logger.Debug("Уменьшение количества %v у материала ID: %d на складе ID: %d в сервисе", quantity, id, warehouseID)
+	if quantity <= 0 {
+		return fmt.Errorf("количество должно быть больше нуля")
+	}
+	return s.repo.SubtractMaterialQuantity(id, warehouseID, quantity)
+}
+
+func (s *MaterialService) CreateCategory(category models.MaterialCategory) (int, error) {
+	logger.Debug("Создание категории материалов в сервисе: %s", category.Name)
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return 0, fmt.Errorf("не указано название категории")
+	}
+	return s.repo.CreateMaterialCategory(category)
+}
+
+func (s *MaterialService) GetCategories() ([]models.MaterialCategory, error) {
+	logger.Debug("Получение списка категорий материалов в сервисе")
+	return s.repo.GetAllMaterialCategories()
+}
+
+func (s *MaterialService) UpdateCategory(id int, category models.MaterialCategory) error {
+	logger.Debug("Обновление категории материалов в сервисе: %d", id)
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return fmt.Errorf("не указано название категории")
+	}
+	return s.repo.UpdateMaterialCategory(id, category)
+}
+
+func (s *MaterialService) DeleteCategory(id int) error {
+	logger.Debug("Удаление категории материалов в сервисе: %d", id)
+	return s.repo.DeleteMaterialCategory(id)
+}
+
+func (s *MaterialService) CreateNorm(norm models.ServiceMaterialNorm) (int, error) {
+	logger.Debug("Создание нормы расхода в сервисе: %s", norm.ServiceName)
+	if err := validateNorm(&norm); err != nil {
+		return 0, err
+	}
+	return s.repo.CreateMaterialNorm(norm)
+}
+
+func (s *MaterialService) GetNorms() ([]models.ServiceMaterialNorm, error) {
+	logger.Debug("Получение списка норм расхода в сервисе")
+	return s.repo.GetAllMaterialNorms()
+}
+
+func (s *MaterialService) UpdateNorm(id int, norm models.ServiceMaterialNorm) error {
+	logger.Debug("Обновление нормы расхода в сервисе: %d", id)
+	if err := validateNorm(&norm); err != nil {
+		return err
+	}
+	return s.repo.UpdateMaterialNorm(id, norm)
+}
+
+func (s *MaterialService) DeleteNorm(id int) error {
+	logger.Debug("Удаление нормы расхода в сервисе: %d", id)
+	return s.repo.DeleteMaterialNorm(id)
+}
+
+func (s *MaterialService) GetVariance(start, end time.Time) ([]models.MaterialVariance, error) {
+	logger.Debug("Получение отчета о расходе материалов в сервисе")
+	return s.repo.GetMaterialVariance(start, end)
+}
+
+func validateNorm(norm *models.ServiceMaterialNorm) error {
+	norm.ServiceName = strings.TrimSpace(norm.ServiceName)
+	if norm.ServiceName == "" {
+		return fmt.Errorf("не указано название услуги")
+	}
+	if norm.MaterialID == 0 {
+		return fmt.Errorf("не указан материал")
+	}
+	if norm.Quantity <= 0 {
+		return fmt.Errorf("количество материала по норме должно быть больше нуля")
+	}
+	if norm.ContractID != nil && *norm.ContractID == 0 {
+		norm.ContractID = nil
+	}
+	return nil
+}
+
+// validateMaterial проверяет и нормализует карточку материала
+func validateMaterial(material *models.Material) error {
+	material.Name = strings.TrimSpace(material.Name)
+	material.SKU = strings.TrimSpace(material.SKU)
+	if material.Name == "" {
+		return fmt.Errorf("не указано название материала")
+	}
+	// Старые клиенты передают категорию в поле type_ds
+	if material.CategoryID == 0 {
+		material.CategoryID = material.TypeDS
+	}
+	if material.CategoryID == 0 {
+		return fmt.Errorf("не указана категория материала")
+	}
+	if material.MinStorage < 0 {
+		return fmt.Errorf("минимальный остаток не может быть отрицательным")
+	}
+	if material.Unit == "" {
+		material.Unit = models.UnitPiece
+	}
+	if !isMaterialUnit(material.Unit) {
+		return fmt.Errorf("неизвестная единица измерения '%s', допустимы: %s", material.Unit, strings.Join(models.MaterialUnits, ", "))
+	}
+	return nil
 }
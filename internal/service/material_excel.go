@@ -0,0 +1,348 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Колонки файла загрузки материалов, шаблон и разбор файла используют один порядок
+var materialImportHeaders = []string{"Артикул", "Название", "Категория", "Ед. изм.", "Мин. остаток", "Приход", "Склад"}
+
+// newHeaderStyle создает стиль заголовков, как в шаблоне загрузки машин
+func newHeaderStyle(f *excelize.File) (int, error) {
+	return f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"#CCCCCC"},
+			Pattern: 1,
+		},
+		Alignment: &excelize.Alignment{
+			Horizontal: "center",
+			Vertical:   "center",
+		},
+	})
+}
+
+// writeSheetHeaders записывает заголовки в первую строку листа
+func writeSheetHeaders(f *excelize.File, sheet string, headers []string) error {
+	style, err := newHeaderStyle(f)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании стиля: %w", err)
+	}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+		f.SetCellStyle(sheet, cell, cell, style)
+	}
+	return nil
+}
+
+// ExportStock формирует отчет об остатках с порогами и датой последнего движения
+func (s *MaterialService) ExportStock(filter models.MaterialFilter) (*bytes.Buffer, error) {
+	logger.Debug("Формирование отчета об остатках материалов")
+
+	materials, err := s.GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Остатки"
+	f.SetSheetName("Sheet1", sheet)
+
+	headers := []string{"Артикул", "Название", "Категория", "Ед. изм.", "Остаток", "Мин. остаток", "Последнее движение", "Статус"}
+	if err := writeSheetHeaders(f, sheet, headers); err != nil {
+		logger.Error("Ошибка при формировании отчета об остатках: %v", err)
+		return nil, err
+	}
+
+	lowStyle, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#F8CBAD"}, Pattern: 1},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании стиля: %w", err)
+	}
+
+	for i, m := range materials {
+		row := i + 2
+		lastMovement := ""
+		if m.LastMovement != nil {
+			lastMovement = m.LastMovement.Format("02.01.2006 15:04")
+		}
+		status := ""
+		if m.BelowMinimum() {
+			status = "ниже минимума"
+		}
+
+		values := []interface{}{m.SKU, m.Name, m.CategoryName, m.Unit, m.Storage, m.MinStorage, lastMovement, status}
+		for col, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, row)
+			f.SetCellValue(sheet, cell, value)
+		}
+		if m.BelowMinimum() {
+			f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("H%d", row), lowStyle)
+		}
+	}
+
+	f.SetColWidth(sheet, "A", "A", 15)
+	f.SetColWidth(sheet, "B", "B", 30)
+	f.SetColWidth(sheet, "C", "C", 20)
+	f.SetColWidth(sheet, "D", "F", 12)
+	f.SetColWidth(sheet, "G", "G", 20)
+	f.SetColWidth(sheet, "H", "H", 15)
+
+	// Остатки в разрезе складов
+	balances, err := s.repo.GetMaterialBalances(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	byWarehouse := "По складам"
+	if _, err := f.NewSheet(byWarehouse); err != nil {
+		return nil, fmt.Errorf("ошибка при создании листа: %w", err)
+	}
+	if err := writeSheetHeaders(f, byWarehouse, []string{"Склад", "Артикул", "Название", "Категория", "Ед. изм.", "Остаток"}); err != nil {
+		return nil, err
+	}
+	// Лист по складам учитывает те же фильтры, что и основной
+	included := make(map[int]bool, len(materials))
+	for _, m := range materials {
+		included[m.ID] = true
+	}
+	row := 2
+	for _, b := range balances {
+		if !included[b.MaterialID] {
+			continue
+		}
+		f.SetSheetRow(byWarehouse, fmt.Sprintf("A%d", row), &[]interface{}{b.WarehouseName, b.SKU, b.MaterialName, b.CategoryName, b.Unit, b.Quantity})
+		row++
+	}
+	f.SetColWidth(byWarehouse, "A", "A", 20)
+	f.SetColWidth(byWarehouse, "B", "B", 15)
+	f.SetColWidth(byWarehouse, "C", "D", 30)
+
+	buffer := new(bytes.Buffer)
+	if err := f.Write(buffer); err != nil {
+		logger.Error("Ошибка при сохранении файла: %v", err)
+		return nil, fmt.Errorf("ошибка при сохранении файла: %w", err)
+	}
+
+	logger.Info("Отчет об остатках сформирован, материалов: %d", len(materials))
+	return buffer, nil
+}
+
+// GenerateImportTemplate создает шаблон Excel файла для загрузки материалов
+func (s *MaterialService) GenerateImportTemplate() (*bytes.Buffer, error) {
+	logger.Debug("Генерация шаблона Excel файла для загрузки материалов")
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Материалы"
+	f.SetSheetName("Sheet1", sheet)
+
+	if err := writeSheetHeaders(f, sheet, materialImportHeaders); err != nil {
+		logger.Error("Ошибка при генерации шаблона материалов: %v", err)
+		return nil, err
+	}
+
+	// Пример заполнения и подсказка по единицам измерения
+	f.SetSheetRow(sheet, "A2", &[]interface{}{"R-19", "Резина 19", "Резина", models.UnitPiece, 20, 100, ""})
+	f.SetCellValue(sheet, "I1", "Ед. изм.: "+strings.Join(models.MaterialUnits, ", "))
+	f.SetCellValue(sheet, "I2", "Пустой склад - склад по умолчанию")
+
+	f.SetColWidth(sheet, "A", "A", 15)
+	f.SetColWidth(sheet, "B", "B", 30)
+	f.SetColWidth(sheet, "C", "C", 20)
+	f.SetColWidth(sheet, "D", "F", 12)
+	f.SetColWidth(sheet, "G", "G", 20)
+
+	buffer := new(bytes.Buffer)
+	if err := f.Write(buffer); err != nil {
+		logger.Error("Ошибка при сохранении файла: %v", err)
+		return nil, fmt.Errorf("ошибка при сохранении файла: %w", err)
+	}
+
+	return buffer, nil
+}
+
+// ImportFromExcel создает или обновляет материалы и проводит приход по строкам файла.
+// Ошибочные строки пропускаются и попадают в отчет, остальные загружаются.
+func (s *MaterialService) ImportFromExcel(fileData []byte, userID int) (models.MaterialImportResult, error) {
+	logger.Debug("Начало загрузки материалов из Excel файла")
+	result := models.MaterialImportResult{Errors: []models.ImportRowError{}}
+
+	f, err := excelize.OpenReader(bytes.NewReader(fileData))
+	if err != nil {
+		logger.Error("Ошибка при открытии Excel файла: %v", err)
+		return result, fmt.Errorf("ошибка при открытии Excel файла: %w", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		logger.Error("Ошибка при чтении листа Excel: %v", err)
+		return result, fmt.Errorf("ошибка при чтении листа Excel: %w", err)
+	}
+	if len(rows) < 2 {
+		return result, fmt.Errorf("файл не содержит данных")
+	}
+
+	// Находим колонки по заголовкам
+	columns := map[string]int{}
+	for i, header := range rows[0] {
+		switch strings.ToLower(strings.TrimSpace(header)) {
+		case "артикул", "sku":
+			columns["sku"] = i
+		case "название", "наименование", "name":
+			columns["name"] = i
+		case "категория", "category":
+			columns["category"] = i
+		case "ед. изм.", "ед.изм.", "единица", "unit":
+			columns["unit"] = i
+		case "мин. остаток", "минимальный остаток", "min_storage":
+			columns["min_storage"] = i
+		case "приход", "количество", "quantity":
+			columns["receipt"] = i
+		case "склад", "warehouse":
+			columns["warehouse"] = i
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return result, fmt.Errorf("не найдена колонка с названием материала")
+	}
+	if _, ok := columns["category"]; !ok {
+		return result, fmt.Errorf("не найдена колонка с категорией материала")
+	}
+
+	categories, err := s.repo.GetAllMaterialCategories()
+	if err != nil {
+		return result, err
+	}
+	categoryIDs := make(map[string]int, len(categories))
+	for _, category := range categories {
+		categoryIDs[strings.ToLower(category.Name)] = category.ID
+	}
+
+	warehouses, err := s.repo.GetAllWarehouses()
+	if err != nil {
+		return result, err
+	}
+	warehouseIDs := make(map[string]int, len(warehouses))
+	for _, warehouse := range warehouses {
+		warehouseIDs[strings.ToLower(warehouse.Name)] = warehouse.ID
+	}
+
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+		cell := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[idx])
+		}
+		rowError := func(format string, args ...interface{}) {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: rowNum, Message: fmt.Sprintf(format, args...)})
+		}
+
+		material := models.Material{
+			SKU:  cell("sku"),
+			Name: cell("name"),
+			Unit: cell("unit"),
+		}
+		if material.Name == "" && material.SKU == "" && cell("category") == "" {
+			continue // пустая строка
+		}
+		if material.Name == "" {
+			rowError("не указано название материала")
+			continue
+		}
+
+		categoryID, ok := categoryIDs[strings.ToLower(cell("category"))]
+		if !ok {
+			rowError("категория '%s' не найдена", cell("category"))
+			continue
+		}
+		material.CategoryID = categoryID
+
+		if material.Unit != "" && !isMaterialUnit(material.Unit) {
+			rowError("неизвестная единица измерения '%s'", material.Unit)
+			continue
+		}
+
+		minStorage, err := parseQuantity(cell("min_storage"))
+		if err != nil {
+			rowError("неверный минимальный остаток: %v", err)
+			continue
+		}
+		material.MinStorage = minStorage
+
+		receipt, err := parseQuantity(cell("receipt"))
+		if err != nil {
+			rowError("неверное количество прихода: %v", err)
+			continue
+		}
+
+		// Пустой склад = склад по умолчанию
+		warehouseID := 0
+		if name := cell("warehouse"); name != "" {
+			id, ok := warehouseIDs[strings.ToLower(name)]
+			if !ok {
+				rowError("склад '%s' не найден", name)
+				continue
+			}
+			warehouseID = id
+		}
+
+		created, err := s.repo.ImportMaterial(material, receipt, warehouseID, userID)
+		if err != nil {
+			rowError("%v", err)
+			continue
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		if receipt > 0 {
+			result.Received++
+		}
+	}
+
+	logger.Info("Загрузка материалов завершена: создано %d, обновлено %d, приход по %d строкам, ошибок %d",
+		result.Created, result.Updated, result.Received, len(result.Errors))
+	return result, nil
+}
+
+// parseQuantity разбирает неотрицательное количество, допускает запятую как разделитель
+func parseQuantity(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	quantity, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' не является числом", value)
+	}
+	if quantity < 0 {
+		return 0, fmt.Errorf("значение не может быть отрицательным")
+	}
+	return quantity, nil
+}
+
+func isMaterialUnit(unit string) bool {
+	for _, u := range models.MaterialUnits {
+		if unit == u {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"fmt"
 	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/plate"
 	"time"
 )
 
@@ -16,16 +19,51 @@ type OrderService interface {
 }
 
 type OrderServiceImpl struct {
-	repo Repository
+	repo            Repository
+	allowUnrostered bool
 }
 
-func NewOrderService(repo Repository) *OrderServiceImpl {
+func NewOrderService(repo Repository, allowUnrostered bool) *OrderServiceImpl {
 	return &OrderServiceImpl{
-		repo: repo,
+		repo:            repo,
+		allowUnrostered: allowUnrostered,
 	}
 }
 
 func (s *OrderServiceImpl) Create(order models.Order) (int, error) {
+	order.VehicleNumber = plate.Normalize(order.VehicleNumber)
+	clientID, err := resolveClientID(s.repo, order.ClientID)
+	if err != nil {
+		return 0, err
+	}
+	order.ClientID = clientID
+	// По умолчанию заказ оформляется на клиента, за которым машина числится на дату заказа
+	if order.ClientID == 0 {
+		clientID, err := s.repo.GetCarOwnerAt(order.VehicleNumber, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		if clientID == 0 {
+			return 0, fmt.Errorf("машина %s ни за кем не числится, укажите клиента", order.VehicleNumber)
+		}
+		order.ClientID = clientID
+	}
+	if err := checkWorkerOnShift(s.repo, order.WorkerID, time.Now(), s.allowUnrostered); err != nil {
+		return 0, err
+	}
+	if err := s.checkReworkOf(&order, 0, time.Now()); err != nil {
+		return 0, err
+	}
+	if err := s.checkWheelPositions(order); err != nil {
+		return 0, err
+	}
+	if err := s.applyMaterialNorms(&order); err != nil {
+		return 0, err
+	}
+	// Скидки по программе лояльности считаются только при создании заказа
+	if err := applyLoyalty(s.repo, &order); err != nil {
+		return 0, err
+	}
 	return s.repo.CreateOrder(order)
 }
 
@@ -42,6 +80,34 @@ func (s *OrderServiceImpl) GetByWorkerIdAndDateRange(workerId int, start, end ti
 }
 
 func (s *OrderServiceImpl) Update(id int, order models.Order) error {
+	order.VehicleNumber = plate.Normalize(order.VehicleNumber)
+	clientID, err := resolveClientID(s.repo, order.ClientID)
+	if err != nil {
+		return err
+	}
+	order.ClientID = clientID
+	// Смену проверяем только при смене исполнителя, чтобы старые заказы оставались редактируемыми
+	currentWorker, createdAt, err := s.repo.GetOrderWorker(id)
+	if err != nil {
+		return err
+	}
+	if currentWorker == nil || *currentWorker != order.WorkerID {
+		if err := checkWorkerOnShift(s.repo, order.WorkerID, createdAt, s.allowUnrostered); err != nil {
+			return err
+		}
+	}
+	if err := s.checkReworkOf(&order, id, createdAt); err != nil {
+		return err
+	}
+	if err := s.checkWheelPositions(order); err != nil {
+		return err
+	}
+	// Расходники пересчитываем только если их передали вместе с заказом
+	if order.Materials != nil {
+		if err := s.applyMaterialNorms(&order); err != nil {
+			return err
+		}
+	}
 	return s.repo.UpdateOrder(id, order)
 }
 
@@ -60,3 +126,128 @@ func (s *OrderServiceImpl) GetStatistics() (models.Statistics, error) {
 func (s *OrderServiceImpl) GetOrderMaterials(orderID int) ([]models.OrderMaterial, error) {
 	return s.repo.GetOrderMaterials(orderID)
 }
+
+// checkReworkOf проверяет, что переделываемый заказ существует и создан раньше заказа-переделки
+func (s *OrderServiceImpl) checkReworkOf(order *models.Order, id int, createdAt time.Time) error {
+	if order.ReworkOfID != nil && *order.ReworkOfID == 0 {
+		order.ReworkOfID = nil
+	}
+	if order.ReworkOfID == nil {
+		return nil
+	}
+	if *order.ReworkOfID == id {
+		return fmt.Errorf("заказ не может быть переделкой самого себя")
+	}
+	_, originalCreatedAt, err := s.repo.GetOrderWorker(*order.ReworkOfID)
+	if err != nil {
+		return err
+	}
+	if !originalCreatedAt.Before(createdAt) {
+		return fmt.Errorf("переделываемый заказ ID %d создан позже заказа-переделки", *order.ReworkOfID)
+	}
+	return nil
+}
+
+// checkWheelPositions проверяет позиции колес услуг по схеме осей машины.
+// Машины без профиля или без заданных осей не проверяются.
+func (s *OrderServiceImpl) checkWheelPositions(order models.Order) error {
+	if order.VehicleNumber == "" {
+		return nil
+	}
+	exists, err := s.repo.CarExists(order.VehicleNumber)
+	if err != nil || !exists {
+		return err
+	}
+	car, err := s.repo.GetCarByNumber(order.VehicleNumber)
+	if err != nil {
+		return err
+	}
+
+	positions := car.WheelPositions()
+	if len(positions) == 0 {
+		return nil
+	}
+	allowed := make(map[string]bool, len(positions))
+	for _, position := range positions {
+		allowed[position] = true
+	}
+	for _, service := range order.Services {
+		if service.WheelPosition != "" && !allowed[service.WheelPosition] {
+			return fmt.Errorf("у машины %s нет колеса в позиции '%s'", order.VehicleNumber, service.WheelPosition)
+		}
+	}
+	return nil
+}
+
+// applyMaterialNorms дополняет расходники заказа материалами по нормам расхода услуг.
+// Указанный работником материал важнее нормы: количество 0 означает, что материал не расходовался.
+func (s *OrderServiceImpl) applyMaterialNorms(order *models.Order) error {
+	explicit := make(map[int]float64)
+	var explicitOrder []int
+	for _, m := range order.Materials {
+		if m.MaterialID == 0 {
+			return fmt.Errorf("не указан ID материала")
+		}
+		if m.Quantity < 0 {
+			return fmt.Errorf("неверное количество материала")
+		}
+		if _, ok := explicit[m.MaterialID]; !ok {
+			explicitOrder = append(explicitOrder, m.MaterialID)
+		}
+		explicit[m.MaterialID] += m.Quantity
+	}
+
+	materials := make([]models.OrderMaterial, 0, len(explicit))
+	for _, id := range explicitOrder {
+		if explicit[id] > 0 {
+			materials = append(materials, models.OrderMaterial{MaterialID: id, Quantity: explicit[id]})
+		}
+	}
+
+	serviceIDs := make([]int, 0, len(order.Services))
+	for _, service := range order.Services {
+		serviceIDs = append(serviceIDs, service.ServiceID)
+	}
+
+	if len(serviceIDs) > 0 {
+		client, err := s.repo.GetClientById(order.ClientID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении клиента заказа: %w", err)
+		}
+
+		norms, err := s.repo.GetMaterialNormsForServices(serviceIDs, client.ContractID)
+		if err != nil {
+			return err
+		}
+
+		byService := make(map[int][]models.ServiceMaterialNorm)
+		for _, norm := range norms {
+			byService[norm.ServiceID] = append(byService[norm.ServiceID], norm)
+		}
+
+		// Каждая строка услуги (например, каждое колесо) расходует материал по норме
+		normQuantity := make(map[int]float64)
+		var normOrder []int
+		for _, service := range order.Services {
+			for _, norm := range byService[service.ServiceID] {
+				if _, ok := explicit[norm.MaterialID]; ok {
+					continue
+				}
+				if _, ok := normQuantity[norm.MaterialID]; !ok {
+					normOrder = append(normOrder, norm.MaterialID)
+				}
+				normQuantity[norm.MaterialID] += norm.Quantity
+			}
+		}
+
+		for _, id := range normOrder {
+			materials = append(materials, models.OrderMaterial{MaterialID: id, Quantity: normQuantity[id], FromNorm: true})
+		}
+		if len(normOrder) > 0 {
+			logger.Debug("По нормам расхода в заказ добавлено материалов: %d", len(normOrder))
+		}
+	}
+
+	order.Materials = materials
+	return nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PayrollService struct {
+	repo    Repository
+	salary  *WorkerServiceImpl
+	company models.Company
+	fonts   DocumentFonts
+}
+
+func NewPayrollService(repo Repository, company models.Company, fonts DocumentFonts) *PayrollService {
+	return &PayrollService{repo: repo, salary: NewWorkerService(repo), company: company, fonts: fonts}
+}
+
+func (s *PayrollService) GetAll() ([]models.PayrollPeriod, error) {
+	logger.Debug("Получение расчетов зарплаты в сервисе")
+	return s.repo.GetPayrollPeriods()
+}
+
+func (s *PayrollService) GetById(id int) (models.PayrollPeriod, error) {
+	return s.repo.GetPayrollPeriodById(id)
+}
+
+// Create заводит расчет зарплаты за период [start, end) и сразу считает его
+func (s *PayrollService) Create(start, end time.Time, userID int) (int, error) {
+	logger.Debug("Создание расчета зарплаты в сервисе")
+	id, err := s.repo.CreatePayrollPeriod(start, end, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.Calculate(id); err != nil {
+		if delErr := s.repo.DeletePayrollPeriod(id); delErr != nil {
+			logger.Error("Не удалось удалить несчитанный расчет зарплаты ID:%d: %v", id, delErr)
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+// Calculate пересчитывает черновик: начисления по схемам, бонусы и штрафы за период (включая созданные правилами),
+// выданные в период авансы и перерасчеты за закрытые периоды, в которых с момента выплаты изменились суммы
+func (s *PayrollService) Calculate(id int) error {
+	logger.Debug("Расчет зарплаты ID:%d в сервисе", id)
+	period, err := s.repo.GetPayrollPeriodById(id)
+	if err != nil {
+		return err
+	}
+	if period.Status != models.PayrollDraft {
+		return fmt.Errorf("расчет зарплаты в статусе '%s' нельзя пересчитать", period.Status)
+	}
+
+	workers, err := s.repo.GetAllWorkers()
+	if err != nil {
+		return err
+	}
+	// Дубль работника получил бы начисления и штрафы по правилам второй раз
+	var duplicates []string
+	for _, worker := range workers {
+		if worker.LinkIssue != nil && *worker.LinkIssue == models.WorkerLinkDuplicate {
+			duplicates = append(duplicates, strconv.Itoa(worker.ID))
+		}
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("работники с ID %s отмечены как дубли: привяжите их к учетным записям или удалите лишние записи",
+			strings.Join(duplicates, ", "))
+	}
+	// Штрафы и бонусы по правилам сохраняются вместе с расчетом, в начисления они подставляются до сохранения
+	rules, err := s.periodRules(period)
+	if err != nil {
+		return err
+	}
+
+	start, end := period.PeriodStart, period.PeriodEnd.AddDate(0, 0, 1)
+	lines := make(map[int]*models.PayrollLine, len(workers))
+	for _, worker := range workers {
+		calc, err := s.salary.CalculateSalary(worker.ID, start, end)
+		if err != nil {
+			return fmt.Errorf("ошибка при расчете зарплаты работника %s %s: %w", worker.Name, worker.Surname, err)
+		}
+		// Расшифровка сохраняется со строкой, чтобы листок утвержденного расчета сходился с итогами
+		details, err := s.lineDetails(worker.ID, start, end, calc.Parts)
+		if err != nil {
+			return err
+		}
+		details.Bonuses = rules.bonuses.merge(worker.ID, details.Bonuses)
+		details.Penalties = rules.penalties.merge(worker.ID, details.Penalties)
+		lines[worker.ID] = &models.PayrollLine{
+			WorkerID:  worker.ID,
+			Accrued:   calc.Accrued,
+			Bonuses:   entriesTotal(details.Bonuses),
+			Penalties: entriesTotal(details.Penalties),
+			Details:   &details,
+		}
+	}
+
+	payments, err := s.repo.GetWorkerPayments(0, start, end)
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		if line, ok := lines[payment.WorkerID]; ok && payment.Kind == models.WorkerPaymentAdvance {
+			line.Advances += payment.Amount
+		}
+	}
+
+	adjustments, err := s.lockedPeriodAdjustments(period, workers)
+	if err != nil {
+		return err
+	}
+	for _, adjustment := range adjustments {
+		line := lines[adjustment.WorkerID]
+		line.Adjustments += adjustment.Amount
+	}
+
+	result := make([]models.PayrollLine, 0, len(lines))
+	for _, worker := range workers {
+		line := lines[worker.ID]
+		if line.Accrued == 0 && line.Bonuses == 0 && line.Penalties == 0 && line.Adjustments == 0 && line.Advances == 0 {
+			continue
+		}
+		line.Total = line.Earned() + line.Adjustments - line.Advances
+		result = append(result, *line)
+	}
+
+	return s.repo.SavePayrollCalculation(id, result, adjustments, rules.penalties.added, rules.bonuses.added)
+}
+
+// entriesTotal сумма бонусов или штрафов
+func entriesTotal(entries []models.PenaltyOrBonus) money.Amount {
+	total := 0
+	for _, entry := range entries {
+		total += entry.Amount
+	}
+	return money.FromRubles(total)
+}
+
+// lockedPeriodAdjustments пересчитывает закрытые периоды до начала period, в которых после закрытия
+// менялись данные. Разница между текущим расчетом и выплаченным (с учетом прошлых перерасчетов) попадает в period.
+func (s *PayrollService) lockedPeriodAdjustments(period models.PayrollPeriod, workers []models.Worker) ([]models.PayrollAdjustment, error) {
+	changed, err := s.repo.GetChangedLockedPayrollPeriods(period.PeriodStart)
+	if err != nil {
+		return nil, err
+	}
+	settledAdjustments, err := s.repo.GetPayrollAdjustmentsExcept(period.ID)
+	if err != nil {
+		return nil, err
+	}
+	settled := make(map[[2]int]money.Amount)
+	for _, a := range settledAdjustments {
+		settled[[2]int{a.SourcePeriodID, a.WorkerID}] += a.Amount
+	}
+
+	adjustments := []models.PayrollAdjustment{}
+	for _, lockedID := range changed {
+		locked, err := s.repo.GetPayrollPeriodById(lockedID)
+		if err != nil {
+			return nil, err
+		}
+		paid := make(map[int]money.Amount, len(locked.Lines))
+		for _, line := range locked.Lines {
+			paid[line.WorkerID] = line.Earned()
+		}
+
+		for _, worker := range workers {
+			calc, err := s.salary.CalculateSalary(worker.ID, locked.PeriodStart, locked.PeriodEnd.AddDate(0, 0, 1))
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при перерасчете зарплаты работника %s %s: %w", worker.Name, worker.Surname, err)
+			}
+			diff := calc.Total - paid[worker.ID] - settled[[2]int{locked.ID, worker.ID}]
+			if diff == 0 {
+				continue
+			}
+			adjustments = append(adjustments, models.PayrollAdjustment{
+				WorkerID:       worker.ID,
+				SourcePeriodID: locked.ID,
+				Amount:         diff,
+				Description: fmt.Sprintf("перерасчет за %s - %s",
+					locked.PeriodStart.Format("02.01.2006"), locked.PeriodEnd.Format("02.01.2006")),
+			})
+			logger.Info("Перерасчет работнику ID:%d за закрытый период ID:%d: %s", worker.ID, locked.ID, diff)
+		}
+	}
+	return adjustments, nil
+}
+
+// Approve фиксирует проверенный расчет, после этого пересчет запрещен
+func (s *PayrollService) Approve(id int, userID int) error {
+	logger.Debug("Утверждение расчета зарплаты ID:%d в сервисе", id)
+	period, err := s.repo.GetPayrollPeriodById(id)
+	if err != nil {
+		return err
+	}
+	if period.CalculatedAt == nil {
+		return fmt.Errorf("расчет зарплаты еще не посчитан")
+	}
+	return s.repo.UpdatePayrollStatus(id, models.PayrollDraft, models.PayrollApproved, userID)
+}
+
+// Reopen возвращает утвержденный расчет в черновик для исправления
+func (s *PayrollService) Reopen(id int) error {
+	logger.Debug("Возврат расчета зарплаты ID:%d в черновик в сервисе", id)
+	return s.repo.UpdatePayrollStatus(id, models.PayrollApproved, models.PayrollDraft, 0)
+}
+
+// Lock закрывает утвержденный период. Суммы закрытого периода больше не меняются,
+// правки заказов за него попадают перерасчетом в следующий расчет.
+func (s *PayrollService) Lock(id int) error {
+	logger.Debug("Закрытие расчета зарплаты ID:%d в сервисе", id)
+	return s.repo.UpdatePayrollStatus(id, models.PayrollApproved, models.PayrollLocked, 0)
+}
+
+func (s *PayrollService) Delete(id int) error {
+	return s.repo.DeletePayrollPeriod(id)
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Payslip собирает расчетный листок работника за период расчета зарплаты
+func (s *PayrollService) Payslip(periodID, workerID int) (models.Payslip, error) {
+	logger.Debug("Формирование расчетного листка работника ID:%d за период ID:%d", workerID, periodID)
+	period, err := s.repo.GetPayrollPeriodById(periodID)
+	if err != nil {
+		return models.Payslip{}, err
+	}
+
+	var line *models.PayrollLine
+	for i := range period.Lines {
+		if period.Lines[i].WorkerID == workerID {
+			line = &period.Lines[i]
+			break
+		}
+	}
+	if line == nil {
+		return models.Payslip{}, fmt.Errorf("работнику ID %d не начислена зарплата за этот период", workerID)
+	}
+
+	// Утвержденный расчет показывается таким, каким был посчитан, даже если заказы потом исправили
+	var details *models.PayrollLineDetails
+	if period.Status != models.PayrollDraft {
+		if details, err = s.repo.GetPayrollLineDetails(periodID, workerID); err != nil {
+			return models.Payslip{}, err
+		}
+		if details == nil {
+			logger.Warning("Расшифровка расчета ID:%d работника ID:%d не сохранена, листок по текущим данным", periodID, workerID)
+		}
+	}
+	if details == nil {
+		start, end := period.PeriodStart, period.PeriodEnd.AddDate(0, 0, 1)
+		calc, err := s.salary.CalculateSalary(workerID, start, end)
+		if err != nil {
+			return models.Payslip{}, err
+		}
+		live, err := s.lineDetails(workerID, start, end, calc.Parts)
+		if err != nil {
+			return models.Payslip{}, err
+		}
+		details = &live
+	}
+
+	payslip := models.Payslip{
+		PeriodID:    period.ID,
+		PeriodStart: period.PeriodStart,
+		PeriodEnd:   period.PeriodEnd,
+		Status:      period.Status,
+		WorkerID:    workerID,
+		WorkerName:  line.WorkerName,
+		Orders:      details.Orders,
+		Parts:       details.Parts,
+		Bonuses:     details.Bonuses,
+		Penalties:   details.Penalties,
+		Adjustments: []models.PayrollAdjustment{},
+		Advances:    details.Advances,
+		Totals:      *line,
+	}
+	for _, adjustment := range period.Adjustments {
+		if adjustment.WorkerID == workerID {
+			payslip.Adjustments = append(payslip.Adjustments, adjustment)
+		}
+	}
+	return payslip, nil
+}
+
+// lineDetails собирает расшифровку начисления работнику за [start, end): выполненные заказы с долями
+// начисления по частям расчета parts, действующие бонусы и штрафы, выданные авансы
+func (s *PayrollService) lineDetails(workerID int, start, end time.Time, parts []models.SalaryPart) (models.PayrollLineDetails, error) {
+	details := models.PayrollLineDetails{Parts: parts, Advances: []models.WorkerPayment{}}
+	orders, err := s.repo.GetOrdersByWorkerIdAndDateRange(workerID, start, end)
+	if err != nil {
+		return details, err
+	}
+	completed := make([]models.Order, 0, len(orders))
+	for _, order := range orders {
+		if order.Status == string(models.OrderStatusCompleted) {
+			completed = append(completed, order)
+		}
+	}
+	bonuses, err := s.repo.GetBonuses(workerID, start, end)
+	if err != nil {
+		return details, err
+	}
+	penalties, err := s.repo.GetPenalties(workerID, start, end)
+	if err != nil {
+		return details, err
+	}
+	payments, err := s.repo.GetWorkerPayments(workerID, start, end)
+	if err != nil {
+		return details, err
+	}
+
+	details.Orders = payslipOrders(completed, parts)
+	details.Bonuses = activeEntries(bonuses)
+	details.Penalties = activeEntries(penalties)
+	for _, payment := range payments {
+		if payment.Kind == models.WorkerPaymentAdvance {
+			details.Advances = append(details.Advances, payment)
+		}
+	}
+	return details, nil
+}
+
+// WorkerPayslips возвращает расчетные листки работника, вошедшего в систему, кроме черновиков
+func (s *PayrollService) WorkerPayslips(userID int) ([]models.PayslipSummary, error) {
+	worker, err := s.repo.GetWorkerByUserId(userID)
+	if err != nil {
+		return nil, err
+	}
+	payslips, err := s.repo.GetWorkerPayslips(worker.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.PayslipSummary{}
+	for _, payslip := range payslips {
+		if payslip.Status != models.PayrollDraft {
+			result = append(result, payslip)
+		}
+	}
+	return result, nil
+}
+
+// WorkerPayslip возвращает расчетный листок работника, вошедшего в систему. Черновик расчета работнику не показывается.
+func (s *PayrollService) WorkerPayslip(userID, periodID int) (models.Payslip, error) {
+	worker, err := s.repo.GetWorkerByUserId(userID)
+	if err != nil {
+		return models.Payslip{}, err
+	}
+	payslip, err := s.Payslip(periodID, worker.ID)
+	if err != nil {
+		return payslip, err
+	}
+	if payslip.Status == models.PayrollDraft {
+		return models.Payslip{}, fmt.Errorf("расчет зарплаты за этот период еще не утвержден")
+	}
+	return payslip, nil
+}
+
+// payslipOrders распределяет начисление каждой части расчета по ее заказам пропорционально выручке.
+// Остаток от округления достается последнему заказу части, чтобы доли сходились с начислением.
+func payslipOrders(orders []models.Order, parts []models.SalaryPart) []models.PayslipOrder {
+	sort.Slice(orders, func(i, j int) bool { return orders[i].CreatedAt.Before(orders[j].CreatedAt) })
+
+	result := make([]models.PayslipOrder, len(orders))
+	for i, order := range orders {
+		result[i] = models.PayslipOrder{
+			OrderID:       order.ID,
+			CreatedAt:     order.CreatedAt,
+			VehicleNumber: order.VehicleNumber,
+			Revenue:       order.TotalAmount,
+		}
+	}
+
+	for _, part := range parts {
+		if part.Revenue <= 0 {
+			continue
+		}
+		last, distributed := -1, money.Amount(0)
+		for i := range result {
+			if result[i].CreatedAt.Before(part.From) || !result[i].CreatedAt.Before(part.To) {
+				continue
+			}
+			result[i].Share = money.FromFloat(part.Amount.Rubles() * result[i].Revenue / part.Revenue)
+			distributed += result[i].Share
+			last = i
+		}
+		if last >= 0 {
+			result[last].Share += part.Amount - distributed
+		}
+	}
+	return result
+}
+
+// activeEntries убирает отмененные бонусы или штрафы
+func activeEntries(entries []models.PenaltyOrBonus) []models.PenaltyOrBonus {
+	result := []models.PenaltyOrBonus{}
+	for _, entry := range entries {
+		if entry.RevokedAt == nil {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// payslipSection таблица расчетного листка, общая для PDF и Excel
+type payslipSection struct {
+	title   string
+	headers []string
+	widths  []float64
+	aligns  []string
+	rows    [][]interface{}
+}
+
+// payslipTitle заголовок листка с периодом
+func payslipTitle(payslip models.Payslip) string {
+	return fmt.Sprintf("Расчетный листок за %s - %s",
+		payslip.PeriodStart.Format("02.01.2006"), payslip.PeriodEnd.Format("02.01.2006"))
+}
+
+// payslipSections раскладывает листок на таблицы, пустые разделы пропускаются
+func payslipSections(payslip models.Payslip) []payslipSection {
+	var sections []payslipSection
+
+	parts := payslipSection{
+		title:   "Начисление по схемам оплаты",
+		headers: []string{"Схема", "Период", "Смен", "Заказов", "Выручка", "Начислено"},
+		widths:  []float64{40, 50, 18, 20, 30, 32},
+		aligns:  []string{"L", "L", "R", "R", "R", "R"},
+	}
+	for _, part := range payslip.Parts {
+		period := fmt.Sprintf("%s - %s", part.From.Format("02.01.2006"), part.To.AddDate(0, 0, -1).Format("02.01.2006"))
+		parts.rows = append(parts.rows, []interface{}{part.SchemeType, period, part.Shifts, part.Orders, part.Revenue, part.Amount})
+	}
+	sections = append(sections, parts)
+
+	orders := payslipSection{
+		title:   "Заказы",
+		headers: []string{"№", "Заказ", "Дата", "Машина", "Выручка", "Доля начисления"},
+		widths:  []float64{10, 20, 40, 40, 40, 40},
+		aligns:  []string{"C", "R", "L", "L", "R", "R"},
+	}
+	for i, order := range payslip.Orders {
+		orders.rows = append(orders.rows, []interface{}{
+			i + 1, order.OrderID, order.CreatedAt.Format("02.01.2006 15:04"), order.VehicleNumber, order.Revenue, order.Share,
+		})
+	}
+	if len(orders.rows) > 0 {
+		sections = append(sections, orders)
+	}
+
+	entries := func(title string, list []models.PenaltyOrBonus) {
+		section := payslipSection{
+			title:   title,
+			headers: []string{"Дата", "Описание", "Сумма"},
+			widths:  []float64{30, 130, 30},
+			aligns:  []string{"L", "L", "R"},
+		}
+		for _, entry := range list {
+			section.rows = append(section.rows, []interface{}{entry.CreatedAt.Format("02.01.2006"), entry.Desc, money.FromRubles(entry.Amount)})
+		}
+		if len(section.rows) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	entries("Бонусы", payslip.Bonuses)
+	entries("Штрафы", payslip.Penalties)
+
+	adjustments := payslipSection{
+		title:   "Перерасчеты за прошлые периоды",
+		headers: []string{"Описание", "Сумма"},
+		widths:  []float64{160, 30},
+		aligns:  []string{"L", "R"},
+	}
+	for _, adjustment := range payslip.Adjustments {
+		adjustments.rows = append(adjustments.rows, []interface{}{adjustment.Description, adjustment.Amount})
+	}
+	if len(adjustments.rows) > 0 {
+		sections = append(sections, adjustments)
+	}
+
+	advances := payslipSection{
+		title:   "Авансы",
+		headers: []string{"Дата", "Способ", "Описание", "Сумма"},
+		widths:  []float64{30, 30, 100, 30},
+		aligns:  []string{"L", "L", "L", "R"},
+	}
+	for _, payment := range payslip.Advances {
+		advances.rows = append(advances.rows, []interface{}{payment.PaidAt.Format("02.01.2006"), payment.Method, payment.Description, payment.Amount})
+	}
+	if len(advances.rows) > 0 {
+		sections = append(sections, advances)
+	}
+	return sections
+}
+
+// payslipTotals строки итогов листка: подпись и сумма
+func payslipTotals(payslip models.Payslip) [][2]interface{} {
+	totals := payslip.Totals
+	return [][2]interface{}{
+		{"Начислено по схемам", totals.Accrued},
+		{"Бонусы", totals.Bonuses},
+		{"Штрафы", -totals.Penalties},
+		{"Перерасчеты", totals.Adjustments},
+		{"Авансы", -totals.Advances},
+		{"К выплате", totals.Total},
+	}
+}
+
+// payslipCell переводит значение ячейки в текст для PDF
+func payslipCell(value interface{}) string {
+	switch v := value.(type) {
+	case money.Amount:
+		return money.Format(v)
+	case float64:
+		return money.Format(money.FromFloat(v))
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// payslipExcelValue переводит суммы в рубли, чтобы Excel записал их числами
+func payslipExcelValue(value interface{}) interface{} {
+	if amount, ok := value.(money.Amount); ok {
+		return amount.Rubles()
+	}
+	return value
+}
+
+// PayslipPDF формирует расчетный листок в PDF
+func (s *PayrollService) PayslipPDF(payslip models.Payslip) (*bytes.Buffer, error) {
+	logger.Debug("Формирование PDF расчетного листка работника ID:%d за период ID:%d", payslip.WorkerID, payslip.PeriodID)
+	pdf, err := newDocumentPDF(s.fonts)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf.SetFont("doc", "B", 14)
+	pdf.MultiCell(0, 8, payslipTitle(payslip), "", "L", false)
+	pdf.SetFont("doc", "", 10)
+	if s.company.Name != "" {
+		pdf.MultiCell(0, 5, s.company.Name, "", "L", false)
+	}
+	pdf.MultiCell(0, 5, "Работник: "+payslip.WorkerName, "", "L", false)
+	pdf.MultiCell(0, 5, "Статус расчета: "+payslip.Status, "", "L", false)
+	pdf.Ln(3)
+
+	for _, section := range payslipSections(payslip) {
+		pdf.SetFont("doc", "B", 11)
+		pdf.MultiCell(0, 6, section.title, "", "L", false)
+		pdf.SetFont("doc", "B", 9)
+		pdfTableRowWidths(pdf, section.widths, section.aligns, section.headers)
+		pdf.SetFont("doc", "", 9)
+		for _, row := range section.rows {
+			values := make([]string, len(row))
+			for i, value := range row {
+				values[i] = payslipCell(value)
+			}
+			pdfTableRowWidths(pdf, section.widths, section.aligns, values)
+		}
+		pdf.Ln(3)
+	}
+
+	totals := payslipTotals(payslip)
+	for i, total := range totals {
+		if i == len(totals)-1 {
+			pdf.SetFont("doc", "B", 11)
+		} else {
+			pdf.SetFont("doc", "", 10)
+		}
+		pdf.CellFormat(150, 6, total[0].(string)+":", "", 0, "R", false, 0, "")
+		pdf.CellFormat(40, 6, payslipCell(total[1]), "", 1, "R", false, 0, "")
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := pdf.Output(buffer); err != nil {
+		logger.Error("Ошибка при формировании PDF: %v", err)
+		return nil, fmt.Errorf("ошибка при формировании PDF: %w", err)
+	}
+
+	logger.Info("PDF расчетного листка работника ID:%d сформирован", payslip.WorkerID)
+	return buffer, nil
+}
+
+// PayslipExcel формирует расчетный листок в Excel
+func (s *PayrollService) PayslipExcel(payslip models.Payslip) (*bytes.Buffer, error) {
+	logger.Debug("Формирование Excel расчетного листка работника ID:%d за период ID:%d", payslip.WorkerID, payslip.PeriodID)
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Расчетный листок"
+	f.SetSheetName("Sheet1", sheet)
+
+	style, err := newHeaderStyle(f)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании стиля: %w", err)
+	}
+
+	f.SetCellValue(sheet, "A1", payslipTitle(payslip))
+	f.SetCellValue(sheet, "A2", "Работник")
+	f.SetCellValue(sheet, "B2", payslip.WorkerName)
+	f.SetCellValue(sheet, "A3", "Статус расчета")
+	f.SetCellValue(sheet, "B3", payslip.Status)
+	row := 5
+
+	// Числа пишутся числами, чтобы с файлом можно было работать дальше
+	for _, section := range payslipSections(payslip) {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), section.title)
+		row++
+		for i, header := range section.headers {
+			cell, _ := excelize.CoordinatesToCellName(i+1, row)
+			f.SetCellValue(sheet, cell, header)
+			f.SetCellStyle(sheet, cell, cell, style)
+		}
+		row++
+		for _, values := range section.rows {
+			for col, value := range values {
+				cell, _ := excelize.CoordinatesToCellName(col+1, row)
+				f.SetCellValue(sheet, cell, payslipExcelValue(value))
+			}
+			row++
+		}
+		row++
+	}
+
+	for _, total := range payslipTotals(payslip) {
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), total[0])
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), payslipExcelValue(total[1]))
+		row++
+	}
+
+	f.SetColWidth(sheet, "A", "A", 22)
+	f.SetColWidth(sheet, "B", "D", 18)
+	f.SetColWidth(sheet, "E", "F", 20)
+
+	buffer := new(bytes.Buffer)
+	if err := f.Write(buffer); err != nil {
+		logger.Error("Ошибка при сохранении файла: %v", err)
+		return nil, fmt.Errorf("ошибка при сохранении файла: %w", err)
+	}
+
+	logger.Info("Excel расчетного листка работника ID:%d сформирован", payslip.WorkerID)
+	return buffer, nil
+}
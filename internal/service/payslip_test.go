@@ -0,0 +1,71 @@
+package service
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/money"
+	"testing"
+	"time"
+)
+
+func TestPayslipOrders(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 10, 0, 0, 0, time.UTC) }
+	order := func(id, d int, revenue float64) models.Order {
+		return models.Order{ID: id, CreatedAt: day(d), TotalAmount: revenue}
+	}
+
+	tests := []struct {
+		name   string
+		orders []models.Order
+		parts  []models.SalaryPart
+		ids    []int
+		shares []money.Amount
+	}{
+		{
+			name:   "остаток округления достается последнему заказу",
+			orders: []models.Order{order(1, 1, 100), order(2, 2, 100), order(3, 3, 100)},
+			parts:  []models.SalaryPart{{From: day(1), To: day(4), Revenue: 300, Amount: 10000}},
+			ids:    []int{1, 2, 3},
+			shares: []money.Amount{3333, 3333, 3334},
+		},
+		{
+			name:   "заказы сортируются по дате",
+			orders: []models.Order{order(3, 3, 100), order(1, 1, 300)},
+			parts:  []models.SalaryPart{{From: day(1), To: day(4), Revenue: 400, Amount: 4000}},
+			ids:    []int{1, 3},
+			shares: []money.Amount{3000, 1000},
+		},
+		{
+			name:   "доли по частям периода с разными схемами",
+			orders: []models.Order{order(1, 1, 100), order(2, 5, 200), order(3, 6, 100)},
+			parts: []models.SalaryPart{
+				{From: day(1), To: day(5), Revenue: 100, Amount: 1500},
+				{From: day(5), To: day(8), Revenue: 300, Amount: 1000},
+			},
+			ids:    []int{1, 2, 3},
+			shares: []money.Amount{1500, 667, 333},
+		},
+		{
+			name:   "часть без выручки пропускается",
+			orders: []models.Order{order(1, 1, 100), order(2, 10, 100)},
+			parts: []models.SalaryPart{
+				{From: day(1), To: day(5), Revenue: 100, Amount: 2000},
+				{From: day(5), To: day(8), Revenue: 0, Amount: 5000},
+			},
+			ids:    []int{1, 2},
+			shares: []money.Amount{2000, 0},
+		},
+	}
+	for _, tt := range tests {
+		got := payslipOrders(tt.orders, tt.parts)
+		if len(got) != len(tt.ids) {
+			t.Errorf("%s: %d заказов, ожидалось %d", tt.name, len(got), len(tt.ids))
+			continue
+		}
+		for i := range got {
+			if got[i].OrderID != tt.ids[i] || got[i].Share != tt.shares[i] {
+				t.Errorf("%s: строка %d заказ %d доля %s, ожидалось заказ %d доля %s", tt.name, i,
+					got[i].OrderID, got[i].Share, tt.ids[i], tt.shares[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"strings"
+	"time"
+)
+
+func (s *WorkerServiceImpl) AddBonus(bonus models.PenaltyOrBonus) (int, error) {
+	logger.Debug("Добавление бонуса работнику ID:%d в сервисе", bonus.WorkerID)
+	if err := s.validatePenaltyOrBonus(&bonus); err != nil {
+		return 0, err
+	}
+	if err := s.checkEntryPeriod(time.Now()); err != nil {
+		return 0, err
+	}
+	return s.repo.AddBonus(bonus)
+}
+
+func (s *WorkerServiceImpl) GetBonuses(workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error) {
+	return s.repo.GetBonuses(workerID, start, end)
+}
+
+func (s *WorkerServiceImpl) AddPenalty(penalty models.PenaltyOrBonus) (int, error) {
+	logger.Debug("Добавление штрафа работнику ID:%d в сервисе", penalty.WorkerID)
+	if err := s.validatePenaltyOrBonus(&penalty); err != nil {
+		return 0, err
+	}
+	if err := s.checkEntryPeriod(time.Now()); err != nil {
+		return 0, err
+	}
+	return s.repo.AddPenalty(penalty)
+}
+
+func (s *WorkerServiceImpl) GetPenalties(workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error) {
+	return s.repo.GetPenalties(workerID, start, end)
+}
+
+// UpdatePenaltyOrBonus исправляет штраф или бонус, причина исправления обязательна и попадает в историю
+func (s *WorkerServiceImpl) UpdatePenaltyOrBonus(kind string, entry models.PenaltyOrBonus, reason string, userID int) error {
+	logger.Debug("Исправление записи '%s' ID:%d в сервисе", kind, entry.ID)
+	reason, err := s.prepareEntryChange(kind, entry.WorkerID, entry.ID, reason)
+	if err != nil {
+		return err
+	}
+	if err := s.validatePenaltyOrBonus(&entry); err != nil {
+		return err
+	}
+	return s.repo.UpdatePenaltyOrBonus(kind, entry, reason, optionalID(userID))
+}
+
+// RevokePenaltyOrBonus отменяет штраф или бонус: запись остается для истории, но не учитывается в зарплате
+func (s *WorkerServiceImpl) RevokePenaltyOrBonus(kind string, workerID, id int, reason string, userID int) error {
+	logger.Debug("Отмена записи '%s' ID:%d в сервисе", kind, id)
+	reason, err := s.prepareEntryChange(kind, workerID, id, reason)
+	if err != nil {
+		return err
+	}
+	return s.repo.RevokePenaltyOrBonus(kind, workerID, id, reason, optionalID(userID))
+}
+
+func (s *WorkerServiceImpl) GetPenaltyOrBonusHistory(kind string, workerID, id int) ([]models.PenaltyOrBonusChange, error) {
+	if err := checkEntryKind(kind); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPenaltyOrBonusChanges(kind, workerID, id)
+}
+
+// prepareEntryChange проверяет вид записи и причину изменения и что запись не попала в утвержденный расчет
+func (s *WorkerServiceImpl) prepareEntryChange(kind string, workerID, id int, reason string) (string, error) {
+	if err := checkEntryKind(kind); err != nil {
+		return "", err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", fmt.Errorf("не указана причина изменения")
+	}
+	entry, err := s.repo.GetPenaltyOrBonusById(kind, id)
+	if err != nil {
+		return "", err
+	}
+	if entry.WorkerID != workerID {
+		return "", fmt.Errorf("запись '%s' с ID %d не найдена у работника", kind, id)
+	}
+	return reason, s.checkEntryPeriod(entry.CreatedAt)
+}
+
+// checkEntryPeriod не дает менять штрафы и бонусы в утвержденном расчете зарплаты.
+// Изменения в закрытых расчетах переносятся корректировкой в следующий расчет.
+func (s *WorkerServiceImpl) checkEntryPeriod(at time.Time) error {
+	periods, err := s.repo.GetPayrollPeriods()
+	if err != nil {
+		return err
+	}
+	for _, period := range periods {
+		if period.Status != models.PayrollApproved || at.Before(period.PeriodStart) || !at.Before(period.PeriodEnd.AddDate(0, 0, 1)) {
+			continue
+		}
+		return fmt.Errorf("расчет зарплаты за %s - %s утвержден, для изменения штрафов и бонусов верните его в черновик",
+			period.PeriodStart.Format("02.01.2006"), period.PeriodEnd.Format("02.01.2006"))
+	}
+	return nil
+}
+
+// validatePenaltyOrBonus проверяет сумму и категорию, заказ должен существовать и быть выполнен этим работником
+func (s *WorkerServiceImpl) validatePenaltyOrBonus(entry *models.PenaltyOrBonus) error {
+	entry.Desc = strings.TrimSpace(entry.Desc)
+	entry.Category = strings.ToLower(strings.TrimSpace(entry.Category))
+	if entry.Amount <= 0 {
+		return fmt.Errorf("сумма должна быть больше нуля")
+	}
+	if entry.Category == "" {
+		entry.Category = models.CategoryOther
+	}
+	known := false
+	for _, category := range models.PenaltyBonusCategories {
+		if entry.Category == category {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("неизвестная категория '%s', допустимые: %s",
+			entry.Category, strings.Join(models.PenaltyBonusCategories, ", "))
+	}
+
+	if entry.OrderID != nil && *entry.OrderID == 0 {
+		entry.OrderID = nil
+	}
+	if entry.OrderID != nil {
+		orderWorker, _, err := s.repo.GetOrderWorker(*entry.OrderID)
+		if err != nil {
+			return err
+		}
+		if orderWorker != nil && *orderWorker != entry.WorkerID {
+			return fmt.Errorf("заказ с ID %d выполнял другой работник", *entry.OrderID)
+		}
+	}
+	return nil
+}
+
+func checkEntryKind(kind string) error {
+	if kind != models.EntryPenalty && kind != models.EntryBonus {
+		return fmt.Errorf("неизвестный вид записи '%s'", kind)
+	}
+	return nil
+}
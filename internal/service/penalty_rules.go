@@ -0,0 +1,214 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *PayrollService) GetRules() ([]models.PenaltyBonusRule, error) {
+	return s.repo.GetPenaltyBonusRules(false)
+}
+
+// CreateRule заводит правило, новое правило сразу действует
+func (s *PayrollService) CreateRule(rule models.PenaltyBonusRule) (int, error) {
+	logger.Debug("Создание правила штрафов и бонусов в сервисе")
+	if err := validatePenaltyBonusRule(&rule); err != nil {
+		return 0, err
+	}
+	rule.Active = true
+	return s.repo.CreatePenaltyBonusRule(rule)
+}
+
+func (s *PayrollService) UpdateRule(id int, rule models.PenaltyBonusRule) error {
+	logger.Debug("Обновление правила ID:%d в сервисе", id)
+	if err := validatePenaltyBonusRule(&rule); err != nil {
+		return err
+	}
+	return s.repo.UpdatePenaltyBonusRule(id, rule)
+}
+
+func (s *PayrollService) DeleteRule(id int) error {
+	return s.repo.DeletePenaltyBonusRule(id)
+}
+
+// ruleEntries записи одного вида по правилам для расчета зарплаты
+type ruleEntries struct {
+	added    []models.PenaltyOrBonus // новые записи, кроме исправленных менеджером
+	replaced map[int]bool            // ID записей прошлого расчета, которые заменяются новыми
+}
+
+// merge подставляет записи правил в действующие записи работника из базы: заменяемые убирает, новые добавляет
+func (e ruleEntries) merge(workerID int, stored []models.PenaltyOrBonus) []models.PenaltyOrBonus {
+	result := []models.PenaltyOrBonus{}
+	for _, entry := range stored {
+		if !e.replaced[entry.ID] {
+			result = append(result, entry)
+		}
+	}
+	for _, entry := range e.added {
+		if entry.WorkerID == workerID {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// periodRules штрафы и бонусы по правилам, которые сохраняются вместе с расчетом зарплаты
+type periodRules struct {
+	penalties ruleEntries
+	bonuses   ruleEntries
+}
+
+// periodRules считает штрафы и бонусы по действующим правилам за период расчета зарплаты.
+// Записи прошлого расчета, исправленные или отмененные менеджером, остаются, и правило не создает их повторно.
+// Записи датируются серединой последнего дня периода, чтобы попасть в период в любом часовом поясе.
+func (s *PayrollService) periodRules(period models.PayrollPeriod) (periodRules, error) {
+	result := periodRules{}
+	rules, err := s.repo.GetPenaltyBonusRules(true)
+	if err != nil {
+		return result, err
+	}
+	metrics, err := s.repo.GetWorkerRuleMetrics(period.PeriodStart, period.PeriodEnd.AddDate(0, 0, 1))
+	if err != nil {
+		return result, err
+	}
+	storedPenalties, storedBonuses, err := s.repo.GetPeriodRuleEntries(period.ID)
+	if err != nil {
+		return result, err
+	}
+
+	overridden := make(map[string]map[[2]int]bool)
+	for kind, stored := range map[string][]models.PenaltyOrBonus{models.EntryPenalty: storedPenalties, models.EntryBonus: storedBonuses} {
+		overridden[kind] = make(map[[2]int]bool)
+		replaced := make(map[int]bool)
+		for _, entry := range stored {
+			if entry.UpdatedAt == nil && entry.RevokedAt == nil {
+				replaced[entry.ID] = true
+			} else if entry.RuleID != nil {
+				overridden[kind][[2]int{*entry.RuleID, entry.WorkerID}] = true
+			}
+		}
+		if kind == models.EntryPenalty {
+			result.penalties.replaced = replaced
+		} else {
+			result.bonuses.replaced = replaced
+		}
+	}
+
+	at := period.PeriodEnd.Add(12 * time.Hour)
+	for _, m := range metrics {
+		for _, rule := range rules {
+			if rule.WorkerID != nil && *rule.WorkerID != m.WorkerID {
+				continue
+			}
+			if overridden[rule.Kind][[2]int{rule.ID, m.WorkerID}] {
+				continue
+			}
+			entry, ok := ruleEntry(rule, m)
+			if !ok {
+				continue
+			}
+			entry.CreatedAt = at
+			if rule.Kind == models.EntryPenalty {
+				result.penalties.added = append(result.penalties.added, entry)
+			} else {
+				result.bonuses.added = append(result.bonuses.added, entry)
+			}
+		}
+	}
+
+	logger.Debug("По правилам для расчета зарплаты ID:%d: штрафов %d, бонусов %d",
+		period.ID, len(result.penalties.added), len(result.bonuses.added))
+	return result, nil
+}
+
+// ruleEntry возвращает запись, которую правило начисляет работнику с показателями m
+func ruleEntry(rule models.PenaltyBonusRule, m models.WorkerRuleMetrics) (models.PenaltyOrBonus, bool) {
+	value := m.Value(rule.Metric)
+	if !rule.Matches(value) {
+		return models.PenaltyOrBonus{}, false
+	}
+	amount := rule.Amount
+	if rule.PerUnit {
+		amount = int(math.Round(float64(rule.Amount) * value))
+	}
+	if amount <= 0 {
+		return models.PenaltyOrBonus{}, false
+	}
+
+	ruleID := rule.ID
+	return models.PenaltyOrBonus{
+		WorkerID: m.WorkerID,
+		Amount:   amount,
+		Category: rule.Category,
+		Desc: fmt.Sprintf("%s: %s %s %s %s", rule.Name, rule.Metric,
+			strconv.FormatFloat(value, 'f', -1, 64), rule.Operator, strconv.FormatFloat(rule.Threshold, 'f', -1, 64)),
+		RuleID: &ruleID,
+	}, true
+}
+
+// validatePenaltyBonusRule проверяет вид, показатель, условие и сумму правила
+func validatePenaltyBonusRule(rule *models.PenaltyBonusRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Metric = strings.ToLower(strings.TrimSpace(rule.Metric))
+	rule.Category = strings.ToLower(strings.TrimSpace(rule.Category))
+	rule.Operator = strings.TrimSpace(rule.Operator)
+	if rule.Name == "" {
+		return fmt.Errorf("не указано название правила")
+	}
+	if err := checkEntryKind(rule.Kind); err != nil {
+		return err
+	}
+
+	known := false
+	for _, metric := range models.RuleMetrics {
+		if rule.Metric == metric {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("неизвестный показатель '%s', допустимые: %s", rule.Metric, strings.Join(models.RuleMetrics, ", "))
+	}
+
+	if rule.Operator == "" {
+		rule.Operator = ">="
+	}
+	if rule.Operator != ">=" && rule.Operator != "<" {
+		return fmt.Errorf("неизвестное условие '%s', допустимые: >=, <", rule.Operator)
+	}
+	if rule.Threshold < 0 {
+		return fmt.Errorf("порог правила не может быть отрицательным")
+	}
+	if rule.Amount <= 0 {
+		return fmt.Errorf("сумма должна быть больше нуля")
+	}
+	if rule.PerUnit && rule.Metric == models.RuleMetricRevenue {
+		return fmt.Errorf("сумма за единицу не применяется к выручке")
+	}
+
+	if rule.Category == "" {
+		rule.Category = models.CategoryOther
+	}
+	known = false
+	for _, category := range models.PenaltyBonusCategories {
+		if rule.Category == category {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("неизвестная категория '%s', допустимые: %s",
+			rule.Category, strings.Join(models.PenaltyBonusCategories, ", "))
+	}
+
+	if rule.WorkerID != nil && *rule.WorkerID == 0 {
+		rule.WorkerID = nil
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"go-hinomontaj/models"
+	"testing"
+)
+
+func TestRuleEntry(t *testing.T) {
+	metrics := models.WorkerRuleMetrics{WorkerID: 7, Orders: 12, Revenue: 54000, Reworks: 3, Complaints: 0}
+
+	tests := []struct {
+		name   string
+		rule   models.PenaltyBonusRule
+		ok     bool
+		amount int
+		desc   string
+	}{
+		{
+			name:   "порог достигнут",
+			rule:   models.PenaltyBonusRule{ID: 1, Name: "план", Metric: models.RuleMetricOrders, Operator: ">=", Threshold: 10, Amount: 1000},
+			ok:     true,
+			amount: 1000,
+			desc:   "план: заказы 12 >= 10",
+		},
+		{
+			name: "порог не достигнут",
+			rule: models.PenaltyBonusRule{ID: 2, Name: "план", Metric: models.RuleMetricOrders, Operator: ">=", Threshold: 20, Amount: 1000},
+		},
+		{
+			name:   "меньше порога",
+			rule:   models.PenaltyBonusRule{ID: 3, Name: "мало выручки", Metric: models.RuleMetricRevenue, Operator: "<", Threshold: 60000.5, Amount: 500},
+			ok:     true,
+			amount: 500,
+			desc:   "мало выручки: выручка 54000 < 60000.5",
+		},
+		{
+			name:   "сумма за каждую единицу",
+			rule:   models.PenaltyBonusRule{ID: 4, Name: "переделки", Metric: models.RuleMetricReworks, Operator: ">=", Threshold: 1, Amount: 300, PerUnit: true},
+			ok:     true,
+			amount: 900,
+			desc:   "переделки: переделки 3 >= 1",
+		},
+		{
+			name: "за единицу при нулевом показателе",
+			rule: models.PenaltyBonusRule{ID: 5, Name: "жалобы", Metric: models.RuleMetricComplaints, Operator: ">=", Threshold: 0, Amount: 300, PerUnit: true},
+		},
+	}
+	for _, tt := range tests {
+		tt.rule.Kind, tt.rule.Category = models.EntryPenalty, models.CategoryOther
+		entry, ok := ruleEntry(tt.rule, metrics)
+		if ok != tt.ok {
+			t.Errorf("%s: применено %v, ожидалось %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if entry.WorkerID != metrics.WorkerID || entry.Amount != tt.amount || entry.Category != models.CategoryOther {
+			t.Errorf("%s: работник %d сумма %d категория '%s', ожидалось %d, %d, '%s'", tt.name,
+				entry.WorkerID, entry.Amount, entry.Category, metrics.WorkerID, tt.amount, models.CategoryOther)
+		}
+		if entry.Desc != tt.desc {
+			t.Errorf("%s: описание %q, ожидалось %q", tt.name, entry.Desc, tt.desc)
+		}
+		if entry.RuleID == nil || *entry.RuleID != tt.rule.ID {
+			t.Errorf("%s: запись не связана с правилом %d", tt.name, tt.rule.ID)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type ReceivablesService struct {
+	repo Repository
+}
+
+func NewReceivablesService(repo Repository) *ReceivablesService {
+	return &ReceivablesService{repo: repo}
+}
+
+// roundMoney округляет сумму до копеек
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// optionalID возвращает указатель на ID или nil для 0
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// summarizeLedger считает долг клиента на дату asOf. Оплаты гасят самые старые начисления,
+// остаток неоплаченных начислений раскладывается по давности.
+func summarizeLedger(entries []models.LedgerEntry, asOf time.Time) models.Receivables {
+	var result models.Receivables
+	var paid money.Amount
+	for _, e := range entries {
+		if e.Type == models.LedgerPayment {
+			result.Paid += e.Amount
+			paid += e.Amount
+		} else {
+			result.Charged += e.Amount
+		}
+	}
+
+	for _, e := range entries {
+		if e.Type != models.LedgerCharge {
+			continue
+		}
+		open := e.Amount
+		applied := min(paid, open)
+		paid -= applied
+		open -= applied
+		if open <= 0 {
+			continue
+		}
+
+		switch days := int(asOf.Sub(e.Date).Hours() / 24); {
+		case days <= 30:
+			result.Aging.Days0To30 += open
+		case days <= 60:
+			result.Aging.Days31To60 += open
+		case days <= 90:
+			result.Aging.Days61To90 += open
+		default:
+			result.Aging.Over90 += open
+		}
+	}
+
+	result.Balance = result.Charged - result.Paid
+	result.AsOf = asOf
+	return result
+}
+
+// GetClientReceivables возвращает долг клиента и его разбивку по давности, contractID 0 - по всем договорам
+func (s *ReceivablesService) GetClientReceivables(clientID, contractID int) (models.Receivables, error) {
+	logger.Debug("Получение расчетов с клиентом ID:%d в сервисе", clientID)
+	client, err := s.getClient(clientID)
+	if err != nil {
+		return models.Receivables{}, err
+	}
+
+	entries, err := s.repo.GetLedgerEntries(client.ID, contractID, time.Time{})
+	if err != nil {
+		return models.Receivables{}, err
+	}
+
+	result := summarizeLedger(entries, time.Now())
+	result.ClientID = client.ID
+	result.ClientName = client.Name
+	result.ContractID = optionalID(contractID)
+	return result, nil
+}
+
+// GetAllReceivables возвращает клиентов с ненулевым сальдо, самые большие долги первыми
+func (s *ReceivablesService) GetAllReceivables() ([]models.Receivables, error) {
+	logger.Debug("Получение расчетов со всеми клиентами в сервисе")
+	entries, err := s.repo.GetLedgerEntries(0, 0, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	clients, err := s.repo.GetAllClients()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(clients))
+	for _, c := range clients {
+		names[c.ID] = c.Name
+	}
+
+	// Записи отсортированы по клиенту, поэтому группы идут подряд
+	now := time.Now()
+	result := []models.Receivables{}
+	for start := 0; start < len(entries); {
+		end := start
+		for end < len(entries) && entries[end].ClientID == entries[start].ClientID {
+			end++
+		}
+		summary := summarizeLedger(entries[start:end], now)
+		if summary.Balance != 0 {
+			summary.ClientID = entries[start].ClientID
+			summary.ClientName = names[summary.ClientID]
+			result = append(result, summary)
+		}
+		start = end
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Balance > result[j].Balance })
+	return result, nil
+}
+
+// AddPayment сохраняет оплату клиента, дата по умолчанию - сегодня
+func (s *ReceivablesService) AddPayment(clientID, userID int, payment models.ClientPayment) (int, error) {
+	logger.Debug("Добавление оплаты клиента ID:%d в сервисе", clientID)
+	if payment.Amount <= 0 {
+		return 0, fmt.Errorf("сумма оплаты должна быть больше нуля")
+	}
+
+	date := time.Now()
+	if payment.Date != nil {
+		if payment.Date.After(date) {
+			return 0, fmt.Errorf("дата оплаты не может быть в будущем")
+		}
+		date = *payment.Date
+	}
+
+	clientID, err := resolveClientID(s.repo, clientID)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.AddClientPayment(models.LedgerEntry{
+		ClientID:    clientID,
+		ContractID:  payment.ContractID,
+		Amount:      payment.Amount,
+		Date:        date,
+		Document:    strings.TrimSpace(payment.Document),
+		Description: strings.TrimSpace(payment.Description),
+		UserID:      optionalID(userID),
+	})
+}
+
+func (s *ReceivablesService) DeletePayment(clientID, id int) error {
+	logger.Debug("Удаление оплаты ID:%d клиента ID:%d в сервисе", id, clientID)
+	return s.repo.DeleteClientPayment(clientID, id)
+}
+
+// GetStatement формирует выписку за период [from, to) с входящим и исходящим сальдо
+func (s *ReceivablesService) GetStatement(clientID, contractID int, from, to time.Time) (models.Statement, error) {
+	logger.Debug("Формирование выписки клиента ID:%d за период %v - %v", clientID, from, to)
+	client, err := s.getClient(clientID)
+	if err != nil {
+		return models.Statement{}, err
+	}
+
+	entries, err := s.repo.GetLedgerEntries(client.ID, contractID, to)
+	if err != nil {
+		return models.Statement{}, err
+	}
+
+	statement := models.Statement{
+		ClientID:   client.ID,
+		ClientName: client.Name,
+		ContractID: optionalID(contractID),
+		From:       from,
+		To:         to.AddDate(0, 0, -1),
+		Entries:    []models.LedgerEntry{},
+	}
+
+	var balance money.Amount
+	for _, e := range entries {
+		delta := e.Amount
+		if e.Type == models.LedgerPayment {
+			delta = -e.Amount
+		}
+		balance += delta
+		if e.Date.Before(from) {
+			statement.OpeningBalance += delta
+			continue
+		}
+
+		if e.Type == models.LedgerPayment {
+			statement.Paid += e.Amount
+		} else {
+			statement.Charged += e.Amount
+		}
+		e.Balance = balance
+		statement.Entries = append(statement.Entries, e)
+	}
+
+	statement.ClosingBalance = balance
+	return statement, nil
+}
+
+// ExportStatement выгружает выписку за период в Excel
+func (s *ReceivablesService) ExportStatement(clientID, contractID int, from, to time.Time) (*bytes.Buffer, error) {
+	statement, err := s.GetStatement(clientID, contractID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Выписка"
+	f.SetSheetName("Sheet1", sheet)
+
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("Выписка по расчетам: %s", statement.ClientName))
+	f.SetCellValue(sheet, "A2", fmt.Sprintf("Период: %s - %s",
+		statement.From.Format("02.01.2006"), statement.To.Format("02.01.2006")))
+	f.SetCellValue(sheet, "A3", "Сальдо на начало")
+	f.SetCellValue(sheet, "G3", statement.OpeningBalance.Rubles())
+
+	headers := []string{"Дата", "Операция", "Документ", "Описание", "Начислено", "Оплачено", "Сальдо"}
+	style, err := newHeaderStyle(f)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании стиля: %w", err)
+	}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 5)
+		f.SetCellValue(sheet, cell, header)
+		f.SetCellStyle(sheet, cell, cell, style)
+	}
+
+	row := 6
+	for _, e := range statement.Entries {
+		var charged, paid interface{}
+		if e.Type == models.LedgerPayment {
+			paid = e.Amount.Rubles()
+		} else {
+			charged = e.Amount.Rubles()
+		}
+		values := []interface{}{e.Date.Format("02.01.2006"), e.Type, e.Document, e.Description, charged, paid, e.Balance.Rubles()}
+		for col, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, row)
+			f.SetCellValue(sheet, cell, value)
+		}
+		row++
+	}
+
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "Итого за период")
+	f.SetCellValue(sheet, fmt.Sprintf("E%d", row), statement.Charged.Rubles())
+	f.SetCellValue(sheet, fmt.Sprintf("F%d", row), statement.Paid.Rubles())
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row+1), "Сальдо на конец")
+	f.SetCellValue(sheet, fmt.Sprintf("G%d", row+1), statement.ClosingBalance.Rubles())
+
+	f.SetColWidth(sheet, "A", "A", 18)
+	f.SetColWidth(sheet, "B", "C", 15)
+	f.SetColWidth(sheet, "D", "D", 35)
+	f.SetColWidth(sheet, "E", "G", 14)
+
+	buffer := new(bytes.Buffer)
+	if err := f.Write(buffer); err != nil {
+		logger.Error("Ошибка при сохранении файла: %v", err)
+		return nil, fmt.Errorf("ошибка при сохранении файла: %w", err)
+	}
+
+	logger.Info("Выписка клиента ID:%d сформирована, записей: %d", statement.ClientID, len(statement.Entries))
+	return buffer, nil
+}
+
+// getClient находит клиента с учетом объединения
+func (s *ReceivablesService) getClient(id int) (models.Client, error) {
+	id, err := resolveClientID(s.repo, id)
+	if err != nil {
+		return models.Client{}, err
+	}
+	return s.repo.GetClientById(id)
+}
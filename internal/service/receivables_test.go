@@ -0,0 +1,86 @@
+package service
+
+import (
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/money"
+	"testing"
+	"time"
+)
+
+func TestSummarizeLedger(t *testing.T) {
+	asOf := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	charge := func(daysAgo int, rubles int) models.LedgerEntry {
+		return models.LedgerEntry{Type: models.LedgerCharge, Amount: money.FromRubles(rubles), Date: asOf.AddDate(0, 0, -daysAgo)}
+	}
+	payment := func(daysAgo int, rubles int) models.LedgerEntry {
+		return models.LedgerEntry{Type: models.LedgerPayment, Amount: money.FromRubles(rubles), Date: asOf.AddDate(0, 0, -daysAgo)}
+	}
+
+	tests := []struct {
+		name    string
+		entries []models.LedgerEntry
+		aging   models.ReceivablesAging
+		charged money.Amount
+		paid    money.Amount
+		balance money.Amount
+	}{
+		{
+			name:    "без записей",
+			entries: nil,
+		},
+		{
+			name:    "оплата гасит самые старые начисления",
+			entries: []models.LedgerEntry{charge(100, 1000), charge(70, 500), charge(40, 300), charge(10, 200), payment(5, 1200)},
+			aging: models.ReceivablesAging{
+				Days0To30:  money.FromRubles(200),
+				Days31To60: money.FromRubles(300),
+				Days61To90: money.FromRubles(300),
+			},
+			charged: money.FromRubles(2000),
+			paid:    money.FromRubles(1200),
+			balance: money.FromRubles(800),
+		},
+		{
+			name:    "без оплат",
+			entries: []models.LedgerEntry{charge(120, 100), charge(1, 50)},
+			aging:   models.ReceivablesAging{Days0To30: money.FromRubles(50), Over90: money.FromRubles(100)},
+			charged: money.FromRubles(150),
+			balance: money.FromRubles(150),
+		},
+		{
+			name:    "переплата",
+			entries: []models.LedgerEntry{charge(10, 100), payment(5, 150)},
+			charged: money.FromRubles(100),
+			paid:    money.FromRubles(150),
+			balance: money.FromRubles(-50),
+		},
+		{
+			name:    "границы 30 и 31 дня",
+			entries: []models.LedgerEntry{charge(31, 20), charge(30, 10)},
+			aging:   models.ReceivablesAging{Days0To30: money.FromRubles(10), Days31To60: money.FromRubles(20)},
+			charged: money.FromRubles(30),
+			balance: money.FromRubles(30),
+		},
+		{
+			name:    "частичная оплата в копейках",
+			entries: []models.LedgerEntry{charge(50, 100), {Type: models.LedgerPayment, Amount: 1, Date: asOf}},
+			aging:   models.ReceivablesAging{Days31To60: 9999},
+			charged: money.FromRubles(100),
+			paid:    1,
+			balance: 9999,
+		},
+	}
+	for _, tt := range tests {
+		got := summarizeLedger(tt.entries, asOf)
+		if got.Aging != tt.aging {
+			t.Errorf("%s: по давности %+v, ожидалось %+v", tt.name, got.Aging, tt.aging)
+		}
+		if got.Charged != tt.charged || got.Paid != tt.paid || got.Balance != tt.balance {
+			t.Errorf("%s: начислено %s, оплачено %s, долг %s; ожидалось %s, %s, %s", tt.name,
+				got.Charged, got.Paid, got.Balance, tt.charged, tt.paid, tt.balance)
+		}
+		if !got.AsOf.Equal(asOf) {
+			t.Errorf("%s: дата %v, ожидалось %v", tt.name, got.AsOf, asOf)
+		}
+	}
+}
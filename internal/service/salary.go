@@ -0,0 +1,335 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"go-hinomontaj/pkg/money"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SalaryWork выработка работника за часть периода, в которой действует одна схема оплаты.
+// Смена - день, в который работник отметил приход по табелю или выполнял заказы.
+type SalaryWork struct {
+	Orders     int
+	Revenue    float64
+	DayRevenue map[string]float64 // выручка по дням YYYY-MM-DD
+	ShiftDays  map[string]bool    // отработанные дни YYYY-MM-DD
+	Services   map[string]int     // количество выполненных услуг по названию
+}
+
+// Shifts возвращает количество отработанных смен
+func (w SalaryWork) Shifts() int {
+	return len(w.ShiftDays)
+}
+
+// SalaryScheme считает начисление по выработке
+type SalaryScheme interface {
+	Calculate(work SalaryWork) float64
+}
+
+type perShiftScheme struct{ rate float64 }
+
+func (s perShiftScheme) Calculate(work SalaryWork) float64 {
+	return s.rate * float64(work.Shifts())
+}
+
+type percentScheme struct{ percent float64 }
+
+func (s percentScheme) Calculate(work SalaryWork) float64 {
+	return work.Revenue * s.percent / 100
+}
+
+// percentWithFloorScheme платит процент от выручки каждой смены, но не меньше минимума за смену
+type percentWithFloorScheme struct{ percent, minimum float64 }
+
+func (s percentWithFloorScheme) Calculate(work SalaryWork) float64 {
+	var total float64
+	for day := range work.ShiftDays {
+		amount := work.DayRevenue[day] * s.percent / 100
+		if amount < s.minimum {
+			amount = s.minimum
+		}
+		total += amount
+	}
+	return total
+}
+
+// tieredScheme считает процент по ступеням как налоговую шкалу: каждая ступень
+// применяется только к выручке внутри своего диапазона, поэтому переход через порог не уменьшает зарплату
+type tieredScheme struct{ tiers []models.SalaryTier }
+
+func (s tieredScheme) Calculate(work SalaryWork) float64 {
+	var total float64
+	for i, tier := range s.tiers {
+		if work.Revenue <= tier.From {
+			break
+		}
+		upper := work.Revenue
+		if i+1 < len(s.tiers) && s.tiers[i+1].From < upper {
+			upper = s.tiers[i+1].From
+		}
+		total += (upper - tier.From) * tier.Percent / 100
+	}
+	return total
+}
+
+// pieceRateScheme платит ставку за каждую услугу, для услуг без своей ставки - ставку по умолчанию
+type pieceRateScheme struct {
+	rates       map[string]float64
+	defaultRate float64
+}
+
+func (s pieceRateScheme) Calculate(work SalaryWork) float64 {
+	var total float64
+	for name, count := range work.Services {
+		rate, ok := s.rates[strings.ToLower(name)]
+		if !ok {
+			rate = s.defaultRate
+		}
+		total += rate * float64(count)
+	}
+	return total
+}
+
+// salarySchemeFactories создают реализацию схемы по ее настройкам
+var salarySchemeFactories = map[string]func(models.WorkerSalaryScheme) SalaryScheme{
+	models.SalaryPerShift: func(s models.WorkerSalaryScheme) SalaryScheme {
+		return perShiftScheme{rate: s.Rate}
+	},
+	models.SalaryPercent: func(s models.WorkerSalaryScheme) SalaryScheme {
+		return percentScheme{percent: s.Percent}
+	},
+	models.SalaryPercentWithFloor: func(s models.WorkerSalaryScheme) SalaryScheme {
+		return percentWithFloorScheme{percent: s.Percent, minimum: s.Rate}
+	},
+	models.SalaryTiered: func(s models.WorkerSalaryScheme) SalaryScheme {
+		tiers := append([]models.SalaryTier(nil), s.Tiers...)
+		sort.Slice(tiers, func(i, j int) bool { return tiers[i].From < tiers[j].From })
+		return tieredScheme{tiers: tiers}
+	},
+	models.SalaryPieceRate: func(s models.WorkerSalaryScheme) SalaryScheme {
+		rates := make(map[string]float64, len(s.Rates))
+		for _, r := range s.Rates {
+			rates[strings.ToLower(r.ServiceName)] = r.Rate
+		}
+		return pieceRateScheme{rates: rates, defaultRate: s.Rate}
+	},
+}
+
+// newSalaryScheme возвращает реализацию схемы оплаты
+func newSalaryScheme(scheme models.WorkerSalaryScheme) (SalaryScheme, error) {
+	factory, ok := salarySchemeFactories[scheme.Type]
+	if !ok {
+		return nil, fmt.Errorf("неизвестная схема оплаты '%s'", scheme.Type)
+	}
+	return factory(scheme), nil
+}
+
+// legacySalaryScheme переводит схему из карточки работника для работников без заданных схем
+func legacySalaryScheme(worker models.Worker) (models.WorkerSalaryScheme, bool) {
+	switch worker.SalarySchema {
+	case "Процентная":
+		return models.WorkerSalaryScheme{Type: models.SalaryPercent, Percent: float64(worker.Salary)}, true
+	case "Фиксированная":
+		return models.WorkerSalaryScheme{Type: models.SalaryPerShift, Rate: float64(worker.Salary)}, true
+	}
+	return models.WorkerSalaryScheme{}, false
+}
+
+// summarizeWork собирает выработку по заказам и отметкам табеля в диапазоне [from, to)
+func summarizeWork(output []models.WorkerOrderOutput, entries []models.TimeEntry, from, to time.Time) SalaryWork {
+	work := SalaryWork{DayRevenue: map[string]float64{}, ShiftDays: map[string]bool{}, Services: map[string]int{}}
+	for _, order := range output {
+		if order.CreatedAt.Before(from) || !order.CreatedAt.Before(to) {
+			continue
+		}
+		day := order.CreatedAt.Format("2006-01-02")
+		work.Orders++
+		work.Revenue += order.TotalAmount
+		work.DayRevenue[day] += order.TotalAmount
+		work.ShiftDays[day] = true
+		for _, name := range order.Services {
+			work.Services[name]++
+		}
+	}
+	for _, entry := range entries {
+		if entry.ClockIn.Before(from) || !entry.ClockIn.Before(to) {
+			continue
+		}
+		work.ShiftDays[entry.ClockIn.Format("2006-01-02")] = true
+	}
+	return work
+}
+
+// CalculateSalary считает зарплату работника за период [start, end). Период делится на части
+// по датам начала действия схем, каждая часть считается по своей схеме.
+// Этот расчет используется и в статистике работника, и при начислении зарплаты.
+func (s *WorkerServiceImpl) CalculateSalary(workerID int, start, end time.Time) (models.SalaryCalculation, error) {
+	calc, _, err := s.calculateSalary(workerID, start, end)
+	return calc, err
+}
+
+func (s *WorkerServiceImpl) calculateSalary(workerID int, start, end time.Time) (models.SalaryCalculation, models.WorkerStatistics, error) {
+	logger.Debug("Расчет зарплаты работника ID:%d с %v по %v", workerID, start, end)
+	calc := models.SalaryCalculation{WorkerID: workerID, Start: start, End: end, Parts: []models.SalaryPart{}}
+
+	stats, err := s.repo.GetWorkerStatistic(workerID, start, end)
+	if err != nil {
+		return calc, stats, err
+	}
+	schemes, err := s.repo.GetSalarySchemes(workerID)
+	if err != nil {
+		return calc, stats, err
+	}
+	if len(schemes) == 0 {
+		worker, err := s.repo.GetWorkerById(workerID)
+		if err != nil {
+			return calc, stats, err
+		}
+		if legacy, ok := legacySalaryScheme(worker); ok {
+			schemes = append(schemes, legacy)
+		} else {
+			logger.Warning("У работника ID:%d не задана схема оплаты, начисление по схеме 0", workerID)
+		}
+	}
+
+	output, err := s.repo.GetWorkerOutput(workerID, start, end)
+	if err != nil {
+		return calc, stats, err
+	}
+	entries, err := s.repo.GetTimeEntries(workerID, start, end)
+	if err != nil {
+		return calc, stats, err
+	}
+
+	// Схема без даты (из карточки работника) действует на весь период
+	for i, scheme := range schemes {
+		from := scheme.EffectiveFrom
+		if from.Before(start) {
+			from = start
+		}
+		to := end
+		if i+1 < len(schemes) && schemes[i+1].EffectiveFrom.Before(end) {
+			to = schemes[i+1].EffectiveFrom
+		}
+		if !from.Before(to) {
+			continue
+		}
+
+		impl, err := newSalaryScheme(scheme)
+		if err != nil {
+			return calc, stats, err
+		}
+		work := summarizeWork(output, entries, from, to)
+		part := models.SalaryPart{
+			SchemeID:   optionalID(scheme.ID),
+			SchemeType: scheme.Type,
+			From:       from,
+			To:         to,
+			Shifts:     work.Shifts(),
+			Orders:     work.Orders,
+			Revenue:    roundMoney(work.Revenue),
+			Amount:     money.FromFloat(impl.Calculate(work)),
+		}
+		calc.Parts = append(calc.Parts, part)
+		calc.Accrued += part.Amount
+	}
+
+	calc.Bonuses = money.FromRubles(stats.TotalBonus)
+	calc.Penalties = money.FromRubles(stats.TotalPenalties)
+	calc.Total = calc.Accrued + calc.Bonuses - calc.Penalties
+	stats.TotalSalary = calc.Total.Rubles()
+	return calc, stats, nil
+}
+
+func (s *WorkerServiceImpl) GetSalarySchemes(workerID int) ([]models.WorkerSalaryScheme, error) {
+	return s.repo.GetSalarySchemes(workerID)
+}
+
+// AddSalaryScheme задает работнику новую схему оплаты с даты EffectiveFrom
+func (s *WorkerServiceImpl) AddSalaryScheme(scheme models.WorkerSalaryScheme) (int, error) {
+	logger.Debug("Добавление схемы оплаты работнику ID:%d в сервисе", scheme.WorkerID)
+	if err := validateSalaryScheme(&scheme); err != nil {
+		return 0, err
+	}
+	return s.repo.CreateSalaryScheme(scheme)
+}
+
+func (s *WorkerServiceImpl) DeleteSalaryScheme(workerID, id int) error {
+	return s.repo.DeleteSalaryScheme(workerID, id)
+}
+
+// validateSalaryScheme проверяет параметры схемы и убирает лишние для ее вида
+func validateSalaryScheme(scheme *models.WorkerSalaryScheme) error {
+	if scheme.EffectiveFrom.IsZero() {
+		return fmt.Errorf("не указана дата начала действия схемы")
+	}
+	y, m, d := scheme.EffectiveFrom.Date()
+	scheme.EffectiveFrom = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+
+	if scheme.Rate < 0 {
+		return fmt.Errorf("ставка не может быть отрицательной")
+	}
+	if scheme.Percent < 0 || scheme.Percent > 100 {
+		return fmt.Errorf("процент должен быть от 0 до 100")
+	}
+
+	switch scheme.Type {
+	case models.SalaryPerShift:
+		if scheme.Rate <= 0 {
+			return fmt.Errorf("не указана сумма за смену")
+		}
+		scheme.Percent, scheme.Tiers, scheme.Rates = 0, nil, nil
+	case models.SalaryPercent, models.SalaryPercentWithFloor:
+		if scheme.Percent <= 0 {
+			return fmt.Errorf("не указан процент от выручки")
+		}
+		if scheme.Type == models.SalaryPercent {
+			scheme.Rate = 0
+		}
+		scheme.Tiers, scheme.Rates = nil, nil
+	case models.SalaryTiered:
+		if len(scheme.Tiers) == 0 {
+			return fmt.Errorf("не указаны ступени выручки")
+		}
+		sort.Slice(scheme.Tiers, func(i, j int) bool { return scheme.Tiers[i].From < scheme.Tiers[j].From })
+		if scheme.Tiers[0].From != 0 {
+			return fmt.Errorf("первая ступень должна начинаться с нулевой выручки")
+		}
+		for i, tier := range scheme.Tiers {
+			if tier.Percent < 0 || tier.Percent > 100 {
+				return fmt.Errorf("процент ступени должен быть от 0 до 100")
+			}
+			if i > 0 && tier.From == scheme.Tiers[i-1].From {
+				return fmt.Errorf("ступени не должны повторяться")
+			}
+		}
+		scheme.Rate, scheme.Percent, scheme.Rates = 0, 0, nil
+	case models.SalaryPieceRate:
+		seen := make(map[string]bool)
+		for i := range scheme.Rates {
+			scheme.Rates[i].ServiceName = strings.TrimSpace(scheme.Rates[i].ServiceName)
+			name := strings.ToLower(scheme.Rates[i].ServiceName)
+			if name == "" {
+				return fmt.Errorf("не указано название услуги для ставки")
+			}
+			if scheme.Rates[i].Rate < 0 {
+				return fmt.Errorf("ставка не может быть отрицательной")
+			}
+			if seen[name] {
+				return fmt.Errorf("ставка для услуги '%s' указана дважды", scheme.Rates[i].ServiceName)
+			}
+			seen[name] = true
+		}
+		if len(scheme.Rates) == 0 && scheme.Rate <= 0 {
+			return fmt.Errorf("не указаны сдельные ставки")
+		}
+		scheme.Percent, scheme.Tiers = 0, nil
+	default:
+		return fmt.Errorf("неизвестная схема оплаты '%s', допустимые: %s", scheme.Type, strings.Join(models.SalarySchemeTypes, ", "))
+	}
+	return nil
+}
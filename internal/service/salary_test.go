@@ -0,0 +1,81 @@
+package service
+
+import (
+	"go-hinomontaj/models"
+	"math"
+	"testing"
+)
+
+func TestTieredScheme(t *testing.T) {
+	scheme, err := newSalaryScheme(models.WorkerSalaryScheme{
+		Type:  models.SalaryTiered,
+		Tiers: []models.SalaryTier{{From: 20000, Percent: 30}, {From: 0, Percent: 10}, {From: 10000, Percent: 20}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		revenue float64
+		want    float64
+	}{
+		{0, 0},
+		{5000, 500},
+		{10000, 1000},
+		{15000, 2000},
+		{20000, 3000},
+		{25000, 4500},
+	}
+	for _, tt := range tests {
+		got := scheme.Calculate(SalaryWork{Revenue: tt.revenue})
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("выручка %v: начислено %v, ожидалось %v", tt.revenue, got, tt.want)
+		}
+	}
+}
+
+func TestPercentWithFloorScheme(t *testing.T) {
+	scheme, err := newSalaryScheme(models.WorkerSalaryScheme{Type: models.SalaryPercentWithFloor, Percent: 10, Rate: 1500})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		work SalaryWork
+		want float64
+	}{
+		{
+			name: "без смен",
+			work: SalaryWork{},
+			want: 0,
+		},
+		{
+			name: "процент выше минимума",
+			work: SalaryWork{
+				DayRevenue: map[string]float64{"2024-03-01": 20000},
+				ShiftDays:  map[string]bool{"2024-03-01": true},
+			},
+			want: 2000,
+		},
+		{
+			name: "минимум за смены с малой выручкой и без выручки",
+			work: SalaryWork{
+				DayRevenue: map[string]float64{"2024-03-01": 20000, "2024-03-02": 5000},
+				ShiftDays:  map[string]bool{"2024-03-01": true, "2024-03-02": true, "2024-03-03": true},
+			},
+			want: 5000,
+		},
+	}
+	for _, tt := range tests {
+		if got := scheme.Calculate(tt.work); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: начислено %v, ожидалось %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewSalarySchemeUnknown(t *testing.T) {
+	if _, err := newSalaryScheme(models.WorkerSalaryScheme{Type: "почасовая"}); err == nil {
+		t.Error("ожидалась ошибка для неизвестной схемы")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"go-hinomontaj/internal/repository/postgres"
 	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/money"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -21,11 +22,22 @@ type Services struct {
 	Service  Service
 	Contract Contract
 	Material Material
+	Vehicle  Vehicle
+
+	Receivables Receivables
+	Invoice     Invoice
+	Deposit     Deposit
+	Loyalty     Loyalty
+	Payroll     Payroll
+	Shift       Shift
 }
 
 type ServicesConfig struct {
-	Repository *postgres.Repository
-	SigningKey string
+	Repository      *postgres.Repository
+	SigningKey      string
+	Company         models.Company
+	Fonts           DocumentFonts
+	AllowUnrostered bool // заказы работникам без смены в графике на день не проверяются
 }
 
 func NewServices(cfg ServicesConfig) *Services {
@@ -33,10 +45,18 @@ func NewServices(cfg ServicesConfig) *Services {
 		Auth:     NewAuthService(cfg.Repository, cfg.SigningKey),
 		Worker:   NewWorkerService(cfg.Repository),
 		Client:   NewClientService(cfg.Repository),
-		Order:    NewOrderService(cfg.Repository),
+		Order:    NewOrderService(cfg.Repository, cfg.AllowUnrostered),
 		Service:  NewServiceService(cfg.Repository),
 		Contract: NewContractService(cfg.Repository),
 		Material: NewMaterialService(cfg.Repository),
+		Vehicle:  NewCarService(cfg.Repository),
+
+		Receivables: NewReceivablesService(cfg.Repository),
+		Invoice:     NewInvoiceService(cfg.Repository, cfg.Company, cfg.Fonts),
+		Deposit:     NewDepositService(cfg.Repository),
+		Loyalty:     NewLoyaltyService(cfg.Repository),
+		Payroll:     NewPayrollService(cfg.Repository, cfg.Company, cfg.Fonts),
+		Shift:       NewShiftService(cfg.Repository),
 	}
 }
 
@@ -52,12 +72,27 @@ type Worker interface {
 	Update(id int, worker models.Worker) error
 	Delete(id int) error
 	GetByUserId(userId int) (models.Worker, error)
+	LinkUser(workerID int, userID *int) error
+	GetUnlinked() ([]models.Worker, error)
 	GetStatistics(workerId int, start time.Time, end time.Time) (models.WorkerStatistics, error)
-	AddBonus(bonus models.PenaltyOrBonus) error
-	GetBonuses(workerID int) ([]models.PenaltyOrBonus, error)
-	AddPenalty(penalty models.PenaltyOrBonus) error
-	GetPenalties(workerID int) ([]models.PenaltyOrBonus, error)
+	AddBonus(bonus models.PenaltyOrBonus) (int, error)
+	GetBonuses(workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error)
+	AddPenalty(penalty models.PenaltyOrBonus) (int, error)
+	GetPenalties(workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error)
+	UpdatePenaltyOrBonus(kind string, entry models.PenaltyOrBonus, reason string, userID int) error
+	RevokePenaltyOrBonus(kind string, workerID, id int, reason string, userID int) error
+	GetPenaltyOrBonusHistory(kind string, workerID, id int) ([]models.PenaltyOrBonusChange, error)
 	Salary(workerID int, start time.Time) (float64, error)
+
+	// Схемы оплаты
+	GetSalarySchemes(workerID int) ([]models.WorkerSalaryScheme, error)
+	AddSalaryScheme(scheme models.WorkerSalaryScheme) (int, error)
+	DeleteSalaryScheme(workerID, id int) error
+	CalculateSalary(workerID int, start, end time.Time) (models.SalaryCalculation, error)
+	GetPayments(workerID int, start, end time.Time) ([]models.WorkerPayment, error)
+	AddPayment(payment models.WorkerPayment) (int, error)
+	DeletePayment(workerID, id int) error
+	Balance(workerID int) (models.WorkerBalance, error)
 }
 
 type Client interface {
@@ -69,9 +104,22 @@ type Client interface {
 	GetClientCars(clientId int) ([]models.Car, error)
 	AddCarToClient(clientId int, car models.Car) error
 	GetTypes() ([]string, error)
-	UploadCarsFromExcel(clientId int, fileData []byte) error
+	GetTypeList() ([]models.ClientType, error)
+	CreateType(clientType models.ClientType) (int, error)
+	UpdateType(id int, clientType models.ClientType) error
+	DeleteType(id int) error
+	ImportCars(clientId int, fileName string, fileData []byte, opts models.CarImportOptions) (models.CarImportResult, error)
 	GetCarsTemplate() (*bytes.Buffer, error)
 	WhooseCar(car string) ([]models.Client, error)
+	FindDuplicates() ([]models.ClientDuplicate, error)
+	Merge(targetID, sourceID, userID int) (models.ClientMergeResult, error)
+
+	// Контакты
+	GetContacts(clientID int) ([]models.ClientContact, error)
+	CreateContact(contact models.ClientContact) (int, error)
+	UpdateContact(contact models.ClientContact) error
+	DeleteContact(clientID, id int) error
+	FindByPhone(number string) ([]models.Client, error)
 
 	// ONLINE DATE
 	OnlineDate(date *models.OnlineDate) error
@@ -79,7 +127,95 @@ type Client interface {
 	UpdateOnlineDate(date models.OnlineDate) error
 }
 
-type OrderService interface {
+type Vehicle interface {
+	GetProfile(number string) (models.VehicleProfile, error)
+	UpdateProfile(number string, car models.Car) error
+	GetLayout(number string) (models.VehicleLayout, error)
+	GetHistory(number string, days int) (models.VehicleHistory, error)
+	Search(query string) ([]models.CarSearchResult, error)
+	Transfer(number string, transfer models.CarTransfer) error
+}
+
+type Receivables interface {
+	GetClientReceivables(clientID, contractID int) (models.Receivables, error)
+	GetAllReceivables() ([]models.Receivables, error)
+	AddPayment(clientID, userID int, payment models.ClientPayment) (int, error)
+	DeletePayment(clientID, id int) error
+	GetStatement(clientID, contractID int, from, to time.Time) (models.Statement, error)
+	ExportStatement(clientID, contractID int, from, to time.Time) (*bytes.Buffer, error)
+}
+
+type Deposit interface {
+	GetAccount(clientID int) (models.DepositAccount, error)
+	TopUp(clientID, userID int, op models.DepositOperation) (int, error)
+	Withdraw(clientID, userID int, op models.DepositOperation) (int, error)
+	SetOverdraft(clientID int, limit money.Amount) error
+	OrderWarning(orderID int) string
+}
+
+type Loyalty interface {
+	GetCustomers(search string) ([]models.RetailCustomer, error)
+	GetCustomer(id int) (models.RetailCustomer, error)
+	GetCustomerOrders(id int) ([]models.Order, error)
+	CreateCustomer(customer models.RetailCustomer) (int, error)
+	UpdateCustomer(id int, customer models.RetailCustomer) error
+	DeleteCustomer(id int) error
+	GetRules() ([]models.LoyaltyRule, error)
+	CreateRule(rule models.LoyaltyRule) (int, error)
+	UpdateRule(id int, rule models.LoyaltyRule) error
+	DeleteRule(id int) error
+	Preview(order models.Order) (models.Order, error)
+}
+
+type Payroll interface {
+	GetAll() ([]models.PayrollPeriod, error)
+	GetById(id int) (models.PayrollPeriod, error)
+	Create(start, end time.Time, userID int) (int, error)
+	Calculate(id int) error
+	Approve(id int, userID int) error
+	Reopen(id int) error
+	Lock(id int) error
+	Delete(id int) error
+	Payslip(periodID, workerID int) (models.Payslip, error)
+	WorkerPayslips(userID int) ([]models.PayslipSummary, error)
+	WorkerPayslip(userID, periodID int) (models.Payslip, error)
+	PayslipPDF(payslip models.Payslip) (*bytes.Buffer, error)
+	PayslipExcel(payslip models.Payslip) (*bytes.Buffer, error)
+
+	// Правила автоматических штрафов и бонусов
+	GetRules() ([]models.PenaltyBonusRule, error)
+	CreateRule(rule models.PenaltyBonusRule) (int, error)
+	UpdateRule(id int, rule models.PenaltyBonusRule) error
+	DeleteRule(id int) error
+}
+
+type Shift interface {
+	GetTemplates() ([]models.ShiftTemplate, error)
+	CreateTemplate(template models.ShiftTemplate) (int, error)
+	UpdateTemplate(id int, template models.ShiftTemplate) error
+	DeleteTemplate(id int) error
+	GetRoster(start, end time.Time) ([]models.ShiftAssignment, error)
+	AddToRoster(date time.Time, templateID int, workerIDs []int, userID int) ([]int, error)
+	RemoveFromRoster(id int) error
+	GetTimeEntries(workerID int, start, end time.Time) ([]models.TimeEntry, error)
+	UpdateTimeEntry(id int, entry models.TimeEntry) error
+	ClockIn(userID int) (int, error)
+	ClockOut(userID int) (int, error)
+	Status(userID int) (models.WorkerShiftStatus, error)
+	Timesheet(start, end time.Time) ([]models.TimesheetRow, error)
+}
+
+type Invoice interface {
+	Preview(contractID int, start, end time.Time) (models.Invoice, error)
+	Create(contractID int, start, end time.Time, userID int) (int, error)
+	GetAll(contractID int) ([]models.Invoice, error)
+	GetById(id int) (models.Invoice, error)
+	Delete(id int) error
+	InvoicePDF(id int, kind string) (*bytes.Buffer, error)
+	InvoiceExcel(id int, kind string) (*bytes.Buffer, error)
+}
+
+type Order interface {
 	Create(order models.Order) (int, error)
 	GetAll() ([]models.Order, error)
 	GetByWorkerId(workerId int) ([]models.Order, error)
@@ -110,13 +246,49 @@ type Contract interface {
 
 type Material interface {
 	Create(material models.Material) error
-	GetAll() ([]models.Material, error)
+	GetAll(filter models.MaterialFilter) ([]models.Material, error)
 	GetById(id int) (models.Material, error)
-	GetByNameAndType(name string, typeDS int) (models.Material, error)
+	GetByNameAndCategory(name string, categoryID int) (models.Material, error)
 	Update(id int, material models.Material) error
 	Delete(id int) error
-	AddQuantity(id int, quantity int) error
-	SubtractQuantity(id int, quantity int) error
+	AddQuantity(id, warehouseID int, quantity float64) error
+	SubtractQuantity(id, warehouseID int, quantity float64) error
+
+	// Категории
+	CreateCategory(category models.MaterialCategory) (int, error)
+	GetCategories() ([]models.MaterialCategory, error)
+	UpdateCategory(id int, category models.MaterialCategory) error
+	DeleteCategory(id int) error
+
+	// Excel
+	ExportStock(filter models.MaterialFilter) (*bytes.Buffer, error)
+	GenerateImportTemplate() (*bytes.Buffer, error)
+	ImportFromExcel(fileData []byte, userID int) (models.MaterialImportResult, error)
+
+	// Склады
+	CreateWarehouse(warehouse models.Warehouse) (int, error)
+	GetWarehouses() ([]models.Warehouse, error)
+	UpdateWarehouse(id int, warehouse models.Warehouse) error
+	DeleteWarehouse(id int) error
+	GetBalances(warehouseID, materialID int) ([]models.MaterialBalance, error)
+	Transfer(transfer models.MaterialTransfer) (int, error)
+	GetTransfers(warehouseID int) ([]models.MaterialTransfer, error)
+	AdjustStock(id, warehouseID int, quantity float64, description string, userID int) error
+
+	// Нормы расхода
+	CreateNorm(norm models.ServiceMaterialNorm) (int, error)
+	GetNorms() ([]models.ServiceMaterialNorm, error)
+	UpdateNorm(id int, norm models.ServiceMaterialNorm) error
+	DeleteNorm(id int) error
+	GetVariance(start, end time.Time) ([]models.MaterialVariance, error)
+	GetMovements(materialID int) ([]models.MaterialMovement, error)
+
+	OpenStockTake(stockTake models.StockTake) (int, error)
+	GetStockTakes() ([]models.StockTake, error)
+	GetStockTake(id int) (models.StockTake, error)
+	AddStockTakeCounts(stockTakeID int, userID int, counts []models.StockTakeCount) error
+	DeleteStockTakeCount(stockTakeID, countID int) error
+	ApproveStockTake(id int, userID int) error
 }
 
 type Repository interface {
@@ -127,8 +299,9 @@ type Repository interface {
 
 	// Workers
 	CreateWorker(worker models.Worker) (int, error) // с аккаунта админа
+	CreateWorkerWithUser(user models.User, worker models.Worker) (int, int, error)
+	SetWorkerUser(workerID int, userID *int) error
 	GetAllWorkers() ([]models.Worker, error)
-	GetWorkerByName(name string) (models.Worker, error)
 	GetWorkerById(id int) (models.Worker, error)
 	GetWorkerByUserId(userId int) (models.Worker, error)
 	UpdateWorker(id int, worker models.Worker) error
@@ -143,9 +316,33 @@ type Repository interface {
 	UpdateClient(id int, client models.Client) error
 	DeleteClient(id int) error
 	GetClientTypes() ([]string, error)
+	GetAllClientTypes() ([]models.ClientType, error)
+	GetClientTypeByName(name string) (models.ClientType, error)
+	CreateClientType(clientType models.ClientType) (int, error)
+	UpdateClientType(id int, clientType models.ClientType) error
+	DeleteClientType(id int) error
 	CarExists(number string) (bool, error)
+	GetCarByNumber(number string) (models.Car, error)
+	UpdateCarProfile(number string, car models.Car) error
+	GetCarOwners(carID int) ([]models.CarOwner, error)
+	GetVehicleOrders(number string) ([]models.VehicleHistoryOrder, error)
+	GetCarSearchCandidates(number string, maxDistance, minPartialLen, limit int) ([]models.Car, error)
+	GetCarOwnerAt(number string, at time.Time) (int, error)
+	TransferCar(number string, transfer models.CarTransfer, at time.Time) error
+	GetCarOwnerships(numbers []string) ([]models.CarOwnership, error)
+	ImportClientCars(clientID int, rows []models.CarImportRow) error
+	GetClientLinks() ([]models.ClientLink, error)
+	MergeClients(targetID, sourceID, userID int) (models.ClientMergeResult, error)
+	GetClientRedirect(id int) (int, error)
 	WhooseCar(car string) ([]models.Client, error)
 
+	// Client contacts
+	GetClientContacts(clientID int) ([]models.ClientContact, error)
+	CreateClientContact(contact models.ClientContact) (int, error)
+	UpdateClientContact(contact models.ClientContact) error
+	DeleteClientContact(clientID, id int) error
+	FindClientsByPhone(number string) ([]models.Client, error)
+
 	//Services
 	CreateService(service models.Service) (int, error)
 	GetAllServices() ([]models.Service, error)
@@ -154,6 +351,38 @@ type Repository interface {
 	DeleteService(id int) error
 	GetServicePricesByContract(contractID int) ([]models.Service, error)
 
+	// Receivables
+	GetLedgerEntries(clientID, contractID int, before time.Time) ([]models.LedgerEntry, error)
+	AddClientPayment(entry models.LedgerEntry) (int, error)
+	DeleteClientPayment(clientID, id int) error
+
+	// Deposits
+	AddDepositMovement(m models.DepositMovement) (int, error)
+	GetDepositAccount(clientID int) (models.DepositAccount, error)
+	SetDepositOverdraft(clientID int, limit money.Amount) error
+	GetOrderDepositBalance(orderID int) (money.Amount, bool, error)
+
+	// Retail customers and loyalty
+	GetRetailCustomers(search, phone, number string) ([]models.RetailCustomer, error)
+	GetRetailCustomerById(id int) (models.RetailCustomer, error)
+	FindRetailCustomer(phone, card, vehicleNumber string) (int, error)
+	CreateRetailCustomer(customer models.RetailCustomer) (int, error)
+	UpdateRetailCustomer(id int, customer models.RetailCustomer) error
+	DeleteRetailCustomer(id int) error
+	GetRetailCustomerOrders(customerID int) ([]models.Order, error)
+	GetLoyaltyRules(activeOnly bool) ([]models.LoyaltyRule, error)
+	CreateLoyaltyRule(rule models.LoyaltyRule) (int, error)
+	UpdateLoyaltyRule(id int, rule models.LoyaltyRule) error
+	DeleteLoyaltyRule(id int) error
+	GetLoyaltyHistory(customerID int) (models.LoyaltyHistory, error)
+
+	// Invoices
+	GetInvoiceDraft(contractID int, start, end time.Time) ([]models.InvoiceLine, int, error)
+	CreateInvoice(contractID int, start, end time.Time, userID int) (int, error)
+	GetInvoices(contractID int) ([]models.Invoice, error)
+	GetInvoiceById(id int) (models.Invoice, error)
+	DeleteInvoice(id int) error
+
 	// Contracts
 	CreateContract(contract models.Contract) (int, error)
 	GetAllContracts() ([]models.Contract, error)
@@ -163,19 +392,54 @@ type Repository interface {
 
 	// Materials
 	AddMaterial(material models.Material) error
-	GetAllMaterials() ([]models.Material, error)
+	GetAllMaterials(filter models.MaterialFilter) ([]models.Material, error)
 	GetMaterialById(id int) (models.Material, error)
-	GetMaterialByNameAndType(name string, typeDS int) (models.Material, error)
+	GetMaterialByNameAndCategory(name string, categoryID int) (models.Material, error)
 	UpdateMaterial(id int, material models.Material) error
 	DeleteMaterial(id int) error
-	AddMaterialQuantity(id int, quantity int) error
-	SubtractMaterialQuantity(id int, quantity int) error
+	AddMaterialQuantity(id, warehouseID int, quantity float64) error
+	SubtractMaterialQuantity(id, warehouseID int, quantity float64) error
+
+	// Material categories
+	CreateMaterialCategory(category models.MaterialCategory) (int, error)
+	GetAllMaterialCategories() ([]models.MaterialCategory, error)
+	UpdateMaterialCategory(id int, category models.MaterialCategory) error
+	DeleteMaterialCategory(id int) error
+	ImportMaterial(material models.Material, receipt float64, warehouseID, userID int) (bool, error)
+
+	// Warehouses
+	CreateWarehouse(warehouse models.Warehouse) (int, error)
+	GetAllWarehouses() ([]models.Warehouse, error)
+	UpdateWarehouse(id int, warehouse models.Warehouse) error
+	DeleteWarehouse(id int) error
+	GetMaterialBalances(warehouseID, materialID int) ([]models.MaterialBalance, error)
+	CreateMaterialTransfer(transfer models.MaterialTransfer) (int, error)
+	GetMaterialTransfers(warehouseID int) ([]models.MaterialTransfer, error)
+	AdjustMaterialStock(id, warehouseID int, quantity float64, description string, userID int) error
+
+	// Material norms
+	CreateMaterialNorm(norm models.ServiceMaterialNorm) (int, error)
+	GetAllMaterialNorms() ([]models.ServiceMaterialNorm, error)
+	UpdateMaterialNorm(id int, norm models.ServiceMaterialNorm) error
+	DeleteMaterialNorm(id int) error
+	GetMaterialNormsForServices(serviceIDs []int, contractID int) ([]models.ServiceMaterialNorm, error)
+	GetMaterialVariance(start, end time.Time) ([]models.MaterialVariance, error)
+
+	// Stock takes
+	GetMaterialMovements(materialID int) ([]models.MaterialMovement, error)
+	CreateStockTake(stockTake models.StockTake) (int, error)
+	GetAllStockTakes() ([]models.StockTake, error)
+	GetStockTakeById(id int) (models.StockTake, error)
+	AddStockTakeCounts(stockTakeID int, counts []models.StockTakeCount) error
+	DeleteStockTakeCount(stockTakeID, countID int) error
+	ApproveStockTake(id int, userID int) error
 
 	// Orders
 	CreateOrder(order models.Order) (int, error)
 	GetAllOrders() ([]models.Order, error)
 	GetOrdersByWorkerId(workerId int) ([]models.Order, error)
 	GetOrdersByWorkerIdAndDateRange(workerId int, start, end time.Time) ([]models.Order, error)
+	GetOrderWorker(id int) (*int, time.Time, error)
 	UpdateOrder(id int, order models.Order) error
 	UpdateOrderStatus(id int, status string) error
 	DeleteOrder(id int) error
@@ -188,9 +452,64 @@ type Repository interface {
 	UpdateOnlineDate(date models.OnlineDate) error
 
 	// Economic
-	AddPenalty(penalty models.PenaltyOrBonus) error
-	AddBonus(penalty models.PenaltyOrBonus) error
-	GetBonuses(workerID int) ([]models.PenaltyOrBonus, error)
-	GetPenalties(workerID int) ([]models.PenaltyOrBonus, error)
+	AddPenalty(penalty models.PenaltyOrBonus) (int, error)
+	AddBonus(bonus models.PenaltyOrBonus) (int, error)
+	GetBonuses(workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error)
+	GetPenalties(workerID int, start, end time.Time) ([]models.PenaltyOrBonus, error)
+	GetPenaltyOrBonusById(kind string, id int) (models.PenaltyOrBonus, error)
+	UpdatePenaltyOrBonus(kind string, entry models.PenaltyOrBonus, reason string, userID *int) error
+	RevokePenaltyOrBonus(kind string, workerID, id int, reason string, userID *int) error
+	GetPenaltyOrBonusChanges(kind string, workerID, id int) ([]models.PenaltyOrBonusChange, error)
 	GetWorkerStatistic(workerID int, start, end time.Time) (models.WorkerStatistics, error)
+
+	// Salary schemes
+	GetSalarySchemes(workerID int) ([]models.WorkerSalaryScheme, error)
+	CreateSalaryScheme(scheme models.WorkerSalaryScheme) (int, error)
+	DeleteSalaryScheme(workerID, id int) error
+	GetWorkerOutput(workerID int, start, end time.Time) ([]models.WorkerOrderOutput, error)
+
+	// Payroll
+	CreatePayrollPeriod(start, end time.Time, userID int) (int, error)
+	GetPayrollPeriods() ([]models.PayrollPeriod, error)
+	GetPayrollPeriodById(id int) (models.PayrollPeriod, error)
+	GetPayrollAdjustmentsExcept(periodID int) ([]models.PayrollAdjustment, error)
+	GetChangedLockedPayrollPeriods(before time.Time) ([]int, error)
+	SavePayrollCalculation(periodID int, lines []models.PayrollLine, adjustments []models.PayrollAdjustment,
+		penalties, bonuses []models.PenaltyOrBonus) error
+	UpdatePayrollStatus(id int, from, to string, userID int) error
+	DeletePayrollPeriod(id int) error
+	GetWorkerPayslips(workerID int) ([]models.PayslipSummary, error)
+	GetPayrollLineDetails(periodID, workerID int) (*models.PayrollLineDetails, error)
+
+	// Worker payments
+	GetWorkerPayments(workerID int, start, end time.Time) ([]models.WorkerPayment, error)
+	GetWorkerPaymentById(id int) (models.WorkerPayment, error)
+	CreateWorkerPayment(payment models.WorkerPayment) (int, error)
+	DeleteWorkerPayment(workerID, id int) error
+	GetWorkerSettlement(workerID int) (models.WorkerBalance, error)
+
+	// Penalty and bonus rules
+	GetPenaltyBonusRules(activeOnly bool) ([]models.PenaltyBonusRule, error)
+	CreatePenaltyBonusRule(rule models.PenaltyBonusRule) (int, error)
+	UpdatePenaltyBonusRule(id int, rule models.PenaltyBonusRule) error
+	DeletePenaltyBonusRule(id int) error
+	GetWorkerRuleMetrics(start, end time.Time) ([]models.WorkerRuleMetrics, error)
+	GetPeriodRuleEntries(periodID int) ([]models.PenaltyOrBonus, []models.PenaltyOrBonus, error)
+
+	// Shifts and timesheets
+	GetShiftTemplates(activeOnly bool) ([]models.ShiftTemplate, error)
+	GetShiftTemplateById(id int) (models.ShiftTemplate, error)
+	CreateShiftTemplate(template models.ShiftTemplate) (int, error)
+	UpdateShiftTemplate(id int, template models.ShiftTemplate) error
+	DeleteShiftTemplate(id int) error
+	GetShiftAssignments(workerID int, start, end time.Time) ([]models.ShiftAssignment, error)
+	CreateShiftAssignments(assignments []models.ShiftAssignment) ([]int, error)
+	DeleteShiftAssignment(id int) error
+	GetTimeEntries(workerID int, start, end time.Time) ([]models.TimeEntry, error)
+	GetOpenTimeEntry(workerID int) (*models.TimeEntry, error)
+	CloseStaleTimeEntries(before time.Time) (int, error)
+	ClockIn(workerID int, at time.Time) (int, error)
+	ClockOut(workerID int, at time.Time) (int, error)
+	UpdateTimeEntry(id int, entry models.TimeEntry) error
+	IsWorkerOnShift(workerID int, at time.Time) (onShift, rostered bool, err error)
 }
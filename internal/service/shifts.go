@@ -0,0 +1,291 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ShiftService struct {
+	repo Repository
+}
+
+func NewShiftService(repo Repository) *ShiftService {
+	return &ShiftService{repo: repo}
+}
+
+func (s *ShiftService) GetTemplates() ([]models.ShiftTemplate, error) {
+	return s.repo.GetShiftTemplates(false)
+}
+
+func (s *ShiftService) CreateTemplate(template models.ShiftTemplate) (int, error) {
+	logger.Debug("Создание шаблона смены в сервисе")
+	if err := validateShiftTemplate(&template); err != nil {
+		return 0, err
+	}
+	return s.repo.CreateShiftTemplate(template)
+}
+
+func (s *ShiftService) UpdateTemplate(id int, template models.ShiftTemplate) error {
+	logger.Debug("Обновление шаблона смены ID:%d в сервисе", id)
+	if err := validateShiftTemplate(&template); err != nil {
+		return err
+	}
+	return s.repo.UpdateShiftTemplate(id, template)
+}
+
+func (s *ShiftService) DeleteTemplate(id int) error {
+	return s.repo.DeleteShiftTemplate(id)
+}
+
+// GetRoster возвращает график смен на дни [start, end)
+func (s *ShiftService) GetRoster(start, end time.Time) ([]models.ShiftAssignment, error) {
+	return s.repo.GetShiftAssignments(0, start, end)
+}
+
+// AddToRoster ставит работников в график на день date по шаблону смены
+func (s *ShiftService) AddToRoster(date time.Time, templateID int, workerIDs []int, userID int) ([]int, error) {
+	logger.Debug("Назначение смены по шаблону ID:%d на %s в сервисе", templateID, date.Format("2006-01-02"))
+	if len(workerIDs) == 0 {
+		return nil, fmt.Errorf("не указаны работники")
+	}
+	template, err := s.repo.GetShiftTemplateById(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.Active {
+		return nil, fmt.Errorf("шаблон смены '%s' отключен", template.Name)
+	}
+	startsAt, endsAt, err := shiftBounds(date, template)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	assignments := make([]models.ShiftAssignment, 0, len(workerIDs))
+	for _, workerID := range workerIDs {
+		if workerID == 0 || seen[workerID] {
+			continue
+		}
+		seen[workerID] = true
+		assignments = append(assignments, models.ShiftAssignment{
+			WorkerID:   workerID,
+			TemplateID: &template.ID,
+			WorkDate:   date,
+			StartsAt:   startsAt,
+			EndsAt:     endsAt,
+			CreatedBy:  optionalID(userID),
+		})
+	}
+	return s.repo.CreateShiftAssignments(assignments)
+}
+
+func (s *ShiftService) RemoveFromRoster(id int) error {
+	return s.repo.DeleteShiftAssignment(id)
+}
+
+func (s *ShiftService) GetTimeEntries(workerID int, start, end time.Time) ([]models.TimeEntry, error) {
+	if err := s.closeStaleEntries(); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTimeEntries(workerID, start, end)
+}
+
+// closeStaleEntries закрывает отметки, у которых уход не отмечен дольше OpenTimeEntryLimit
+func (s *ShiftService) closeStaleEntries() error {
+	_, err := s.repo.CloseStaleTimeEntries(time.Now().Add(-models.OpenTimeEntryLimit))
+	return err
+}
+
+// UpdateTimeEntry исправляет отметку табеля менеджером
+func (s *ShiftService) UpdateTimeEntry(id int, entry models.TimeEntry) error {
+	logger.Debug("Исправление отметки табеля ID:%d в сервисе", id)
+	if entry.ClockIn.IsZero() {
+		return fmt.Errorf("не указано время прихода")
+	}
+	if entry.ClockOut != nil && !entry.ClockOut.After(entry.ClockIn) {
+		return fmt.Errorf("время ухода должно быть позже времени прихода")
+	}
+	if entry.ClockIn.After(time.Now()) {
+		return fmt.Errorf("время прихода не может быть в будущем")
+	}
+	return s.repo.UpdateTimeEntry(id, entry)
+}
+
+// ClockIn отмечает приход работника, вошедшего в систему
+func (s *ShiftService) ClockIn(userID int) (int, error) {
+	worker, err := s.repo.GetWorkerByUserId(userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.closeStaleEntries(); err != nil {
+		return 0, err
+	}
+	return s.repo.ClockIn(worker.ID, time.Now())
+}
+
+// ClockOut отмечает уход работника, вошедшего в систему
+func (s *ShiftService) ClockOut(userID int) (int, error) {
+	worker, err := s.repo.GetWorkerByUserId(userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.closeStaleEntries(); err != nil {
+		return 0, err
+	}
+	return s.repo.ClockOut(worker.ID, time.Now())
+}
+
+// Status показывает работнику, отмечен ли он на смене и какие смены у него по графику на сегодня
+func (s *ShiftService) Status(userID int) (models.WorkerShiftStatus, error) {
+	worker, err := s.repo.GetWorkerByUserId(userID)
+	if err != nil {
+		return models.WorkerShiftStatus{}, err
+	}
+
+	status := models.WorkerShiftStatus{}
+	if err := s.closeStaleEntries(); err != nil {
+		return status, err
+	}
+	if status.OpenEntry, err = s.repo.GetOpenTimeEntry(worker.ID); err != nil {
+		return status, err
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if status.Scheduled, err = s.repo.GetShiftAssignments(worker.ID, today, today.AddDate(0, 0, 1)); err != nil {
+		return status, err
+	}
+	status.OnShift = status.OpenEntry != nil
+	return status, nil
+}
+
+// Timesheet считает по каждому работнику смены и часы по графику и по отметкам прихода в [start, end)
+func (s *ShiftService) Timesheet(start, end time.Time) ([]models.TimesheetRow, error) {
+	logger.Debug("Формирование табеля с %v по %v в сервисе", start, end)
+	workers, err := s.repo.GetAllWorkers()
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.repo.GetShiftAssignments(0, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.closeStaleEntries(); err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.GetTimeEntries(0, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[int]*models.TimesheetRow, len(workers))
+	result := make([]models.TimesheetRow, 0, len(workers))
+	for _, worker := range workers {
+		rows[worker.ID] = &models.TimesheetRow{WorkerID: worker.ID, WorkerName: worker.Name + " " + worker.Surname}
+	}
+	for _, a := range assignments {
+		if row, ok := rows[a.WorkerID]; ok {
+			row.ScheduledShifts++
+			row.ScheduledHours += a.EndsAt.Sub(a.StartsAt).Hours()
+		}
+	}
+
+	now := time.Now()
+	days := make(map[int]map[string]bool)
+	for _, e := range entries {
+		row, ok := rows[e.WorkerID]
+		if !ok {
+			continue
+		}
+		if days[e.WorkerID] == nil {
+			days[e.WorkerID] = make(map[string]bool)
+		}
+		days[e.WorkerID][e.ClockIn.Format("2006-01-02")] = true
+		row.WorkedHours += e.Hours(now)
+		if e.ClockOut == nil {
+			row.OpenEntries++
+		}
+		if e.AutoClosed {
+			row.AutoClosed++
+		}
+	}
+
+	for _, worker := range workers {
+		row := rows[worker.ID]
+		row.WorkedShifts = len(days[worker.ID])
+		row.ScheduledHours = roundMoney(row.ScheduledHours)
+		row.WorkedHours = roundMoney(row.WorkedHours)
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].WorkerName < result[j].WorkerName })
+	return result, nil
+}
+
+// checkWorkerOnShift не дает назначить заказ работнику, который в момент at не на смене по графику
+// и не отметил приход. allowUnrostered (настройка shifts.allow_unrostered) пропускает работников
+// без смены в графике на этот день, пока график ведется не для всех.
+func checkWorkerOnShift(repo Repository, workerID int, at time.Time, allowUnrostered bool) error {
+	if workerID == 0 {
+		return nil
+	}
+	onShift, rostered, err := repo.IsWorkerOnShift(workerID, at)
+	if err != nil {
+		return err
+	}
+	if onShift || (!rostered && allowUnrostered) {
+		return nil
+	}
+	worker, err := repo.GetWorkerById(workerID)
+	if err != nil {
+		return err
+	}
+	if !rostered {
+		return fmt.Errorf("работник %s %s не на смене %s: нет смены в графике и отметки прихода",
+			worker.Name, worker.Surname, at.Format("02.01.2006 15:04"))
+	}
+	return fmt.Errorf("работник %s %s не на смене %s: по графику смена в другое время и нет отметки прихода",
+		worker.Name, worker.Surname, at.Format("02.01.2006 15:04"))
+}
+
+// shiftBounds считает начало и конец смены по шаблону на день date, смена может заканчиваться на следующий день
+func shiftBounds(date time.Time, template models.ShiftTemplate) (time.Time, time.Time, error) {
+	startClock, err := time.Parse("15:04", template.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("неверное время начала смены '%s'", template.StartTime)
+	}
+	endClock, err := time.Parse("15:04", template.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("неверное время окончания смены '%s'", template.EndTime)
+	}
+
+	y, m, d := date.Date()
+	startsAt := time.Date(y, m, d, startClock.Hour(), startClock.Minute(), 0, 0, time.Local)
+	endsAt := time.Date(y, m, d, endClock.Hour(), endClock.Minute(), 0, 0, time.Local)
+	if !endsAt.After(startsAt) {
+		endsAt = endsAt.AddDate(0, 0, 1)
+	}
+	return startsAt, endsAt, nil
+}
+
+// validateShiftTemplate проверяет название и время смены в формате HH:MM
+func validateShiftTemplate(template *models.ShiftTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return fmt.Errorf("не указано название смены")
+	}
+	for _, clock := range []*string{&template.StartTime, &template.EndTime} {
+		*clock = strings.TrimSpace(*clock)
+		parsed, err := time.Parse("15:04", *clock)
+		if err != nil {
+			return fmt.Errorf("неверное время '%s', ожидается HH:MM", *clock)
+		}
+		*clock = parsed.Format("15:04")
+	}
+	if template.StartTime == template.EndTime {
+		return fmt.Errorf("время начала и окончания смены совпадают")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"strings"
+)
+
+func (s *MaterialService) GetMovements(materialID int) ([]models.MaterialMovement, error) {
+	logger.Debug("Получение журнала движения материала ID: %d в сервисе", materialID)
+	return s.repo.GetMaterialMovements(materialID)
+}
+
+func (s *MaterialService) OpenStockTake(stockTake models.StockTake) (int, error) {
+	logger.Debug("Открытие инвентаризации в сервисе")
+	stockTake.Description = strings.TrimSpace(stockTake.Description)
+	return s.repo.CreateStockTake(stockTake)
+}
+
+func (s *MaterialService) GetStockTakes() ([]models.StockTake, error) {
+	logger.Debug("Получение списка инвентаризаций в сервисе")
+	return s.repo.GetAllStockTakes()
+}
+
+func (s *MaterialService) GetStockTake(id int) (models.StockTake, error) {
+	logger.Debug("Получение инвентаризации ID: %d в сервисе", id)
+	return s.repo.GetStockTakeById(id)
+}
+
+// AddStockTakeCounts записывает очередной проход пересчета. Количества по одному материалу из разных проходов суммируются.
+func (s *MaterialService) AddStockTakeCounts(stockTakeID int, userID int, counts []models.StockTakeCount) error {
+	logger.Debug("Запись пересчета в инвентаризацию ID: %d в сервисе", stockTakeID)
+	if len(counts) == 0 {
+		return fmt.Errorf("не указано ни одной позиции пересчета")
+	}
+
+	for i := range counts {
+		if counts[i].MaterialID == 0 {
+			return fmt.Errorf("не указан материал в позиции %d", i+1)
+		}
+		if counts[i].Quantity < 0 {
+			return fmt.Errorf("количество материала ID %d не может быть отрицательным", counts[i].MaterialID)
+		}
+		if userID != 0 {
+			counts[i].UserID = &userID
+		}
+	}
+
+	return s.repo.AddStockTakeCounts(stockTakeID, counts)
+}
+
+func (s *MaterialService) DeleteStockTakeCount(stockTakeID, countID int) error {
+	logger.Debug("Удаление прохода ID: %d из инвентаризации ID: %d в сервисе", countID, stockTakeID)
+	return s.repo.DeleteStockTakeCount(stockTakeID, countID)
+}
+
+func (s *MaterialService) ApproveStockTake(id int, userID int) error {
+	logger.Debug("Утверждение инвентаризации ID: %d в сервисе", id)
+	return s.repo.ApproveStockTake(id, userID)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"strings"
+)
+
+func (s *MaterialService) CreateWarehouse(warehouse models.Warehouse) (int, error) {
+	logger.Debug("Создание склада в сервисе: %s", warehouse.Name)
+	warehouse.Name = strings.TrimSpace(warehouse.Name)
+	if warehouse.Name == "" {
+		return 0, fmt.Errorf("не указано название склада")
+	}
+	return s.repo.CreateWarehouse(warehouse)
+}
+
+func (s *MaterialService) GetWarehouses() ([]models.Warehouse, error) {
+	logger.Debug("Получение списка складов в сервисе")
+	return s.repo.GetAllWarehouses()
+}
+
+func (s *MaterialService) UpdateWarehouse(id int, warehouse models.Warehouse) error {
+	logger.Debug("Обновление склада в сервисе: %d", id)
+	warehouse.Name = strings.TrimSpace(warehouse.Name)
+	if warehouse.Name == "" {
+		return fmt.Errorf("не указано название склада")
+	}
+	return s.repo.UpdateWarehouse(id, warehouse)
+}
+
+func (s *MaterialService) DeleteWarehouse(id int) error {
+	logger.Debug("Удаление склада в сервисе: %d", id)
+	return s.repo.DeleteWarehouse(id)
+}
+
+func (s *MaterialService) GetBalances(warehouseID, materialID int) ([]models.MaterialBalance, error) {
+	logger.Debug("Получение остатков по складам в сервисе")
+	return s.repo.GetMaterialBalances(warehouseID, materialID)
+}
+
+// Transfer перемещает материал между складами
+func (s *MaterialService) Transfer(transfer models.MaterialTransfer) (int, error) {
+	logger.Debug("Перемещение материала ID: %d в сервисе", transfer.MaterialID)
+	if transfer.MaterialID == 0 {
+		return 0, fmt.Errorf("не указан материал")
+	}
+	if transfer.FromWarehouseID == 0 || transfer.ToWarehouseID == 0 {
+		return 0, fmt.Errorf("не указан склад отправления или назначения")
+	}
+	if transfer.FromWarehouseID == transfer.ToWarehouseID {
+		return 0, fmt.Errorf("склады отправления и назначения совпадают")
+	}
+	if transfer.Quantity <= 0 {
+		return 0, fmt.Errorf("количество должно быть больше нуля")
+	}
+	transfer.Description = strings.TrimSpace(transfer.Description)
+	return s.repo.CreateMaterialTransfer(transfer)
+}
+
+// AdjustStock приводит остаток материала на складе к фактическому количеству
+func (s *MaterialService) AdjustStock(id, warehouseID int, quantity float64, description string, userID int) error {
+	logger.Debug("Корректировка остатка материала ID: %d на складе ID: %d в сервисе", id, warehouseID)
+	if warehouseID == 0 {
+		return fmt.Errorf("не указан склад")
+	}
+	if quantity < 0 {
+		return fmt.Errorf("остаток не может быть отрицательным")
+	}
+	return s.repo.AdjustMaterialStock(id, warehouseID, quantity, strings.TrimSpace(description), userID)
+}
+
+func (s *MaterialService) GetTransfers(warehouseID int) ([]models.MaterialTransfer, error) {
+	logger.Debug("Получение списка перемещений в сервисе")
+	return s.repo.GetMaterialTransfers(warehouseID)
+}
@@ -27,15 +27,45 @@ func NewWorkerService(repo Repository) *WorkerServiceImpl {
 	}
 }
 
+// Create создает работника. Если указан пароль, вместе с работником в одной транзакции
+// создается его учетная запись с email работника.
 func (s *WorkerServiceImpl) Create(worker models.Worker) (int, error) {
-	// Создаем работника
-	workerId, err := s.repo.CreateWorker(worker)
+	normalizeWorkerWarehouse(&worker)
+
+	if worker.Password == "" {
+		workerId, err := s.repo.CreateWorker(worker)
+		if err != nil {
+			logger.Error("Ошибка при создании работника: %v", err)
+			return 0, fmt.Errorf("ошибка при создании работника: %w", err)
+		}
+		logger.Info("Создан новый работник ID:%d без учетной записи", workerId)
+		return workerId, nil
+	}
+
+	if worker.Role == "" {
+		worker.Role = "worker"
+	}
+	if worker.Role != "worker" && worker.Role != "manager" {
+		return 0, fmt.Errorf("неизвестная роль '%s', допустимые: worker, manager", worker.Role)
+	}
+	if worker.Email == "" {
+		return 0, fmt.Errorf("для учетной записи работника нужен email")
+	}
+	if len(worker.Password) < 6 {
+		return 0, fmt.Errorf("пароль должен быть не короче 6 символов")
+	}
+	hashedPassword, err := hashPassword(worker.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	user := models.User{Name: worker.Name, Email: worker.Email, Password: hashedPassword, Role: worker.Role}
+	userId, workerId, err := s.repo.CreateWorkerWithUser(user, worker)
 	if err != nil {
-		logger.Error("Ошибка при создании работника: %v", err)
-		return 0, fmt.Errorf("ошибка при создании работника: %w", err)
+		return 0, err
 	}
 
-	logger.Info("Создан новый работник ID:%d", workerId)
+	logger.Info("Создан новый работник ID:%d с учетной записью ID:%d", workerId, userId)
 	return workerId, nil
 }
 
@@ -48,9 +78,17 @@ func (s *WorkerServiceImpl) GetById(id int) (models.Worker, error) {
 }
 
 func (s *WorkerServiceImpl) Update(id int, worker models.Worker) error {
+	normalizeWorkerWarehouse(&worker)
 	return s.repo.UpdateWorker(id, worker)
 }
 
+// normalizeWorkerWarehouse: warehouse_id = 0 означает склад по умолчанию
+func normalizeWorkerWarehouse(worker *models.Worker) {
+	if worker.WarehouseID != nil && *worker.WarehouseID == 0 {
+		worker.WarehouseID = nil
+	}
+}
+
 func (s *WorkerServiceImpl) Delete(id int) error {
 	return s.repo.DeleteWorker(id)
 }
@@ -60,44 +98,48 @@ func (s *WorkerServiceImpl) GetByUserId(userId int) (models.Worker, error) {
 	return s.repo.GetWorkerByUserId(userId)
 }
 
-func (s *WorkerServiceImpl) GetStatistics(workerId int, start time.Time, end time.Time) (models.WorkerStatistics, error) {
-	return s.repo.GetWorkerStatistic(workerId, start, end)
-}
-
-func (s *WorkerServiceImpl) AddBonus(bonus models.PenaltyOrBonus) error {
-	return s.repo.AddBonus(bonus)
+// LinkUser привязывает работника к учетной записи, nil или 0 отвязывает
+func (s *WorkerServiceImpl) LinkUser(workerID int, userID *int) error {
+	if userID != nil && *userID == 0 {
+		userID = nil
+	}
+	return s.repo.SetWorkerUser(workerID, userID)
 }
 
-func (s *WorkerServiceImpl) GetBonuses(workerID int) ([]models.PenaltyOrBonus, error) {
-	return s.repo.GetBonuses(workerID)
+// GetUnlinked возвращает работников, которых перенос не связал с учетной записью, для ручной привязки
+func (s *WorkerServiceImpl) GetUnlinked() ([]models.Worker, error) {
+	workers, err := s.repo.GetAllWorkers()
+	if err != nil {
+		return nil, err
+	}
+	unlinked := []models.Worker{}
+	for _, worker := range workers {
+		if worker.LinkIssue != nil {
+			unlinked = append(unlinked, worker)
+		}
+	}
+	return unlinked, nil
 }
 
-func (s *WorkerServiceImpl) AddPenalty(penalty models.PenaltyOrBonus) error {
-	return s.repo.AddPenalty(penalty)
-}
+func (s *WorkerServiceImpl) GetStatistics(workerId int, start time.Time, end time.Time) (models.WorkerStatistics, error) {
+	_, stats, err := s.calculateSalary(workerId, start, end)
+	if err != nil {
+		return stats, err
+	}
 
-func (s *WorkerServiceImpl) GetPenalties(workerID int) ([]models.PenaltyOrBonus, error) {
-	return s.repo.GetPenalties(workerID)
+	balance, err := s.Balance(workerId)
+	if err != nil {
+		return stats, err
+	}
+	stats.Balance = &balance
+	return stats, nil
 }
 
-// считает зп за сутки +24 часа от старт для рбаотника, пока если схема не известна возвращает просто всю выручку с заказов
+// Salary считает начисление по схеме оплаты за сутки от start, без бонусов и штрафов
 func (s *WorkerServiceImpl) Salary(workerID int, start time.Time) (float64, error) {
-	stats, err := s.GetStatistics(workerID, start, start.Add(24*time.Hour))
+	calc, err := s.CalculateSalary(workerID, start, start.Add(24*time.Hour))
 	if err != nil {
 		return 0, err
 	}
-	worker, err := s.repo.GetWorkerById(workerID)
-	if err != nil {
-		return 0, err
-	}
-
-	if stats.SalarySchema == "Процентная" {
-		return stats.TotalRevenue * (float64(worker.Salary) / 100.0), nil // делим на 100 чтоб получить процент
-	}
-
-	if stats.SalarySchema == "Фиксированная" {
-		return float64(worker.Salary), nil // если нет схемы то просто возвращаем сумму
-	}
-
-	return stats.TotalRevenue, nil // если нет схемы то просто возвращаем сумму
+	return calc.Accrued.Rubles(), nil
 }
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"go-hinomontaj/models"
+	"go-hinomontaj/pkg/logger"
+	"strings"
+	"time"
+)
+
+func (s *WorkerServiceImpl) GetPayments(workerID int, start, end time.Time) ([]models.WorkerPayment, error) {
+	return s.repo.GetWorkerPayments(workerID, start, end)
+}
+
+// AddPayment записывает аванс или выплату работнику. Аванс нельзя провести задним числом
+// в период, расчет зарплаты за который уже утвержден.
+func (s *WorkerServiceImpl) AddPayment(payment models.WorkerPayment) (int, error) {
+	logger.Debug("Запись выплаты работнику ID:%d в сервисе", payment.WorkerID)
+	if err := validateWorkerPayment(&payment); err != nil {
+		return 0, err
+	}
+	if payment.Kind == models.WorkerPaymentAdvance {
+		if err := s.checkAdvancePeriod(payment.PaidAt); err != nil {
+			return 0, err
+		}
+	}
+	return s.repo.CreateWorkerPayment(payment)
+}
+
+func (s *WorkerServiceImpl) DeletePayment(workerID, id int) error {
+	logger.Debug("Удаление выплаты ID:%d работника ID:%d в сервисе", id, workerID)
+	payment, err := s.repo.GetWorkerPaymentById(id)
+	if err != nil {
+		return err
+	}
+	if payment.Kind == models.WorkerPaymentAdvance {
+		if err := s.checkAdvancePeriod(payment.PaidAt); err != nil {
+			return err
+		}
+	}
+	return s.repo.DeleteWorkerPayment(workerID, id)
+}
+
+// checkAdvancePeriod не дает менять авансы, уже вычтенные в утвержденном или закрытом расчете
+func (s *WorkerServiceImpl) checkAdvancePeriod(paidAt time.Time) error {
+	periods, err := s.repo.GetPayrollPeriods()
+	if err != nil {
+		return err
+	}
+	for _, period := range periods {
+		if period.Status == models.PayrollDraft || paidAt.Before(period.PeriodStart) || paidAt.After(period.PeriodEnd) {
+			continue
+		}
+		return fmt.Errorf("расчет зарплаты за %s - %s уже %s, авансы за этот период менять нельзя",
+			period.PeriodStart.Format("02.01.2006"), period.PeriodEnd.Format("02.01.2006"), period.Status)
+	}
+	return nil
+}
+
+// Balance считает долг перед работником: заработанное по утвержденным расчетам и начисленное
+// по схемам после них минус все выданные деньги
+func (s *WorkerServiceImpl) Balance(workerID int) (models.WorkerBalance, error) {
+	balance, err := s.repo.GetWorkerSettlement(workerID)
+	if err != nil {
+		return balance, err
+	}
+
+	worker, err := s.repo.GetWorkerById(workerID)
+	if err != nil {
+		return balance, err
+	}
+	from := worker.CreatedAt
+	if balance.SettledUntil != nil {
+		from = balance.SettledUntil.AddDate(0, 0, 1)
+	}
+	if now := time.Now(); from.Before(now) {
+		calc, err := s.CalculateSalary(workerID, from, now)
+		if err != nil {
+			return balance, err
+		}
+		balance.Unsettled = calc.Total
+	}
+
+	balance.Balance = balance.Earned + balance.Unsettled - balance.Paid
+	return balance, nil
+}
+
+// validateWorkerPayment проверяет вид, способ и сумму выплаты, дата выплаты приводится к дню
+func validateWorkerPayment(payment *models.WorkerPayment) error {
+	payment.Description = strings.TrimSpace(payment.Description)
+	payment.Method = strings.ToLower(strings.TrimSpace(payment.Method))
+	if payment.Kind != models.WorkerPaymentAdvance && payment.Kind != models.WorkerPaymentPayout {
+		return fmt.Errorf("неизвестный вид выплаты '%s', допустимые: %s, %s",
+			payment.Kind, models.WorkerPaymentAdvance, models.WorkerPaymentPayout)
+	}
+	if payment.Amount <= 0 {
+		return fmt.Errorf("сумма выплаты должна быть больше нуля")
+	}
+
+	known := false
+	for _, method := range models.WorkerPaymentMethods {
+		if payment.Method == method {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("неизвестный способ выплаты '%s', допустимые: %s",
+			payment.Method, strings.Join(models.WorkerPaymentMethods, ", "))
+	}
+
+	if payment.PaidAt.IsZero() {
+		return fmt.Errorf("не указана дата выплаты")
+	}
+	y, m, d := payment.PaidAt.Date()
+	payment.PaidAt = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	if payment.PeriodID != nil && *payment.PeriodID == 0 {
+		payment.PeriodID = nil
+	}
+	if payment.Kind == models.WorkerPaymentAdvance {
+		payment.PeriodID = nil
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS service_material_norms ( -- нормы расхода материалов на одну услугу
+    id SERIAL PRIMARY KEY,
+    service_name VARCHAR(255) NOT NULL, -- услуги хранятся отдельно по каждому договору, поэтому норма привязана к названию
+    contract_id INTEGER REFERENCES contracts(id) ON DELETE CASCADE, -- null = норма по умолчанию для всех договоров
+    material_id INTEGER NOT NULL REFERENCES material(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_service_material_norms_unique
+    ON service_material_norms(service_name, COALESCE(contract_id, 0), material_id);
+
+-- Отмечаем расходники, подставленные автоматически по норме
+ALTER TABLE order_materials ADD COLUMN IF NOT EXISTS from_norm BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_materials DROP COLUMN IF EXISTS from_norm;
+DROP TABLE IF EXISTS service_material_norms;
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS stock_takes ( -- инвентаризация склада
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'открыта', -- открыта / утверждена
+    description TEXT,
+    opened_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    approved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    approved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Одновременно может быть открыта только одна инвентаризация
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_takes_single_open ON stock_takes ((true)) WHERE status = 'открыта';
+
+CREATE TABLE IF NOT EXISTS stock_take_counts ( -- проходы пересчета, по материалу суммируются
+    id SERIAL PRIMARY KEY,
+    stock_take_id INTEGER NOT NULL REFERENCES stock_takes(id) ON DELETE CASCADE,
+    material_id INTEGER NOT NULL REFERENCES material(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    system_quantity INTEGER NOT NULL DEFAULT 0, -- остаток в системе на момент прохода
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS stock_take_lines ( -- итог утвержденной инвентаризации
+    id SERIAL PRIMARY KEY,
+    stock_take_id INTEGER NOT NULL REFERENCES stock_takes(id) ON DELETE CASCADE,
+    material_id INTEGER NOT NULL REFERENCES material(id) ON DELETE CASCADE,
+    system_quantity INTEGER NOT NULL,
+    counted_quantity INTEGER NOT NULL,
+    delta INTEGER NOT NULL,
+    UNIQUE(stock_take_id, material_id)
+);
+
+CREATE TABLE IF NOT EXISTS material_movements ( -- журнал движения материалов
+    id SERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES material(id) ON DELETE CASCADE,
+    delta INTEGER NOT NULL, -- положительное значение = приход, отрицательное = расход
+    reason VARCHAR(50) NOT NULL,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    stock_take_id INTEGER REFERENCES stock_takes(id) ON DELETE SET NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_take_counts_stock_take_id ON stock_take_counts(stock_take_id);
+CREATE INDEX IF NOT EXISTS idx_material_movements_material_id ON material_movements(material_id, created_at);
+
+-- Начальные остатки, чтобы журнал сходился с текущим складом
+INSERT INTO material_movements (material_id, delta, reason, description)
+SELECT id, storage, 'начальный остаток', 'остаток на момент ведения журнала'
+FROM material
+WHERE storage <> 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS material_movements;
+DROP TABLE IF EXISTS stock_take_lines;
+DROP TABLE IF EXISTS stock_take_counts;
+DROP TABLE IF EXISTS stock_takes;
+-- +goose StatementEnd
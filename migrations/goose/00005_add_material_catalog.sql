@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS material_categories ( -- категории материалов вместо числового type_ds
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Категории для уже используемых значений type_ds, ID совпадают со старыми номерами
+INSERT INTO material_categories (id, name)
+SELECT DISTINCT type_ds,
+       CASE type_ds WHEN 1 THEN 'Резина' WHEN 2 THEN 'Колодки' ELSE 'Тип ДС ' || type_ds END
+FROM material
+ON CONFLICT DO NOTHING;
+
+INSERT INTO material_categories (id, name) VALUES (1, 'Резина'), (2, 'Колодки')
+ON CONFLICT DO NOTHING;
+
+SELECT setval(pg_get_serial_sequence('material_categories', 'id'), (SELECT MAX(id) FROM material_categories));
+
+ALTER TABLE material RENAME COLUMN type_ds TO category_id;
+ALTER TABLE material ADD CONSTRAINT material_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES material_categories(id) ON DELETE RESTRICT;
+
+-- Единица измерения и артикул
+ALTER TABLE material ADD COLUMN unit VARCHAR(10) NOT NULL DEFAULT 'шт'
+    CHECK (unit IN ('шт', 'г', 'л', 'туба'));
+ALTER TABLE material ADD COLUMN sku VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_material_sku ON material(sku) WHERE sku IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_material_category_id ON material(category_id);
+
+-- Дробные количества (граммы, литры)
+ALTER TABLE material ALTER COLUMN storage TYPE NUMERIC(12,3);
+ALTER TABLE order_materials ALTER COLUMN quantity TYPE NUMERIC(12,3);
+ALTER TABLE service_material_norms ALTER COLUMN quantity TYPE NUMERIC(12,3);
+ALTER TABLE material_movements ALTER COLUMN delta TYPE NUMERIC(12,3);
+ALTER TABLE stock_take_counts ALTER COLUMN quantity TYPE NUMERIC(12,3);
+ALTER TABLE stock_take_lines ALTER COLUMN system_quantity TYPE NUMERIC(12,3);
+ALTER TABLE stock_take_lines ALTER COLUMN counted_quantity TYPE NUMERIC(12,3);
+ALTER TABLE stock_take_lines ALTER COLUMN delta TYPE NUMERIC(12,3);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_take_lines ALTER COLUMN delta TYPE INTEGER USING ROUND(delta);
+ALTER TABLE stock_take_lines ALTER COLUMN counted_quantity TYPE INTEGER USING ROUND(counted_quantity);
+ALTER TABLE stock_take_lines ALTER COLUMN system_quantity TYPE INTEGER USING ROUND(system_quantity);
+ALTER TABLE stock_take_counts ALTER COLUMN quantity TYPE INTEGER USING ROUND(quantity);
+ALTER TABLE material_movements ALTER COLUMN delta TYPE INTEGER USING ROUND(delta);
+ALTER TABLE service_material_norms ALTER COLUMN quantity TYPE INTEGER USING ROUND(quantity);
+ALTER TABLE order_materials ALTER COLUMN quantity TYPE INTEGER USING ROUND(quantity);
+ALTER TABLE material ALTER COLUMN storage TYPE INTEGER USING ROUND(storage);
+
+DROP INDEX IF EXISTS idx_material_category_id;
+DROP INDEX IF EXISTS idx_material_sku;
+ALTER TABLE material DROP COLUMN IF EXISTS sku;
+ALTER TABLE material DROP COLUMN IF EXISTS unit;
+
+ALTER TABLE material DROP CONSTRAINT IF EXISTS material_category_id_fkey;
+ALTER TABLE material RENAME COLUMN category_id TO type_ds;
+DROP TABLE IF EXISTS material_categories;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE material ADD COLUMN min_storage NUMERIC(12,3) NOT NULL DEFAULT 0; -- минимальный остаток, ниже которого нужна закупка
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE material DROP COLUMN IF EXISTS min_storage;
+-- +goose StatementEnd
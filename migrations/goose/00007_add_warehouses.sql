@@ -0,0 +1,87 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS warehouses ( -- места хранения материалов
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    is_mobile BOOLEAN NOT NULL DEFAULT false, -- выездная машина
+    is_default BOOLEAN NOT NULL DEFAULT false, -- склад по умолчанию для работников без привязки
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_single_default ON warehouses ((true)) WHERE is_default;
+
+INSERT INTO warehouses (name, is_mobile, is_default) VALUES
+    ('Основной склад', false, true),
+    ('Выездная машина', true, false);
+
+-- Остатки по складам, material.storage остается общим остатком по всем складам
+CREATE TABLE IF NOT EXISTS material_stock (
+    material_id INTEGER NOT NULL REFERENCES material(id) ON DELETE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    quantity NUMERIC(12,3) NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    PRIMARY KEY (material_id, warehouse_id)
+);
+
+INSERT INTO material_stock (material_id, warehouse_id, quantity)
+SELECT m.id, w.id, m.storage
+FROM material m, warehouses w
+WHERE w.is_default;
+
+CREATE TABLE IF NOT EXISTS material_transfers ( -- перемещения между складами
+    id SERIAL PRIMARY KEY,
+    material_id INTEGER NOT NULL REFERENCES material(id) ON DELETE CASCADE,
+    from_warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    to_warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    quantity NUMERIC(12,3) NOT NULL CHECK (quantity > 0),
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (from_warehouse_id <> to_warehouse_id)
+);
+
+-- Движения привязываются к складу
+ALTER TABLE material_movements ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE RESTRICT;
+ALTER TABLE material_movements ADD COLUMN transfer_id INTEGER REFERENCES material_transfers(id) ON DELETE SET NULL;
+UPDATE material_movements SET warehouse_id = (SELECT id FROM warehouses WHERE is_default);
+ALTER TABLE material_movements ALTER COLUMN warehouse_id SET NOT NULL;
+
+-- Расходники заказа списываются с конкретного склада и туда же возвращаются
+ALTER TABLE order_materials ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE RESTRICT;
+UPDATE order_materials SET warehouse_id = (SELECT id FROM warehouses WHERE is_default);
+ALTER TABLE order_materials ALTER COLUMN warehouse_id SET NOT NULL;
+
+-- Инвентаризация проводится по одному складу, открытой может быть одна на склад
+ALTER TABLE stock_takes ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE RESTRICT;
+UPDATE stock_takes SET warehouse_id = (SELECT id FROM warehouses WHERE is_default);
+ALTER TABLE stock_takes ALTER COLUMN warehouse_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_stock_takes_single_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_takes_single_open ON stock_takes (warehouse_id) WHERE status = 'открыта';
+
+-- Работник расходует материалы со своего склада, работники с машиной - из выездной машины
+ALTER TABLE workers ADD COLUMN warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE SET NULL;
+UPDATE workers SET warehouse_id = (SELECT id FROM warehouses WHERE is_mobile ORDER BY id LIMIT 1) WHERE has_car;
+
+CREATE INDEX IF NOT EXISTS idx_material_movements_warehouse_id ON material_movements(warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_material_stock_warehouse_id ON material_stock(warehouse_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_material_stock_warehouse_id;
+DROP INDEX IF EXISTS idx_material_movements_warehouse_id;
+
+ALTER TABLE workers DROP COLUMN IF EXISTS warehouse_id;
+
+DROP INDEX IF EXISTS idx_stock_takes_single_open;
+ALTER TABLE stock_takes DROP COLUMN IF EXISTS warehouse_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_takes_single_open ON stock_takes ((true)) WHERE status = 'открыта';
+
+ALTER TABLE order_materials DROP COLUMN IF EXISTS warehouse_id;
+ALTER TABLE material_movements DROP COLUMN IF EXISTS transfer_id;
+ALTER TABLE material_movements DROP COLUMN IF EXISTS warehouse_id;
+
+DROP TABLE IF EXISTS material_transfers;
+DROP TABLE IF EXISTS material_stock;
+DROP TABLE IF EXISTS warehouses;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE cars ADD COLUMN vehicle_type VARCHAR(30)
+    CHECK (vehicle_type IN ('тягач', 'грузовик', 'прицеп', 'полуприцеп', 'автобус', 'легковой'));
+ALTER TABLE cars ADD COLUMN vin VARCHAR(17) CHECK (vin ~ '^[A-HJ-NPR-Z0-9]{17}$');
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cars_vin ON cars(vin) WHERE vin IS NOT NULL;
+
+-- Оси машины по порядку от передней, по ним строится схема колес для заказа
+CREATE TABLE IF NOT EXISTS car_axles (
+    car_id INTEGER NOT NULL REFERENCES cars(id) ON DELETE CASCADE,
+    axle_number INTEGER NOT NULL CHECK (axle_number > 0),
+    is_dual BOOLEAN NOT NULL DEFAULT false, -- спаренные колеса (внутреннее и внешнее)
+    tire_size VARCHAR(30), -- типоразмер шин, например 315/80 R22.5
+    PRIMARY KEY (car_id, axle_number)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS car_axles;
+DROP INDEX IF EXISTS idx_cars_vin;
+ALTER TABLE cars DROP COLUMN IF EXISTS vin;
+ALTER TABLE cars DROP COLUMN IF EXISTS vehicle_type;
+-- +goose StatementEnd
@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Номера в заказах приводим к виду, в котором они хранятся в cars: латиница без пробелов и приписки RUS
+UPDATE orders
+SET vehicle_number = regexp_replace(
+        regexp_replace(translate(upper(vehicle_number), 'АВЕКМНОРСТУХ', 'ABEKMHOPCTYX'), '[^A-Z0-9]', '', 'g'),
+        'RUS$', '')
+WHERE vehicle_number !~ '^[A-Z0-9]+$' OR vehicle_number ~ 'RUS$';
+
+-- Номера машин, заведенные до нормализации, приводим к тому же виду. Если нормализованный номер
+-- совпал с другой машиной, остается одна: уже записанная в новом виде или заведенная раньше
+CREATE TEMP TABLE car_number_fix ON COMMIT DROP AS
+SELECT id, normalized,
+       first_value(id) OVER (PARTITION BY normalized ORDER BY (number = normalized) DESC, id) AS keep_id
+FROM (
+    SELECT id, number, regexp_replace(
+               regexp_replace(translate(upper(number), 'АВЕКМНОРСТУХ', 'ABEKMHOPCTYX'), '[^A-Z0-9]', '', 'g'),
+               'RUS$', '') AS normalized
+    FROM cars
+) c;
+
+-- Владельцев дублей переносим на оставшуюся машину, если клиент еще не привязан к ней
+UPDATE clients_cars cc SET car_id = f.keep_id, updated_at = CURRENT_TIMESTAMP
+FROM car_number_fix f
+WHERE cc.car_id = f.id AND f.id <> f.keep_id
+  AND NOT EXISTS (SELECT 1 FROM clients_cars k WHERE k.car_id = f.keep_id AND k.client_id = cc.client_id)
+  AND cc.id = (SELECT MIN(cc2.id) FROM clients_cars cc2
+               JOIN car_number_fix f2 ON f2.id = cc2.car_id
+               WHERE f2.keep_id = f.keep_id AND f2.id <> f2.keep_id AND cc2.client_id = cc.client_id);
+
+-- Оси берем у первого дубля, только если у оставшейся машины они не заполнены
+UPDATE car_axles a SET car_id = f.keep_id
+FROM (SELECT DISTINCT ON (keep_id) id, keep_id
+      FROM car_number_fix
+      WHERE id <> keep_id
+      ORDER BY keep_id, id) f
+WHERE a.car_id = f.id
+  AND NOT EXISTS (SELECT 1 FROM car_axles k WHERE k.car_id = f.keep_id);
+
+DELETE FROM cars WHERE id IN (SELECT id FROM car_number_fix WHERE id <> keep_id);
+
+UPDATE cars c SET number = f.normalized, updated_at = CURRENT_TIMESTAMP
+FROM car_number_fix f
+WHERE c.id = f.id AND c.number <> f.normalized;
+
+-- Поиск по номеру с опечатками: levenshtein считается в БД, поиск по части номера идет по триграммному индексу
+CREATE EXTENSION IF NOT EXISTS fuzzystrmatch;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_cars_number_trgm ON cars USING gin (number gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Исходное написание номеров не сохраняется, откатывать нечего. Расширения могут использоваться не только здесь
+DROP INDEX IF EXISTS idx_cars_number_trgm;
+-- +goose StatementEnd
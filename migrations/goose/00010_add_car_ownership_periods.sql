@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Привязка машины к клиенту действует в периоде, закрытая привязка остается в истории владельцев
+ALTER TABLE clients_cars ADD COLUMN valid_from TIMESTAMP WITH TIME ZONE;
+UPDATE clients_cars SET valid_from = COALESCE(created_at, CURRENT_TIMESTAMP);
+ALTER TABLE clients_cars ALTER COLUMN valid_from SET NOT NULL;
+ALTER TABLE clients_cars ALTER COLUMN valid_from SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE clients_cars ADD COLUMN valid_to TIMESTAMP WITH TIME ZONE; -- NULL = действует сейчас
+ALTER TABLE clients_cars ADD CONSTRAINT clients_cars_valid_period CHECK (valid_to IS NULL OR valid_to >= valid_from);
+
+-- Один и тот же клиент может владеть машиной несколько раз, но действующая привязка одна
+ALTER TABLE clients_cars DROP CONSTRAINT IF EXISTS clients_cars_client_id_car_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_clients_cars_active ON clients_cars (client_id, car_id) WHERE valid_to IS NULL;
+CREATE INDEX IF NOT EXISTS idx_clients_cars_car_id ON clients_cars (car_id, valid_from);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_clients_cars_car_id;
+DROP INDEX IF EXISTS idx_clients_cars_active;
+-- История владения при откате теряется, остаются только действующие привязки
+DELETE FROM clients_cars WHERE valid_to IS NOT NULL;
+ALTER TABLE clients_cars ADD CONSTRAINT clients_cars_client_id_car_id_key UNIQUE (client_id, car_id);
+ALTER TABLE clients_cars DROP CONSTRAINT IF EXISTS clients_cars_valid_period;
+ALTER TABLE clients_cars DROP COLUMN IF EXISTS valid_to;
+ALTER TABLE clients_cars DROP COLUMN IF EXISTS valid_from;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Объединенные клиенты: по старому ID можно найти клиента, в которого он влит
+CREATE TABLE IF NOT EXISTS client_merges (
+    merged_client_id INTEGER PRIMARY KEY, -- клиент удален, поэтому без внешнего ключа
+    merged_name VARCHAR(255) NOT NULL,
+    into_client_id INTEGER NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_merges_into ON client_merges(into_client_id);
+
+-- Онлайн-запись привязывается к клиенту по владельцу машины
+ALTER TABLE online_date ADD COLUMN client_id INTEGER REFERENCES clients(id) ON DELETE SET NULL;
+UPDATE online_date od
+SET client_id = (
+    SELECT cc.client_id
+    FROM clients_cars cc
+    JOIN cars ON cars.id = cc.car_id
+    WHERE cars.number = od.car_number AND cc.valid_to IS NULL
+    ORDER BY cc.valid_from DESC
+    LIMIT 1
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE online_date DROP COLUMN IF EXISTS client_id;
+DROP TABLE IF EXISTS client_merges;
+-- +goose StatementEnd
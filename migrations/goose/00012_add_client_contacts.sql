@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS client_contacts ( -- контактные лица клиента
+    id SERIAL PRIMARY KEY,
+    client_id INTEGER NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'другое'
+        CHECK (role IN ('владелец', 'менеджер', 'диспетчер', 'бухгалтер', 'водитель', 'другое')),
+    phones VARCHAR(16)[] NOT NULL DEFAULT '{}', -- в формате E.164: +79161234567
+    email VARCHAR(255),
+    messenger VARCHAR(100), -- ник или номер в мессенджере, например @dispatcher
+    notify_sms BOOLEAN NOT NULL DEFAULT false,
+    notify_email BOOLEAN NOT NULL DEFAULT false,
+    notify_messenger BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_contacts_client_id ON client_contacts(client_id);
+CREATE INDEX IF NOT EXISTS idx_client_contacts_phones ON client_contacts USING GIN (phones);
+
+-- Телефоны владельца и менеджера переносим в контакты, нераспознанные номера пропускаем.
+-- У клиента налички (ID 1) и у клиентов без телефона стоит заглушка +7-000-000-0000, ее не переносим
+INSERT INTO client_contacts (client_id, name, role, phones)
+SELECT id, name, role, ARRAY[phone]
+FROM (
+    SELECT c.id, c.name, p.role,
+           CASE
+               WHEN p.digits ~ '^[78][0-9]{10}$' THEN '+7' || substr(p.digits, 2)
+               WHEN p.digits ~ '^9[0-9]{9}$' THEN '+7' || p.digits
+           END AS phone
+    FROM clients c
+    CROSS JOIN LATERAL (VALUES
+        ('владелец', regexp_replace(c.owner_phone, '[^0-9]', '', 'g')),
+        ('менеджер', regexp_replace(c.manager_phone, '[^0-9]', '', 'g'))
+    ) AS p(role, digits)
+) phones
+WHERE id <> 1 AND phone IS NOT NULL AND phone <> '+70000000000';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS client_contacts;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Взаиморасчеты с клиентами по договору: начисления по заказам и поступившие оплаты
+CREATE TABLE IF NOT EXISTS client_ledger (
+    id SERIAL PRIMARY KEY,
+    client_id INTEGER NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    contract_id INTEGER REFERENCES contracts(id) ON DELETE SET NULL,
+    order_id INTEGER UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    entry_type VARCHAR(20) NOT NULL CHECK (entry_type IN ('начисление', 'оплата')),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    entry_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    document VARCHAR(100) NOT NULL DEFAULT '', -- номер платежного поручения
+    description TEXT NOT NULL DEFAULT '',
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    -- начисления создаются только по заказам, оплаты вносятся вручную
+    CONSTRAINT client_ledger_entry_source CHECK ((entry_type = 'начисление') = (order_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_ledger_client ON client_ledger(client_id, entry_date);
+CREATE INDEX IF NOT EXISTS idx_client_ledger_contract ON client_ledger(contract_id);
+
+-- Начисления по уже созданным заказам по договору
+INSERT INTO client_ledger (client_id, contract_id, order_id, entry_type, amount, entry_date, description)
+SELECT o.client_id, cl.contract_id, o.id, 'начисление', o.total_amount, o.created_at,
+       'Заказ №' || o.id || ', ' || o.vehicle_number
+FROM orders o
+JOIN clients cl ON cl.id = o.client_id
+WHERE o.payment_method = 'contract' AND o.total_amount > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS client_ledger;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Счета по договорам за период
+CREATE TABLE IF NOT EXISTS invoices (
+    id SERIAL PRIMARY KEY,
+    contract_id INTEGER NOT NULL REFERENCES contracts(id),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    total_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT invoices_period CHECK (period_end >= period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_contract ON invoices(contract_id, period_start);
+
+-- Строки счета сохраняются отдельно, чтобы правка заказа не меняла выставленный счет
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    vehicle_number VARCHAR(20) NOT NULL,
+    service_name VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price NUMERIC(10,2) NOT NULL,
+    amount NUMERIC(12,2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_lines_invoice ON invoice_lines(invoice_id);
+
+-- Заказ попадает только в один счет, при удалении счета заказы снова доступны для выставления
+ALTER TABLE orders ADD COLUMN invoice_id INTEGER REFERENCES invoices(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_invoice_id ON orders(invoice_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN IF EXISTS invoice_id;
+DROP TABLE IF EXISTS invoice_lines;
+DROP TABLE IF EXISTS invoices;
+-- +goose StatementEnd
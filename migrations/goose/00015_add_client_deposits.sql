@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Допустимый минус по предоплате: до этой суммы заказ списывается с предупреждением, дальше - отказ
+ALTER TABLE clients ADD COLUMN deposit_overdraft_limit NUMERIC(12,2) NOT NULL DEFAULT 0
+    CHECK (deposit_overdraft_limit >= 0);
+
+-- Движения по предоплате клиента, баланс - сумма amount
+CREATE TABLE IF NOT EXISTS client_deposit_movements (
+    id SERIAL PRIMARY KEY,
+    client_id INTEGER NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    movement_type VARCHAR(20) NOT NULL CHECK (movement_type IN ('пополнение', 'выдача', 'списание', 'возврат')),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount <> 0), -- пополнение и возврат > 0, выдача и списание < 0
+    document VARCHAR(100) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT client_deposit_movements_sign CHECK (
+        (movement_type IN ('пополнение', 'возврат') AND amount > 0) OR
+        (movement_type IN ('выдача', 'списание') AND amount < 0)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_deposit_movements_client ON client_deposit_movements(client_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_client_deposit_movements_order ON client_deposit_movements(order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS client_deposit_movements;
+ALTER TABLE clients DROP COLUMN IF EXISTS deposit_overdraft_limit;
+-- +goose StatementEnd
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Розничные покупатели внутри клиентов за наличку: узнаются по телефону, номеру машины или карте
+CREATE TABLE IF NOT EXISTS retail_customers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(16) UNIQUE CHECK (phone ~ '^\+[0-9]{8,15}$'), -- E.164
+    card_number VARCHAR(32) UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Машина принадлежит одному покупателю, по номеру покупатель находится без телефона
+CREATE TABLE IF NOT EXISTS retail_customer_cars (
+    customer_id INTEGER NOT NULL REFERENCES retail_customers(id) ON DELETE CASCADE,
+    vehicle_number VARCHAR(20) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (customer_id, vehicle_number)
+);
+
+-- Правила лояльности: каждая N-я услуга бесплатно или скидка в процентах после N визитов
+CREATE TABLE IF NOT EXISTS loyalty_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    rule_type VARCHAR(20) NOT NULL CHECK (rule_type IN ('бесплатная', 'процент')),
+    service_id INTEGER REFERENCES services(id) ON DELETE CASCADE, -- NULL = все услуги
+    every_n INTEGER CHECK (every_n >= 2),
+    min_visits INTEGER NOT NULL DEFAULT 0 CHECK (min_visits >= 0),
+    percent NUMERIC(5,2) CHECK (percent > 0 AND percent <= 100),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT loyalty_rules_params CHECK (
+        (rule_type = 'бесплатная' AND every_n IS NOT NULL AND service_id IS NOT NULL) OR
+        (rule_type = 'процент' AND percent IS NOT NULL)
+    )
+);
+
+ALTER TABLE orders ADD COLUMN retail_customer_id INTEGER REFERENCES retail_customers(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN discount_amount NUMERIC(10,2) NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_orders_retail_customer ON orders(retail_customer_id);
+
+-- Скидка по строке: price - итоговая цена, discount - сколько снято с цены по прайсу
+ALTER TABLE order_services ADD COLUMN discount NUMERIC(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE order_services ADD COLUMN discount_reason VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE order_services DROP COLUMN IF EXISTS discount_reason;
+ALTER TABLE order_services DROP COLUMN IF EXISTS discount;
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE orders DROP COLUMN IF EXISTS retail_customer_id;
+DROP TABLE IF EXISTS loyalty_rules;
+DROP TABLE IF EXISTS retail_customer_cars;
+DROP TABLE IF EXISTS retail_customers;
+-- +goose StatementEnd
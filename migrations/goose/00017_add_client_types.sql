@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Справочник типов клиентов вместо произвольных строк, договор по умолчанию подставляется новым клиентам типа
+CREATE TABLE IF NOT EXISTS client_types (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE CHECK (name = UPPER(TRIM(name)) AND name <> ''),
+    description TEXT NOT NULL DEFAULT '',
+    default_contract_id INTEGER REFERENCES contracts(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Типы при обновлении клиента не приводились к верхнему регистру
+UPDATE clients SET client_type = UPPER(TRIM(client_type)) WHERE client_type <> UPPER(TRIM(client_type));
+UPDATE contracts SET client_type = UPPER(TRIM(client_type)) WHERE client_type <> UPPER(TRIM(client_type));
+
+INSERT INTO client_types (name)
+SELECT client_type FROM clients WHERE client_type <> ''
+UNION
+SELECT client_type FROM contracts WHERE client_type <> ''
+UNION
+SELECT unnest(ARRAY['НАЛИЧКА', 'КОНТРАГЕНТЫ', 'АГРЕГАТОРЫ'])
+ON CONFLICT (name) DO NOTHING;
+
+-- По умолчанию - первый договор этого типа
+UPDATE client_types ct
+SET default_contract_id = (SELECT MIN(c.id) FROM contracts c WHERE c.client_type = ct.name);
+
+-- Переименование типа переносится на клиентов и договоры, удалить используемый тип нельзя
+ALTER TABLE clients ADD CONSTRAINT clients_client_type_fkey
+    FOREIGN KEY (client_type) REFERENCES client_types(name) ON UPDATE CASCADE;
+ALTER TABLE contracts ADD CONSTRAINT contracts_client_type_fkey
+    FOREIGN KEY (client_type) REFERENCES client_types(name) ON UPDATE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE contracts DROP CONSTRAINT IF EXISTS contracts_client_type_fkey;
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_client_type_fkey;
+DROP TABLE IF EXISTS client_types;
+-- +goose StatementEnd
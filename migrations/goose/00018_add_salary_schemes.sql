@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Схемы оплаты работника, действуют с effective_from до начала следующей схемы
+CREATE TABLE IF NOT EXISTS worker_salary_schemes (
+    id SERIAL PRIMARY KEY,
+    worker_id INTEGER NOT NULL REFERENCES workers(id) ON DELETE CASCADE,
+    scheme_type VARCHAR(30) NOT NULL
+        CHECK (scheme_type IN ('за смену', 'процент', 'процент с минимумом', 'ступенчатый', 'сдельная')),
+    rate NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (rate >= 0), -- сумма за смену, минимум за смену или сдельная ставка по умолчанию
+    percent NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (percent >= 0 AND percent <= 100),
+    effective_from DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (worker_id, effective_from)
+);
+
+-- Ступени выручки для ступенчатой схемы: процент действует на выручку от revenue_from до следующей ступени
+CREATE TABLE IF NOT EXISTS salary_scheme_tiers (
+    scheme_id INTEGER NOT NULL REFERENCES worker_salary_schemes(id) ON DELETE CASCADE,
+    revenue_from NUMERIC(12,2) NOT NULL CHECK (revenue_from >= 0),
+    percent NUMERIC(5,2) NOT NULL CHECK (percent >= 0 AND percent <= 100),
+    PRIMARY KEY (scheme_id, revenue_from)
+);
+
+-- Сдельные ставки, услуги хранятся по каждому договору отдельно, поэтому ставка привязана к названию
+CREATE TABLE IF NOT EXISTS salary_scheme_rates (
+    scheme_id INTEGER NOT NULL REFERENCES worker_salary_schemes(id) ON DELETE CASCADE,
+    service_name VARCHAR(255) NOT NULL,
+    rate NUMERIC(10,2) NOT NULL CHECK (rate >= 0),
+    PRIMARY KEY (scheme_id, service_name)
+);
+
+-- Переносим прежние схемы из карточки работника
+INSERT INTO worker_salary_schemes (worker_id, scheme_type, rate, percent, effective_from)
+SELECT id,
+       CASE salary_schema WHEN 'Процентная' THEN 'процент' ELSE 'за смену' END,
+       CASE salary_schema WHEN 'Процентная' THEN 0 ELSE salary END,
+       CASE salary_schema WHEN 'Процентная' THEN LEAST(salary, 100) ELSE 0 END,
+       created_at::date
+FROM workers
+WHERE salary_schema IN ('Процентная', 'Фиксированная');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS salary_scheme_rates;
+DROP TABLE IF EXISTS salary_scheme_tiers;
+DROP TABLE IF EXISTS worker_salary_schemes;
+-- +goose StatementEnd
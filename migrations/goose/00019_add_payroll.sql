@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Расчет зарплаты за период: черновик можно пересчитывать, утвержденный проверен, закрытый выплачен
+CREATE TABLE IF NOT EXISTS payroll_periods (
+    id SERIAL PRIMARY KEY,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'черновик' CHECK (status IN ('черновик', 'утвержден', 'закрыт')),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    approved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    calculated_at TIMESTAMP WITH TIME ZONE,
+    approved_at TIMESTAMP WITH TIME ZONE,
+    locked_at TIMESTAMP WITH TIME ZONE,
+    changed_at TIMESTAMP WITH TIME ZONE, -- удаление заказа или схемы оплаты, задевшее закрытый период
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT payroll_periods_range CHECK (period_end >= period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payroll_periods_start ON payroll_periods(period_start);
+
+-- Начисления работнику за период, сохраняются при расчете и после закрытия не меняются,
+-- поэтому работника с начислениями удалить нельзя
+CREATE TABLE IF NOT EXISTS payroll_lines (
+    id SERIAL PRIMARY KEY,
+    period_id INTEGER NOT NULL REFERENCES payroll_periods(id) ON DELETE CASCADE,
+    worker_id INTEGER NOT NULL REFERENCES workers(id) ON DELETE RESTRICT,
+    accrued NUMERIC(12,2) NOT NULL DEFAULT 0, -- по схемам оплаты
+    bonuses NUMERIC(12,2) NOT NULL DEFAULT 0,
+    penalties NUMERIC(12,2) NOT NULL DEFAULT 0,
+    advances NUMERIC(12,2) NOT NULL DEFAULT 0,
+    adjustments NUMERIC(12,2) NOT NULL DEFAULT 0, -- перерасчеты за закрытые периоды
+    total NUMERIC(12,2) NOT NULL DEFAULT 0,
+    details JSONB, -- расшифровка начисления на момент расчета для расчетного листка
+    UNIQUE (period_id, worker_id)
+);
+
+-- Перерасчет за закрытый период source_period_id, учитывается в периоде period_id
+CREATE TABLE IF NOT EXISTS payroll_adjustments (
+    id SERIAL PRIMARY KEY,
+    period_id INTEGER NOT NULL REFERENCES payroll_periods(id) ON DELETE CASCADE,
+    worker_id INTEGER NOT NULL REFERENCES workers(id) ON DELETE RESTRICT,
+    source_period_id INTEGER NOT NULL REFERENCES payroll_periods(id),
+    amount NUMERIC(12,2) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payroll_adjustments_period ON payroll_adjustments(period_id);
+CREATE INDEX IF NOT EXISTS idx_payroll_adjustments_source ON payroll_adjustments(source_period_id, worker_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payroll_adjustments;
+DROP TABLE IF EXISTS payroll_lines;
+DROP TABLE IF EXISTS payroll_periods;
+-- +goose StatementEnd
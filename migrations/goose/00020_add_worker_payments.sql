@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Выданные работнику деньги: авансы вычитаются при расчете зарплаты за период, в который выданы,
+-- выплаты закрывают начисленную зарплату. Работника с выплатами удалить нельзя, чтобы не потерять кассовые записи
+CREATE TABLE IF NOT EXISTS worker_payments (
+    id SERIAL PRIMARY KEY,
+    worker_id INTEGER NOT NULL REFERENCES workers(id) ON DELETE RESTRICT,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('аванс', 'выплата')),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    paid_at DATE NOT NULL,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('наличные', 'перевод', 'карта')),
+    period_id INTEGER REFERENCES payroll_periods(id) ON DELETE SET NULL, -- расчет зарплаты, по которому сделана выплата
+    description TEXT,
+    issued_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_worker_payments_worker ON worker_payments(worker_id, paid_at);
+CREATE INDEX IF NOT EXISTS idx_worker_payments_paid_at ON worker_payments(paid_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS worker_payments;
+-- +goose StatementEnd
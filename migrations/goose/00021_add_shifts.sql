@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Шаблоны смен, конец раньше начала - смена через полночь
+CREATE TABLE IF NOT EXISTS shift_templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- График: смена работника на день, время смены копируется из шаблона на момент назначения
+CREATE TABLE IF NOT EXISTS shift_assignments (
+    id SERIAL PRIMARY KEY,
+    worker_id INTEGER NOT NULL REFERENCES workers(id) ON DELETE CASCADE,
+    template_id INTEGER REFERENCES shift_templates(id) ON DELETE SET NULL,
+    work_date DATE NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT shift_assignments_range CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shift_assignments_date ON shift_assignments(work_date);
+CREATE INDEX IF NOT EXISTS idx_shift_assignments_worker ON shift_assignments(worker_id, starts_at);
+
+-- Табель: приход и уход работника, открытой может быть только одна отметка.
+-- Забытая отметка закрывается автоматически временем прихода (auto_closed), менеджер исправляет ее вручную
+CREATE TABLE IF NOT EXISTS time_entries (
+    id SERIAL PRIMARY KEY,
+    worker_id INTEGER NOT NULL REFERENCES workers(id) ON DELETE CASCADE,
+    clock_in TIMESTAMP WITH TIME ZONE NOT NULL,
+    clock_out TIMESTAMP WITH TIME ZONE,
+    auto_closed BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE, -- исправление менеджером или автоматическое закрытие
+    CONSTRAINT time_entries_range CHECK (clock_out IS NULL OR clock_out > clock_in OR auto_closed)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_open ON time_entries(worker_id) WHERE clock_out IS NULL;
+CREATE INDEX IF NOT EXISTS idx_time_entries_worker ON time_entries(worker_id, clock_in);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS time_entries;
+DROP TABLE IF EXISTS shift_assignments;
+DROP TABLE IF EXISTS shift_templates;
+-- +goose StatementEnd
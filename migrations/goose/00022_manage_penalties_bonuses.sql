@@ -0,0 +1,87 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Штрафы и бонусы: одинаковые колонки, категория причины, отмена с причиной
+ALTER TABLE bonuses DROP COLUMN IF EXISTS isOrder;
+
+ALTER TABLE bonuses
+    ADD COLUMN category VARCHAR(30) NOT NULL DEFAULT 'другое'
+        CHECK (category IN ('опоздание', 'повреждение колеса', 'жалоба клиента', 'переработка', 'другое')),
+    ADD COLUMN created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN revoked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN revoke_reason TEXT;
+
+ALTER TABLE penalties
+    ADD COLUMN category VARCHAR(30) NOT NULL DEFAULT 'другое'
+        CHECK (category IN ('опоздание', 'повреждение колеса', 'жалоба клиента', 'переработка', 'другое')),
+    ADD COLUMN created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN revoked_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN revoke_reason TEXT;
+
+-- order_id раньше не проверялся: 0 и ссылки на удаленные заказы обнуляем перед внешним ключом
+UPDATE bonuses b SET order_id = NULL
+WHERE order_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.id = b.order_id);
+UPDATE penalties p SET order_id = NULL
+WHERE order_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.id = p.order_id);
+
+ALTER TABLE bonuses ADD CONSTRAINT bonuses_order_id_fkey
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE SET NULL;
+ALTER TABLE penalties ADD CONSTRAINT penalties_order_id_fkey
+    FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_bonuses_worker ON bonuses(workerID, created_at);
+CREATE INDEX IF NOT EXISTS idx_penalties_worker ON penalties(workerID, created_at);
+
+-- История исправлений и отмен штрафов и бонусов
+CREATE TABLE IF NOT EXISTS penalty_bonus_changes (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('штраф', 'бонус')),
+    entry_id INTEGER NOT NULL, -- id в penalties или bonuses, в зависимости от kind
+    worker_id INTEGER NOT NULL REFERENCES workers(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('изменение', 'отмена')),
+    old_delta INTEGER NOT NULL,
+    new_delta INTEGER NOT NULL,
+    old_category VARCHAR(30) NOT NULL,
+    new_category VARCHAR(30) NOT NULL,
+    old_description TEXT,
+    new_description TEXT,
+    old_order_id INTEGER,
+    new_order_id INTEGER,
+    reason TEXT NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_penalty_bonus_changes_entry ON penalty_bonus_changes(kind, entry_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS penalty_bonus_changes;
+
+DROP INDEX IF EXISTS idx_bonuses_worker;
+DROP INDEX IF EXISTS idx_penalties_worker;
+
+ALTER TABLE bonuses DROP CONSTRAINT IF EXISTS bonuses_order_id_fkey;
+ALTER TABLE penalties DROP CONSTRAINT IF EXISTS penalties_order_id_fkey;
+
+ALTER TABLE bonuses
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS revoked_by,
+    DROP COLUMN IF EXISTS revoke_reason,
+    ADD COLUMN isOrder BOOLEAN DEFAULT false;
+
+ALTER TABLE penalties
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS revoked_by,
+    DROP COLUMN IF EXISTS revoke_reason;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Заказ-переделка ссылается на заказ, работу по которому пришлось переделывать
+ALTER TABLE orders ADD COLUMN rework_of_id INTEGER REFERENCES orders(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_rework_of ON orders(rework_of_id) WHERE rework_of_id IS NOT NULL;
+
+-- Правила автоматических штрафов и бонусов: срабатывают на показатель работника за период расчета зарплаты
+CREATE TABLE IF NOT EXISTS penalty_bonus_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('штраф', 'бонус')),
+    metric VARCHAR(20) NOT NULL CHECK (metric IN ('заказы', 'выручка', 'переделки', 'жалобы')),
+    operator VARCHAR(2) NOT NULL CHECK (operator IN ('>=', '<')),
+    threshold NUMERIC(12,2) NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    per_unit BOOLEAN NOT NULL DEFAULT false, -- сумма за каждую единицу показателя
+    category VARCHAR(30) NOT NULL DEFAULT 'другое',
+    worker_id INTEGER REFERENCES workers(id) ON DELETE CASCADE, -- NULL - для всех работников
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Записи, созданные правилами при расчете зарплаты: period_id - расчет, при пересчете которого они
+-- создаются заново. Исправленные или отмененные менеджером записи при пересчете не трогаются.
+ALTER TABLE bonuses
+    ADD COLUMN rule_id INTEGER REFERENCES penalty_bonus_rules(id) ON DELETE SET NULL,
+    ADD COLUMN period_id INTEGER REFERENCES payroll_periods(id) ON DELETE CASCADE;
+ALTER TABLE penalties
+    ADD COLUMN rule_id INTEGER REFERENCES penalty_bonus_rules(id) ON DELETE SET NULL,
+    ADD COLUMN period_id INTEGER REFERENCES payroll_periods(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_bonuses_period ON bonuses(period_id) WHERE period_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_penalties_period ON penalties(period_id) WHERE period_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM bonuses WHERE period_id IS NOT NULL;
+DELETE FROM penalties WHERE period_id IS NOT NULL;
+ALTER TABLE bonuses DROP COLUMN IF EXISTS rule_id, DROP COLUMN IF EXISTS period_id;
+ALTER TABLE penalties DROP COLUMN IF EXISTS rule_id, DROP COLUMN IF EXISTS period_id;
+DROP TABLE IF EXISTS penalty_bonus_rules;
+ALTER TABLE orders DROP COLUMN IF EXISTS rework_of_id;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Учетная запись работника: раньше работник находился по совпадению имени с пользователем
+ALTER TABLE workers ADD COLUMN user_id INTEGER UNIQUE REFERENCES users(id) ON DELETE SET NULL;
+-- Почему перенос не связал работника с учетной записью, NULL - вопросов нет. Снимается привязкой через PUT /workers/:id/user
+ALTER TABLE workers ADD COLUMN link_issue VARCHAR(30)
+    CHECK (link_issue IN ('дубль работника', 'несколько учетных записей', 'учетная запись не найдена'));
+
+-- Сначала связываем по email, если он не повторяется ни у работников, ни у пользователей без учета регистра
+UPDATE workers w SET user_id = u.id
+FROM users u
+WHERE u.role = 'worker' AND w.email <> '' AND lower(w.email) = lower(u.email)
+  AND (SELECT COUNT(*) FROM workers w2 WHERE lower(w2.email) = lower(w.email)) = 1
+  AND (SELECT COUNT(*) FROM users u2 WHERE lower(u2.email) = lower(w.email)) = 1;
+
+-- Остальных по имени, только если имя однозначно и у работников, и у пользователей
+UPDATE workers w SET user_id = u.id
+FROM users u
+WHERE w.user_id IS NULL AND u.role = 'worker' AND w.name = u.name
+  AND NOT EXISTS (SELECT 1 FROM workers w2 WHERE w2.user_id = u.id)
+  AND (SELECT COUNT(*) FROM workers w2 WHERE w2.name = w.name) = 1
+  AND (SELECT COUNT(*) FROM users u2 WHERE u2.name = u.name AND u2.role = 'worker') = 1;
+
+-- Несвязанных помечаем для ручной привязки (GET /workers/unlinked). Дубли работников не попадают
+-- в расчет зарплаты, пока менеджер не разберет их, иначе одному человеку начисляется дважды
+UPDATE workers w SET link_issue = CASE
+        WHEN EXISTS (SELECT 1 FROM workers w2
+                     WHERE w2.id <> w.id
+                       AND ((w.email <> '' AND lower(w2.email) = lower(w.email))
+                            OR (w2.name = w.name AND w2.surname = w.surname)))
+            THEN 'дубль работника'
+        WHEN (w.email <> '' AND (SELECT COUNT(*) FROM users u WHERE lower(u.email) = lower(w.email)) > 1)
+          OR (SELECT COUNT(*) FROM users u WHERE u.name = w.name AND u.role = 'worker') > 1
+            THEN 'несколько учетных записей'
+        ELSE 'учетная запись не найдена'
+    END
+WHERE w.user_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE workers DROP COLUMN IF EXISTS link_issue;
+ALTER TABLE workers DROP COLUMN IF EXISTS user_id;
+-- +goose StatementEnd
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"go-hinomontaj/pkg/money"
 	"time"
 
 	"github.com/lib/pq"
@@ -30,7 +32,7 @@ type SignUpInput struct {
 type Client struct {
 	ID           int            `json:"id" db:"id"`
 	Name         string         `json:"name" db:"name"`
-	ClientType   string         `json:"client_type" db:"client_type"` // если == имени то цены индивидуальные
+	ClientType   string         `json:"client_type" db:"client_type"` // название из справочника client_types
 	Cars         []Car          `json:"cars"`
 	OwnerPhone   string         `json:"owner_phone" db:"owner_phone"`
 	ManagerPhone string         `json:"manager_phone" db:"manager_phone"`
@@ -40,18 +42,271 @@ type Client struct {
 	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
 }
 
+// Роли контактных лиц клиента
+const (
+	ContactOwner      = "владелец"
+	ContactManager    = "менеджер"
+	ContactDispatcher = "диспетчер"
+	ContactAccountant = "бухгалтер"
+	ContactDriver     = "водитель"
+	ContactOther      = "другое"
+)
+
+var ContactRoles = []string{ContactOwner, ContactManager, ContactDispatcher, ContactAccountant, ContactDriver, ContactOther}
+
+// ClientContact контактное лицо клиента
+type ClientContact struct {
+	ID              int            `json:"id" db:"id"`
+	ClientID        int            `json:"client_id" db:"client_id"`
+	Name            string         `json:"name" db:"name"`
+	Role            string         `json:"role" db:"role"`
+	Phones          pq.StringArray `json:"phones" db:"phones"` // в формате E.164
+	Email           string         `json:"email" db:"email"`
+	Messenger       string         `json:"messenger" db:"messenger"`
+	NotifySMS       bool           `json:"notify_sms" db:"notify_sms"`
+	NotifyEmail     bool           `json:"notify_email" db:"notify_email"`
+	NotifyMessenger bool           `json:"notify_messenger" db:"notify_messenger"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// ClientDuplicate пара клиентов, похожих на одного и того же
+type ClientDuplicate struct {
+	ClientID      int      `json:"client_id"`
+	ClientName    string   `json:"client_name"`
+	DuplicateID   int      `json:"duplicate_id"`
+	DuplicateName string   `json:"duplicate_name"`
+	Reasons       []string `json:"reasons"`
+}
+
+// ClientLink совпадение двух клиентов, найденное в базе: общая машина или одинаковый ИНН
+type ClientLink struct {
+	ClientID    int    `db:"client_id"`
+	DuplicateID int    `db:"duplicate_id"`
+	Reason      string `db:"reason"`
+}
+
+// ClientMergeResult что перенесено при объединении клиентов
+type ClientMergeResult struct {
+	ClientID int `json:"client_id"`
+	Cars     int `json:"cars"`
+	Orders   int `json:"orders"`
+	Bookings int `json:"bookings"`
+}
+
 type ClientsCars struct {
 	ClientID int `json:"client_id" db:"client_id"`
 	CarID    int `json:"car_id" db:"car_id"`
 }
 
 type Car struct {
-	ID        int       `json:"id" db:"id"`
-	Number    string    `json:"number" db:"number"`
-	Model     string    `json:"model" db:"model"`
-	Year      int       `json:"year" db:"year"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID          int       `json:"id" db:"id"`
+	Number      string    `json:"number" db:"number"`
+	Model       string    `json:"model" db:"model"`
+	Year        int       `json:"year" db:"year"`
+	VehicleType string    `json:"vehicle_type" db:"vehicle_type"`
+	VIN         string    `json:"vin" db:"vin"`
+	Axles       []CarAxle `json:"axles,omitempty" db:"-"` // заполняется только в профиле машины
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Типы транспортных средств
+const (
+	VehicleTractor      = "тягач"
+	VehicleTruck        = "грузовик"
+	VehicleTrailer      = "прицеп"
+	VehicleSemitrailer  = "полуприцеп"
+	VehicleBus          = "автобус"
+	VehiclePassengerCar = "легковой"
+)
+
+var VehicleTypes = []string{VehicleTractor, VehicleTruck, VehicleTrailer, VehicleSemitrailer, VehicleBus, VehiclePassengerCar}
+
+// Позиция запасного колеса есть у любой машины
+const WheelSpare = "spare"
+
+// CarAxle ось машины, нумерация с передней оси начиная с 1
+type CarAxle struct {
+	Number   int    `json:"number" db:"axle_number"`
+	Dual     bool   `json:"dual" db:"is_dual"`
+	TireSize string `json:"tire_size" db:"tire_size"`
+}
+
+// Positions возвращает позиции колес оси в формате заказа: left_1, right_2_inner и т.д.
+func (a CarAxle) Positions() []string {
+	if !a.Dual {
+		return []string{fmt.Sprintf("left_%d", a.Number), fmt.Sprintf("right_%d", a.Number)}
+	}
+	return []string{
+		fmt.Sprintf("left_%d_inner", a.Number), fmt.Sprintf("left_%d_outer", a.Number),
+		fmt.Sprintf("right_%d_inner", a.Number), fmt.Sprintf("right_%d_outer", a.Number),
+	}
+}
+
+// WheelPositions возвращает все допустимые позиции колес машины, пустой список - схема не задана
+func (c Car) WheelPositions() []string {
+	if len(c.Axles) == 0 {
+		return nil
+	}
+	var positions []string
+	for _, axle := range c.Axles {
+		positions = append(positions, axle.Positions()...)
+	}
+	return append(positions, WheelSpare)
+}
+
+// AxlePreset типовая схема осей, которую можно применить к машине
+type AxlePreset struct {
+	Name        string    `json:"name"`
+	VehicleType string    `json:"vehicle_type"`
+	Axles       []CarAxle `json:"axles"`
+}
+
+// Типовые схемы, раньше зашитые в интерфейс заказа
+var AxlePresets = []AxlePreset{
+	{Name: "Фура/Евро", VehicleType: VehicleTractor, Axles: []CarAxle{{Number: 1}, {Number: 2, Dual: true}, {Number: 3, Dual: true}}},
+	{Name: "Трал/Американец", VehicleType: VehicleTractor, Axles: []CarAxle{{Number: 1}, {Number: 2, Dual: true}, {Number: 3, Dual: true}, {Number: 4, Dual: true}}},
+}
+
+// CarOwner период, в котором машина числилась за клиентом
+type CarOwner struct {
+	ClientID   int        `json:"client_id" db:"client_id"`
+	ClientName string     `json:"client_name" db:"client_name"`
+	ValidFrom  time.Time  `json:"valid_from" db:"valid_from"`
+	ValidTo    *time.Time `json:"valid_to" db:"valid_to"` // nil = владеет сейчас
+}
+
+// CarTransfer передача машины другому клиенту
+type CarTransfer struct {
+	FromClientID int        `json:"from_client_id"` // 0 = закрыть привязки ко всем текущим владельцам
+	ToClientID   int        `json:"to_client_id"`
+	Date         *time.Time `json:"date"` // nil = сейчас
+}
+
+// Виды совпадения при поиске машины по номеру
+const (
+	PlateMatchExact   = "точное"
+	PlateMatchPartial = "часть номера"
+	PlateMatchTypo    = "опечатка"
+)
+
+// CarSearchResult машина, найденная по введенному номеру
+type CarSearchResult struct {
+	Car
+	Match    string `json:"match"`
+	Distance int    `json:"distance"` // сколько символов отличается от введенного номера
+}
+
+// Как поступать при загрузке машины, которая уже числится за другим клиентом
+const (
+	CarConflictSkip  = "skip"  // оставить у прежнего владельца
+	CarConflictMove  = "move"  // передать загружающему клиенту
+	CarConflictShare = "share" // владеть вместе
+)
+
+// Итог обработки строки загрузки машин
+const (
+	CarImportAdded   = "добавлена"         // новая машина
+	CarImportLinked  = "привязана"         // машина была в базе без владельца
+	CarImportMoved   = "передана"          // забрана у другого клиента
+	CarImportShared  = "общая"             // привязана вместе с другим клиентом
+	CarImportSkipped = "уже у клиента"     // повтор, ничего не меняется
+	CarImportOther   = "у другого клиента" // пропущена, т.к. числится за другим клиентом
+	CarImportInvalid = "ошибка"            // строка не загружена
+)
+
+// CarImportOptions параметры загрузки машин клиента
+type CarImportOptions struct {
+	DryRun   bool   `json:"dry_run"`  // только проверить файл, ничего не сохранять
+	Conflict string `json:"conflict"` // skip, move или share
+}
+
+// CarImportRow результат по строке файла
+type CarImportRow struct {
+	Row            int    `json:"row"`
+	Number         string `json:"number"`
+	Status         string `json:"status"`
+	Message        string `json:"message,omitempty"`
+	OtherClientIDs []int  `json:"other_client_ids,omitempty"` // текущие владельцы машины кроме загружающего клиента
+	Car            Car    `json:"-"`
+}
+
+// CarImportResult итог загрузки машин клиента
+type CarImportResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Added   int            `json:"added"`
+	Linked  int            `json:"linked"`
+	Moved   int            `json:"moved"`
+	Shared  int            `json:"shared"`
+	Skipped int            `json:"skipped"`
+	Other   int            `json:"linked_to_other_client"`
+	Invalid int            `json:"invalid"`
+	Rows    []CarImportRow `json:"rows"`
+}
+
+// CarOwnership текущие владельцы машины, используется при загрузке
+type CarOwnership struct {
+	Number    string        `db:"number"`
+	CarID     int           `db:"car_id"`
+	ClientIDs pq.Int64Array `db:"client_ids"`
+}
+
+// VehicleLayout схема колес машины для выбора позиции в заказе
+type VehicleLayout struct {
+	Number      string    `json:"number"`
+	VehicleType string    `json:"vehicle_type"`
+	Axles       []CarAxle `json:"axles"`
+	Positions   []string  `json:"positions"`
+}
+
+// VehicleProfile карточка машины со схемой осей и историей владельцев
+type VehicleProfile struct {
+	Car
+	Positions []string   `json:"positions"`
+	Owners    []CarOwner `json:"owners"`
+}
+
+// VehicleHistoryService услуга в истории машины
+type VehicleHistoryService struct {
+	ServiceID     *int    `json:"service_id" db:"service_id"`
+	ServiceName   string  `json:"service_name" db:"service_name"`
+	Description   string  `json:"service_description" db:"service_description"`
+	WheelPosition string  `json:"wheel_position" db:"wheel_position"`
+	Price         float64 `json:"price" db:"price"`
+}
+
+// VehicleHistoryOrder заказ по машине с клиентом, работником, услугами и расходниками
+type VehicleHistoryOrder struct {
+	OrderID       int                     `json:"order_id" db:"id"`
+	Status        string                  `json:"status" db:"status"`
+	ClientID      *int                    `json:"client_id" db:"client_id"`
+	ClientName    string                  `json:"client_name" db:"client_name"`
+	WorkerID      *int                    `json:"worker_id" db:"worker_id"`
+	WorkerName    string                  `json:"worker_name" db:"worker_name"`
+	PaymentMethod string                  `json:"payment_method" db:"payment_method"`
+	TotalAmount   float64                 `json:"total_amount" db:"total_amount"`
+	CreatedAt     time.Time               `json:"created_at" db:"created_at"`
+	Services      []VehicleHistoryService `json:"services" db:"-"`
+	Materials     []OrderMaterial         `json:"materials" db:"-"`
+}
+
+// WheelPositionSummary сколько раз услуга выполнялась на позиции колеса
+type WheelPositionSummary struct {
+	Position    string    `json:"position"`
+	ServiceName string    `json:"service_name"`
+	Total       int       `json:"total"`
+	Recent      int       `json:"recent"`    // за последние WindowDays дней
+	Recurring   bool      `json:"recurring"` // повторялась за окно, стоит проверить причину
+	LastAt      time.Time `json:"last_at"`
+}
+
+// VehicleHistory полная история обслуживания машины по всем клиентам
+type VehicleHistory struct {
+	Number     string                 `json:"number"`
+	WindowDays int                    `json:"window_days"`
+	Orders     []VehicleHistoryOrder  `json:"orders"`
+	Positions  []WheelPositionSummary `json:"positions"`
 }
 
 type Order struct {
@@ -67,6 +322,14 @@ type Order struct {
 	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
 	Services      []OrderService  `json:"services"`
 	Materials     []OrderMaterial `json:"materials" db:"-"`
+
+	// Розничный покупатель заказа за наличку, узнается по ID, телефону, карте или номеру машины
+	RetailCustomerID *int    `json:"retail_customer_id" db:"retail_customer_id"`
+	CustomerPhone    string  `json:"customer_phone,omitempty" db:"-"`
+	CustomerCard     string  `json:"customer_card,omitempty" db:"-"`
+	DiscountAmount   float64 `json:"discount_amount" db:"discount_amount"` // сумма скидок по услугам
+
+	ReworkOfID *int `json:"rework_of_id" db:"rework_of_id"` // заказ, работу по которому переделывали
 }
 
 // OrderStatus представляет статус заказа
@@ -80,14 +343,16 @@ const (
 
 // связующая таблица для бд
 type OrderService struct {
-	ID            int       `json:"id" db:"id"`
-	OrderID       int       `json:"order_id" db:"order_id"`
-	ServiceID     int       `json:"service_id" db:"service_id"`
-	Description   string    `json:"service_description" db:"service_description"`
-	WheelPosition string    `json:"wheel_position" db:"wheel_position"`
-	Price         float64   `json:"price" db:"price"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	ID             int       `json:"id" db:"id"`
+	OrderID        int       `json:"order_id" db:"order_id"`
+	ServiceID      int       `json:"service_id" db:"service_id"`
+	Description    string    `json:"service_description" db:"service_description"`
+	WheelPosition  string    `json:"wheel_position" db:"wheel_position"`
+	Price          float64   `json:"price" db:"price"` // цена с учетом скидки
+	Discount       float64   `json:"discount" db:"discount"`
+	DiscountReason string    `json:"discount_reason" db:"discount_reason"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // Услуга и её прайс для определённого типа клиента
@@ -154,12 +419,22 @@ type Worker struct {
 	SalarySchema string    `json:"salary_schema" db:"salary_schema"`
 	Salary       int       `json:"tmp_salary" db:"salary"`
 	HasCar       bool      `json:"has_car" db:"has_car"`
+	WarehouseID  *int      `json:"warehouse_id" db:"warehouse_id"` // склад, с которого списываются материалы; nil = склад по умолчанию
+	UserID       *int      `json:"user_id" db:"user_id"`           // учетная запись работника
+	LinkIssue    *string   `json:"link_issue" db:"link_issue"`     // почему перенос не связал работника с учетной записью
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 	Password     string    `json:"password" db:"-"`
 	Role         string    `json:"role" db:"-"`
 }
 
+// Причины, по которым перенос не связал работника с учетной записью
+const (
+	WorkerLinkDuplicate = "дубль работника"
+	WorkerLinkAmbiguous = "несколько учетных записей"
+	WorkerLinkNotFound  = "учетная запись не найдена"
+)
+
 type Statistics struct {
 	TotalOrders       int     `json:"total_orders" db:"total_orders"`
 	TotalRevenue      float64 `json:"total_revenue" db:"total_revenue"`
@@ -179,44 +454,853 @@ type WorkerStatistics struct {
 	TotalRevenue   float64 `json:"total_revenue" db:"total_revenue"`
 	TotalBonus     int     `json:"total_bonus" db:"total_bonus"`
 	TotalPenalties int     `json:"total_penalties" db:"total_penalties"`
-	TotalSalary    float64 `json:"total_salary" db:"total_salary"`
+	TotalSalary    float64 `json:"total_salary" db:"-"` // считается по схемам оплаты
+
+	Balance *WorkerBalance `json:"balance,omitempty" db:"-"` // долг перед работником на сегодня
 }
 
+// Виды записей: штраф вычитается из зарплаты, бонус добавляется
+const (
+	EntryPenalty = "штраф"
+	EntryBonus   = "бонус"
+)
+
+// Категории причин штрафов и бонусов
+const (
+	CategoryLate         = "опоздание"
+	CategoryDamagedWheel = "повреждение колеса"
+	CategoryComplaint    = "жалоба клиента"
+	CategoryOvertime     = "переработка"
+	CategoryOther        = "другое"
+)
+
+var PenaltyBonusCategories = []string{CategoryLate, CategoryDamagedWheel, CategoryComplaint, CategoryOvertime, CategoryOther}
+
 type PenaltyOrBonus struct {
+	ID           int        `json:"id" db:"id"`
+	WorkerID     int        `json:"worker_id" db:"workerID"`
+	Desc         string     `json:"description" db:"description"`
+	Amount       int        `json:"delta" db:"delta"`
+	Category     string     `json:"category" db:"category"`
+	OrderID      *int       `json:"order_id" db:"order_id"`
+	CreatedBy    *int       `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at" db:"updated_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" db:"revoked_at"` // отмененная запись не учитывается в зарплате
+	RevokedBy    *int       `json:"revoked_by,omitempty" db:"revoked_by"`
+	RevokeReason string     `json:"revoke_reason,omitempty" db:"revoke_reason"`
+	RuleID       *int       `json:"rule_id,omitempty" db:"rule_id"`     // правило, создавшее запись
+	PeriodID     *int       `json:"period_id,omitempty" db:"period_id"` // расчет зарплаты, при котором запись создана правилом
+}
+
+// Показатели работника за период, по которым срабатывают правила штрафов и бонусов
+const (
+	RuleMetricOrders     = "заказы"
+	RuleMetricRevenue    = "выручка"
+	RuleMetricReworks    = "переделки" // заказы работника, которые пришлось переделывать
+	RuleMetricComplaints = "жалобы"    // штрафы с категорией "жалоба клиента"
+)
+
+var RuleMetrics = []string{RuleMetricOrders, RuleMetricRevenue, RuleMetricReworks, RuleMetricComplaints}
+
+// PenaltyBonusRule правило автоматического штрафа или бонуса: если показатель за период
+// удовлетворяет условию, при расчете зарплаты работнику начисляется Amount (или Amount за единицу показателя)
+type PenaltyBonusRule struct {
 	ID        int       `json:"id" db:"id"`
-	WorkerID  int       `json:"worker_id" db:"workerID"`
-	Desc      string    `json:"description" db:"description"`
-	Amount    int       `json:"delta" db:"delta"`
-	OrderID   int       `json:"order_id" db:"order_id"`
+	Name      string    `json:"name" db:"name"`
+	Kind      string    `json:"kind" db:"kind"`
+	Metric    string    `json:"metric" db:"metric"`
+	Operator  string    `json:"operator" db:"operator"` // ">=" или "<"
+	Threshold float64   `json:"threshold" db:"threshold"`
+	Amount    int       `json:"amount" db:"amount"`
+	PerUnit   bool      `json:"per_unit" db:"per_unit"`
+	Category  string    `json:"category" db:"category"`
+	WorkerID  *int      `json:"worker_id" db:"worker_id"` // nil - для всех работников
+	Active    bool      `json:"active" db:"active"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
+// Matches проверяет условие правила для значения показателя
+func (r PenaltyBonusRule) Matches(value float64) bool {
+	if r.Operator == "<" {
+		return value < r.Threshold
+	}
+	return value >= r.Threshold
+}
+
+// WorkerRuleMetrics показатели работника за период для правил штрафов и бонусов
+type WorkerRuleMetrics struct {
+	WorkerID   int     `db:"worker_id"`
+	Orders     int     `db:"orders"`
+	Revenue    float64 `db:"revenue"`
+	Reworks    int     `db:"reworks"`
+	Complaints int     `db:"complaints"`
+}
+
+// Value возвращает значение показателя metric
+func (m WorkerRuleMetrics) Value(metric string) float64 {
+	switch metric {
+	case RuleMetricOrders:
+		return float64(m.Orders)
+	case RuleMetricRevenue:
+		return m.Revenue
+	case RuleMetricReworks:
+		return float64(m.Reworks)
+	case RuleMetricComplaints:
+		return float64(m.Complaints)
+	}
+	return 0
+}
+
+// Действия в истории штрафов и бонусов
+const (
+	EntryChangeUpdate = "изменение"
+	EntryChangeRevoke = "отмена"
+)
+
+// PenaltyOrBonusChange исправление или отмена штрафа или бонуса: значения до и после и причина
+type PenaltyOrBonusChange struct {
+	ID          int       `json:"id" db:"id"`
+	Kind        string    `json:"kind" db:"kind"`
+	EntryID     int       `json:"entry_id" db:"entry_id"`
+	WorkerID    int       `json:"worker_id" db:"worker_id"`
+	Action      string    `json:"action" db:"action"`
+	OldAmount   int       `json:"old_delta" db:"old_delta"`
+	NewAmount   int       `json:"new_delta" db:"new_delta"`
+	OldCategory string    `json:"old_category" db:"old_category"`
+	NewCategory string    `json:"new_category" db:"new_category"`
+	OldDesc     string    `json:"old_description" db:"old_description"`
+	NewDesc     string    `json:"new_description" db:"new_description"`
+	OldOrderID  *int      `json:"old_order_id" db:"old_order_id"`
+	NewOrderID  *int      `json:"new_order_id" db:"new_order_id"`
+	Reason      string    `json:"reason" db:"reason"`
+	UserID      *int      `json:"user_id" db:"user_id"`
+	UserName    string    `json:"user_name" db:"user_name"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
 type OnlineDate struct {
 	ID          int       `json:"id" db:"id"`
 	Date        time.Time `json:"date" db:"date"`
 	Name        string    `json:"name" db:"name"`
 	Phone       string    `json:"phone" db:"phone"`
 	CarNumber   string    `json:"car_number" db:"car_number"`
+	ClientID    *int      `json:"client_id" db:"client_id"` // владелец машины на момент записи
 	ClientDesc  string    `json:"client_desc" db:"client_desc"`
 	ManagerDesc string    `json:"manager_desc" db:"manager_desc"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
-type Material struct {
+// Единицы измерения материалов
+const (
+	UnitPiece = "шт"
+	UnitGram  = "г"
+	UnitLitre = "л"
+	UnitTube  = "туба"
+)
+
+var MaterialUnits = []string{UnitPiece, UnitGram, UnitLitre, UnitTube}
+
+type MaterialCategory struct {
 	ID        int       `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name"`
-	TypeDS    int       `json:"type_ds" db:"type_ds"`
-	Storage   int       `json:"storage" db:"storage"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+type Material struct {
+	ID           int        `json:"id" db:"id"`
+	Name         string     `json:"name" db:"name"`
+	SKU          string     `json:"sku" db:"sku"` // артикул, уникален если указан
+	CategoryID   int        `json:"category_id" db:"category_id"`
+	CategoryName string     `json:"category_name" db:"category_name"`
+	TypeDS       int        `json:"type_ds" db:"type_ds"` // устаревший синоним category_id для старых клиентов
+	Unit         string     `json:"unit" db:"unit"`
+	Storage      float64    `json:"storage" db:"storage"`
+	MinStorage   float64    `json:"min_storage" db:"min_storage"` // 0 = порог не задан
+	LastMovement *time.Time `json:"last_movement_at" db:"last_movement_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// BelowMinimum сообщает, что остаток опустился ниже заданного порога
+func (m Material) BelowMinimum() bool {
+	return m.MinStorage > 0 && m.Storage < m.MinStorage
+}
+
+// ImportRowError ошибка в строке загружаемого файла
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// MaterialImportResult итог загрузки материалов из Excel
+type MaterialImportResult struct {
+	Created  int              `json:"created"`
+	Updated  int              `json:"updated"`
+	Received int              `json:"received"` // строк с проведенным приходом
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// MaterialFilter параметры поиска материалов
+type MaterialFilter struct {
+	CategoryID int    // 0 = все категории
+	Search     string // подстрока названия или артикула
+}
+
 type OrderMaterial struct {
-	ID         int      `json:"id" db:"id"`
-	OrderID    int      `json:"order_id" db:"order_id"`
-	MaterialID int      `json:"material_id" db:"material_id"`
-	Quantity   int      `json:"quantity" db:"quantity"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	Material   *Material `json:"material" db:"-"`
+	ID          int       `json:"id" db:"id"`
+	OrderID     int       `json:"order_id" db:"order_id"`
+	MaterialID  int       `json:"material_id" db:"material_id"`
+	Quantity    float64   `json:"quantity" db:"quantity"`
+	FromNorm    bool      `json:"from_norm" db:"from_norm"`       // подставлен автоматически по норме расхода
+	WarehouseID int       `json:"warehouse_id" db:"warehouse_id"` // склад, с которого списан материал
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	Material    *Material `json:"material" db:"-"`
+}
+
+// ServiceMaterialNorm норма расхода материала на одну услугу
+type ServiceMaterialNorm struct {
+	ID           int       `json:"id" db:"id"`
+	ServiceID    int       `json:"service_id,omitempty" db:"service_id"` // заполняется при подборе норм для услуг заказа
+	ServiceName  string    `json:"service_name" db:"service_name"`
+	ContractID   *int      `json:"contract_id" db:"contract_id"` // nil = норма по умолчанию для всех договоров
+	MaterialID   int       `json:"material_id" db:"material_id"`
+	MaterialName string    `json:"material_name" db:"material_name"`
+	Quantity     float64   `json:"quantity" db:"quantity"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MaterialVariance сравнение нормативного и фактического расхода материала работником
+type MaterialVariance struct {
+	WorkerID       int     `json:"worker_id" db:"worker_id"`
+	WorkerName     string  `json:"worker_name" db:"worker_name"`
+	WorkerSurname  string  `json:"worker_surname" db:"worker_surname"`
+	MaterialID     int     `json:"material_id" db:"material_id"`
+	MaterialName   string  `json:"material_name" db:"material_name"`
+	NormQuantity   float64 `json:"norm_quantity" db:"norm_quantity"`
+	ActualQuantity float64 `json:"actual_quantity" db:"actual_quantity"`
+	Variance       float64 `json:"variance" db:"variance"` // факт - норма, положительное значение = перерасход
+}
+
+// Причины движения материалов
+const (
+	MovementOpening     = "начальный остаток"
+	MovementReceipt     = "приход"
+	MovementWriteOff    = "списание"
+	MovementCorrection  = "корректировка"
+	MovementOrder       = "расход на заказ"
+	MovementOrderReturn = "возврат с заказа"
+	MovementStockTake   = "инвентаризация"
+	MovementTransfer    = "перемещение"
+)
+
+// MaterialMovement строка журнала движения материалов
+type MaterialMovement struct {
+	ID            int       `json:"id" db:"id"`
+	MaterialID    int       `json:"material_id" db:"material_id"`
+	WarehouseID   int       `json:"warehouse_id" db:"warehouse_id"` // 0 = склад по умолчанию
+	WarehouseName string    `json:"warehouse_name" db:"warehouse_name"`
+	Delta         float64   `json:"delta" db:"delta"` // положительное значение = приход, отрицательное = расход
+	Reason        string    `json:"reason" db:"reason"`
+	OrderID       *int      `json:"order_id" db:"order_id"`
+	StockTakeID   *int      `json:"stock_take_id" db:"stock_take_id"`
+	TransferID    *int      `json:"transfer_id" db:"transfer_id"`
+	UserID        *int      `json:"user_id" db:"user_id"`
+	Description   string    `json:"description" db:"description"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// Статусы инвентаризации
+const (
+	StockTakeStatusOpen     = "открыта"
+	StockTakeStatusApproved = "утверждена"
+)
+
+// StockTake сессия инвентаризации склада
+type StockTake struct {
+	ID            int              `json:"id" db:"id"`
+	Status        string           `json:"status" db:"status"`
+	WarehouseID   int              `json:"warehouse_id" db:"warehouse_id"`
+	WarehouseName string           `json:"warehouse_name" db:"warehouse_name"`
+	Description   string           `json:"description" db:"description"`
+	OpenedBy      *int             `json:"opened_by" db:"opened_by"`
+	ApprovedBy    *int             `json:"approved_by" db:"approved_by"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	ApprovedAt    *time.Time       `json:"approved_at" db:"approved_at"`
+	Lines         []StockTakeLine  `json:"lines" db:"-"`
+	Counts        []StockTakeCount `json:"counts" db:"-"`
+}
+
+// StockTakeCount один проход пересчета материала
+type StockTakeCount struct {
+	ID             int       `json:"id" db:"id"`
+	StockTakeID    int       `json:"stock_take_id" db:"stock_take_id"`
+	MaterialID     int       `json:"material_id" db:"material_id"`
+	MaterialName   string    `json:"material_name" db:"material_name"`
+	Quantity       float64   `json:"quantity" db:"quantity"`
+	SystemQuantity float64   `json:"system_quantity" db:"system_quantity"` // остаток в системе на момент прохода
+	UserID         *int      `json:"user_id" db:"user_id"`
+	UserName       string    `json:"user_name" db:"user_name"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// StockTakeLine расхождение пересчитанного количества с остатком в системе
+type StockTakeLine struct {
+	MaterialID      int     `json:"material_id" db:"material_id"`
+	MaterialName    string  `json:"material_name" db:"material_name"`
+	SystemQuantity  float64 `json:"system_quantity" db:"system_quantity"`
+	CountedQuantity float64 `json:"counted_quantity" db:"counted_quantity"`
+	Delta           float64 `json:"delta" db:"delta"` // пересчитано - в системе на момент первого прохода
+}
+
+// Warehouse место хранения материалов: основной склад или выездная машина
+type Warehouse struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	IsMobile  bool      `json:"is_mobile" db:"is_mobile"`
+	IsDefault bool      `json:"is_default" db:"is_default"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MaterialBalance остаток материала на складе
+type MaterialBalance struct {
+	MaterialID    int     `json:"material_id" db:"material_id"`
+	MaterialName  string  `json:"material_name" db:"material_name"`
+	SKU           string  `json:"sku" db:"sku"`
+	CategoryName  string  `json:"category_name" db:"category_name"`
+	Unit          string  `json:"unit" db:"unit"`
+	WarehouseID   int     `json:"warehouse_id" db:"warehouse_id"`
+	WarehouseName string  `json:"warehouse_name" db:"warehouse_name"`
+	Quantity      float64 `json:"quantity" db:"quantity"`
+}
+
+// MaterialTransfer перемещение материала между складами
+type MaterialTransfer struct {
+	ID                int       `json:"id" db:"id"`
+	MaterialID        int       `json:"material_id" db:"material_id"`
+	MaterialName      string    `json:"material_name" db:"material_name"`
+	FromWarehouseID   int       `json:"from_warehouse_id" db:"from_warehouse_id"`
+	FromWarehouseName string    `json:"from_warehouse_name" db:"from_warehouse_name"`
+	ToWarehouseID     int       `json:"to_warehouse_id" db:"to_warehouse_id"`
+	ToWarehouseName   string    `json:"to_warehouse_name" db:"to_warehouse_name"`
+	Quantity          float64   `json:"quantity" db:"quantity"`
+	UserID            *int      `json:"user_id" db:"user_id"`
+	Description       string    `json:"description" db:"description"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+}
+
+// Способ оплаты заказа, по договору заказ попадает в расчеты с клиентом
+const PaymentContract = "contract"
+
+// Виды записей в расчетах с клиентом
+const (
+	LedgerCharge  = "начисление"
+	LedgerPayment = "оплата"
+)
+
+// LedgerEntry начисление по заказу или оплата клиента.
+// Balance заполняется в выписке и показывает долг после записи.
+type LedgerEntry struct {
+	ID          int          `json:"id" db:"id"`
+	ClientID    int          `json:"client_id" db:"client_id"`
+	ContractID  *int         `json:"contract_id" db:"contract_id"`
+	OrderID     *int         `json:"order_id" db:"order_id"`
+	Type        string       `json:"type" db:"entry_type"`
+	Amount      money.Amount `json:"amount" db:"amount"`
+	Date        time.Time    `json:"date" db:"entry_date"`
+	Document    string       `json:"document" db:"document"`
+	Description string       `json:"description" db:"description"`
+	UserID      *int         `json:"user_id" db:"user_id"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	Balance     money.Amount `json:"balance,omitempty" db:"-"`
+}
+
+// ClientPayment оплата от клиента, например по банковской выписке
+type ClientPayment struct {
+	ContractID  *int         `json:"contract_id"`
+	Amount      money.Amount `json:"amount"`
+	Date        *time.Time   `json:"date"` // nil = сегодня
+	Document    string       `json:"document"`
+	Description string       `json:"description"`
+}
+
+// ReceivablesAging неоплаченные начисления по давности
+type ReceivablesAging struct {
+	Days0To30  money.Amount `json:"days_0_30"`
+	Days31To60 money.Amount `json:"days_31_60"`
+	Days61To90 money.Amount `json:"days_61_90"`
+	Over90     money.Amount `json:"days_over_90"`
+}
+
+// Receivables состояние расчетов с клиентом. Balance > 0 - долг клиента, < 0 - переплата
+type Receivables struct {
+	ClientID   int              `json:"client_id"`
+	ClientName string           `json:"client_name"`
+	ContractID *int             `json:"contract_id,omitempty"`
+	Charged    money.Amount     `json:"charged"`
+	Paid       money.Amount     `json:"paid"`
+	Balance    money.Amount     `json:"balance"`
+	Aging      ReceivablesAging `json:"aging"`
+	AsOf       time.Time        `json:"as_of"`
+}
+
+// Statement акт сверки с клиентом за период
+type Statement struct {
+	ClientID       int           `json:"client_id"`
+	ClientName     string        `json:"client_name"`
+	ContractID     *int          `json:"contract_id,omitempty"`
+	From           time.Time     `json:"from"`
+	To             time.Time     `json:"to"`
+	OpeningBalance money.Amount  `json:"opening_balance"`
+	Charged        money.Amount  `json:"charged"`
+	Paid           money.Amount  `json:"paid"`
+	ClosingBalance money.Amount  `json:"closing_balance"`
+	Entries        []LedgerEntry `json:"entries"`
+}
+
+// Company реквизиты сервиса для счетов и актов, задаются в конфиге
+type Company struct {
+	Name        string `json:"name" yaml:"name"`
+	INN         string `json:"inn" yaml:"inn"`
+	KPP         string `json:"kpp" yaml:"kpp"`
+	Address     string `json:"address" yaml:"address"`
+	Bank        string `json:"bank" yaml:"bank"`
+	BIK         string `json:"bik" yaml:"bik"`
+	Account     string `json:"account" yaml:"account"`
+	CorrAccount string `json:"corr_account" yaml:"corr_account"`
+	VATRate     int    `json:"vat_rate" yaml:"vat_rate"` // 0 = без НДС
+}
+
+// InvoiceRequest параметры выставления счета: договор и период в формате YYYY-MM-DD
+type InvoiceRequest struct {
+	ContractID int    `json:"contract_id"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+}
+
+// Invoice счет по договору за период, заказы из счета повторно не выставляются
+type Invoice struct {
+	ID             int           `json:"id" db:"id"`
+	ContractID     int           `json:"contract_id" db:"contract_id"`
+	ContractNumber string        `json:"contract_number" db:"contract_number"`
+	CompanyName    string        `json:"company_name" db:"company_name"`
+	PeriodStart    time.Time     `json:"period_start" db:"period_start"`
+	PeriodEnd      time.Time     `json:"period_end" db:"period_end"`
+	TotalAmount    money.Amount  `json:"total_amount" db:"total_amount"`
+	OrdersCount    int           `json:"orders_count" db:"orders_count"`
+	UserID         *int          `json:"user_id" db:"user_id"`
+	CreatedAt      time.Time     `json:"created_at" db:"created_at"`
+	Lines          []InvoiceLine `json:"lines,omitempty" db:"-"`
+}
+
+// InvoiceLine строка счета: услуга по машине, одинаковые услуги по одной цене складываются
+type InvoiceLine struct {
+	ID            int          `json:"id" db:"id"`
+	InvoiceID     int          `json:"invoice_id" db:"invoice_id"`
+	VehicleNumber string       `json:"vehicle_number" db:"vehicle_number"`
+	ServiceName   string       `json:"service_name" db:"service_name"`
+	Quantity      int          `json:"quantity" db:"quantity"`
+	Price         money.Amount `json:"price" db:"price"`
+	Amount        money.Amount `json:"amount" db:"amount"`
+}
+
+// Документы по счету
+const (
+	DocumentInvoice = "invoice" // счет на оплату
+	DocumentAct     = "act"     // акт выполненных работ
+)
+
+// Способ оплаты заказа с предоплаты клиента, списывается при выполнении заказа
+const PaymentPrepaid = "prepaid"
+
+// Виды движений по предоплате
+const (
+	DepositTopUp      = "пополнение"
+	DepositWithdrawal = "выдача"   // возврат денег клиенту
+	DepositDrawdown   = "списание" // оплата выполненного заказа
+	DepositRefund     = "возврат"  // отмена списания по заказу
+)
+
+// DepositMovement движение по предоплате, Balance - баланс после движения
+type DepositMovement struct {
+	ID          int          `json:"id" db:"id"`
+	ClientID    int          `json:"client_id" db:"client_id"`
+	OrderID     *int         `json:"order_id" db:"order_id"`
+	Type        string       `json:"type" db:"movement_type"`
+	Amount      money.Amount `json:"amount" db:"amount"`
+	Balance     money.Amount `json:"balance" db:"balance"`
+	Document    string       `json:"document" db:"document"`
+	Description string       `json:"description" db:"description"`
+	UserID      *int         `json:"user_id" db:"user_id"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+}
+
+// DepositOperation пополнение предоплаты или выдача денег клиенту, сумма всегда положительная
+type DepositOperation struct {
+	Amount      money.Amount `json:"amount"`
+	Document    string       `json:"document"`
+	Description string       `json:"description"`
+}
+
+// DepositAccount предоплата клиента: Available - сколько еще можно списать с учетом допустимого минуса
+type DepositAccount struct {
+	ClientID       int               `json:"client_id" db:"client_id"`
+	ClientName     string            `json:"client_name" db:"client_name"`
+	Balance        money.Amount      `json:"balance" db:"balance"`
+	OverdraftLimit money.Amount      `json:"overdraft_limit" db:"overdraft_limit"`
+	Available      money.Amount      `json:"available" db:"-"`
+	Movements      []DepositMovement `json:"movements,omitempty" db:"-"`
+}
+
+// Тип клиента для расчетов наличными, под ним ведутся розничные покупатели
+const ClientTypeCash = "НАЛИЧКА"
+
+// CashClientID клиент налички, заводится миграцией и всегда остается в базе
+const CashClientID = 1
+
+// RetailCustomer розничный покупатель, Visits - число его заказов
+type RetailCustomer struct {
+	ID         int            `json:"id" db:"id"`
+	Name       string         `json:"name" db:"name"`
+	Phone      string         `json:"phone" db:"phone"` // в формате E.164
+	CardNumber string         `json:"card_number" db:"card_number"`
+	Cars       pq.StringArray `json:"cars" db:"cars"`
+	Visits     int            `json:"visits" db:"visits"`
+	LastVisit  *time.Time     `json:"last_visit" db:"last_visit"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Виды правил лояльности
+const (
+	LoyaltyFreeNth = "бесплатная" // каждая N-я услуга бесплатно
+	LoyaltyPercent = "процент"    // скидка в процентах после MinVisits визитов
+)
+
+// LoyaltyRule правило лояльности для розничных покупателей.
+// ServiceID nil означает все услуги, для правила "бесплатная" услуга обязательна.
+type LoyaltyRule struct {
+	ID          int       `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Type        string    `json:"type" db:"rule_type"`
+	ServiceID   *int      `json:"service_id" db:"service_id"`
+	ServiceName string    `json:"service_name" db:"service_name"`
+	EveryN      *int      `json:"every_n" db:"every_n"`
+	MinVisits   int       `json:"min_visits" db:"min_visits"`
+	Percent     *float64  `json:"percent" db:"percent"`
+	Active      bool      `json:"active" db:"active"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// LoyaltyHistory прошлые заказы покупателя: число визитов и строк по каждой услуге
+type LoyaltyHistory struct {
+	Visits        int
+	ServiceCounts map[int]int
+}
+
+// ClientType тип клиента из справочника. Новому клиенту без договора подставляется DefaultContractID.
+type ClientType struct {
+	ID                    int       `json:"id" db:"id"`
+	Name                  string    `json:"name" db:"name"`
+	Description           string    `json:"description" db:"description"`
+	DefaultContractID     *int      `json:"default_contract_id" db:"default_contract_id"`
+	DefaultContractNumber string    `json:"default_contract_number" db:"default_contract_number"`
+	ClientsCount          int       `json:"clients_count" db:"clients_count"`
+	CreatedAt             time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Виды схем оплаты работника
+const (
+	SalaryPerShift         = "за смену"            // фиксированная сумма за смену
+	SalaryPercent          = "процент"             // процент от выручки
+	SalaryPercentWithFloor = "процент с минимумом" // процент от выручки смены, но не меньше минимума за смену
+	SalaryTiered           = "ступенчатый"         // процент по ступеням выручки за период
+	SalaryPieceRate        = "сдельная"            // ставка за каждую выполненную услугу
+)
+
+var SalarySchemeTypes = []string{SalaryPerShift, SalaryPercent, SalaryPercentWithFloor, SalaryTiered, SalaryPieceRate}
+
+// SalaryTier ступень выручки: Percent действует на выручку от From до следующей ступени
+type SalaryTier struct {
+	From    float64 `json:"from" db:"revenue_from"`
+	Percent float64 `json:"percent" db:"percent"`
+}
+
+// PieceRate сдельная ставка за одну услугу
+type PieceRate struct {
+	ServiceName string  `json:"service_name" db:"service_name"`
+	Rate        float64 `json:"rate" db:"rate"`
+}
+
+// WorkerSalaryScheme схема оплаты работника, действует с EffectiveFrom до начала следующей.
+// Rate - сумма за смену, минимум за смену или сдельная ставка по умолчанию, в зависимости от вида.
+type WorkerSalaryScheme struct {
+	ID            int          `json:"id" db:"id"`
+	WorkerID      int          `json:"worker_id" db:"worker_id"`
+	Type          string       `json:"type" db:"scheme_type"`
+	Rate          float64      `json:"rate" db:"rate"`
+	Percent       float64      `json:"percent" db:"percent"`
+	Tiers         []SalaryTier `json:"tiers,omitempty" db:"-"`
+	Rates         []PieceRate  `json:"rates,omitempty" db:"-"`
+	EffectiveFrom time.Time    `json:"effective_from" db:"effective_from"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+}
+
+// WorkerOrderOutput заказ работника для расчета зарплаты: выручка и названия выполненных услуг
+type WorkerOrderOutput struct {
+	OrderID     int            `db:"id"`
+	CreatedAt   time.Time      `db:"created_at"`
+	TotalAmount float64        `db:"total_amount"`
+	Services    pq.StringArray `db:"services"`
+}
+
+// SalaryPart начисление за часть периода, в которой действовала одна схема
+type SalaryPart struct {
+	SchemeID   *int         `json:"scheme_id"`
+	SchemeType string       `json:"scheme_type"`
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	Shifts     int          `json:"shifts"`
+	Orders     int          `json:"orders"`
+	Revenue    float64      `json:"revenue"`
+	Amount     money.Amount `json:"amount"`
+}
+
+// SalaryCalculation расчет зарплаты за период: начислено по схемам, плюс бонусы, минус штрафы
+type SalaryCalculation struct {
+	WorkerID  int          `json:"worker_id"`
+	Start     time.Time    `json:"start"`
+	End       time.Time    `json:"end"`
+	Parts     []SalaryPart `json:"parts"`
+	Accrued   money.Amount `json:"accrued"`
+	Bonuses   money.Amount `json:"bonuses"`
+	Penalties money.Amount `json:"penalties"`
+	Total     money.Amount `json:"total"`
+}
+
+// Статусы расчета зарплаты за период
+const (
+	PayrollDraft    = "черновик"  // можно пересчитывать
+	PayrollApproved = "утвержден" // суммы проверены, пересчет запрещен
+	PayrollLocked   = "закрыт"    // выплачено, изменения идут перерасчетом в следующий период
+)
+
+// PayrollRequest период расчета зарплаты в формате YYYY-MM-DD
+type PayrollRequest struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// PayrollPeriod расчет зарплаты всех работников за период
+type PayrollPeriod struct {
+	ID           int                 `json:"id" db:"id"`
+	PeriodStart  time.Time           `json:"period_start" db:"period_start"`
+	PeriodEnd    time.Time           `json:"period_end" db:"period_end"`
+	Status       string              `json:"status" db:"status"`
+	TotalAmount  money.Amount        `json:"total_amount" db:"total_amount"`
+	CreatedBy    *int                `json:"created_by" db:"created_by"`
+	ApprovedBy   *int                `json:"approved_by" db:"approved_by"`
+	CalculatedAt *time.Time          `json:"calculated_at" db:"calculated_at"`
+	ApprovedAt   *time.Time          `json:"approved_at" db:"approved_at"`
+	LockedAt     *time.Time          `json:"locked_at" db:"locked_at"`
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
+	Lines        []PayrollLine       `json:"lines,omitempty" db:"-"`
+	Adjustments  []PayrollAdjustment `json:"adjustments,omitempty" db:"-"`
+}
+
+// PayrollLine начисления работнику за период: Total = Accrued + Bonuses - Penalties + Adjustments - Advances
+type PayrollLine struct {
+	ID          int          `json:"id" db:"id"`
+	PeriodID    int          `json:"period_id" db:"period_id"`
+	WorkerID    int          `json:"worker_id" db:"worker_id"`
+	WorkerName  string       `json:"worker_name" db:"worker_name"`
+	Accrued     money.Amount `json:"accrued" db:"accrued"`
+	Bonuses     money.Amount `json:"bonuses" db:"bonuses"`
+	Penalties   money.Amount `json:"penalties" db:"penalties"`
+	Advances    money.Amount `json:"advances" db:"advances"`
+	Adjustments money.Amount `json:"adjustments" db:"adjustments"`
+	Total       money.Amount `json:"total" db:"total"`
+
+	Details *PayrollLineDetails `json:"-" db:"-"` // сохраняется вместе со строкой при расчете
+}
+
+// PayrollLineDetails расшифровка строки расчета: из чего сложились суммы на момент расчета
+type PayrollLineDetails struct {
+	Parts     []SalaryPart     `json:"parts"`
+	Orders    []PayslipOrder   `json:"orders"`
+	Bonuses   []PenaltyOrBonus `json:"bonuses"`
+	Penalties []PenaltyOrBonus `json:"penalties"`
+	Advances  []WorkerPayment  `json:"advances"`
+}
+
+// Earned заработано за сам период, без перерасчетов прошлых периодов и авансов
+func (l PayrollLine) Earned() money.Amount {
+	return l.Accrued + l.Bonuses - l.Penalties
+}
+
+// PayrollAdjustment перерасчет за закрытый период, например после правки заказа
+type PayrollAdjustment struct {
+	ID             int          `json:"id" db:"id"`
+	PeriodID       int          `json:"period_id" db:"period_id"`
+	WorkerID       int          `json:"worker_id" db:"worker_id"`
+	WorkerName     string       `json:"worker_name" db:"worker_name"`
+	SourcePeriodID int          `json:"source_period_id" db:"source_period_id"`
+	Amount         money.Amount `json:"amount" db:"amount"`
+	Description    string       `json:"description" db:"description"`
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+}
+
+// PayslipOrder заказ в расчетном листке и его доля в начислении по схеме
+type PayslipOrder struct {
+	OrderID       int          `json:"order_id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	VehicleNumber string       `json:"vehicle_number"`
+	Revenue       float64      `json:"revenue"`
+	Share         money.Amount `json:"share"`
+}
+
+// Payslip расчетный листок работника за период расчета зарплаты.
+// Итоги и расшифровка утвержденного расчета берутся из сохраненного расчета, черновика - по текущим данным.
+type Payslip struct {
+	PeriodID    int                 `json:"period_id"`
+	PeriodStart time.Time           `json:"period_start"`
+	PeriodEnd   time.Time           `json:"period_end"`
+	Status      string              `json:"status"`
+	WorkerID    int                 `json:"worker_id"`
+	WorkerName  string              `json:"worker_name"`
+	Orders      []PayslipOrder      `json:"orders"`
+	Parts       []SalaryPart        `json:"parts"`
+	Bonuses     []PenaltyOrBonus    `json:"bonuses"`
+	Penalties   []PenaltyOrBonus    `json:"penalties"`
+	Adjustments []PayrollAdjustment `json:"adjustments"`
+	Advances    []WorkerPayment     `json:"advances"`
+	Totals      PayrollLine         `json:"totals"`
+}
+
+// PayslipSummary расчетный листок в списке листков работника
+type PayslipSummary struct {
+	PeriodID    int          `json:"period_id" db:"period_id"`
+	PeriodStart time.Time    `json:"period_start" db:"period_start"`
+	PeriodEnd   time.Time    `json:"period_end" db:"period_end"`
+	Status      string       `json:"status" db:"status"`
+	Total       money.Amount `json:"total" db:"total"`
+}
+
+// Виды выплат работнику
+const (
+	WorkerPaymentAdvance = "аванс"   // в счет зарплаты, вычитается при расчете за период, в который выдан
+	WorkerPaymentPayout  = "выплата" // выплата начисленной зарплаты
+)
+
+// WorkerPaymentMethods способы выдачи денег работнику
+var WorkerPaymentMethods = []string{"наличные", "перевод", "карта"}
+
+// WorkerPayment аванс или выплата зарплаты работнику
+type WorkerPayment struct {
+	ID           int          `json:"id" db:"id"`
+	WorkerID     int          `json:"worker_id" db:"worker_id"`
+	Kind         string       `json:"kind" db:"kind"`
+	Amount       money.Amount `json:"amount" db:"amount"`
+	PaidAt       time.Time    `json:"paid_at" db:"paid_at"`
+	Method       string       `json:"method" db:"method"`
+	PeriodID     *int         `json:"period_id" db:"period_id"`
+	Description  string       `json:"description" db:"description"`
+	IssuedBy     *int         `json:"issued_by" db:"issued_by"`
+	IssuedByName string       `json:"issued_by_name" db:"issued_by_name"`
+	CreatedAt    time.Time    `json:"created_at" db:"created_at"`
+}
+
+// WorkerBalance расчеты с работником: заработано минус выдано.
+// Unsettled - начислено по схемам после последнего утвержденного расчета зарплаты.
+type WorkerBalance struct {
+	Earned       money.Amount `json:"earned"`
+	Unsettled    money.Amount `json:"unsettled"`
+	Paid         money.Amount `json:"paid"`
+	Balance      money.Amount `json:"balance"`
+	SettledUntil *time.Time   `json:"settled_until"`
+}
+
+// ShiftTemplate шаблон смены, время в формате HH:MM
+type ShiftTemplate struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	StartTime string    `json:"start_time" db:"start_time"`
+	EndTime   string    `json:"end_time" db:"end_time"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ShiftAssignment смена работника в графике на день
+type ShiftAssignment struct {
+	ID           int       `json:"id" db:"id"`
+	WorkerID     int       `json:"worker_id" db:"worker_id"`
+	WorkerName   string    `json:"worker_name" db:"worker_name"`
+	TemplateID   *int      `json:"template_id" db:"template_id"`
+	TemplateName string    `json:"template_name" db:"template_name"`
+	WorkDate     time.Time `json:"work_date" db:"work_date"`
+	StartsAt     time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt       time.Time `json:"ends_at" db:"ends_at"`
+	CreatedBy    *int      `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// RosterRequest назначение работников на смену по шаблону, дата в формате YYYY-MM-DD
+type RosterRequest struct {
+	Date       string `json:"date"`
+	TemplateID int    `json:"template_id"`
+	WorkerIDs  []int  `json:"worker_ids"`
+}
+
+// OpenTimeEntryLimit срок, после которого отметка без ухода считается забытой и закрывается автоматически
+const OpenTimeEntryLimit = 24 * time.Hour
+
+// TimeEntry отметка прихода и ухода работника, ClockOut nil - работник на смене.
+// AutoClosed - уход не был отмечен, отметка закрыта временем прихода и ждет исправления менеджером
+type TimeEntry struct {
+	ID         int        `json:"id" db:"id"`
+	WorkerID   int        `json:"worker_id" db:"worker_id"`
+	WorkerName string     `json:"worker_name" db:"worker_name"`
+	ClockIn    time.Time  `json:"clock_in" db:"clock_in"`
+	ClockOut   *time.Time `json:"clock_out" db:"clock_out"`
+	AutoClosed bool       `json:"auto_closed" db:"auto_closed"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Hours отработанные часы, для открытой отметки - по момент now, но не больше OpenTimeEntryLimit
+func (e TimeEntry) Hours(now time.Time) float64 {
+	end := now
+	if e.ClockOut != nil {
+		end = *e.ClockOut
+	} else if limit := e.ClockIn.Add(OpenTimeEntryLimit); end.After(limit) {
+		end = limit
+	}
+	if end.Before(e.ClockIn) {
+		return 0
+	}
+	return end.Sub(e.ClockIn).Hours()
+}
+
+// WorkerShiftStatus текущее состояние смены работника
+type WorkerShiftStatus struct {
+	OnShift   bool              `json:"on_shift"`
+	OpenEntry *TimeEntry        `json:"open_entry"`
+	Scheduled []ShiftAssignment `json:"scheduled"` // смены по графику на сегодня
+}
+
+// TimesheetRow табель работника за период: смены и часы по графику и по отметкам
+type TimesheetRow struct {
+	WorkerID        int     `json:"worker_id"`
+	WorkerName      string  `json:"worker_name"`
+	ScheduledShifts int     `json:"scheduled_shifts"`
+	ScheduledHours  float64 `json:"scheduled_hours"`
+	WorkedShifts    int     `json:"worked_shifts"` // дни с отметками
+	WorkedHours     float64 `json:"worked_hours"`
+	OpenEntries     int     `json:"open_entries"`        // отметки без ухода
+	AutoClosed      int     `json:"auto_closed_entries"` // забытые отметки, закрытые автоматически, часы не учтены
 }
@@ -0,0 +1,24 @@
+package fuzzy
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "A123BC77", 8},
+		{"A123BC77", "A123BC77", 0},
+		{"A123BC77", "A128BC77", 1},
+		{"A123BC77", "A123BC7", 1},
+		{"A123BC7", "A123BC77", 1},
+		{"kitten", "sitting", 3},
+		{"шиномонтаж", "шинамонтаж", 1},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, ожидалось %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
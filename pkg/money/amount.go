@@ -0,0 +1,167 @@
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount денежная сумма в копейках. Из NUMERIC и JSON читается без перевода в float64,
+// поэтому суммы по счетам, расчетам и зарплате складываются без погрешности
+type Amount int64
+
+// FromFloat переводит рубли в копейки с округлением, для сумм, посчитанных от процента или выручки
+func FromFloat(rubles float64) Amount {
+	return Amount(math.Round(rubles * 100))
+}
+
+// FromRubles переводит целые рубли в копейки, для штрафов и бонусов
+func FromRubles(rubles int) Amount {
+	return Amount(rubles) * 100
+}
+
+// Parse читает сумму в рублях вида 1234.56, больше двух знаков после точки не допускается
+func Parse(s string) (Amount, error) {
+	return parseDecimal(s, false)
+}
+
+// parseDecimal читает десятичную запись суммы без перевода в float64.
+// round округляет лишние знаки после точки до копейки, иначе они считаются ошибкой.
+func parseDecimal(s string, round bool) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("неверная сумма '%s'", s)
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	roundUp := false
+	if len(fraction) > 2 {
+		if !round {
+			return 0, fmt.Errorf("в сумме '%s' больше двух знаков после точки", s)
+		}
+		roundUp = fraction[2] >= '5'
+		fraction = fraction[:2]
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	rubles, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || rubles < 0 {
+		return 0, fmt.Errorf("неверная сумма '%s'", s)
+	}
+	var kopecks int64
+	if fraction != "" {
+		if kopecks, err = strconv.ParseInt((fraction + "0")[:2], 10, 64); err != nil || kopecks < 0 {
+			return 0, fmt.Errorf("неверная сумма '%s'", s)
+		}
+	}
+
+	amount := Amount(rubles*100 + kopecks)
+	if roundUp {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// Rubles возвращает сумму в рублях для расчетов с процентами
+func (a Amount) Rubles() float64 {
+	return float64(a) / 100
+}
+
+// Mul умножает сумму на количество
+func (a Amount) Mul(n int) Amount {
+	return a * Amount(n)
+}
+
+// MulDiv возвращает долю суммы num/den, округленную до копейки от нуля, например НДС в сумме
+func (a Amount) MulDiv(num, den int) Amount {
+	n, d := int64(a)*int64(num), int64(den)
+	q, r := n/d, n%d
+	if r < 0 {
+		r = -r
+	}
+	if d < 0 {
+		d = -d
+	}
+	if 2*r >= d {
+		if (n < 0) != (den < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Amount(q)
+}
+
+// String записывает сумму в рублях с точкой: 1234.50
+func (a Amount) String() string {
+	sign := ""
+	kopecks := int64(a)
+	if kopecks < 0 {
+		sign = "-"
+		kopecks = -kopecks
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, kopecks/100, kopecks%100)
+}
+
+// Value передает сумму в запрос текстом, Postgres приводит его к NUMERIC без потерь
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan читает NUMERIC, который драйвер отдает текстом
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case []byte:
+		return a.parse(string(v))
+	case string:
+		return a.parse(v)
+	case int64:
+		*a = Amount(v * 100)
+	case float64:
+		*a = FromFloat(v)
+	default:
+		return fmt.Errorf("неподдерживаемый тип суммы %T", src)
+	}
+	return nil
+}
+
+// parse читает сумму из базы: NUMERIC с тремя и более знаками (например AVG) округляется до копейки
+func (a *Amount) parse(s string) error {
+	amount, err := parseDecimal(s, true)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// MarshalJSON записывает сумму числом в рублях: 1234.5
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON читает сумму числом или строкой в рублях
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*a = 0
+		return nil
+	}
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
@@ -0,0 +1,120 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Amount
+		wantErr bool
+	}{
+		{s: "1234.56", want: 123456},
+		{s: "1234.5", want: 123450},
+		{s: "1234", want: 123400},
+		{s: ".5", want: 50},
+		{s: " -10.05 ", want: -1005},
+		{s: "12.3400", want: 1234},
+		{s: "12.345", wantErr: true},
+		{s: "", wantErr: true},
+		{s: "abc", wantErr: true},
+		{s: "1.-5", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.s)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %d, ожидалась ошибка", tt.s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, ожидалось %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		amount   Amount
+		num, den int
+		want     Amount
+	}{
+		{120000, 20, 120, 20000},
+		{100, 20, 120, 17},
+		{3, 1, 2, 2},
+		{-3, 1, 2, -2},
+		{5, 1, 3, 2},
+		{-5, 1, 3, -2},
+		{4, 1, 3, 1},
+		{0, 20, 120, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.MulDiv(tt.num, tt.den); got != tt.want {
+			t.Errorf("%d.MulDiv(%d, %d) = %d, ожидалось %d", tt.amount, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Amount
+	}{
+		{nil, 0},
+		{[]byte("1234.50"), 123450},
+		{"10.125", 1013},
+		{"10.124", 1012},
+		{"-10.125", -1013},
+		{"0.3333333333333333", 33},
+		{int64(15), 1500},
+		{0.1 + 0.2, 30},
+	}
+	for _, tt := range tests {
+		var got Amount
+		if err := got.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%v): %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Scan(%v) = %d, ожидалось %d", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want Amount
+		out  string
+	}{
+		{`1234.5`, 123450, `1234.50`},
+		{`"99.99"`, 9999, `99.99`},
+		{`-0.05`, -5, `-0.05`},
+		{`null`, 0, `0.00`},
+	}
+	for _, tt := range tests {
+		var got Amount
+		if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.data, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, ожидалось %d", tt.data, got, tt.want)
+		}
+		out, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("Marshal(%d): %v", got, err)
+			continue
+		}
+		if string(out) != tt.out {
+			t.Errorf("Marshal(%d) = %s, ожидалось %s", got, out, tt.out)
+		}
+	}
+}
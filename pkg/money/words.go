@@ -0,0 +1,117 @@
+// Package money форматирует денежные суммы для документов: с разделителями разрядов и прописью.
+package money
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+var (
+	units      = []string{"", "один", "два", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	unitsFem   = []string{"", "одна", "две", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	teens      = []string{"десять", "одиннадцать", "двенадцать", "тринадцать", "четырнадцать", "пятнадцать", "шестнадцать", "семнадцать", "восемнадцать", "девятнадцать"}
+	tens       = []string{"", "", "двадцать", "тридцать", "сорок", "пятьдесят", "шестьдесят", "семьдесят", "восемьдесят", "девяносто"}
+	hundreds   = []string{"", "сто", "двести", "триста", "четыреста", "пятьсот", "шестьсот", "семьсот", "восемьсот", "девятьсот"}
+	scaleForms = [][3]string{
+		{"", "", ""},
+		{"тысяча", "тысячи", "тысяч"},
+		{"миллион", "миллиона", "миллионов"},
+		{"миллиард", "миллиарда", "миллиардов"},
+	}
+)
+
+// plural выбирает форму слова для числа: 1 рубль, 2 рубля, 5 рублей
+func plural(n int64, forms [3]string) string {
+	n %= 100
+	if n >= 11 && n <= 19 {
+		return forms[2]
+	}
+	switch n % 10 {
+	case 1:
+		return forms[0]
+	case 2, 3, 4:
+		return forms[1]
+	default:
+		return forms[2]
+	}
+}
+
+// triad записывает число от 0 до 999 словами
+func triad(n int64, feminine bool) []string {
+	var words []string
+	if h := n / 100; h > 0 {
+		words = append(words, hundreds[h])
+	}
+	switch rest := n % 100; {
+	case rest >= 10 && rest < 20:
+		words = append(words, teens[rest-10])
+	default:
+		if t := rest / 10; t > 0 {
+			words = append(words, tens[t])
+		}
+		if u := rest % 10; u > 0 {
+			if feminine {
+				words = append(words, unitsFem[u])
+			} else {
+				words = append(words, units[u])
+			}
+		}
+	}
+	return words
+}
+
+// abs возвращает число копеек без знака
+func abs(amount Amount) int64 {
+	if amount < 0 {
+		return -int64(amount)
+	}
+	return int64(amount)
+}
+
+// InWords записывает сумму прописью: "Одна тысяча двести рублей 50 копеек"
+func InWords(amount Amount) string {
+	kopecks := abs(amount)
+	rubles := kopecks / 100
+	kopecks %= 100
+
+	var words []string
+	if rubles == 0 {
+		words = append(words, "ноль")
+	}
+	for scale := len(scaleForms) - 1; scale >= 0; scale-- {
+		divisor := int64(math.Pow(1000, float64(scale)))
+		part := rubles / divisor % 1000
+		if part == 0 {
+			continue
+		}
+		words = append(words, triad(part, scale == 1)...)
+		if scale > 0 {
+			words = append(words, plural(part, scaleForms[scale]))
+		}
+	}
+	words = append(words, plural(rubles, [3]string{"рубль", "рубля", "рублей"}))
+
+	text := []rune(strings.Join(words, " "))
+	text[0] = unicode.ToUpper(text[0])
+	return fmt.Sprintf("%s %02d %s", string(text), kopecks, plural(kopecks, [3]string{"копейка", "копейки", "копеек"}))
+}
+
+// Format записывает сумму с пробелами между разрядами и копейками через запятую: 12 345,50
+func Format(amount Amount) string {
+	kopecks := abs(amount)
+	digits := fmt.Sprintf("%d", kopecks/100)
+
+	var b strings.Builder
+	if amount < 0 {
+		b.WriteString("-")
+	}
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(d)
+	}
+	return fmt.Sprintf("%s,%02d", b.String(), kopecks%100)
+}
@@ -0,0 +1,45 @@
+package money
+
+import "testing"
+
+func TestInWords(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{0, "Ноль рублей 00 копеек"},
+		{100, "Один рубль 00 копеек"},
+		{200, "Два рубля 00 копеек"},
+		{500, "Пять рублей 00 копеек"},
+		{1100, "Одиннадцать рублей 00 копеек"},
+		{2101, "Двадцать один рубль 01 копейка"},
+		{11202, "Сто двенадцать рублей 02 копейки"},
+		{100000, "Одна тысяча рублей 00 копеек"},
+		{120050, "Одна тысяча двести рублей 50 копеек"},
+		{234500, "Две тысячи триста сорок пять рублей 00 копеек"},
+		{200000000, "Два миллиона рублей 00 копеек"},
+		{-2101, "Двадцать один рубль 01 копейка"},
+	}
+	for _, tt := range tests {
+		if got := InWords(tt.amount); got != tt.want {
+			t.Errorf("InWords(%d) = %q, ожидалось %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{0, "0,00"},
+		{-5, "-0,05"},
+		{1234550, "12 345,50"},
+		{-123456789, "-1 234 567,89"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.amount); got != tt.want {
+			t.Errorf("Format(%d) = %q, ожидалось %q", tt.amount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package phone приводит телефонные номера к формату E.164 (+79161234567).
+// Номера без кода страны считаются российскими.
+package phone
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Normalize возвращает номер в формате E.164 или ошибку, если номер не распознан
+func Normalize(number string) (string, error) {
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return "", fmt.Errorf("не указан номер телефона")
+	}
+
+	var digits strings.Builder
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+		// Номер с кодом страны, российские - ровно 11 цифр
+		if strings.HasPrefix(d, "7") && len(d) != 11 {
+			return "", fmt.Errorf("неверный номер телефона '%s'", number)
+		}
+		if len(d) < 8 || len(d) > 15 {
+			return "", fmt.Errorf("неверный номер телефона '%s'", number)
+		}
+		return "+" + d, nil
+	case len(d) == 11 && (d[0] == '8' || d[0] == '7'):
+		return "+7" + d[1:], nil
+	case len(d) == 10 && d[0] == '9':
+		return "+7" + d, nil
+	default:
+		return "", fmt.Errorf("неверный номер телефона '%s'", number)
+	}
+}
+
+// Key возвращает номер в формате E.164 для сравнения, пустую строку если номер не распознан
+func Key(number string) string {
+	normalized, err := Normalize(number)
+	if err != nil {
+		return ""
+	}
+	return normalized
+}
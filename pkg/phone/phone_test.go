@@ -0,0 +1,54 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		number  string
+		want    string
+		wantErr bool
+	}{
+		{number: "8 (916) 123-45-67", want: "+79161234567"},
+		{number: "9161234567", want: "+79161234567"},
+		{number: "+7 916 123 45 67", want: "+79161234567"},
+		{number: "7-916-123-45-67", want: "+79161234567"},
+		{number: " +44 20 7946 0958 ", want: "+442079460958"},
+		{number: "+7 916 123", wantErr: true},
+		{number: "+123", wantErr: true},
+		{number: "", wantErr: true},
+		{number: "12345", wantErr: true},
+		{number: "4161234567", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.number)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Normalize(%q) = %q, ожидалась ошибка", tt.number, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Normalize(%q): %v", tt.number, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) = %q, ожидалось %q", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"8 916 123 45 67", "+79161234567"},
+		{"abc", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Key(tt.number); got != tt.want {
+			t.Errorf("Key(%q) = %q, ожидалось %q", tt.number, got, tt.want)
+		}
+	}
+}
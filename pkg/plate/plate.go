@@ -0,0 +1,72 @@
+// Package plate приводит российские госномера к единому виду и проверяет их формат.
+// В базе номера хранятся латиницей без пробелов, например A123BC77.
+package plate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Виды номеров
+const (
+	KindRegular = "обычный" // А123ВС77 - легковые, грузовики, тягачи
+	KindTrailer = "прицеп"  // АВ1234 77
+	KindTransit = "транзит" // АВ123С 77
+	KindPublic  = "такси"   // АВ123 77 - такси и общественный транспорт
+)
+
+// Буквы, допустимые в российских номерах, кириллица переводится в латинские двойники
+var cyrillicToLatin = map[rune]rune{
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H',
+	'О': 'O', 'Р': 'P', 'С': 'C', 'Т': 'T', 'У': 'Y', 'Х': 'X',
+}
+
+const letters = `[ABEKMHOPCTYX]`
+
+var formats = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{KindRegular, regexp.MustCompile(`^` + letters + `\d{3}` + letters + `{2}\d{2,3}$`)},
+	{KindTrailer, regexp.MustCompile(`^` + letters + `{2}\d{4}\d{2,3}$`)},
+	{KindTransit, regexp.MustCompile(`^` + letters + `{2}\d{3}` + letters + `\d{2,3}$`)},
+	{KindPublic, regexp.MustCompile(`^` + letters + `{2}\d{3}\d{2,3}$`)},
+}
+
+// Normalize переводит номер в верхний регистр, заменяет кириллицу латиницей
+// и убирает пробелы, разделители региона и приписку RUS
+func Normalize(number string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(number) {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			r = latin
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "RUS")
+}
+
+// Kind возвращает вид нормализованного номера или пустую строку, если формат не российский
+func Kind(number string) string {
+	for _, f := range formats {
+		if f.re.MatchString(number) {
+			return f.kind
+		}
+	}
+	return ""
+}
+
+// Parse нормализует номер и проверяет, что он соответствует одному из российских форматов
+func Parse(number string) (string, error) {
+	normalized := Normalize(number)
+	if normalized == "" {
+		return "", fmt.Errorf("не указан номер машины")
+	}
+	if Kind(normalized) == "" {
+		return "", fmt.Errorf("номер '%s' не соответствует формату российских номеров", number)
+	}
+	return normalized, nil
+}
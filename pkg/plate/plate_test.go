@@ -0,0 +1,58 @@
+package plate
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"а123вс 77 RUS", "A123BC77"},
+		{"A123BC777", "A123BC777"},
+		{"а 123 вс | 77", "A123BC77"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.number); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, ожидалось %q", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		number  string
+		want    string
+		kind    string
+		wantErr bool
+	}{
+		{number: "А123ВС 77", want: "A123BC77", kind: KindRegular},
+		{number: "а123вс777 rus", want: "A123BC777", kind: KindRegular},
+		{number: "АВ1234 77", want: "AB123477", kind: KindTrailer},
+		{number: "АВ123С 77", want: "AB123C77", kind: KindTransit},
+		{number: "АВ123 77", want: "AB12377", kind: KindPublic},
+		{number: "", wantErr: true},
+		{number: "  ", wantErr: true},
+		{number: "12345", wantErr: true},
+		{number: "Z123ZZ77", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.number)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %q, ожидалась ошибка", tt.number, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.number, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %q, ожидалось %q", tt.number, got, tt.want)
+		}
+		if kind := Kind(got); kind != tt.kind {
+			t.Errorf("Kind(%q) = %q, ожидалось %q", got, kind, tt.kind)
+		}
+	}
+}
@@ -362,21 +362,21 @@ func (g *TestDataGenerator) generateMaterials() error {
 	logger.Info("Генерация базовых материалов...")
 
 	materials := []models.Material{
-		{Name: "Резина 25", TypeDS: 1, Storage: 100},
-		{Name: "Резина 19", TypeDS: 1, Storage: 150},
-		{Name: "Резина 20", TypeDS: 1, Storage: 120},
-		{Name: "Резина 25.1", TypeDS: 1, Storage: 80},
-		{Name: "Резина 13", TypeDS: 1, Storage: 200},
-		{Name: "Резина 15", TypeDS: 1, Storage: 180},
-		{Name: "Колодки 9", TypeDS: 2, Storage: 50},
-		{Name: "Колодки 12", TypeDS: 2, Storage: 40},
-		{Name: "Колодки 15", TypeDS: 2, Storage: 30},
+		{Name: "Резина 25", CategoryID: 1, Storage: 100},
+		{Name: "Резина 19", CategoryID: 1, Storage: 150},
+		{Name: "Резина 20", CategoryID: 1, Storage: 120},
+		{Name: "Резина 25.1", CategoryID: 1, Storage: 80},
+		{Name: "Резина 13", CategoryID: 1, Storage: 200},
+		{Name: "Резина 15", CategoryID: 1, Storage: 180},
+		{Name: "Колодки 9", CategoryID: 2, Storage: 50},
+		{Name: "Колодки 12", CategoryID: 2, Storage: 40},
+		{Name: "Колодки 15", CategoryID: 2, Storage: 30},
 	}
 
 	createdCount := 0
 	for _, material := range materials {
-		// Проверяем, существует ли уже материал с таким именем и типом
-		existingMaterials, err := g.services.Material.GetAll()
+		// Проверяем, существует ли уже материал с таким именем и категорией
+		existingMaterials, err := g.services.Material.GetAll(models.MaterialFilter{CategoryID: material.CategoryID})
 		if err != nil {
 			logger.Error("Ошибка при получении существующих материалов: %v", err)
 			return err
@@ -385,8 +385,8 @@ func (g *TestDataGenerator) generateMaterials() error {
 		// Проверяем, есть ли уже материал с таким именем
 		materialExists := false
 		for _, existing := range existingMaterials {
-			if existing.Name == material.Name && existing.TypeDS == material.TypeDS {
-				logger.Debug("Материал %s (тип ДС: %d) уже существует, пропускаем", material.Name, material.TypeDS)
+			if existing.Name == material.Name {
+				logger.Debug("Материал %s (категория ID: %d) уже существует, пропускаем", material.Name, material.CategoryID)
 				materialExists = true
 				break
 			}
@@ -398,7 +398,7 @@ func (g *TestDataGenerator) generateMaterials() error {
 				return err
 			}
 			createdCount++
-			logger.Debug("Создан материал: %s (тип ДС: %d)", material.Name, material.TypeDS)
+			logger.Debug("Создан материал: %s (категория ID: %d)", material.Name, material.CategoryID)
 		}
 	}
 